@@ -2,8 +2,31 @@ package main
 
 import (
 	"blog-posts-api/internal/api/handlers"
+	"blog-posts-api/internal/api/middleware"
 	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/auth"
+	"blog-posts-api/internal/blob"
+	"blog-posts-api/internal/collab"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/gql"
+	"blog-posts-api/internal/grpcapi"
+	"blog-posts-api/internal/health"
+	"blog-posts-api/internal/imaging"
+	"blog-posts-api/internal/mail"
+	"blog-posts-api/internal/openapi"
+	"blog-posts-api/internal/outbox"
+	"blog-posts-api/internal/server"
+	"blog-posts-api/internal/sitemap"
+	"blog-posts-api/internal/theme"
+	"blog-posts-api/internal/webhook"
+	"context"
+	"io"
 	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -14,7 +37,16 @@ import (
 
 // @title Blog Posts API
 // @version 1.0
-// @description A simple REST API for managing blog posts
+// @description A simple REST API for managing blog posts.
+// @description Errors are reported as RFC 7807 problem details (application/problem+json).
+// @termsOfService http://swagger.io/terms/
+
+// @contact.name API Support
+// @contact.url http://www.example.com/support
+// @contact.email support@example.com
+
+// @license.name MIT
+// @license.url https://opensource.org/licenses/MIT
 
 // @host localhost:8080
 // @BasePath /api/v1
@@ -23,8 +55,29 @@ import (
 // @tag.name Blog Posts
 // @tag.description Operations related to blog posts management
 
+// @tag.name Authors
+// @tag.description Authors of blog posts, referred to by the posts
+
+// @tag.name Attachments
+// @tag.description Files uploaded to blog posts, such as images
+
+// @tag.name Users
+// @tag.description User accounts with verified email addresses and the access tokens of their API requests
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access token from POST /auth/token, sent as "Bearer <access_token>"
+
+// @tag.name Webhooks
+// @tag.description Subscriptions of HTTP endpoints to post events and their delivery log
+
 func main() {
+	cfg := config.Load()
+
 	r := gin.Default()
+	// Render errors reported by handlers as problem+json
+	r.Use(middleware.Problems())
 
 	// Add CORS middleware for Swagger UI
 	r.Use(func(c *gin.Context) {
@@ -43,45 +96,292 @@ func main() {
 	// Swagger documentation route
 	r.GET("/api/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Health check endpoint
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
-
 	// API routes
 	repo := services.NewInMemoryStoreBlogPostRepo()
 	service := services.NewBlogPostService(repo)
 	handler := handlers.NewBlogPostHandler(service)
+
+	// Authors of the posts; the posts stored before authors existed are
+	// linked to the author named after them by POST /api/v1/authors/backfill
+	authors := services.NewAuthorService(services.NewInMemoryAuthorRepo(), service)
+
+	// Webhooks, fed with the post events of the outbox
+	webhooks := services.NewWebhookService(services.NewInMemoryWebhookRepo(), services.RetryPolicy{
+		MaxAttempts: cfg.Webhook.MaxAttempts,
+		Backoff:     cfg.Webhook.Backoff,
+		MaxBackoff:  cfg.Webhook.MaxBackoff,
+	})
+
+	// The relay publishes the events recorded in the outbox of the
+	// repository, woken up after every change
+	sinks := []outbox.Sink{outbox.NewSink("webhooks", webhooks.Enqueue)}
+	var eventFile *outbox.FileSink
+	if cfg.Outbox.File != "" {
+		var err error
+		if eventFile, err = outbox.OpenFileSink(cfg.Outbox.File); err != nil {
+			log.Fatal("Failed to open the event file: ", err)
+		}
+		sinks = append(sinks, eventFile)
+	}
+	relayBeat := health.NewHeartbeat()
+	relay := outbox.NewRelay(repo, sinks, outbox.Options{
+		BatchSize:    cfg.Outbox.BatchSize,
+		PollInterval: cfg.Outbox.PollInterval,
+		Heartbeat:    relayBeat,
+	})
+	service.Subscribe(relay.Notify)
+
+	// Live stream of the post changes for dashboards
+	streams := handlers.NewEventStreamHandler(service, cfg.Stream)
+
+	// Editing sessions shared by the editors of a post
+	editing := collab.NewHub(service, collab.Options{SaveDelay: cfg.Collab.SaveDelay})
+
+	// Files attached to posts, removed along with their post
+	files, err := blob.NewLocalStore(cfg.Attachments.Dir)
+	if err != nil {
+		log.Fatal("Failed to open the attachment store: ", err)
+	}
+	attachments := services.NewAttachmentService(service, files, services.AttachmentOptions{
+		MaxBytes:   cfg.Attachments.MaxBytes,
+		Types:      cfg.Attachments.Types,
+		URLPrefix:  "/api/v1/posts",
+		ImageTypes: imaging.Types,
+	})
+	service.Subscribe(attachments.Notify)
+
+	// User accounts, whose links are sent by email: written to files or
+	// logged unless a mail directory is set
+	var mailer mail.Mailer = mail.LogMailer{}
+	if cfg.Mail.Dir != "" {
+		mailer = mail.FileMailer{Dir: cfg.Mail.Dir, From: cfg.Mail.From}
+	}
+	if cfg.Auth.PasswordHash != auth.Argon2id && cfg.Auth.PasswordHash != auth.Bcrypt {
+		log.Fatalf("Invalid AUTH_PASSWORD_HASH %q, use %s or %s", cfg.Auth.PasswordHash, auth.Argon2id, auth.Bcrypt)
+	}
+	if cfg.Auth.JWTSecret == "" {
+		log.Println("AUTH_JWT_SECRET is not set, access tokens are only valid until restart")
+	}
+	users := services.NewUserService(services.NewInMemoryUserRepo(), services.NewInMemoryTokenRepo(), mailer, services.UserOptions{
+		PasswordHash:    cfg.Auth.PasswordHash,
+		SiteURL:         cfg.Site.URL,
+		JWTSecret:       []byte(cfg.Auth.JWTSecret),
+		AccessTokenTTL:  cfg.Auth.AccessTokenTTL,
+		SessionTTL:      cfg.Auth.SessionTTL,
+		VerificationTTL: cfg.Auth.VerificationTTL,
+		ResetTTL:        cfg.Auth.ResetTTL,
+	})
+
+	// OpenAPI 3.1 description of the REST API, optionally enforced
+	spec, err := openapi.Load()
+	if err != nil {
+		log.Fatal("Failed to load the OpenAPI description: ", err)
+	}
+	handlers.NewOpenAPIHandler(spec).RegisterRoutes(&r.RouterGroup)
+
 	v1 := r.Group("/api/v1")
+	v1.Use(middleware.OpenAPI(spec, middleware.OpenAPIOptions{
+		Requests:  cfg.OpenAPI.ValidateRequests,
+		Responses: cfg.OpenAPI.ValidateResponses,
+	}))
 	{
 		handler.RegisterRoutes(v1)
+		handlers.NewAuthorHandler(authors).RegisterRoutes(v1)
+		streams.RegisterRoutes(v1)
+		handlers.NewCollabHandler(editing, cfg.Collab).RegisterRoutes(v1)
+		handlers.NewAttachmentHandler(attachments).RegisterRoutes(v1)
+		handlers.NewWebhookHandler(webhooks, users).RegisterRoutes(v1)
+		handlers.NewAuthHandler(users).RegisterRoutes(v1)
 	}
 
-	// Root endpoint with API information
-	r.GET("/", func(c *gin.Context) {
+	// GraphQL for clients fetching posts with their authors and tags at once
+	schema, err := gql.NewSchema(service, gql.Limits{MaxDepth: cfg.GraphQL.MaxDepth, MaxComplexity: cfg.GraphQL.MaxComplexity})
+	if err != nil {
+		log.Fatal("Failed to build the GraphQL schema: ", err)
+	}
+	handlers.NewGraphQLHandler(schema).RegisterRoutes(&r.RouterGroup)
+
+	// Syndication feeds
+	handlers.NewFeedHandler(service, cfg.Site, cfg.Feed).RegisterRoutes(&r.RouterGroup)
+
+	// Sitemap and robots.txt, kept up to date as posts change
+	sitemaps := handlers.NewSitemapHandler(service, cfg.Site, sitemap.MaxURLs)
+	if err := sitemaps.Load(context.Background()); err != nil {
+		log.Fatal("Failed to build the sitemap: ", err)
+	}
+	sitemaps.RegisterRoutes(&r.RouterGroup)
+
+	// Liveness and readiness probes
+	probes := health.NewRegistry()
+	probes.Register(health.Check{
+		Name:  "blog_post_repository",
+		Probe: health.Readiness,
+		Fn:    health.PingCheck(repo),
+	})
+	probes.Register(health.Check{
+		Name:  "attachment_disk_space",
+		Probe: health.Readiness,
+		// room for at least one more upload
+		Fn: health.DiskSpaceCheck(cfg.Attachments.Dir, uint64(cfg.Attachments.MaxBytes)),
+	})
+
+	// Webhook deliveries are sent in the background
+	dispatcherBeat := health.NewHeartbeat()
+	dispatcher := webhook.NewDispatcher(webhooks, webhook.Options{
+		Workers:              cfg.Webhook.Workers,
+		Timeout:              cfg.Webhook.Timeout,
+		Heartbeat:            dispatcherBeat,
+		AllowPrivateNetworks: cfg.Webhook.AllowPrivateNetworks,
+	})
+	probes.Register(health.Check{
+		Name:  "webhook_dispatcher",
+		Probe: health.Liveness,
+		// the loop beats at least every second while it runs
+		Fn: dispatcherBeat.Check(30 * time.Second),
+	})
+	// Uploaded images are resized in the background
+	imagesBeat := health.NewHeartbeat()
+	images := imaging.NewProcessor(attachments, imaging.Options{
+		Workers:   cfg.Images.Workers,
+		MaxPixels: cfg.Images.MaxPixels,
+		Heartbeat: imagesBeat,
+	})
+	probes.Register(health.Check{
+		Name:  "image_processor",
+		Probe: health.Liveness,
+		// the loop beats at least every second while it runs
+		Fn: imagesBeat.Check(30 * time.Second),
+	})
+	probes.Register(health.Check{
+		Name:  "outbox_relay",
+		Probe: health.Liveness,
+		// sinks backing off wait at most a minute
+		Fn: relayBeat.Check(max(2*time.Minute, 3*cfg.Outbox.PollInterval)),
+	})
+	handlers.NewHealthHandler(probes).RegisterRoutes(&r.RouterGroup)
+
+	// Public HTML pages
+	site, err := theme.LoadDir(cfg.Site.ThemeDir)
+	if err != nil {
+		log.Fatal("Failed to load the theme: ", err)
+	}
+	handlers.NewSiteHandler(service, site, cfg.Site, cfg.Feed.ExcerptLength).RegisterRoutes(&r.RouterGroup)
+	handlers.NewAccountHandler(users, site, cfg.Site).RegisterRoutes(&r.RouterGroup)
+
+	// gRPC API for internal services, on a port of its own
+	rpc := grpcapi.New(service, cfg.Stream.LogSize)
+	rpcListener, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		log.Fatal("Failed to listen for gRPC: ", err)
+	}
+	go func() {
+		if err := rpc.Serve(rpcListener); err != nil {
+			log.Printf("gRPC server stopped with error: %v", err)
+		}
+	}()
+
+	// API information
+	r.GET("/api", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message":  "Welcome to Blog Posts API",
 			"version":  "1.0.0",
 			"docs":     "/api/docs/index.html",
+			"openapi":  "/api/openapi.json",
 			"health":   "/health",
+			"livez":    "/livez",
+			"readyz":   "/readyz",
+			"feeds":    "/feed.rss, /feed.atom, /feed.json",
+			"sitemap":  "/sitemap.xml",
+			"graphql":  "/graphql",
+			"site":     "/",
+			"account":  "/login",
 			"api_base": "/api/v1",
+			"grpc":     cfg.GRPC.Addr,
 			"endpoints": map[string]string{
-				"GET /api/v1/posts":        "Get all blog posts",
-				"GET /api/v1/posts/:id":    "Get a blog post by ID",
-				"POST /api/v1/posts":       "Create a new blog post",
-				"PUT /api/v1/posts/:id":    "Update a blog post",
-				"DELETE /api/v1/posts/:id": "Delete a blog post",
+				"GET /api/v1/posts":                           "Get all blog posts",
+				"GET /api/v1/posts/:id":                       "Get a blog post by ID",
+				"POST /api/v1/posts":                          "Create a new blog post",
+				"PUT /api/v1/posts/:id":                       "Update a blog post",
+				"DELETE /api/v1/posts/:id":                    "Delete a blog post",
+				"GET /api/v1/posts/export":                    "Export all blog posts as NDJSON",
+				"POST /api/v1/posts/import":                   "Import blog posts from NDJSON",
+				"POST /api/v1/posts/batch":                    "Apply a batch of operations",
+				"GET /api/v1/posts/events":                    "Stream post changes as server-sent events",
+				"GET /api/v1/posts/:id/collab":                "Edit a post with other editors over a WebSocket",
+				"GET /api/v1/authors":                         "Get all authors",
+				"GET /api/v1/authors/:id/posts":               "Get the posts of an author",
+				"POST /api/v1/authors/backfill":               "Link the posts to their authors",
+				"POST /api/v1/posts/:id/attachments":          "Upload a file to a blog post",
+				"GET /api/v1/posts/:id/attachments":           "List the files attached to a blog post",
+				"GET /api/v1/webhooks":                        "Get all webhooks",
+				"POST /api/v1/webhooks":                       "Subscribe to post events",
+				"GET /api/v1/webhooks/deliveries?status=dead": "Get the dead-letter list",
+				"POST /api/v1/auth/register":                  "Register a user",
+				"POST /api/v1/auth/token":                     "Issue an access token",
+				"GET /api/v1/auth/me":                         "Get the current user",
 			},
 		})
 	})
 
+	srv := server.New(cfg.Server, r)
+	srv.OnDrain(probes.SetDraining)
+	// open streams would hold the drain until its timeout
+	srv.OnDrain(streams.Close)
+	srv.OnDrain(rpc.Drain)
+	// hooks run in reverse order, so the repository is closed last
+	if closer, ok := any(repo).(io.Closer); ok {
+		srv.OnShutdown("blog post repository", func(ctx context.Context) error {
+			return closer.Close()
+		})
+	}
+	if eventFile != nil {
+		srv.OnShutdown("event file", func(ctx context.Context) error {
+			return eventFile.Close()
+		})
+	}
+	runWorker(srv, "webhook dispatcher", dispatcher.Run)
+	runWorker(srv, "outbox relay", relay.Run)
+	// images in flight are saved while the relay still publishes the events
+	runWorker(srv, "image processor", images.Run)
+	// editing sessions are not drained with the requests, closing them
+	// saves their documents while the relay still publishes the events
+	srv.OnShutdown("editing sessions", editing.Close)
+	// like requests, running calls finish while the relay publishes
+	srv.OnShutdown("grpc server", rpc.Shutdown)
+
 	log.Println("🚀 Blog Posts API is starting...")
-	log.Println("🏥 Health check available at: http://localhost:8080/health")
-	log.Println("🌐 API endpoints available at: http://localhost:8080/api/v1")
-	log.Println("📖 Swagger documentation available at: http://localhost:8080/api/docs/index.html")
+	log.Printf("🏥 Health probes available at: http://localhost%s/livez and http://localhost%s/readyz", cfg.Server.Addr, cfg.Server.Addr)
+	log.Printf("📰 Blog available at: http://localhost%s/", cfg.Server.Addr)
+	log.Printf("🌐 API endpoints available at: http://localhost%s/api/v1", cfg.Server.Addr)
+	log.Printf("🔌 gRPC API available at: localhost%s", cfg.GRPC.Addr)
+	log.Printf("📖 Swagger documentation available at: http://localhost%s/api/docs/index.html", cfg.Server.Addr)
 
-	// Start server
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start server and block until SIGINT/SIGTERM
+	if err := srv.Run(ctx); err != nil {
+		log.Fatal("Server stopped with error: ", err)
 	}
+	log.Println("👋 Blog Posts API stopped")
+}
+
+// runWorker runs fn in the background until shutdown, when its context is
+// canceled and the shutdown hook waits for fn to return
+func runWorker(srv *server.Server, name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(ctx)
+	}()
+	srv.OnShutdown(name, func(hookCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-hookCtx.Done():
+			return hookCtx.Err()
+		}
+	})
 }
@@ -0,0 +1,118 @@
+// Command export-static renders the published posts as a static website,
+// e.g. to serve a CDN mirror of the blog while the API is down.
+//
+//	go run ./cmd/export-static -in http://localhost:8080/api/v1/posts/export -out ./public
+//
+// Posts are read from an NDJSON export, a file or the export endpoint of a
+// running API, and served to the generator by the blog post service over
+// the in-memory repository. The site and feed settings, including the theme
+// directory, come from the same environment variables as the API.
+// Repeated runs only rewrite the files that changed and remove the pages of
+// deleted posts.
+package main
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/static"
+	"blog-posts-api/internal/theme"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+func main() {
+	cfg := config.Load()
+	in := flag.String("in", "", "NDJSON export to read, a file, an http(s) URL or - for stdin")
+	out := flag.String("out", "public", "directory to write the site to")
+	themeDir := flag.String("theme", cfg.Site.ThemeDir, "directory of theme files overriding the built-in theme")
+	perPage := flag.Int("per-page", cfg.Site.PostsPerPage, "number of posts of index and tag pages")
+	flag.Parse()
+
+	if *in == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	t, err := theme.LoadDir(*themeDir)
+	if err != nil {
+		log.Fatalf("failed to load the theme: %v", err)
+	}
+
+	repo := services.NewInMemoryStoreBlogPostRepo()
+	n, err := load(ctx, repo, *in)
+	if err != nil {
+		log.Fatalf("failed to read %s: %v", *in, err)
+	}
+
+	service := services.NewBlogPostService(repo)
+	generator := static.NewGenerator(service, t, static.Options{Site: cfg.Site, Feed: cfg.Feed, PerPage: *perPage})
+	stats, err := generator.Build(ctx, *out)
+	if err != nil {
+		log.Fatalf("failed to build the site: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d posts: %d files written, %d unchanged, %d removed\n", n, stats.Written, stats.Unchanged, stats.Removed)
+}
+
+// load stores the posts of the export as they are, rather than through the
+// service, so that they keep the dates set by the API and repeated builds
+// render the same files
+func load(ctx context.Context, repo *services.InMemoryStoreBlogPostRepo, source string) (int, error) {
+	r, err := open(ctx, source)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	dec := json.NewDecoder(r)
+	n := 0
+	for {
+		var post models.BlogPost
+		if err := dec.Decode(&post); errors.Is(err, io.EOF) {
+			return n, nil
+		} else if err != nil {
+			return n, fmt.Errorf("post %d: %w", n+1, err)
+		}
+		if _, err := repo.Create(ctx, &post); err != nil {
+			return n, fmt.Errorf("post %d: %w", n+1, err)
+		}
+		n++
+	}
+}
+
+func open(ctx context.Context, source string) (io.ReadCloser, error) {
+	switch {
+	case source == "-":
+		return io.NopCloser(os.Stdin), nil
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return resp.Body, nil
+	default:
+		return os.Open(source)
+	}
+}
@@ -0,0 +1,243 @@
+// Command import loads a directory of Markdown files with YAML or TOML
+// front matter (Hugo, Jekyll), or a WordPress WXR export, as blog posts.
+//
+//	go run ./cmd/import -author "John Doe" -out posts.ndjson ./content/posts
+//	go run ./cmd/import -map ids.csv -api http://localhost:8080 wordpress.xml
+//
+// Posts are loaded through the blog post service, with the same validation
+// as the API. Since the service runs on the in-memory repository, -out or
+// -api is required unless -dry-run is set: -out writes the imported posts
+// as NDJSON, ready for POST /api/v1/posts/import, and -api sends them to
+// that endpoint of a running server, where -on-conflict tells what to do
+// with the posts it already has.
+// Re-running the command over the same directory or export maps every file
+// or WordPress post to the same post ID; for WXR exports, -map keeps the
+// WordPress to post ID mapping in a CSV file read back by the next run.
+package main
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/importer"
+	"blog-posts-api/internal/importer/markdown"
+	"blog-posts-api/internal/importer/wxr"
+	"blog-posts-api/pkg/client"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "validate and report without writing anything")
+	onConflict := flag.String("on-conflict", string(services.ConflictSkip), "with -api, what to do with a post whose ID already exists on the server: skip or upsert")
+	author := flag.String("author", "", "author of the files without one in their front matter")
+	out := flag.String("out", "", "write the imported posts as NDJSON to this file, - for stdout")
+	api := flag.String("api", "", "import the posts into the server at this base URL, e.g. http://localhost:8080")
+	format := flag.String("format", "auto", "format of the source: markdown, wxr, or auto for markdown directories and wxr files")
+	idMap := flag.String("map", "", "CSV file mapping WordPress post IDs to post IDs, read if it exists and rewritten (wxr only)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <directory or WXR file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *out == "" && *api == "" && !*dryRun {
+		// the posts only live in memory, they would be lost
+		log.Fatal("-out or -api is required unless -dry-run is set")
+	}
+	policy := services.ConflictPolicy(*onConflict)
+	if policy != services.ConflictSkip && policy != services.ConflictUpsert {
+		log.Fatalf("invalid -on-conflict %q, expected skip or upsert", *onConflict)
+	}
+	if policy != services.ConflictSkip && *api == "" {
+		// the posts are loaded into an empty service
+		log.Fatal("-on-conflict only applies with -api")
+	}
+	var c *client.Client
+	if *api != "" {
+		var err error
+		if c, err = client.New(*api, client.Options{}); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	source := flag.Arg(0)
+	if *format == "auto" {
+		info, err := os.Stat(source)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*format = "wxr"
+		if info.IsDir() {
+			*format = "markdown"
+		}
+	}
+	if *format != "markdown" && *format != "wxr" {
+		log.Fatalf("invalid -format %q, expected auto, markdown or wxr", *format)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	service := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	// with -api, a dry run is left to the server, which knows the posts
+	// the import would conflict with
+	opts := importer.Options{
+		ImportOptions: services.ImportOptions{DryRun: *dryRun && c == nil},
+		Author:        *author,
+	}
+
+	var summary models.ImportSummary
+	report := func(r importer.Result) {
+		summary.Add(models.ImportResult{Result: r.Outcome})
+		switch {
+		case r.Err != nil:
+			fmt.Fprintf(os.Stderr, "%-8s %s: %v\n", r.Outcome, r.Source, r.Err)
+		case r.Outcome == models.ImportSkipped && r.ID == "":
+			fmt.Fprintf(os.Stderr, "%-8s %s: %s\n", r.Outcome, r.Source, r.Note)
+		case r.Note != "":
+			fmt.Fprintf(os.Stderr, "%-8s %s -> %s (%s)\n", r.Outcome, r.Source, r.Slug, r.Note)
+		default:
+			fmt.Fprintf(os.Stderr, "%-8s %s -> %s\n", r.Outcome, r.Source, r.Slug)
+		}
+	}
+
+	var err error
+	if *format == "markdown" {
+		err = markdown.Import(ctx, service, source, opts, report)
+	} else {
+		err = importWXR(ctx, service, source, *idMap, opts, *dryRun, report)
+	}
+	if err != nil {
+		log.Fatalf("import stopped: %v", err)
+	}
+
+	if *out != "" && !*dryRun {
+		if err := writeFile(*out, func(w io.Writer) error { return export(ctx, service, w) }); err != nil {
+			log.Fatalf("failed to write %s: %v", *out, err)
+		}
+	}
+
+	mode := ""
+	if *dryRun {
+		mode = " (dry run)"
+	}
+	printSummary(fmt.Sprintf("%d items%s", summary.Lines, mode), summary)
+	failed := summary.Invalid > 0 || summary.Failed > 0
+
+	if c != nil {
+		server, err := send(ctx, c, service, client.ImportOptions{DryRun: *dryRun, OnConflict: string(policy)})
+		if err != nil {
+			log.Fatalf("failed to import into %s: %v", *api, err)
+		}
+		printSummary(fmt.Sprintf("%d posts sent to %s%s", server.Lines, *api, mode), server)
+		failed = failed || server.Invalid > 0 || server.Failed > 0 || server.Error != ""
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func printSummary(what string, summary models.ImportSummary) {
+	fmt.Fprintf(os.Stderr, "\n%s: %d created, %d updated, %d skipped, %d invalid, %d failed\n",
+		what, summary.Created, summary.Updated, summary.Skipped, summary.Invalid, summary.Failed)
+}
+
+// send streams the loaded posts to the import endpoint of a server, and
+// prints the lines it did not import
+func send(ctx context.Context, c *client.Client, service *services.BlogPostService, opts client.ImportOptions) (models.ImportSummary, error) {
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(export(ctx, service, w))
+	}()
+	report, err := c.ImportPosts(ctx, r, opts)
+	// unblocks the export if the request stopped reading
+	r.Close()
+	if err != nil {
+		return models.ImportSummary{}, err
+	}
+	for _, line := range report.Results {
+		if line.Error != "" {
+			fmt.Fprintf(os.Stderr, "%-8s %s: %s\n", line.Result, line.ID, line.Error)
+		}
+	}
+	if report.Summary.Error != "" {
+		fmt.Fprintf(os.Stderr, "import stopped: %s\n", report.Summary.Error)
+	}
+	return report.Summary, nil
+}
+
+func importWXR(ctx context.Context, service *services.BlogPostService, path, mapPath string, opts importer.Options, dryRun bool, report func(importer.Result)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var ids wxr.IDMap
+	if mapPath != "" {
+		ids, err = readIDMap(mapPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", mapPath, err)
+		}
+	}
+
+	if err := wxr.Import(ctx, service, f, opts, ids, report); err != nil {
+		return err
+	}
+	if mapPath == "" || dryRun {
+		return nil
+	}
+	return writeFile(mapPath, func(w io.Writer) error {
+		return wxr.WriteIDMap(w, ids)
+	})
+}
+
+func readIDMap(path string) (wxr.IDMap, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return wxr.IDMap{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return wxr.ReadIDMap(f)
+}
+
+// export writes the loaded posts as NDJSON
+func export(ctx context.Context, service *services.BlogPostService, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return service.Export(ctx, func(p *models.BlogPost) error {
+		return enc.Encode(p)
+	})
+}
+
+// writeFile calls write with the file at path, or stdout for -
+func writeFile(path string, write func(io.Writer) error) (err error) {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	return write(f)
+}
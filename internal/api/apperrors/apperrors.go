@@ -0,0 +1,161 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind classifies a domain error so that transports can map it to a
+// status code without knowing which service produced it
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindBadRequest
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindForbidden
+	KindPreconditionFailed
+	KindUnauthorized
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindBadRequest:
+		return "bad request"
+	case KindValidation:
+		return "validation"
+	case KindNotFound:
+		return "not found"
+	case KindConflict:
+		return "conflict"
+	case KindForbidden:
+		return "forbidden"
+	case KindPreconditionFailed:
+		return "precondition failed"
+	case KindUnauthorized:
+		return "unauthorized"
+	default:
+		return "internal"
+	}
+}
+
+// FieldError describes why a single input field was rejected
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+// Error is a domain error returned by services
+type Error struct {
+	Kind    Kind
+	Message string
+	// Fields lists every invalid field of a KindValidation error
+	Fields []FieldError
+	// Err is the underlying cause, never exposed to clients
+	Err error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Message)
+	for i, f := range e.Fields {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "%s %s", f.Field, f.Reason)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+// PreconditionFailed reports a request whose preconditions, e.g. an
+// If-Match header, do not hold
+func PreconditionFailed(message string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Message: message}
+}
+
+// Unauthorized reports a request without valid credentials
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+func BadRequest(message string, cause error) *Error {
+	return &Error{Kind: KindBadRequest, Message: message, Err: cause}
+}
+
+// Validation builds an error listing every invalid field
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+func Internal(message string, cause error) *Error {
+	return &Error{Kind: KindInternal, Message: message, Err: cause}
+}
+
+// Wrap returns err unchanged if it already carries a domain error,
+// otherwise it hides it behind an internal error with the given message
+func Wrap(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	return Internal(message, err)
+}
+
+// KindOf returns the kind of the domain error carried by err, or
+// KindInternal if there is none
+func KindOf(err error) Kind {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	return KindInternal
+}
+
+// Is reports whether err carries a domain error of the given kind
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// Nest returns a copy of the domain error carried by err with its message
+// prefixed and its fields nested under a parent field, e.g. "title" becomes
+// "operations[2].post.title". Other errors are returned unchanged.
+func Nest(err error, parent, messagePrefix string) error {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		return err
+	}
+	nested := *appErr
+	nested.Message = messagePrefix + appErr.Message
+	nested.Fields = make([]FieldError, len(appErr.Fields))
+	for i, f := range appErr.Fields {
+		nested.Fields[i] = FieldError{Field: parent + "." + f.Field, Reason: f.Reason}
+	}
+	return &nested
+}
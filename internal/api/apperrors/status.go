@@ -0,0 +1,42 @@
+package apperrors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// transportStatus is what a kind of error is reported as by each transport
+type transportStatus struct {
+	http int
+	grpc codes.Code
+}
+
+// statuses is shared by the transports so that REST and gRPC clients see
+// the same errors the same way
+var statuses = map[Kind]transportStatus{
+	KindInternal:           {http.StatusInternalServerError, codes.Internal},
+	KindBadRequest:         {http.StatusBadRequest, codes.InvalidArgument},
+	KindValidation:         {http.StatusBadRequest, codes.InvalidArgument},
+	KindNotFound:           {http.StatusNotFound, codes.NotFound},
+	KindConflict:           {http.StatusConflict, codes.Aborted},
+	KindForbidden:          {http.StatusForbidden, codes.PermissionDenied},
+	KindPreconditionFailed: {http.StatusPreconditionFailed, codes.FailedPrecondition},
+	KindUnauthorized:       {http.StatusUnauthorized, codes.Unauthenticated},
+}
+
+// HTTPStatus returns the HTTP status code of errors of the kind
+func (k Kind) HTTPStatus() int {
+	if s, ok := statuses[k]; ok {
+		return s.http
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode returns the gRPC status code of errors of the kind
+func (k Kind) GRPCCode() codes.Code {
+	if s, ok := statuses[k]; ok {
+		return s.grpc
+	}
+	return codes.Internal
+}
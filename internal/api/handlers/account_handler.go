@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/api/validation"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/theme"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler serves the HTML pages of the user accounts: login,
+// registration, email verification, password reset and the account of the
+// session. Forms are protected by middleware.CSRF and the session is kept
+// in the middleware.SessionCookie cookie.
+type AccountHandler struct {
+	service *services.UserService
+	theme   *theme.Theme
+	site    config.SiteConfig
+}
+
+func NewAccountHandler(s *services.UserService, t *theme.Theme, site config.SiteConfig) *AccountHandler {
+	return &AccountHandler{service: s, theme: t, site: site}
+}
+
+func (h *AccountHandler) RegisterRoutes(r *gin.RouterGroup) {
+	g := r.Group("", middleware.CSRF(middleware.CSRFOptions{Secure: h.secure(), Origin: h.site.URL}))
+	g.GET("/login", h.LoginForm)
+	g.POST("/login", h.Login)
+	g.POST("/logout", h.Logout)
+	g.GET("/register", h.RegisterForm)
+	g.POST("/register", h.Register)
+	g.GET("/verify", h.Verify)
+	g.POST("/verify/resend", h.ResendVerification)
+	g.GET("/forgot-password", h.ForgotPasswordForm)
+	g.POST("/forgot-password", h.ForgotPassword)
+	g.GET("/reset-password", h.ResetPasswordForm)
+	g.POST("/reset-password", h.ResetPassword)
+	g.GET("/account", h.Account)
+}
+
+// LoginForm serves the login form, or redirects to the account page when
+// logged in already
+func (h *AccountHandler) LoginForm(c *gin.Context) {
+	if h.sessionUser(c) != nil {
+		c.Redirect(http.StatusSeeOther, "/account")
+		return
+	}
+	h.render(c, http.StatusOK, "Log in", &theme.Account{View: "login"})
+}
+
+// Login starts a session for the posted credentials
+func (h *AccountHandler) Login(c *gin.Context) {
+	credentials := models.Credentials{Email: c.PostForm("email"), Password: c.PostForm("password")}
+	user, err := h.service.Login(c.Request.Context(), credentials)
+	if err != nil {
+		view := "login"
+		if errors.Is(err, services.ErrEmailNotVerified) {
+			// offer a new link, the first one may have expired
+			view = "verify"
+		}
+		h.formError(c, err, "Log in", &theme.Account{View: view, Email: credentials.Email})
+		return
+	}
+
+	// a session set before logging in, possibly by someone else, is not
+	// carried over
+	if token, err := c.Cookie(middleware.SessionCookie); err == nil {
+		h.service.EndSession(c.Request.Context(), token)
+	}
+	token, err := h.service.StartSession(c.Request.Context(), user.ID)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to start a session"))
+		return
+	}
+	h.setSession(c, token, int(h.service.SessionTTL().Seconds()))
+	c.Redirect(http.StatusSeeOther, "/account")
+}
+
+// Logout ends the session
+func (h *AccountHandler) Logout(c *gin.Context) {
+	if token, err := c.Cookie(middleware.SessionCookie); err == nil {
+		if err := h.service.EndSession(c.Request.Context(), token); err != nil {
+			c.Error(apperrors.Wrap(err, "failed to end the session"))
+			return
+		}
+	}
+	h.setSession(c, "", -1)
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+// RegisterForm serves the registration form
+func (h *AccountHandler) RegisterForm(c *gin.Context) {
+	h.render(c, http.StatusOK, "Register", &theme.Account{View: "register"})
+}
+
+// Register creates an account, whose address is verified by the link sent
+// to it
+func (h *AccountHandler) Register(c *gin.Context) {
+	body := models.UserRegister{Email: c.PostForm("email"), Password: c.PostForm("password"), Name: c.PostForm("name")}
+	user, err := h.service.Register(c.Request.Context(), &body)
+	if err != nil {
+		h.formError(c, err, "Register", &theme.Account{View: "register", Email: body.Email, Name: body.Name})
+		return
+	}
+	h.render(c, http.StatusCreated, "Verify your email address", &theme.Account{
+		View:   "verify",
+		Email:  user.Email,
+		Notice: "We sent a link to " + user.Email + ", open it to verify your address before logging in.",
+	})
+}
+
+// Verify verifies the address of the token of the link sent by email. The
+// page without token offers to send a new link.
+func (h *AccountHandler) Verify(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		h.render(c, http.StatusOK, "Verify your email address", &theme.Account{View: "verify"})
+		return
+	}
+	user, err := h.service.VerifyEmail(c.Request.Context(), token)
+	if err != nil {
+		h.formError(c, err, "Verify your email address", &theme.Account{View: "verify"})
+		return
+	}
+	h.render(c, http.StatusOK, "Log in", &theme.Account{
+		View:   "login",
+		Email:  user.Email,
+		Notice: "Your email address is verified, you can log in.",
+	})
+}
+
+// ResendVerification sends a new verification link
+func (h *AccountHandler) ResendVerification(c *gin.Context) {
+	h.sendLink(c, "Verify your email address", "verify", h.service.ResendVerification,
+		"If an unverified account exists for %s, a new link was sent to it.")
+}
+
+// ForgotPasswordForm serves the form asking for a password reset link
+func (h *AccountHandler) ForgotPasswordForm(c *gin.Context) {
+	h.render(c, http.StatusOK, "Forgot your password?", &theme.Account{View: "forgot"})
+}
+
+// ForgotPassword sends a password reset link
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	h.sendLink(c, "Forgot your password?", "forgot", h.service.RequestPasswordReset,
+		"If an account exists for %s, a link to choose a new password was sent to it.")
+}
+
+// ResetPasswordForm serves the form choosing a new password, for the token
+// of the link sent by email
+func (h *AccountHandler) ResetPasswordForm(c *gin.Context) {
+	h.render(c, http.StatusOK, "Choose a new password", &theme.Account{View: "reset", Token: c.Query("token")})
+}
+
+// ResetPassword sets a new password. The sessions of the account end,
+// this one included.
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	body := models.PasswordReset{Token: c.PostForm("token"), Password: c.PostForm("password")}
+	if err := h.service.ResetPassword(c.Request.Context(), body); err != nil {
+		h.formError(c, err, "Choose a new password", &theme.Account{View: "reset", Token: body.Token})
+		return
+	}
+	h.setSession(c, "", -1)
+	h.render(c, http.StatusOK, "Log in", &theme.Account{View: "login", Notice: "Your password was changed, you can log in."})
+}
+
+// Account serves the account of the session, or redirects to the login
+// form
+func (h *AccountHandler) Account(c *gin.Context) {
+	user := h.sessionUser(c)
+	if user == nil {
+		c.Redirect(http.StatusSeeOther, "/login")
+		return
+	}
+	h.render(c, http.StatusOK, "Your account", &theme.Account{View: "account", User: user})
+}
+
+// sendLink mails a link to the posted address and tells so, the same way
+// whether the account exists or not
+func (h *AccountHandler) sendLink(c *gin.Context, title, view string, send func(ctx context.Context, email string) error, notice string) {
+	body := models.EmailRequest{Email: c.PostForm("email")}
+	account := &theme.Account{View: view, Email: body.Email}
+	if err := validation.Struct(&body); err != nil {
+		h.formError(c, err, title, account)
+		return
+	}
+	if err := send(c.Request.Context(), body.Email); err != nil {
+		c.Error(apperrors.Wrap(err, "failed to send the link"))
+		return
+	}
+	account.Notice = fmt.Sprintf(notice, body.Email)
+	h.render(c, http.StatusOK, title, account)
+}
+
+// sessionUser returns the user of the session cookie, nil without a valid
+// session
+func (h *AccountHandler) sessionUser(c *gin.Context) *models.User {
+	token, err := c.Cookie(middleware.SessionCookie)
+	if err != nil || token == "" {
+		return nil
+	}
+	user, err := h.service.Session(c.Request.Context(), token)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// setSession sets the session cookie, deleted with a negative maxAge
+func (h *AccountHandler) setSession(c *gin.Context, token string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     middleware.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.secure(),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// secure reports whether the site is served over HTTPS, so that its
+// cookies are only sent over HTTPS
+func (h *AccountHandler) secure() bool {
+	return strings.HasPrefix(h.site.URL, "https://")
+}
+
+// formError shows the form again with the reason it was rejected, for the
+// errors the user can fix, and reports the others
+func (h *AccountHandler) formError(c *gin.Context, err error, title string, account *theme.Account) {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Kind == apperrors.KindInternal {
+		c.Error(apperrors.Wrap(err, "failed to process the form"))
+		return
+	}
+	account.Error = appErr.Message
+	account.Invalid = appErr.Fields
+	h.render(c, appErr.Kind.HTTPStatus(), title, account)
+}
+
+func (h *AccountHandler) render(c *gin.Context, status int, title string, account *theme.Account) {
+	if !h.theme.Has("account") {
+		c.Error(apperrors.NotFound("the theme has no account pages"))
+		return
+	}
+	account.CSRF = middleware.CSRFToken(c)
+	page := theme.NewPage(h.site, title+" - "+h.site.Title, c.Request.URL.Path)
+	page.Account = account
+
+	var buf bytes.Buffer
+	if err := h.theme.Render(&buf, "account", page); err != nil {
+		c.Error(apperrors.Internal("failed to render the page", err))
+		return
+	}
+	// pages carry tokens, in the form and in the URL of the links sent by
+	// email, which must not be cached or leak to other sites
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Data(status, htmlContentType, buf.Bytes())
+}
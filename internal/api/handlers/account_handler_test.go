@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/theme"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// browser sends requests with the cookies set by the previous responses
+type browser struct {
+	router  *gin.Engine
+	cookies map[string]string
+}
+
+func (b *browser) do(method, target string, form url.Values) *httptest.ResponseRecorder {
+	var req *http.Request
+	if form != nil {
+		form.Set(middleware.CSRFField, b.cookies[middleware.CSRFCookie])
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	for name, value := range b.cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	w := httptest.NewRecorder()
+	b.router.ServeHTTP(w, req)
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(b.cookies, cookie.Name)
+		} else {
+			b.cookies[cookie.Name] = cookie.Value
+		}
+	}
+	return w
+}
+
+func newTestAccountBrowser(t *testing.T) (*browser, *testMailer) {
+	t.Helper()
+	service, mailer := newTestUserService()
+	th, err := theme.Default()
+	if err != nil {
+		t.Fatalf("failed to load the theme: %v", err)
+	}
+	site := config.SiteConfig{URL: "https://blog.example.com", Title: "Blog"}
+	router := newTestRouter(t, "", NewAccountHandler(service, th, site).RegisterRoutes)
+
+	if _, err := service.Register(context.Background(), &models.UserRegister{Email: "ann@example.com", Password: "correct horse", Name: "Ann"}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	b := &browser{router: router, cookies: map[string]string{}}
+	return b, mailer
+}
+
+func TestAccountHandler_Login(t *testing.T) {
+	b, mailer := newTestAccountBrowser(t)
+
+	if w := b.do(http.MethodGet, "/account", nil); w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/login" {
+		t.Errorf("expected a redirect to the login form, got %d", w.Code)
+	}
+	w := b.do(http.MethodGet, "/login", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("expected an uncached page, got %d %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+	if token := b.cookies[middleware.CSRFCookie]; token == "" || !strings.Contains(w.Body.String(), `value="`+token+`"`) {
+		t.Errorf("expected the CSRF token in the form, got %s", w.Body.String())
+	}
+
+	credentials := url.Values{"email": {"ann@example.com"}, "password": {"correct horse"}}
+	w = b.do(http.MethodPost, "/login", credentials)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), `action="/verify/resend"`) {
+		t.Errorf("expected to be offered a new link before verifying, got %d", w.Code)
+	}
+	if w := b.do(http.MethodGet, "/verify?token="+mailer.lastToken(t), nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "is verified") {
+		t.Fatalf("expected the address to be verified, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = b.do(http.MethodPost, "/login", url.Values{"email": {"ann@example.com"}, "password": {"wrong horse"}})
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "invalid email address or password") {
+		t.Errorf("expected the form again for a wrong password, got %d", w.Code)
+	}
+	w = b.do(http.MethodPost, "/login", credentials)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/account" {
+		t.Fatalf("expected a redirect to the account, got %d: %s", w.Code, w.Body.String())
+	}
+	session := w.Result().Cookies()[0]
+	if session.Name != middleware.SessionCookie || !session.HttpOnly || !session.Secure || session.SameSite != http.SameSiteLaxMode {
+		t.Errorf("expected a protected session cookie, got %+v", session)
+	}
+	if w := b.do(http.MethodGet, "/account", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "ann@example.com") {
+		t.Errorf("expected the account, got %d", w.Code)
+	}
+
+	// the token of the form is required
+	csrf := b.cookies[middleware.CSRFCookie]
+	b.cookies[middleware.CSRFCookie] = strings.Repeat("a", len(csrf))
+	req := httptest.NewRequest(http.MethodPost, "/logout", strings.NewReader(url.Values{middleware.CSRFField: {csrf}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for name, value := range b.cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	w = httptest.NewRecorder()
+	b.router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d without the CSRF token, got %d", http.StatusForbidden, w.Code)
+	}
+	b.cookies[middleware.CSRFCookie] = csrf
+
+	if w := b.do(http.MethodPost, "/logout", url.Values{}); w.Code != http.StatusSeeOther {
+		t.Errorf("expected a redirect, got %d", w.Code)
+	}
+	if w := b.do(http.MethodGet, "/account", nil); w.Code != http.StatusSeeOther {
+		t.Errorf("expected the session to be over, got %d", w.Code)
+	}
+}
+
+func TestAccountHandler_Register(t *testing.T) {
+	b, mailer := newTestAccountBrowser(t)
+	b.do(http.MethodGet, "/register", nil)
+
+	w := b.do(http.MethodPost, "/register", url.Values{"email": {"bob@example"}, "password": {"short"}, "name": {"<Bob>"}})
+	body := w.Body.String()
+	if w.Code != http.StatusBadRequest || !strings.Contains(body, "password") || !strings.Contains(body, `value="&lt;Bob&gt;"`) {
+		t.Errorf("expected the form again with the invalid fields, got %d: %s", w.Code, body)
+	}
+	w = b.do(http.MethodPost, "/register", url.Values{"email": {"bob@example.com"}, "password": {"correct horse"}})
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "We sent a link to bob@example.com") {
+		t.Errorf("expected a notice, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mailer.messages) != 2 {
+		t.Errorf("expected a verification email, got %d emails", len(mailer.messages))
+	}
+
+	w = b.do(http.MethodPost, "/register", url.Values{"email": {"ann@example.com"}, "password": {"correct horse"}})
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "We sent a link to ann@example.com") {
+		t.Errorf("expected the same notice for a taken address, got %d: %s", w.Code, w.Body.String())
+	}
+	if msg := mailer.messages[len(mailer.messages)-1]; msg.To != "ann@example.com" || msg.Subject != "You already have an account" {
+		t.Errorf("expected the owner to be told about the account, got %+v", msg)
+	}
+}
+
+func TestAccountHandler_ResetPassword(t *testing.T) {
+	b, mailer := newTestAccountBrowser(t)
+	b.do(http.MethodGet, "/forgot-password", nil)
+
+	w := b.do(http.MethodPost, "/forgot-password", url.Values{"email": {"ann@example.com"}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "a link to choose a new password was sent") {
+		t.Fatalf("expected a notice, got %d: %s", w.Code, w.Body.String())
+	}
+	token := mailer.lastToken(t)
+
+	w = b.do(http.MethodGet, "/reset-password?token="+token, nil)
+	if !strings.Contains(w.Body.String(), `value="`+token+`"`) || w.Header().Get("Referrer-Policy") != "no-referrer" {
+		t.Errorf("expected the token in the form and no referrer, got %s", w.Body.String())
+	}
+	if w := b.do(http.MethodPost, "/reset-password", url.Values{"token": {"unknown"}, "password": {"battery staple"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown token, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := b.do(http.MethodPost, "/reset-password", url.Values{"token": {token}, "password": {"battery staple"}}); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := b.do(http.MethodPost, "/login", url.Values{"email": {"ann@example.com"}, "password": {"battery staple"}}); w.Code != http.StatusSeeOther {
+		t.Errorf("expected to log in with the new password, got %d", w.Code)
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverheadBytes is the room left for the headers and boundaries
+// of a multipart upload on top of the size of its file
+const multipartOverheadBytes = 64 << 10
+
+// Uploads are given as long as the largest file takes at
+// minUploadBytesPerSecond, and at least minUploadTime, to be read in full
+// whatever the read timeout of the server
+const (
+	minUploadBytesPerSecond = 32 << 10
+	minUploadTime           = 30 * time.Second
+)
+
+// processingRetryAfter is the delay in seconds clients polling an image
+// being processed are told to wait
+const processingRetryAfter = 1
+
+// attachmentCacheControl lets clients and proxies keep attachments for a
+// year, their content never changes under their URL
+const attachmentCacheControl = "public, max-age=31536000, immutable"
+
+type AttachmentHandler struct {
+	service *services.AttachmentService
+}
+
+func NewAttachmentHandler(s *services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{s}
+}
+
+func (h *AttachmentHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/posts/:id/attachments", h.GetAttachments)
+	r.POST("/posts/:id/attachments", h.UploadAttachment)
+	r.GET("/posts/:id/attachments/:attachment_id", h.DownloadAttachment)
+	r.HEAD("/posts/:id/attachments/:attachment_id", h.DownloadAttachment)
+	r.DELETE("/posts/:id/attachments/:attachment_id", h.DeleteAttachment)
+	r.GET("/posts/:id/attachments/:attachment_id/metadata", h.GetAttachment)
+	r.GET("/posts/:id/attachments/:attachment_id/variants/:variant", h.DownloadVariant)
+	r.HEAD("/posts/:id/attachments/:attachment_id/variants/:variant", h.DownloadVariant)
+}
+
+// @Summary Get the attachments of a blog post
+// @Description Retrieves the metadata of the files attached to a blog post, oldest first
+// @Tags Attachments
+// @Produce json,application/problem+json
+// @Param id path string true "Blog post ID"
+// @Success 200 {array} models.Attachment "Attachments of the blog post"
+// @Failure 404 {object} models.Problem "Blog post not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /posts/{id}/attachments [get]
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	attachments, err := h.service.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve the attachments of a post"))
+		return
+	}
+	if attachments == nil {
+		attachments = []models.Attachment{}
+	}
+	c.JSON(http.StatusOK, attachments)
+}
+
+// @Summary Upload an attachment
+// @Description Attaches a file to a blog post, as a new version of the post. The content type is sniffed from
+// @Description the content rather than trusted from the client, and must be one of the accepted types:
+// @Description PNG, JPEG, GIF and WebP images and PDF documents unless configured. The file is streamed to
+// @Description the store along with its SHA-256 checksum. Images are stored without their metadata, e.g.
+// @Description EXIF data, and malformed images are rejected.
+// @Tags Attachments
+// @Accept multipart/form-data
+// @Produce json,application/problem+json
+// @Param id path string true "Blog post ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.Attachment "Attachment created successfully"
+// @Failure 400 {object} models.Problem "Missing, too large, unsupported or malformed file"
+// @Failure 404 {object} models.Problem "Blog post not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /posts/{id}/attachments [post]
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	limit := h.service.MaxBytes() + multipartOverheadBytes
+	if err := extendDeadlines(http.NewResponseController(c.Writer), uploadTime(limit)); err != nil {
+		c.Error(apperrors.Internal("failed to upload an attachment", err))
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.Error(apperrors.BadRequest("body must be multipart/form-data", err))
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			c.Error(apperrors.Validation("attachment is invalid", apperrors.FieldError{Field: "file", Reason: "is required"}))
+			return
+		}
+		if err != nil {
+			c.Error(uploadError(apperrors.BadRequest("invalid body provided", err)))
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.service.Upload(c.Request.Context(), c.Param("id"), part.FileName(), part)
+		part.Close()
+		if err != nil {
+			c.Error(uploadError(err))
+			return
+		}
+		c.Header("Location", attachment.URL)
+		c.JSON(http.StatusCreated, attachment)
+		return
+	}
+}
+
+// uploadTime returns the time given to read an upload of up to limit
+// bytes
+func uploadTime(limit int64) time.Duration {
+	return max(minUploadTime, time.Duration(limit/minUploadBytesPerSecond)*time.Second)
+}
+
+// extendDeadlines gives the request d to be read and answered, the server
+// timeouts being too short for large bodies sent over slow links
+func extendDeadlines(rc *http.ResponseController, d time.Duration) error {
+	deadline := time.Now().Add(d)
+	if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// uploadError reports the bodies cut by the request size limit as such
+func uploadError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return apperrors.BadRequest(fmt.Sprintf("body must be at most %d bytes long", maxErr.Limit), err)
+	}
+	return apperrors.Wrap(err, "failed to upload an attachment")
+}
+
+// @Summary Download an attachment
+// @Description Serves the content of an attachment with its sniffed content type. Range requests are supported,
+// @Description and the response can be cached for good: an attachment never changes under its URL. Its ETag is
+// @Description the SHA-256 checksum of the content.
+// @Tags Attachments
+// @Produce png,jpeg,gif,image/webp,application/pdf,application/problem+json
+// @Param id path string true "Blog post ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Success 200 {file} file "Content of the attachment"
+// @Success 206 {file} file "Requested range of the content"
+// @Success 304 "Not modified since the version the client has"
+// @Failure 404 {object} models.Problem "Blog post or attachment not found"
+// @Failure 416 "Range not satisfiable"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /posts/{id}/attachments/{attachment_id} [get]
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachment, file, err := h.service.Open(c.Request.Context(), c.Param("id"), c.Param("attachment_id"))
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve an attachment"))
+		return
+	}
+	defer file.Close()
+	serveFile(c, attachment.ContentType, attachment.SHA256, attachment.Filename, attachment.CreatedAt, file)
+}
+
+// serveFile serves the content of an attachment or of one of its variants
+func serveFile(c *gin.Context, contentType, sha256, filename string, modTime time.Time, content io.ReadSeeker) {
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("ETag", `"`+sha256+`"`)
+	header.Set("Cache-Control", attachmentCacheControl)
+	// the content type was sniffed on upload, browsers must not guess
+	// another one
+	header.Set("X-Content-Type-Options", "nosniff")
+	if disposition := contentDisposition(contentType, filename); disposition != "" {
+		header.Set("Content-Disposition", disposition)
+	}
+	http.ServeContent(c.Writer, c.Request, filename, modTime, content)
+}
+
+// contentDisposition displays images inline and has other files, e.g.
+// PDF documents, downloaded under their original name
+func contentDisposition(contentType, filename string) string {
+	disposition := "attachment"
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+	params := map[string]string{}
+	if filename != "" {
+		params["filename"] = filename
+	}
+	return mime.FormatMediaType(disposition, params)
+}
+
+// @Summary Get an attachment
+// @Description Retrieves the metadata of an attachment, e.g. to poll the processing of an image until its
+// @Description resized variants are listed. Retry-After is set while the image is pending.
+// @Tags Attachments
+// @Produce json,application/problem+json
+// @Param id path string true "Blog post ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Success 200 {object} models.Attachment "Attachment"
+// @Failure 404 {object} models.Problem "Blog post or attachment not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /posts/{id}/attachments/{attachment_id}/metadata [get]
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+	attachment, err := h.service.Get(c.Request.Context(), c.Param("id"), c.Param("attachment_id"))
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve an attachment"))
+		return
+	}
+	if attachment.Processing == models.ProcessingPending {
+		c.Header("Retry-After", strconv.Itoa(processingRetryAfter))
+	}
+	c.JSON(http.StatusOK, attachment)
+}
+
+// @Summary Download a variant of an image
+// @Description Serves a resized copy of an image attachment, encoded again, like the original is served. Images have thumbnail, medium and large variants once processed.
+// @Tags Attachments
+// @Produce jpeg,png,application/problem+json
+// @Param id path string true "Blog post ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Param variant path string true "Variant name" example(thumbnail)
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Success 200 {file} file "Content of the variant"
+// @Success 206 {file} file "Requested range of the content"
+// @Success 304 "Not modified since the version the client has"
+// @Failure 404 {object} models.Problem "Blog post, attachment or variant not found, or not processed yet"
+// @Failure 416 "Range not satisfiable"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /posts/{id}/attachments/{attachment_id}/variants/{variant} [get]
+func (h *AttachmentHandler) DownloadVariant(c *gin.Context) {
+	attachment, variant, file, err := h.service.OpenVariant(c.Request.Context(), c.Param("id"), c.Param("attachment_id"), c.Param("variant"))
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve a variant"))
+		return
+	}
+	defer file.Close()
+	serveFile(c, variant.ContentType, variant.SHA256, variantFilename(attachment.Filename, variant), attachment.CreatedAt, file)
+}
+
+// variantFilename names a variant after its original, e.g.
+// photo-thumbnail.jpg for photo.png
+func variantFilename(original string, v *models.AttachmentVariant) string {
+	if original == "" {
+		return ""
+	}
+	ext := ".jpg"
+	if v.ContentType == "image/png" {
+		ext = ".png"
+	}
+	return strings.TrimSuffix(original, path.Ext(original)) + "-" + v.Name + ext
+}
+
+// @Summary Delete an attachment
+// @Description Detaches a file from a blog post, as a new version of the post, and deletes its content
+// @Tags Attachments
+// @Produce json,application/problem+json
+// @Param id path string true "Blog post ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Success 204 "Attachment deleted successfully (no content)"
+// @Failure 404 {object} models.Problem "Blog post or attachment not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /posts/{id}/attachments/{attachment_id} [delete]
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), c.Param("attachment_id")); err != nil {
+		c.Error(apperrors.Wrap(err, "failed to delete an attachment"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
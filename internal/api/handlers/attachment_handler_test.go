@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/blob"
+	"blog-posts-api/internal/imaging"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newAttachmentService(t *testing.T, posts *services.BlogPostService, maxBytes int64) *services.AttachmentService {
+	t.Helper()
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create the store: %v", err)
+	}
+	return services.NewAttachmentService(posts, store, services.AttachmentOptions{
+		MaxBytes:   maxBytes,
+		URLPrefix:  "/api/v1/posts",
+		ImageTypes: imaging.Types,
+	})
+}
+
+func newTestAttachmentRouter(t *testing.T) (*gin.Engine, *services.BlogPostService, *services.AttachmentService, string) {
+	t.Helper()
+	posts := newTestPostService(t, testPosts()[0])
+	dir := t.TempDir()
+	store, err := blob.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create the store: %v", err)
+	}
+	attachments := services.NewAttachmentService(posts, store, services.AttachmentOptions{
+		MaxBytes:   16 << 10,
+		URLPrefix:  "/api/v1/posts",
+		ImageTypes: imaging.Types,
+	})
+	posts.Subscribe(attachments.Notify)
+	return newTestRouter(t, "/api/v1", NewAttachmentHandler(attachments).RegisterRoutes), posts, attachments, dir
+}
+
+// processImages processes the queued images right away
+func processImages(attachments *services.AttachmentService) {
+	processor := imaging.NewProcessor(attachments, imaging.Options{})
+	for _, job := range attachments.NextImages(100) {
+		processor.Process(context.Background(), job)
+	}
+}
+
+// pngImage returns a small PNG image
+func pngImage(t *testing.T) []byte {
+	return encodePNG(t, image.NewGray(image.Rect(0, 0, 4, 4)))
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode the image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// multipartFile returns the content type and body of a form uploading a
+// file
+func multipartFile(t *testing.T, field, filename string, content []byte) (string, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	w.WriteField("caption", "ignored")
+	part, err := w.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatalf("failed to create the form: %v", err)
+	}
+	part.Write(content)
+	w.Close()
+	return w.FormDataContentType(), buf.String()
+}
+
+// attachmentPath returns the path of an attachment relative to /api/v1
+func attachmentPath(a models.Attachment) string {
+	return strings.TrimPrefix(a.URL, "/api/v1")
+}
+
+func send(router *gin.Engine, method, target, contentType, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func upload(t *testing.T, router *gin.Engine, filename string, content []byte) models.Attachment {
+	t.Helper()
+	contentType, body := multipartFile(t, "file", filename, content)
+	w := send(router, http.MethodPost, "/api/v1/posts/1/attachments", contentType, body, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var attachment models.Attachment
+	if err := json.Unmarshal(w.Body.Bytes(), &attachment); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return attachment
+}
+
+func TestAttachmentHandler_UploadAndDownload(t *testing.T) {
+	router, posts, _, _ := newTestAttachmentRouter(t)
+	content := pngImage(t)
+
+	attachment := upload(t, router, `C:\photos\dot.png`, content)
+	sum := sha256.Sum256(content)
+	if attachment.ContentType != "image/png" || attachment.Filename != "dot.png" ||
+		attachment.Size != int64(len(content)) || attachment.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the metadata of the PNG image, got %+v", attachment)
+	}
+
+	post, _ := posts.GetById(context.Background(), "1")
+	if len(post.Attachments) != 1 || post.Attachments[0].ID != attachment.ID || post.Version != 2 {
+		t.Fatalf("expected the attachment to be stored on a new version of the post, got %+v", post)
+	}
+	// updates of the post keep its attachments
+	post.Title = "Renamed"
+	if updated, err := posts.Update(context.Background(), "1", post); err != nil || len(updated.Attachments) != 1 {
+		t.Errorf("expected the update to keep the attachment, got %v", err)
+	}
+
+	w := send(router, http.MethodGet, "/api/v1/posts/1/attachments/"+attachment.ID, "", "", nil)
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), content) {
+		t.Fatalf("expected the content, got %d: %q", w.Code, w.Body.String())
+	}
+	for name, want := range map[string]string{
+		"Content-Type":        "image/png",
+		"ETag":                `"` + attachment.SHA256 + `"`,
+		"Cache-Control":       attachmentCacheControl,
+		"Content-Disposition": `inline; filename=dot.png`,
+		"Accept-Ranges":       "bytes",
+	} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("expected %s %q, got %q", name, want, got)
+		}
+	}
+
+	w = send(router, http.MethodGet, "/api/v1/posts/1/attachments", "", "", nil)
+	var list []models.Attachment
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list) != 1 || list[0].ID != attachment.ID {
+		t.Errorf("expected the attachment to be listed, got %s", w.Body.String())
+	}
+}
+
+func TestAttachmentHandler_SlowUpload(t *testing.T) {
+	router, _, _, _ := newTestAttachmentRouter(t)
+	server := httptest.NewUnstartedServer(router)
+	server.Config.ReadTimeout = 200 * time.Millisecond
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	// the body takes longer than the timeouts of the server to arrive
+	contentType, body := multipartFile(t, "file", "dot.png", pngImage(t))
+	r, w := io.Pipe()
+	go func() {
+		chunk := len(body)/5 + 1
+		for len(body) > 0 {
+			n := min(chunk, len(body))
+			time.Sleep(100 * time.Millisecond)
+			if _, err := io.WriteString(w, body[:n]); err != nil {
+				return
+			}
+			body = body[n:]
+		}
+		w.Close()
+	}()
+
+	resp, err := http.Post(server.URL+"/api/v1/posts/1/attachments", contentType, r)
+	if err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("expected status %d, got %d: %s", http.StatusCreated, resp.StatusCode, data)
+	}
+}
+
+func TestAttachmentHandler_RangesAndConditionalRequests(t *testing.T) {
+	router, _, _, _ := newTestAttachmentRouter(t)
+	content := pngImage(t)
+	attachment := upload(t, router, "dot.png", content)
+	target := "/api/v1/posts/1/attachments/" + attachment.ID
+
+	w := send(router, http.MethodGet, target, "", "", map[string]string{"Range": "bytes=1-3"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != string(content[1:4]) {
+		t.Errorf("expected bytes 1 to 3, got %d: %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Range"); !strings.HasPrefix(got, "bytes 1-3/") {
+		t.Errorf("expected a Content-Range header, got %q", got)
+	}
+
+	w = send(router, http.MethodGet, target, "", "", map[string]string{"If-None-Match": `"` + attachment.SHA256 + `"`})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	w = send(router, http.MethodHead, target, "", "", nil)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("ETag") == "" {
+		t.Errorf("expected the headers without content, got %d: %q", w.Code, w.Body.String())
+	}
+}
+
+func TestAttachmentHandler_RejectsFiles(t *testing.T) {
+	router, posts, _, _ := newTestAttachmentRouter(t)
+
+	html := []byte("<!DOCTYPE html><script>alert(1)</script>")
+	large := append(pngImage(t), make([]byte, 16<<10)...)
+	cases := []struct {
+		name    string
+		target  string
+		field   string
+		content []byte
+		status  int
+		reason  string
+	}{
+		{name: "unsupported type", target: "/api/v1/posts/1/attachments", field: "file", content: html, status: http.StatusBadRequest, reason: "must be one of: image/png, image/jpeg, image/gif, image/webp, application/pdf"},
+		{name: "too large", target: "/api/v1/posts/1/attachments", field: "file", content: large, status: http.StatusBadRequest, reason: "must be at most 16384 bytes long"},
+		{name: "empty", target: "/api/v1/posts/1/attachments", field: "file", content: nil, status: http.StatusBadRequest, reason: "is required"},
+		{name: "missing file", target: "/api/v1/posts/1/attachments", field: "image", content: pngImage(t), status: http.StatusBadRequest, reason: "is required"},
+		{name: "missing post", target: "/api/v1/posts/2/attachments", field: "file", content: pngImage(t), status: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			contentType, body := multipartFile(t, tc.field, "upload.png", tc.content)
+			w := send(router, http.MethodPost, tc.target, contentType, body, nil)
+			if w.Code != tc.status {
+				t.Fatalf("expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+			if tc.reason == "" {
+				return
+			}
+			var problem models.Problem
+			json.Unmarshal(w.Body.Bytes(), &problem)
+			if len(problem.InvalidParams) != 1 || problem.InvalidParams[0].Name != "file" || problem.InvalidParams[0].Reason != tc.reason {
+				t.Errorf("expected file to be reported with %q, got %+v", tc.reason, problem.InvalidParams)
+			}
+		})
+	}
+
+	w := send(router, http.MethodPost, "/api/v1/posts/1/attachments", "application/json", "{}", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a JSON body, got %d", http.StatusBadRequest, w.Code)
+	}
+	if post, _ := posts.GetById(context.Background(), "1"); len(post.Attachments) != 0 || post.Version != 1 {
+		t.Errorf("expected the post to be left alone, got %+v", post)
+	}
+}
+
+func TestAttachmentHandler_Delete(t *testing.T) {
+	router, posts, _, dir := newTestAttachmentRouter(t)
+	first := upload(t, router, "first.png", pngImage(t))
+	second := upload(t, router, "second.png", pngImage(t))
+
+	w := send(router, http.MethodDelete, "/api/v1/posts/1/attachments/"+first.ID, "", "", nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "posts", "1", first.ID)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the file to be deleted, got %v", err)
+	}
+	w = send(router, http.MethodGet, "/api/v1/posts/1/attachments/"+first.ID, "", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	w = send(router, http.MethodDelete, "/api/v1/posts/1/attachments/"+first.ID, "", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	// the files of a deleted post are removed in the background
+	if err := posts.Delete(context.Background(), "1"); err != nil {
+		t.Fatalf("failed to delete the post: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, err := os.Stat(filepath.Join(dir, "posts", "1", second.ID))
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the files of the post to be deleted, got %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAttachmentHandler_Variants(t *testing.T) {
+	router, _, attachments, _ := newTestAttachmentRouter(t)
+	opaque := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	for i := range opaque.Pix {
+		opaque.Pix[i] = 0xff
+	}
+	attachment := upload(t, router, "photo.png", encodePNG(t, opaque))
+	if attachment.Processing != models.ProcessingPending {
+		t.Fatalf("expected the image to be pending, got %q", attachment.Processing)
+	}
+	metadata := "/api/v1/posts/1/attachments/" + attachment.ID + "/metadata"
+
+	w := send(router, http.MethodGet, metadata, "", "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Retry-After") == "" {
+		t.Errorf("expected the pending attachment with Retry-After, got %d: %v", w.Code, w.Header())
+	}
+	w = send(router, http.MethodGet, "/api/v1/posts/1/attachments/"+attachment.ID+"/variants/thumbnail", "", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d before processing, got %d", http.StatusNotFound, w.Code)
+	}
+
+	processImages(attachments)
+
+	w = send(router, http.MethodGet, metadata, "", "", nil)
+	var processed models.Attachment
+	json.Unmarshal(w.Body.Bytes(), &processed)
+	if processed.Processing != models.ProcessingSucceeded || w.Header().Get("Retry-After") != "" {
+		t.Fatalf("expected the image to be processed, got %s", w.Body.String())
+	}
+	sizes := map[string]string{}
+	for _, v := range processed.Variants {
+		sizes[v.Name] = fmt.Sprintf("%dx%d %s", v.Width, v.Height, v.ContentType)
+	}
+	want := map[string]string{"thumbnail": "200x200 image/jpeg", "medium": "400x300 image/jpeg", "large": "400x300 image/jpeg"}
+	if !reflect.DeepEqual(sizes, want) {
+		t.Errorf("expected variants %v, got %v", want, sizes)
+	}
+
+	thumbnail := processed.Variants[0]
+	w = send(router, http.MethodGet, thumbnail.URL, "", "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/jpeg" || w.Header().Get("ETag") != `"`+thumbnail.SHA256+`"` {
+		t.Fatalf("expected the thumbnail, got %d: %v", w.Code, w.Header())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != "inline; filename=photo-thumbnail.jpg" {
+		t.Errorf("expected the thumbnail to be named after the original, got %q", got)
+	}
+	img, err := jpeg.Decode(w.Body)
+	if err != nil || img.Bounds().Dx() != 200 || img.Bounds().Dy() != 200 {
+		t.Errorf("expected a 200x200 JPEG image, got %v", err)
+	}
+
+	w = send(router, http.MethodGet, "/api/v1/posts/1/attachments/"+attachment.ID+"/variants/huge", "", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for an unknown variant, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/api/validation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxAuthBodyBytes bounds the size of an account request body
+const MaxAuthBodyBytes = 16 << 10
+
+type AuthHandler struct {
+	service *services.UserService
+}
+
+func NewAuthHandler(s *services.UserService) *AuthHandler {
+	return &AuthHandler{s}
+}
+
+func (h *AuthHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/auth/register", h.Register)
+	r.POST("/auth/verify", h.VerifyEmail)
+	r.POST("/auth/verify/resend", h.ResendVerification)
+	r.POST("/auth/token", h.IssueToken)
+	r.POST("/auth/password/forgot", h.ForgotPassword)
+	r.POST("/auth/password/reset", h.ResetPassword)
+	r.GET("/auth/me", middleware.Authenticate(h.service), middleware.RequireUser(), h.Me)
+}
+
+// @Summary Register a user
+// @Description Creates an account and sends a link verifying its email address. Logging in is possible once it is opened. A taken address gets the same response, without creating an account, and its owner is told by email instead.
+// @Tags Users
+// @Accept json
+// @Produce json,application/problem+json
+// @Param user body models.UserRegister true "Account data"
+// @Success 201 {object} models.User "Created account"
+// @Failure 400 {object} models.Problem "Invalid request body, every invalid field is listed in invalid-params"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /auth/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
+	var body models.UserRegister
+	if !decodeAuthBody(c, &body) {
+		return
+	}
+
+	user, err := h.service.Register(c.Request.Context(), &body)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to register a user"))
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+// @Summary Verify an email address
+// @Description Verifies the email address of an account with the token of the link sent to it
+// @Tags Users
+// @Accept json
+// @Produce json,application/problem+json
+// @Param verification body models.EmailVerification true "Token of the link"
+// @Success 200 {object} models.User "Verified account"
+// @Failure 400 {object} models.Problem "Invalid or expired token"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /auth/verify [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var body models.EmailVerification
+	if !decodeAuthBody(c, &body) {
+		return
+	}
+
+	user, err := h.service.VerifyEmail(c.Request.Context(), body.Token)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to verify an email address"))
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Resend the verification link
+// @Description Sends a new verification link to an unverified account. The response is the same for unknown addresses.
+// @Tags Users
+// @Accept json
+// @Produce application/problem+json
+// @Param email body models.EmailRequest true "Email address of the account"
+// @Success 202 "Link sent if the account exists (no content)"
+// @Failure 400 {object} models.Problem "Invalid request body, every invalid field is listed in invalid-params"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /auth/verify/resend [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var body models.EmailRequest
+	if !decodeAuthBody(c, &body) {
+		return
+	}
+
+	if err := h.service.ResendVerification(c.Request.Context(), body.Email); err != nil {
+		c.Error(apperrors.Wrap(err, "failed to resend the verification link"))
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// @Summary Issue an access token
+// @Description Logs in with the email address and password of a verified account and returns a JWT,
+// @Description sent as Authorization: Bearer <access_token> by the requests of the user.
+// @Tags Users
+// @Accept json
+// @Produce json,application/problem+json
+// @Param credentials body models.Credentials true "Email address and password"
+// @Success 200 {object} models.AccessToken "Access token"
+// @Failure 400 {object} models.Problem "Invalid request body, every invalid field is listed in invalid-params"
+// @Failure 401 {object} models.Problem "Wrong email address or password"
+// @Failure 403 {object} models.Problem "Email address not verified"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /auth/token [post]
+func (h *AuthHandler) IssueToken(c *gin.Context) {
+	var body models.Credentials
+	if !decodeAuthBody(c, &body) {
+		return
+	}
+
+	user, err := h.service.Login(c.Request.Context(), body)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to log in"))
+		return
+	}
+	token, err := h.service.IssueAccessToken(user)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to issue an access token"))
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, token)
+}
+
+// @Summary Ask for a password reset
+// @Description Sends a link to choose a new password. The response is the same for unknown addresses.
+// @Tags Users
+// @Accept json
+// @Produce application/problem+json
+// @Param email body models.EmailRequest true "Email address of the account"
+// @Success 202 "Link sent if the account exists (no content)"
+// @Failure 400 {object} models.Problem "Invalid request body, every invalid field is listed in invalid-params"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var body models.EmailRequest
+	if !decodeAuthBody(c, &body) {
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(c.Request.Context(), body.Email); err != nil {
+		c.Error(apperrors.Wrap(err, "failed to send a password reset link"))
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// @Summary Reset a password
+// @Description Sets a new password with the token of the link sent by email. The sessions and access tokens of the account are revoked.
+// @Tags Users
+// @Accept json
+// @Produce application/problem+json
+// @Param reset body models.PasswordReset true "Token of the link and new password"
+// @Success 204 "Password changed (no content)"
+// @Failure 400 {object} models.Problem "Invalid or expired token, or invalid password"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var body models.PasswordReset
+	if !decodeAuthBody(c, &body) {
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), body); err != nil {
+		c.Error(apperrors.Wrap(err, "failed to reset a password"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get the current user
+// @Description Retrieves the account of the access token
+// @Tags Users
+// @Produce json,application/problem+json
+// @Security BearerAuth
+// @Success 200 {object} models.User "Current account"
+// @Failure 401 {object} models.Problem "Missing, invalid or expired access token"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /auth/me [get]
+func (h *AuthHandler) Me(c *gin.Context) {
+	c.Header("Cache-Control", "private, no-cache")
+	c.JSON(http.StatusOK, middleware.CurrentUser(c))
+}
+
+// decodeAuthBody decodes and validates an account request body into dst,
+// or reports the error
+func decodeAuthBody(c *gin.Context, dst any) bool {
+	data, err := middleware.ReadBody(c, MaxAuthBodyBytes)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+	fields, err := validation.DecodeJSON(data, dst)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+	if len(fields) > 0 {
+		c.Error(apperrors.Validation("request has invalid fields", fields...))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/mail"
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testMailer keeps the emails instead of sending them
+type testMailer struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (m *testMailer) Send(_ context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+var tokenPattern = regexp.MustCompile(`\?token=([\w-]+)`)
+
+// lastToken returns the token of the link of the last email
+func (m *testMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) == 0 {
+		t.Fatal("expected an email")
+	}
+	match := tokenPattern.FindStringSubmatch(m.messages[len(m.messages)-1].Text)
+	if match == nil {
+		t.Fatalf("expected a link, got %s", m.messages[len(m.messages)-1].Text)
+	}
+	return match[1]
+}
+
+func newTestUserService() (*services.UserService, *testMailer) {
+	mailer := &testMailer{}
+	s := services.NewUserService(services.NewInMemoryUserRepo(), services.NewInMemoryTokenRepo(), mailer, services.UserOptions{
+		SiteURL: "https://blog.example.com",
+	})
+	return s, mailer
+}
+
+// newTestAccessToken registers a verified account and returns an access
+// token of it
+func newTestAccessToken(t *testing.T, users *services.UserService, mailer *testMailer) string {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := users.Register(ctx, &models.UserRegister{Email: "ops@example.com", Password: "correct horse", Name: "Ops"}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	user, err := users.VerifyEmail(ctx, mailer.lastToken(t))
+	if err != nil {
+		t.Fatalf("failed to verify the email: %v", err)
+	}
+	token, err := users.IssueAccessToken(user)
+	if err != nil {
+		t.Fatalf("failed to issue an access token: %v", err)
+	}
+	return token.AccessToken
+}
+
+// withAccessToken sends the requests to h with token as bearer credentials
+func withAccessToken(h http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+token)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func newTestAuthRouter(t *testing.T) (*gin.Engine, *testMailer) {
+	t.Helper()
+	service, mailer := newTestUserService()
+	return newTestRouter(t, "/api/v1", NewAuthHandler(service).RegisterRoutes), mailer
+}
+
+func TestAuthHandler_Register(t *testing.T) {
+	router, mailer := newTestAuthRouter(t)
+
+	w := postJSON(router, "/api/v1/auth/register", `{"email":" Ann@Example.com ","password":"correct horse","name":"Ann"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var user map[string]any
+	json.Unmarshal(w.Body.Bytes(), &user)
+	if user["email"] != "ann@example.com" || user["email_verified"] != false {
+		t.Errorf("unexpected user %v", user)
+	}
+	if _, ok := user["password_hash"]; ok {
+		t.Error("expected the password hash to stay private")
+	}
+
+	if w := postJSON(router, "/api/v1/auth/register", `{"email":"ann@example.com","password":"other horse"}`); w.Code != http.StatusCreated {
+		t.Errorf("expected status %d for a taken address, got %d", http.StatusCreated, w.Code)
+	}
+	w = postJSON(router, "/api/v1/auth/register", `{"email":"ann","password":"short","role":"admin"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var problem models.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	if len(problem.InvalidParams) != 3 {
+		t.Errorf("expected email, password and role to be invalid, got %+v", problem.InvalidParams)
+	}
+
+	if w := postJSON(router, "/api/v1/auth/token", `{"email":"ann@example.com","password":"correct horse"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d before verifying, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := postJSON(router, "/api/v1/auth/verify/resend", `{"email":"ann@example.com"}`); w.Code != http.StatusAccepted || len(mailer.messages) != 3 {
+		t.Errorf("expected a new link, got %d and %d emails", w.Code, len(mailer.messages))
+	}
+	if w := postJSON(router, "/api/v1/auth/verify", `{"token":"unknown"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown token, got %d", http.StatusBadRequest, w.Code)
+	}
+	w = postJSON(router, "/api/v1/auth/verify", `{"token":"`+mailer.lastToken(t)+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &user)
+	if user["email_verified"] != true {
+		t.Errorf("expected a verified user, got %v", user)
+	}
+}
+
+// verifiedAccount registers an account and opens the link sent to it
+func verifiedAccount(t *testing.T, router *gin.Engine, mailer *testMailer, email, password string) {
+	t.Helper()
+	if w := postJSON(router, "/api/v1/auth/register", `{"email":"`+email+`","password":"`+password+`"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if w := postJSON(router, "/api/v1/auth/verify", `{"token":"`+mailer.lastToken(t)+`"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
+
+// accessToken logs in and returns the access token
+func accessToken(t *testing.T, router *gin.Engine, email, password string) string {
+	t.Helper()
+	w := postJSON(router, "/api/v1/auth/token", `{"email":"`+email+`","password":"`+password+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var token models.AccessToken
+	json.Unmarshal(w.Body.Bytes(), &token)
+	if token.TokenType != "Bearer" || token.ExpiresIn != 3600 || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("unexpected token %+v", token)
+	}
+	return token.AccessToken
+}
+
+func TestAuthHandler_Token(t *testing.T) {
+	router, mailer := newTestAuthRouter(t)
+	verifiedAccount(t, router, mailer, "ann@example.com", "correct horse")
+
+	if w := postJSON(router, "/api/v1/auth/token", `{"email":"ann@example.com","password":"wrong horse"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for a wrong password, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := postJSON(router, "/api/v1/auth/token", `{"email":"bob@example.com","password":"correct horse"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for an unknown address, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	token := accessToken(t, router, "ann@example.com", "correct horse")
+	w := send(router, http.MethodGet, "/api/v1/auth/me", "", "", map[string]string{"Authorization": "Bearer " + token})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var user models.User
+	json.Unmarshal(w.Body.Bytes(), &user)
+	if user.Email != "ann@example.com" {
+		t.Errorf("expected the user of the token, got %+v", user)
+	}
+
+	for _, header := range []map[string]string{nil, {"Authorization": "Bearer " + token + "x"}} {
+		w := send(router, http.MethodGet, "/api/v1/auth/me", "", "", header)
+		if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("expected status %d with a challenge, got %d", http.StatusUnauthorized, w.Code)
+		}
+	}
+}
+
+func TestAuthHandler_ResetPassword(t *testing.T) {
+	router, mailer := newTestAuthRouter(t)
+	verifiedAccount(t, router, mailer, "ann@example.com", "correct horse")
+
+	sent := len(mailer.messages)
+	if w := postJSON(router, "/api/v1/auth/password/forgot", `{"email":"bob@example.com"}`); w.Code != http.StatusAccepted || len(mailer.messages) != sent {
+		t.Errorf("expected status %d and no email for an unknown address, got %d", http.StatusAccepted, w.Code)
+	}
+	if w := postJSON(router, "/api/v1/auth/password/forgot", `{"email":"ann@example.com"}`); w.Code != http.StatusAccepted || len(mailer.messages) != sent+1 {
+		t.Fatalf("expected status %d and an email, got %d", http.StatusAccepted, w.Code)
+	}
+	token := mailer.lastToken(t)
+
+	if w := postJSON(router, "/api/v1/auth/password/reset", `{"token":"`+token+`","password":"short"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a short password, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := postJSON(router, "/api/v1/auth/password/reset", `{"token":"`+token+`","password":"battery staple"}`); w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	if w := postJSON(router, "/api/v1/auth/password/reset", `{"token":"`+token+`","password":"battery staple"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a used token, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := postJSON(router, "/api/v1/auth/token", `{"email":"ann@example.com","password":"correct horse"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the old password to be rejected, got %d", w.Code)
+	}
+	accessToken(t, router, "ann@example.com", "battery staple")
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/api/validation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxAuthorBodyBytes bounds the size of an author request body
+const MaxAuthorBodyBytes = 64 << 10
+
+type AuthorHandler struct {
+	service *services.AuthorService
+}
+
+func NewAuthorHandler(s *services.AuthorService) *AuthorHandler {
+	return &AuthorHandler{s}
+}
+
+func (h *AuthorHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/authors", h.GetAllAuthors)
+	r.POST("/authors", h.CreateAuthor)
+	r.POST("/authors/backfill", h.BackfillAuthors)
+	r.GET("/authors/:id", h.GetAuthor)
+	r.PUT("/authors/:id", h.UpdateAuthor)
+	r.DELETE("/authors/:id", h.DeleteAuthor)
+	r.GET("/authors/:id/posts", h.GetAuthorPosts)
+}
+
+// @Summary Get all authors
+// @Description Retrieves every author, sorted by name
+// @Tags Authors
+// @Produce json,application/problem+json
+// @Success 200 {array} models.Author "List of authors"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /authors [get]
+func (h *AuthorHandler) GetAllAuthors(c *gin.Context) {
+	authors, err := h.service.GetAll(c.Request.Context())
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve all authors"))
+		return
+	}
+	c.JSON(http.StatusOK, authors)
+}
+
+// @Summary Create an author
+// @Description Creates an author, whose name must be unique. Posts refer to it by author_id.
+// @Tags Authors
+// @Accept json
+// @Produce json,application/problem+json
+// @Param author body models.AuthorCreate true "Author data"
+// @Success 201 {object} models.Author "Created author"
+// @Failure 400 {object} models.Problem "Invalid request body, every invalid field is listed in invalid-params"
+// @Failure 409 {object} models.Problem "Another author has the name"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /authors [post]
+func (h *AuthorHandler) CreateAuthor(c *gin.Context) {
+	var body models.AuthorCreate
+	if !decodeAuthorBody(c, &body) {
+		return
+	}
+
+	author := body.ToAuthor()
+	created, err := h.service.Create(c.Request.Context(), &author)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to create a new author"))
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// @Summary Link the posts to their authors
+// @Description Links the posts stored without author_id, e.g. before authors existed, to the author named after
+// @Description their author field, creating the authors missing. Each linked post gets a new version. Posts
+// @Description already linked are left alone, so running it again is harmless.
+// @Tags Authors
+// @Produce json,application/problem+json
+// @Success 200 {object} models.AuthorBackfill "Number of posts linked"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /authors/backfill [post]
+func (h *AuthorHandler) BackfillAuthors(c *gin.Context) {
+	linked, err := h.service.Backfill(c.Request.Context())
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to link the posts to their authors"))
+		return
+	}
+	c.JSON(http.StatusOK, models.AuthorBackfill{Linked: linked})
+}
+
+// @Summary Get an author by ID
+// @Tags Authors
+// @Produce json,application/problem+json
+// @Param id path string true "Author ID"
+// @Success 200 {object} models.Author "Author details"
+// @Failure 404 {object} models.Problem "Author not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /authors/{id} [get]
+func (h *AuthorHandler) GetAuthor(c *gin.Context) {
+	author, err := h.service.GetById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve an author with a given id"))
+		return
+	}
+	c.JSON(http.StatusOK, author)
+}
+
+// @Summary Update an author
+// @Description Replaces the profile of an author. A new name is set on the posts of the author, as new versions of them.
+// @Tags Authors
+// @Accept json
+// @Produce json,application/problem+json
+// @Param id path string true "Author ID"
+// @Param author body models.AuthorUpdate true "Updated author data"
+// @Success 200 {object} models.Author "Updated author"
+// @Failure 400 {object} models.Problem "Invalid request body, every invalid field is listed in invalid-params"
+// @Failure 404 {object} models.Problem "Author not found"
+// @Failure 409 {object} models.Problem "Another author has the name"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /authors/{id} [put]
+func (h *AuthorHandler) UpdateAuthor(c *gin.Context) {
+	var body models.AuthorUpdate
+	if !decodeAuthorBody(c, &body) {
+		return
+	}
+
+	author := body.ToAuthor()
+	updated, err := h.service.Update(c.Request.Context(), c.Param("id"), &author)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to update an author with a given id"))
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// @Summary Delete an author
+// @Description Deletes an author, which must not have posts anymore
+// @Tags Authors
+// @Produce json,application/problem+json
+// @Param id path string true "Author ID"
+// @Success 204 "Author deleted successfully (no content)"
+// @Failure 404 {object} models.Problem "Author not found"
+// @Failure 409 {object} models.Problem "Author still has posts"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /authors/{id} [delete]
+func (h *AuthorHandler) DeleteAuthor(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.Error(apperrors.Wrap(err, "failed to delete an author with a given id"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get the posts of an author
+// @Description Retrieves the posts of an author, newest first, paginated like GET /posts: a Link header
+// @Description with rel="next" points to the next page unless this is the last one.
+// @Tags Authors
+// @Produce json,application/problem+json
+// @Param id path string true "Author ID"
+// @Param page_size query int false "Number of posts per page, at most 100" default(50)
+// @Param page_token query string false "Token of the page, from the Link header of the previous one"
+// @Success 200 {array} models.BlogPost "Posts of the author"
+// @Header 200 {string} Link "Link to the next page, with rel next"
+// @Failure 400 {object} models.Problem "Invalid page size or token"
+// @Failure 404 {object} models.Problem "Author not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /authors/{id}/posts [get]
+func (h *AuthorHandler) GetAuthorPosts(c *gin.Context) {
+	pageToken, pageSize, _, err := pageQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	posts, next, err := h.service.Posts(c.Request.Context(), c.Param("id"), pageToken, pageSize)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve the posts of an author"))
+		return
+	}
+	setNextPage(c, next)
+	c.JSON(http.StatusOK, posts)
+}
+
+// decodeAuthorBody decodes and validates an author request body into dst,
+// or reports the error
+func decodeAuthorBody(c *gin.Context, dst any) bool {
+	data, err := middleware.ReadBody(c, MaxAuthorBodyBytes)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+	fields, err := validation.DecodeJSON(data, dst)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+	if len(fields) > 0 {
+		c.Error(apperrors.Validation("author has invalid fields", fields...))
+		return false
+	}
+	return true
+}
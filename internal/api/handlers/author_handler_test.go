@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestAuthorRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	posts := newTestPostService(t)
+	authors := services.NewAuthorService(services.NewInMemoryAuthorRepo(), posts)
+	return newTestRouter(t, "/api/v1", NewBlogPostHandler(posts).RegisterRoutes, NewAuthorHandler(authors).RegisterRoutes)
+}
+
+func TestAuthorHandler_CRUD(t *testing.T) {
+	router := newTestAuthorRouter(t)
+
+	w := postJSON(router, "/api/v1/authors", `{"name":" Ann ","bio":"Writes about Go.","links":["https://github.com/ann"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Author
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if created.ID == "" || created.Name != "Ann" || len(created.Links) != 1 {
+		t.Errorf("expected the created author, got %+v", created)
+	}
+	if w := postJSON(router, "/api/v1/authors", `{"name":"Ann"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for a taken name, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = send(router, http.MethodPut, "/api/v1/authors/"+created.ID, "application/json", `{"name":"Ann Smith"}`, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"Ann Smith"`) {
+		t.Errorf("expected the updated author, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := get(router, "/api/v1/authors"); !strings.Contains(w.Body.String(), created.ID) {
+		t.Errorf("expected the author to be listed, got %s", w.Body.String())
+	}
+
+	if w := send(router, http.MethodDelete, "/api/v1/authors/"+created.ID, "", "", nil); w.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if w := get(router, "/api/v1/authors/"+created.ID); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestAuthorHandler_InvalidBody(t *testing.T) {
+	router := newTestAuthorRouter(t)
+
+	w := postJSON(router, "/api/v1/authors", `{"avatar_url":"avatar.png","links":["https://github.com/ann","ann"]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var problem models.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	var names []string
+	for _, p := range problem.InvalidParams {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "name,avatar_url,links" {
+		t.Errorf("expected name, avatar_url and links to be reported, got %v", names)
+	}
+}
+
+func TestAuthorHandler_Posts(t *testing.T) {
+	router := newTestAuthorRouter(t)
+	w := postJSON(router, "/api/v1/authors", `{"name":"Ann"}`)
+	var author models.Author
+	json.Unmarshal(w.Body.Bytes(), &author)
+
+	for range 3 {
+		body := `{"title":"Test Post","content":"Test content","author_id":"` + author.ID + `"}`
+		if w := postJSON(router, "/api/v1/posts", body); w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+	postJSON(router, "/api/v1/posts", `{"title":"Test Post","content":"Test content","author":"Bob"}`)
+
+	w = get(router, "/api/v1/authors/"+author.ID+"/posts?page_size=2")
+	var page []models.BlogPost
+	json.Unmarshal(w.Body.Bytes(), &page)
+	if w.Code != http.StatusOK || len(page) != 2 || page[0].Author != "Ann" {
+		t.Fatalf("expected a first page of 2 posts by Ann, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Link"), `rel="next"`) {
+		t.Errorf("expected a link to the next page, got %q", w.Header().Get("Link"))
+	}
+
+	if w := send(router, http.MethodDelete, "/api/v1/authors/"+author.ID, "", "", nil); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d while the author has posts, got %d", http.StatusConflict, w.Code)
+	}
+	if w := get(router, "/api/v1/authors/missing/posts"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := get(router, "/api/v1/authors/"+author.ID+"/posts?page_size=0"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestAuthorHandler_UnknownAuthorID(t *testing.T) {
+	router := newTestAuthorRouter(t)
+
+	w := postJSON(router, "/api/v1/posts", `{"title":"Test Post","content":"Test content","author_id":"missing"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "author_id") {
+		t.Errorf("expected author_id to be reported, got %d: %s", w.Code, w.Body.String())
+	}
+}
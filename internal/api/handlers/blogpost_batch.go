@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/api/validation"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBatchBodyBytes bounds the size of a batch request body
+const MaxBatchBodyBytes = 8 << 20
+
+// @Summary Apply a batch of operations
+// @Description Applies up to 100 create, update and delete operations in order.
+// @Description Atomic batches apply every operation or none: the first failing operation rolls the batch back
+// @Description and is reported as a problem whose invalid-params are prefixed with operations[i].
+// @Description Otherwise every operation is attempted and reported with its own status.
+// @Tags Blog Posts
+// @Accept json
+// @Produce json,application/problem+json
+// @Param batch body models.BatchRequest true "Operations to apply"
+// @Success 200 {object} models.BatchResponse "Per-operation results"
+// @Failure 400 {object} models.Problem "Invalid batch"
+// @Failure 404 {object} models.Problem "A post of an atomic batch was not found"
+// @Failure 409 {object} models.Problem "A post created by an atomic batch already exists"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /posts/batch [post]
+func (h *BlogPostHandler) BatchPosts(c *gin.Context) {
+	data, err := middleware.ReadBody(c, MaxBatchBodyBytes)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req models.BatchRequest
+	fields, err := validation.DecodeJSON(data, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if len(fields) > 0 {
+		c.Error(apperrors.Validation("batch has invalid fields", fields...))
+		return
+	}
+
+	ops := make([]services.BatchOp, len(req.Operations))
+	decodeErrs := make([]error, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i], decodeErrs[i] = batchOp(op)
+	}
+
+	if req.Atomic {
+		h.batchAtomic(c, ops, decodeErrs)
+		return
+	}
+	h.batchBestEffort(c, ops, decodeErrs)
+}
+
+func (h *BlogPostHandler) batchAtomic(c *gin.Context, ops []services.BatchOp, decodeErrs []error) {
+	// nothing runs unless every operation can be decoded
+	var fields []apperrors.FieldError
+	for i, err := range decodeErrs {
+		if err == nil {
+			continue
+		}
+		parent := fmt.Sprintf("operations[%d]", i)
+		var appErr *apperrors.Error
+		if errors.As(apperrors.Nest(err, parent, ""), &appErr) && len(appErr.Fields) > 0 {
+			fields = append(fields, appErr.Fields...)
+		} else {
+			fields = append(fields, apperrors.FieldError{Field: parent, Reason: "is invalid"})
+		}
+	}
+	if len(fields) > 0 {
+		c.Error(apperrors.Validation("batch has invalid operations", fields...))
+		return
+	}
+
+	results, err := h.service.Batch(c.Request.Context(), ops, true)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to apply the batch"))
+		return
+	}
+
+	resp := models.BatchResponse{Atomic: true, Results: make([]models.BatchResult, len(results))}
+	for i, r := range results {
+		resp.Results[i] = batchResult(i, ops[i].Kind, r)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *BlogPostHandler) batchBestEffort(c *gin.Context, ops []services.BatchOp, decodeErrs []error) {
+	resp := models.BatchResponse{Results: make([]models.BatchResult, len(ops))}
+
+	// only the operations that could be decoded reach the service
+	var valid []services.BatchOp
+	var indexes []int
+	for i, err := range decodeErrs {
+		if err != nil {
+			resp.Results[i] = batchResult(i, ops[i].Kind, services.BatchResult{Err: err})
+			continue
+		}
+		valid = append(valid, ops[i])
+		indexes = append(indexes, i)
+	}
+
+	results, err := h.service.Batch(c.Request.Context(), valid, false)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to apply the batch"))
+		return
+	}
+	for j, r := range results {
+		i := indexes[j]
+		resp.Results[i] = batchResult(i, ops[i].Kind, r)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// batchOp converts an operation of the request, decoding its post with the
+// same rules as the create and update endpoints
+func batchOp(op models.BatchOperation) (services.BatchOp, error) {
+	out := services.BatchOp{Kind: services.BatchOpKind(op.Op)}
+	if err := validation.Struct(&op); err != nil {
+		return out, err
+	}
+	out.ID = op.ID
+	if len(op.Post) == 0 || string(op.Post) == "null" {
+		// reported as missing by the service
+		return out, nil
+	}
+
+	var post models.BlogPost
+	var err error
+	switch out.Kind {
+	case services.BatchCreate:
+		post, err = decodePost[models.BlogPostCreate](op.Post)
+	case services.BatchUpdate:
+		post, err = decodePost[models.BlogPostUpdate](op.Post)
+	default:
+		return out, nil
+	}
+	switch {
+	case apperrors.Is(err, apperrors.KindValidation):
+		return out, apperrors.Nest(err, "post", "")
+	case err != nil:
+		// not a JSON object
+		return out, apperrors.Validation("operation has invalid fields", apperrors.FieldError{Field: "post", Reason: "has an invalid type"})
+	}
+	out.Post = &post
+	return out, nil
+}
+
+func decodePost[T interface {
+	models.BlogPostCreate | models.BlogPostUpdate
+	ToBlogPost() models.BlogPost
+}](data []byte) (models.BlogPost, error) {
+	var body T
+	fields, err := validation.DecodeJSON(data, &body)
+	if err != nil {
+		return models.BlogPost{}, err
+	}
+	if len(fields) > 0 {
+		return models.BlogPost{}, apperrors.Validation("blog post has invalid fields", fields...)
+	}
+	return body.ToBlogPost(), nil
+}
+
+func batchResult(i int, kind services.BatchOpKind, r services.BatchResult) models.BatchResult {
+	if r.Err != nil {
+		problem := middleware.NewProblem(r.Err)
+		return models.BatchResult{Index: i, Status: problem.Status, Error: &problem}
+	}
+
+	result := models.BatchResult{Index: i, Data: r.Post}
+	switch kind {
+	case services.BatchCreate:
+		result.Status = http.StatusCreated
+	case services.BatchDelete:
+		result.Status = http.StatusNoContent
+	default:
+		result.Status = http.StatusOK
+	}
+	return result
+}
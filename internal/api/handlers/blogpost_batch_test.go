@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// missingID is the ID of no post
+const missingID = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+func TestBatchPosts_BestEffort(t *testing.T) {
+	router, service := newTestTransferRouter(t)
+	service.Create(context.Background(), &models.BlogPost{ID: importedID, Title: "Go basics", Content: "Go is simple.", Author: "John Doe"})
+
+	body := `{"operations":[
+		{"op":"create","post":{"title":"Go channels","content":"Channels.","author":"Jane Smith"}},
+		{"op":"update","id":"` + importedID + `","post":{"title":"","content":"Go is simple.","author":"John Doe","extra":1}},
+		{"op":"delete","id":"` + missingID + `"},
+		{"op":"delete","id":"` + importedID + `"},
+		{"op":"delete","id":"missing"},
+		{"op":"create","post":"x"}
+	]}`
+	w := postJSON(router, "/api/v1/posts/batch", body)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp models.BatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	statuses := []int{http.StatusCreated, http.StatusBadRequest, http.StatusNotFound, http.StatusNoContent, http.StatusBadRequest, http.StatusBadRequest}
+	for i, status := range statuses {
+		if resp.Results[i].Status != status {
+			t.Errorf("expected operation %d to have status %d, got %d", i, status, resp.Results[i].Status)
+		}
+	}
+	if resp.Results[0].Data == nil || resp.Results[0].Data.ID == "" {
+		t.Error("expected the created post to be returned")
+	}
+	if params := resp.Results[1].Error.InvalidParams; len(params) != 2 || params[0].Name != "post.extra" {
+		t.Errorf("expected nested invalid params, got %+v", params)
+	}
+	if params := resp.Results[4].Error.InvalidParams; len(params) != 1 || params[0].Name != "id" {
+		t.Errorf("expected the ID to be invalid, got %+v", params)
+	}
+	if params := resp.Results[5].Error.InvalidParams; len(params) != 1 || params[0].Name != "post" {
+		t.Errorf("expected the post to be invalid, got %+v", params)
+	}
+}
+
+func TestBatchPosts_AtomicFailureRollsBack(t *testing.T) {
+	router, service := newTestTransferRouter(t)
+
+	body := `{"atomic":true,"operations":[
+		{"op":"create","id":"` + importedID + `","post":{"title":"Go basics","content":"Go is simple.","author":"John Doe"}},
+		{"op":"delete","id":"` + missingID + `"}
+	]}`
+	w := postJSON(router, "/api/v1/posts/batch", body)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNotFound, w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "operation 1: blog post not found") {
+		t.Errorf("expected the failing operation in the problem, got %s", w.Body.String())
+	}
+	if posts, _ := service.GetAll(context.Background()); len(posts) != 0 {
+		t.Errorf("expected the creation to be rolled back, got %d posts", len(posts))
+	}
+}
+
+func TestBatchPosts_AtomicInvalidOperations(t *testing.T) {
+	router, _ := newTestTransferRouter(t)
+
+	body := `{"atomic":true,"operations":[
+		{"op":"create","post":{"title":"Go basics","content":"Go is simple.","author":"John Doe"}},
+		{"op":"create","post":{"content":"No title.","author":"John Doe"}},
+		{"op":"create","post":[]},
+		{"op":"update","id":"not-a-uuid","post":{"title":"Go basics","content":"Go is simple.","author":"John Doe"}}
+	]}`
+	w := postJSON(router, "/api/v1/posts/batch", body)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var problem models.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	var names []string
+	for _, p := range problem.InvalidParams {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "operations[1].post.title,operations[2].post,operations[3].id" {
+		t.Errorf("expected the invalid fields of operations 1 to 3, got %+v", problem.InvalidParams)
+	}
+}
+
+func TestBatchPosts_AtomicSuccess(t *testing.T) {
+	router, _ := newTestTransferRouter(t)
+
+	body := `{"atomic":true,"operations":[
+		{"op":"create","id":"` + importedID + `","post":{"title":"Go basics","content":"Go is simple.","author":"John Doe"}},
+		{"op":"update","id":"` + importedID + `","post":{"title":"Go basics, revised","content":"Go is simple.","author":"John Doe"}}
+	]}`
+	w := postJSON(router, "/api/v1/posts/batch", body)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp models.BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if !resp.Atomic || len(resp.Results) != 2 || resp.Results[1].Data.Version != 2 {
+		t.Errorf("unexpected response %s", w.Body.String())
+	}
+}
+
+func TestBatchPosts_InvalidRequest(t *testing.T) {
+	router, _ := newTestTransferRouter(t)
+
+	for _, body := range []string{`{}`, `{"operations":[]}`, `{"operations":[{"op":"delete","id":"1"}],"dryrun":true}`, `[]`} {
+		if w := postJSON(router, "/api/v1/posts/batch", body); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+}
+
+func postJSON(router http.Handler, target, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"blog-posts-api/internal/api/apperrors"
 	"blog-posts-api/internal/api/middleware"
 	"blog-posts-api/internal/api/models"
 	"blog-posts-api/internal/api/services"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -20,61 +25,135 @@ func NewBlogPostHandler(s *services.BlogPostService) *BlogPostHandler {
 
 func (h *BlogPostHandler) RegisterRoutes(r *gin.RouterGroup) {
 	r.GET("/posts", h.GetAllPosts)
+	r.GET("/posts/export", h.ExportPosts)
+	r.POST("/posts/import", h.ImportPosts)
+	r.POST("/posts/batch", h.BatchPosts)
 	r.GET("/posts/:id", h.GetPost)
-	r.POST("/posts", middleware.ValidateBlogPostBody(), h.CreatePost)
-	r.PUT("/posts/:id", middleware.ValidateBlogPostBody(), h.UpdatePost)
+	r.POST("/posts", middleware.ValidateBlogPostBody[models.BlogPostCreate](), h.CreatePost)
+	r.PUT("/posts/:id", middleware.ValidateBlogPostBody[models.BlogPostUpdate](), h.UpdatePost)
 	r.DELETE("/posts/:id", h.DeletePost)
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error string `json:"error" example:"error message"`
-}
-
 // @Summary Get all blog posts
-// @Description Retrieves a list of all blog posts
+// @Description Retrieves a list of all blog posts, optionally filtered by author, tag and status.
+// @Description With page_size or page_token the posts are paginated newest first, and a Link header
+// @Description with rel="next" points to the next page unless this is the last one.
 // @Tags Blog Posts
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
+// @Param author query string false "Only the posts by this author"
+// @Param tag query string false "Only the posts with this tag"
+// @Param status query string false "Only the posts in this status" Enums(draft, published)
+// @Param page_size query int false "Number of posts per page, at most 100" default(50)
+// @Param page_token query string false "Token of the page, from the Link header of the previous one"
 // @Success 200 {array} models.BlogPost "List of blog posts"
-// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Header 200 {string} Link "Link to the next page, with rel next"
+// @Failure 400 {object} models.Problem "Invalid page size or token"
+// @Failure 500 {object} models.Problem "Internal server error"
 // @Router /posts [get]
 func (h *BlogPostHandler) GetAllPosts(c *gin.Context) {
 	ctx := c.Request.Context()
+	filter := models.PostFilter{Author: c.Query("author"), Tag: c.Query("tag"), Status: c.Query("status")}
+
+	pageToken, pageSize, paginated, err := pageQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if paginated {
+		posts, next, err := h.service.GetPage(ctx, filter, pageToken, pageSize)
+		if err != nil {
+			c.Error(apperrors.Wrap(err, "failed to retrieve a page of posts"))
+			return
+		}
+		setNextPage(c, next)
+		c.JSON(http.StatusOK, posts)
+		return
+	}
 
 	posts, err := h.service.GetAll(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve all posts"})
+		c.Error(apperrors.Wrap(err, "failed to retrieve all posts"))
 		return
 	}
+	if !filter.IsZero() {
+		posts = slices.DeleteFunc(posts, func(p *models.BlogPost) bool { return !filter.Matches(p) })
+	}
 
 	c.JSON(http.StatusOK, posts)
 }
 
+// pageQuery reads the page_token and page_size query parameters, paginated
+// telling whether either is set
+func pageQuery(c *gin.Context) (pageToken string, pageSize int, paginated bool, err error) {
+	pageToken = c.Query("page_token")
+	rawSize, hasSize := c.GetQuery("page_size")
+	if rawSize != "" {
+		if pageSize, err = strconv.Atoi(rawSize); err != nil || pageSize < 1 {
+			return "", 0, false, apperrors.BadRequest("page_size must be a positive integer", err)
+		}
+	}
+	return pageToken, pageSize, hasSize || pageToken != "", nil
+}
+
+// setNextPage points the Link header to the next page, unless next is the
+// empty token of the last page
+func setNextPage(c *gin.Context, next string) {
+	if next == "" {
+		return
+	}
+	query := c.Request.URL.Query()
+	query.Set("page_token", next)
+	c.Header("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, c.Request.URL.Path, query.Encode()))
+}
+
 // @Summary Get a blog post by ID
-// @Description Retrieves a single blog post by its unique identifier
+// @Description Retrieves a single blog post by its unique identifier.
+// @Description With render=html the response also carries the content rendered to sanitized HTML.
+// @Description With an Accept header preferring text/html only the rendered HTML fragment is returned.
 // @Tags Blog Posts
 // @Accept json
-// @Produce json
+// @Produce json,text/html,application/problem+json
 // @Param id path string true "Blog Post ID" example("550e8400-e29b-41d4-a716-446655440000")
-// @Success 200 {object} models.BlogPost "Blog post details"
-// @Failure 404 {object} ErrorResponse "Blog post not found"
-// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Param render query string false "Render the content to HTML" Enums(html)
+// @Param If-None-Match header string false "ETag of the version of the post the client has"
+// @Success 200 {object} models.RenderedBlogPost "Blog post details, content_html is only set with render=html"
+// @Header 200 {string} ETag "Version of the post, for If-Match"
+// @Success 304 "The post is still at the version of If-None-Match"
+// @Failure 404 {object} models.Problem "Blog post not found"
+// @Failure 500 {object} models.Problem "Internal server error"
 // @Router /posts/{id} [get]
 func (h *BlogPostHandler) GetPost(c *gin.Context) {
 	ctx := c.Request.Context()
 	id := c.Param("id")
 
-	post, err := h.service.GetById(ctx, id)
-	if err != nil {
-		if err == services.ErrNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "blog post with a given id not found"})
+	wantsHTML := c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML
+	if wantsHTML || c.Query("render") == "html" {
+		rendered, err := h.service.GetRendered(ctx, id)
+		if err != nil {
+			c.Error(apperrors.Wrap(err, "failed to render a blog post with a given id"))
+			return
+		}
+		if wantsHTML {
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rendered.ContentHTML))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve a blog post with a given id"})
+		c.JSON(http.StatusOK, rendered)
 		return
 	}
 
+	post, err := h.service.GetById(ctx, id)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve a blog post with a given id"))
+		return
+	}
+
+	etag := versionETag(post.Version)
+	c.Header("ETag", etag)
+	if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
 	c.JSON(http.StatusOK, post)
 }
 
@@ -82,43 +161,49 @@ func (h *BlogPostHandler) GetPost(c *gin.Context) {
 // @Description Creates a new blog post with the provided data
 // @Tags Blog Posts
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Param blogpost body models.BlogPostCreate true "Blog post data"
 // @Success 201 {object} models.BlogPost "Created blog post"
-// @Failure 400 {object} ErrorResponse "Invalid request body or missing required fields"
-// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Header 201 {string} ETag "Version of the post, for If-Match"
+// @Failure 400 {object} models.Problem "Invalid request body, unknown fields or invalid fields, every invalid field is listed in invalid-params"
+// @Failure 500 {object} models.Problem "Internal server error"
 // @Router /posts [post]
 func (h *BlogPostHandler) CreatePost(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	postInterface, exists := c.Get("validatedPost")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "validated post not found in the context"})
+		c.Error(apperrors.Internal("validated post not found in the context", nil))
 		return
 	}
-	post := postInterface.(models.BlogPost)
+	post := postInterface.(models.BlogPostCreate).ToBlogPost()
 
 	post.ID = uuid.New().String()
 	created, err := h.service.Create(ctx, &post)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create a new blog post"})
+		c.Error(apperrors.Wrap(err, "failed to create a new blog post"))
 		return
 	}
 
+	c.Header("ETag", versionETag(created.Version))
 	c.JSON(http.StatusCreated, created)
 }
 
 // @Summary Update a blog post
-// @Description Updates an existing blog post with the provided data
+// @Description Updates an existing blog post with the provided data.
+// @Description With If-Match the update fails with 412 unless the post is still at that version, so that concurrent updates are not lost.
 // @Tags Blog Posts
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Param id path string true "Blog Post ID" example("550e8400-e29b-41d4-a716-446655440000")
 // @Param blogpost body models.BlogPostUpdate true "Updated blog post data"
+// @Param If-Match header string false "ETag of the version of the post the update is based on"
 // @Success 200 {object} models.BlogPost "Updated blog post"
-// @Failure 400 {object} ErrorResponse "Invalid request body or missing required fields"
-// @Failure 404 {object} ErrorResponse "Blog post not found"
-// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Header 200 {string} ETag "Version of the post, for If-Match"
+// @Failure 400 {object} models.Problem "Invalid request body, unknown fields or invalid fields, every invalid field is listed in invalid-params"
+// @Failure 404 {object} models.Problem "Blog post not found"
+// @Failure 412 {object} models.Problem "The post changed since the version of If-Match"
+// @Failure 500 {object} models.Problem "Internal server error"
 // @Router /posts/{id} [put]
 func (h *BlogPostHandler) UpdatePost(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -126,33 +211,70 @@ func (h *BlogPostHandler) UpdatePost(c *gin.Context) {
 
 	postInterface, exists := c.Get("validatedPost")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "validated post not found in the context"})
+		c.Error(apperrors.Internal("validated post not found in the context", nil))
 		return
 	}
-	post := postInterface.(models.BlogPost)
+	post := postInterface.(models.BlogPostUpdate).ToBlogPost()
 
-	updated, err := h.service.Update(ctx, id, &post)
-	if err != nil {
-		if err == services.ErrNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "blog post with a given id not found"})
+	version := 0
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		var err error
+		if version, err = h.matchedVersion(c, id, ifMatch); err != nil {
+			c.Error(err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update a blog post with a given id"})
+	}
+
+	updated, err := h.service.UpdateVersion(ctx, id, version, &post)
+	if errors.Is(err, services.ErrVersionConflict) {
+		err = errPostChanged
+	}
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to update a blog post with a given id"))
 		return
 	}
 
+	c.Header("ETag", versionETag(updated.Version))
 	c.JSON(http.StatusOK, updated)
 }
 
+// errPostChanged is reported when If-Match does not hold
+var errPostChanged = apperrors.PreconditionFailed("blog post was changed since this version")
+
+// matchedVersion returns the version an If-Match header asks to update,
+// zero for any. A header listing several versions matches the current one
+// if it is listed, which UpdateVersion checks again atomically.
+func (h *BlogPostHandler) matchedVersion(c *gin.Context, id, ifMatch string) (int, error) {
+	versions, ok := ifMatchVersions(ifMatch)
+	switch {
+	case !ok:
+		return 0, errPostChanged
+	case len(versions) == 0:
+		// If-Match: *
+		return 0, nil
+	case len(versions) == 1:
+		return versions[0], nil
+	}
+
+	current, err := h.service.GetById(c.Request.Context(), id)
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(versions, current.Version) {
+		return 0, errPostChanged
+	}
+	return current.Version, nil
+}
+
 // @Summary Delete a blog post
 // @Description Deletes a blog post by its unique identifier
 // @Tags Blog Posts
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Param id path string true "Blog Post ID" example("550e8400-e29b-41d4-a716-446655440000")
 // @Success 204 "Blog post deleted successfully (no content)"
-// @Failure 404 {object} ErrorResponse "Blog post not found"
-// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 404 {object} models.Problem "Blog post not found"
+// @Failure 500 {object} models.Problem "Internal server error"
 // @Router /posts/{id} [delete]
 func (h *BlogPostHandler) DeletePost(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -160,11 +282,7 @@ func (h *BlogPostHandler) DeletePost(c *gin.Context) {
 
 	err := h.service.Delete(ctx, id)
 	if err != nil {
-		if err == services.ErrNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "blog post with a given id not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete a blog post with a given id"})
+		c.Error(apperrors.Wrap(err, "failed to delete a blog post with a given id"))
 		return
 	}
 
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"blog-posts-api/internal/api/middleware"
 	"blog-posts-api/internal/api/models"
 	"blog-posts-api/internal/api/services"
 	"context"
@@ -8,7 +9,9 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -78,6 +81,13 @@ func (m *mockBlogPostService) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// serve runs a handler the way the router does, followed by the problem
+// rendering of the middleware.Problems middleware
+func serve(c *gin.Context, h gin.HandlerFunc) {
+	h(c)
+	middleware.WriteProblem(c)
+}
+
 func TestBlogPostHandler_GetAllPosts_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -100,7 +110,7 @@ func TestBlogPostHandler_GetAllPosts_Success(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/posts", nil)
 	c.Request = req
 
-	handler.GetAllPosts(c)
+	serve(c, handler.GetAllPosts)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
@@ -134,7 +144,7 @@ func TestBlogPostHandler_GetAllPosts_Empty(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/posts", nil)
 	c.Request = req
 
-	handler.GetAllPosts(c)
+	serve(c, handler.GetAllPosts)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
@@ -166,21 +176,21 @@ func TestBlogPostHandler_GetAllPosts_ServiceError(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/posts", nil)
 	c.Request = req
 
-	handler.GetAllPosts(c)
+	serve(c, handler.GetAllPosts)
 
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 
 	// verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "failed to retrieve all posts" {
-		t.Errorf("expected error message 'failed to retrieve all posts', got '%s'", response["error"])
+	if response.Detail != "failed to retrieve all posts" {
+		t.Errorf("expected problem detail 'failed to retrieve all posts', got '%s'", response.Detail)
 	}
 }
 
@@ -207,7 +217,7 @@ func TestBlogPostHandler_GetPost_Success(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/posts/1", nil)
 	c.Request = req
 
-	handler.GetPost(c)
+	serve(c, handler.GetPost)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
@@ -242,21 +252,21 @@ func TestBlogPostHandler_GetPost_NotFound(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/posts/nonexistent", nil)
 	c.Request = req
 
-	handler.GetPost(c)
+	serve(c, handler.GetPost)
 
 	if w.Code != http.StatusNotFound {
 		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "blog post with a given id not found" {
-		t.Errorf("expected error message 'blog post with a given id not found', got '%s'", response["error"])
+	if response.Detail != "blog post not found" {
+		t.Errorf("expected problem detail 'blog post not found', got '%s'", response.Detail)
 	}
 }
 
@@ -275,21 +285,21 @@ func TestBlogPostHandler_GetPost_ServiceError(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/posts/1", nil)
 	c.Request = req
 
-	handler.GetPost(c)
+	serve(c, handler.GetPost)
 
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "failed to retrieve a blog post with a given id" {
-		t.Errorf("expected error message 'failed to retrieve a blog post with a given id', got '%s'", response["error"])
+	if response.Detail != "failed to retrieve a blog post with a given id" {
+		t.Errorf("expected problem detail 'failed to retrieve a blog post with a given id', got '%s'", response.Detail)
 	}
 }
 
@@ -300,7 +310,7 @@ func TestBlogPostHandler_CreatePost_Success(t *testing.T) {
 	blogPostMockService := services.NewBlogPostService(mockService)
 	handler := NewBlogPostHandler(blogPostMockService)
 
-	post := models.BlogPost{
+	post := models.BlogPostCreate{
 		Title:   "Test Post",
 		Content: "Test content",
 		Author:  "Test Author",
@@ -315,7 +325,7 @@ func TestBlogPostHandler_CreatePost_Success(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/posts", nil)
 	c.Request = req
 
-	handler.CreatePost(c)
+	serve(c, handler.CreatePost)
 
 	if w.Code != http.StatusCreated {
 		t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
@@ -349,21 +359,21 @@ func TestBlogPostHandler_CreatePost_NoValidatedPost(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/posts", nil)
 	c.Request = req
 
-	handler.CreatePost(c)
+	serve(c, handler.CreatePost)
 
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "validated post not found in the context" {
-		t.Errorf("expected error message 'validated post not found in the context', got '%s'", response["error"])
+	if response.Detail != "validated post not found in the context" {
+		t.Errorf("expected problem detail 'validated post not found in the context', got '%s'", response.Detail)
 	}
 }
 
@@ -375,7 +385,7 @@ func TestBlogPostHandler_CreatePost_ServiceError(t *testing.T) {
 	blogPostMockService := services.NewBlogPostService(mockService)
 	handler := NewBlogPostHandler(blogPostMockService)
 
-	post := models.BlogPost{
+	post := models.BlogPostCreate{
 		Title:   "Test Post",
 		Content: "Test content",
 		Author:  "Test Author",
@@ -390,21 +400,21 @@ func TestBlogPostHandler_CreatePost_ServiceError(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/posts", nil)
 	c.Request = req
 
-	handler.CreatePost(c)
+	serve(c, handler.CreatePost)
 
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "failed to create a new blog post" {
-		t.Errorf("expected error message 'failed to create a new blog post', got '%s'", response["error"])
+	if response.Detail != "failed to create a new blog post" {
+		t.Errorf("expected problem detail 'failed to create a new blog post', got '%s'", response.Detail)
 	}
 }
 
@@ -424,7 +434,7 @@ func TestBlogPostHandler_UpdatePost_Success(t *testing.T) {
 	}
 	mockService.posts["1"] = existing
 
-	updatedPost := models.BlogPost{
+	updatedPost := models.BlogPostUpdate{
 		Title:   "Updated Title",
 		Content: "Updated content",
 		Author:  "Updated Author",
@@ -440,7 +450,7 @@ func TestBlogPostHandler_UpdatePost_Success(t *testing.T) {
 	req, _ := http.NewRequest("PUT", "/posts/1", nil)
 	c.Request = req
 
-	handler.UpdatePost(c)
+	serve(c, handler.UpdatePost)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
@@ -468,7 +478,7 @@ func TestBlogPostHandler_UpdatePost_NotFound(t *testing.T) {
 	blogPostMockService := services.NewBlogPostService(mockService)
 	handler := NewBlogPostHandler(blogPostMockService)
 
-	updatedPost := models.BlogPost{
+	updatedPost := models.BlogPostUpdate{
 		Title:   "Updated Title",
 		Content: "Updated content",
 		Author:  "Updated Author",
@@ -484,21 +494,21 @@ func TestBlogPostHandler_UpdatePost_NotFound(t *testing.T) {
 	req, _ := http.NewRequest("PUT", "/posts/nonexistent", nil)
 	c.Request = req
 
-	handler.UpdatePost(c)
+	serve(c, handler.UpdatePost)
 
 	if w.Code != http.StatusNotFound {
 		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "blog post with a given id not found" {
-		t.Errorf("expected error message 'blog post with a given id not found', got '%s'", response["error"])
+	if response.Detail != "blog post not found" {
+		t.Errorf("expected problem detail 'blog post not found', got '%s'", response.Detail)
 	}
 }
 
@@ -516,21 +526,21 @@ func TestBlogPostHandler_UpdatePost_NoValidatedPost(t *testing.T) {
 	req, _ := http.NewRequest("PUT", "/posts/1", nil)
 	c.Request = req
 
-	handler.UpdatePost(c)
+	serve(c, handler.UpdatePost)
 
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "validated post not found in the context" {
-		t.Errorf("expected error message 'validated post not found in the context', got '%s'", response["error"])
+	if response.Detail != "validated post not found in the context" {
+		t.Errorf("expected problem detail 'validated post not found in the context', got '%s'", response.Detail)
 	}
 }
 
@@ -551,7 +561,7 @@ func TestBlogPostHandler_UpdatePost_ServiceError(t *testing.T) {
 	}
 	mockService.posts["1"] = existing
 
-	updatedPost := models.BlogPost{
+	updatedPost := models.BlogPostUpdate{
 		Title:   "Updated Title",
 		Content: "Updated content",
 		Author:  "Updated Author",
@@ -567,21 +577,21 @@ func TestBlogPostHandler_UpdatePost_ServiceError(t *testing.T) {
 	req, _ := http.NewRequest("PUT", "/posts/1", nil)
 	c.Request = req
 
-	handler.UpdatePost(c)
+	serve(c, handler.UpdatePost)
 
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "failed to update a blog post with a given id" {
-		t.Errorf("expected error message 'failed to update a blog post with a given id', got '%s'", response["error"])
+	if response.Detail != "failed to update a blog post with a given id" {
+		t.Errorf("expected problem detail 'failed to update a blog post with a given id', got '%s'", response.Detail)
 	}
 }
 
@@ -608,7 +618,7 @@ func TestBlogPostHandler_DeletePost_Success(t *testing.T) {
 	req, _ := http.NewRequest("DELETE", "/posts/1", nil)
 	c.Request = req
 
-	handler.DeletePost(c)
+	serve(c, handler.DeletePost)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
@@ -634,21 +644,21 @@ func TestBlogPostHandler_DeletePost_NotFound(t *testing.T) {
 	req, _ := http.NewRequest("DELETE", "/posts/nonexistent", nil)
 	c.Request = req
 
-	handler.DeletePost(c)
+	serve(c, handler.DeletePost)
 
 	if w.Code != http.StatusNotFound {
 		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "blog post with a given id not found" {
-		t.Errorf("expected error message 'blog post with a given id not found', got '%s'", response["error"])
+	if response.Detail != "blog post not found" {
+		t.Errorf("expected problem detail 'blog post not found', got '%s'", response.Detail)
 	}
 }
 
@@ -676,20 +686,211 @@ func TestBlogPostHandler_DeletePost_ServiceError(t *testing.T) {
 	req, _ := http.NewRequest("DELETE", "/posts/1", nil)
 	c.Request = req
 
-	handler.DeletePost(c)
+	serve(c, handler.DeletePost)
 
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "failed to delete a blog post with a given id" {
-		t.Errorf("expected error message 'failed to delete a blog post with a given id', got '%s'", response["error"])
+	if response.Detail != "failed to delete a blog post with a given id" {
+		t.Errorf("expected problem detail 'failed to delete a blog post with a given id', got '%s'", response.Detail)
+	}
+}
+
+func TestBlogPostHandler_GetPost_RenderHTML(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := newMockBlogPostService()
+	blogPostMockService := services.NewBlogPostService(mockService)
+	handler := NewBlogPostHandler(blogPostMockService)
+
+	mockService.posts["1"] = &models.BlogPost{
+		ID:            "1",
+		Title:         "Test Post",
+		Content:       "# Hello\n\n<script>alert(1)</script>",
+		ContentFormat: models.ContentFormatMarkdown,
+		Author:        "Test Author",
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	req, _ := http.NewRequest("GET", "/posts/1?render=html", nil)
+	c.Request = req
+
+	serve(c, handler.GetPost)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var responsePost models.RenderedBlogPost
+	err := json.Unmarshal(w.Body.Bytes(), &responsePost)
+	if err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if responsePost.ID != "1" {
+		t.Errorf("expected post ID '1', got %s", responsePost.ID)
+	}
+	if !strings.Contains(responsePost.ContentHTML, `<h1 id="hello">Hello</h1>`) {
+		t.Errorf("expected rendered heading, got %s", responsePost.ContentHTML)
+	}
+	if strings.Contains(responsePost.ContentHTML, "<script") {
+		t.Errorf("expected script to be sanitized, got %s", responsePost.ContentHTML)
+	}
+}
+
+func TestBlogPostHandler_GetPost_AcceptHTML(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := newMockBlogPostService()
+	blogPostMockService := services.NewBlogPostService(mockService)
+	handler := NewBlogPostHandler(blogPostMockService)
+
+	mockService.posts["1"] = &models.BlogPost{
+		ID:            "1",
+		Title:         "Test Post",
+		Content:       "Test <content>",
+		ContentFormat: models.ContentFormatPlain,
+		Author:        "Test Author",
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	req, _ := http.NewRequest("GET", "/posts/1", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	c.Request = req
+
+	serve(c, handler.GetPost)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("expected content type 'text/html; charset=utf-8', got '%s'", ct)
+	}
+	if body := w.Body.String(); body != "<p>Test &lt;content&gt;</p>\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestBlogPostHandler_GetPost_RenderNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := newMockBlogPostService()
+	blogPostMockService := services.NewBlogPostService(mockService)
+	handler := NewBlogPostHandler(blogPostMockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "nonexistent"}}
+
+	req, _ := http.NewRequest("GET", "/posts/nonexistent?render=html", nil)
+	c.Request = req
+
+	serve(c, handler.GetPost)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestBlogPostHandler_GetAllPosts_Paginated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	handler := NewBlogPostHandler(service)
+	for i, author := range []string{"Alice", "Bob", "Alice", "Alice"} {
+		post := &models.BlogPost{ID: string(rune('a' + i)), Title: "Test Post", Content: "Test content", Author: author, CreatedAt: time.Date(2025, 1, i+1, 0, 0, 0, 0, time.UTC)}
+		service.Create(context.Background(), post)
+	}
+
+	get := func(target string) ([]*models.BlogPost, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", target, nil)
+		serve(c, handler.GetAllPosts)
+		var posts []*models.BlogPost
+		json.Unmarshal(w.Body.Bytes(), &posts)
+		return posts, w
+	}
+
+	posts, w := get("/api/v1/posts?author=Alice&page_size=2")
+	if len(posts) != 2 || posts[0].ID != "d" || posts[1].ID != "c" {
+		t.Fatalf("expected posts d and c, got %s", w.Body.String())
+	}
+	link := w.Header().Get("Link")
+	if !strings.HasPrefix(link, "</api/v1/posts?") || !strings.HasSuffix(link, `>; rel="next"`) || !strings.Contains(link, "author=Alice") {
+		t.Fatalf("expected a link to the next page, got %q", link)
+	}
+
+	posts, w = get(strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`))
+	if len(posts) != 1 || posts[0].ID != "a" || w.Header().Get("Link") != "" {
+		t.Errorf("expected post a on the last page, got %s and %q", w.Body.String(), w.Header().Get("Link"))
+	}
+
+	// without pagination every matching post is returned
+	if posts, _ = get("/api/v1/posts?author=Bob"); len(posts) != 1 || posts[0].ID != "b" {
+		t.Errorf("expected the post of Bob, got %v", posts)
+	}
+
+	for _, target := range []string{"/api/v1/posts?page_size=0", "/api/v1/posts?page_token=invalid"} {
+		if _, w = get(target); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for %s, got %d", http.StatusBadRequest, target, w.Code)
+		}
+	}
+}
+
+func TestBlogPostHandler_UpdatePost_IfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	handler := NewBlogPostHandler(service)
+	service.Create(context.Background(), &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Request, _ = http.NewRequest("GET", "/posts/1", nil)
+	c.Request.Header.Set("If-None-Match", `"1"`)
+	serve(c, handler.GetPost)
+	if c.Writer.Status() != http.StatusNotModified || w.Header().Get("ETag") != `"1"` {
+		t.Errorf("expected status %d with ETag \"1\", got %d and %q", http.StatusNotModified, c.Writer.Status(), w.Header().Get("ETag"))
+	}
+
+	tests := []struct {
+		ifMatch string
+		status  int
+		etag    string
+	}{
+		{`"1"`, http.StatusOK, `"2"`},
+		{`"1"`, http.StatusPreconditionFailed, ""},
+		{`W/"2"`, http.StatusPreconditionFailed, ""},
+		{`"1", "2"`, http.StatusOK, `"3"`},
+		{`*`, http.StatusOK, `"4"`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Set("validatedPost", models.BlogPostUpdate{Title: "Updated Title", Content: "Test content", Author: "Test Author"})
+		c.Request, _ = http.NewRequest("PUT", "/posts/1", nil)
+		c.Request.Header.Set("If-Match", tt.ifMatch)
+		serve(c, handler.UpdatePost)
+
+		if w.Code != tt.status || w.Header().Get("ETag") != tt.etag {
+			t.Errorf("expected status %d with ETag %q for If-Match %s, got %d and %q", tt.status, tt.etag, tt.ifMatch, w.Code, w.Header().Get("ETag"))
+		}
 	}
 }
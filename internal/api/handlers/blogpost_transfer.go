@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/api/validation"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NDJSONContentType is the media type of newline delimited JSON streams
+const NDJSONContentType = "application/x-ndjson"
+
+// importFlushEvery is the number of line results written between flushes
+const importFlushEvery = 100
+
+// @Summary Export all blog posts
+// @Description Streams every blog post as newline delimited JSON, one post per line, oldest first.
+// @Description The output can be fed back to the import endpoint.
+// @Tags Blog Posts
+// @Produce application/x-ndjson,application/problem+json
+// @Success 200 {object} models.BlogPost "One blog post per line"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /posts/export [get]
+func (h *BlogPostHandler) ExportPosts(c *gin.Context) {
+	if err := clearDeadlines(http.NewResponseController(c.Writer)); err != nil {
+		c.Error(apperrors.Internal("failed to export posts", err))
+		return
+	}
+	c.Header("Content-Type", NDJSONContentType)
+	c.Header("Content-Disposition", `attachment; filename="posts.ndjson"`)
+
+	enc := json.NewEncoder(c.Writer)
+	enc.SetEscapeHTML(false)
+	err := h.service.Export(c.Request.Context(), func(p *models.BlogPost) error {
+		return enc.Encode(p)
+	})
+	if err == nil {
+		return
+	}
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		c.Error(apperrors.Wrap(err, "failed to export posts"))
+		return
+	}
+	// the status is already sent, the client sees a truncated stream
+	log.Printf("export aborted: %v", err)
+}
+
+// @Summary Import blog posts
+// @Description Reads newline delimited JSON, one blog post per line, e.g. the output of the export endpoint.
+// @Description Each line is validated with the same rules as the create endpoint and imported on its own:
+// @Description an invalid line does not stop the import. Posts keep their id, created_at and published_at
+// @Description when set. The report is streamed as the lines are processed, so large files are not held in memory.
+// @Tags Blog Posts
+// @Accept application/x-ndjson
+// @Produce json,application/problem+json
+// @Param posts body models.BlogPostImport true "One blog post per line"
+// @Param dry_run query bool false "Validate and report without writing anything"
+// @Param on_conflict query string false "What to do with a post whose id already exists" Enums(skip, upsert) default(skip)
+// @Success 200 {object} models.ImportReport "Per-line results"
+// @Failure 400 {object} models.Problem "Invalid query parameters"
+// @Router /posts/import [post]
+func (h *BlogPostHandler) ImportPosts(c *gin.Context) {
+	opts, err := importOptions(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	rc := http.NewResponseController(c.Writer)
+	if err := clearDeadlines(rc); err != nil {
+		c.Error(apperrors.Internal("failed to import posts", err))
+		return
+	}
+	// the report is written while the body is read, which HTTP/1.1
+	// servers only allow once asked to, discarding the unread body
+	// otherwise. HTTP/2 always allows it.
+	if err := rc.EnableFullDuplex(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		c.Error(apperrors.Internal("failed to import posts", err))
+		return
+	}
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, `{"dry_run":%t,"results":[`, opts.DryRun)
+
+	ctx := c.Request.Context()
+	reader := bufio.NewReader(c.Request.Body)
+	var summary models.ImportSummary
+	for n := 1; ; n++ {
+		line, tooLong, err := readLine(reader, middleware.MaxBlogPostBodyBytes)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			summary.Error = "failed to read the request body"
+			break
+		}
+		if !tooLong && len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var result models.ImportResult
+		if tooLong {
+			result = models.ImportResult{
+				Line:   n,
+				Result: models.ImportInvalid,
+				Error:  fmt.Sprintf("line must be at most %d bytes long", middleware.MaxBlogPostBodyBytes),
+			}
+		} else {
+			result = h.importLine(ctx, n, line, opts)
+		}
+
+		if summary.Lines > 0 {
+			w.WriteString(",")
+		}
+		summary.Add(result)
+		writeJSON(w, result)
+		if summary.Lines%importFlushEvery == 0 {
+			w.Flush()
+		}
+	}
+
+	w.WriteString(`],"summary":`)
+	writeJSON(w, summary)
+	w.WriteString("}\n")
+}
+
+// clearDeadlines lifts the read and write timeouts of the server, which
+// would cut off transfers lasting as long as their data takes to stream
+func clearDeadlines(rc *http.ResponseController) error {
+	if err := rc.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+func importOptions(c *gin.Context) (services.ImportOptions, error) {
+	opts := services.ImportOptions{OnConflict: services.ConflictSkip}
+	var fields []apperrors.FieldError
+
+	if raw := c.Query("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			fields = append(fields, apperrors.FieldError{Field: "dry_run", Reason: "must be a boolean"})
+		}
+		opts.DryRun = dryRun
+	}
+
+	switch policy := services.ConflictPolicy(c.Query("on_conflict")); policy {
+	case "":
+	case services.ConflictSkip, services.ConflictUpsert:
+		opts.OnConflict = policy
+	default:
+		fields = append(fields, apperrors.FieldError{Field: "on_conflict", Reason: "must be one of: skip, upsert"})
+	}
+
+	if len(fields) > 0 {
+		return opts, apperrors.Validation("invalid query parameters", fields...)
+	}
+	return opts, nil
+}
+
+func (h *BlogPostHandler) importLine(ctx context.Context, n int, line []byte, opts services.ImportOptions) models.ImportResult {
+	result := models.ImportResult{Line: n}
+
+	var body models.BlogPostImport
+	fields, err := validation.DecodeJSON(line, &body)
+	if err == nil && len(fields) > 0 {
+		err = apperrors.Validation("blog post has invalid fields", fields...)
+	}
+	if err != nil {
+		return withImportError(result, err)
+	}
+
+	post := body.ToBlogPost()
+	outcome, err := h.service.Import(ctx, &post, opts)
+	result.ID = post.ID
+	if err != nil {
+		return withImportError(result, err)
+	}
+	result.Result = outcome
+	return result
+}
+
+// withImportError reports a failed line the same way the API reports errors
+func withImportError(result models.ImportResult, err error) models.ImportResult {
+	problem := middleware.NewProblem(err)
+	result.Result = models.ImportInvalid
+	if problem.Status >= http.StatusInternalServerError {
+		result.Result = models.ImportFailed
+		log.Printf("import of line %d failed: %v", result.Line, err)
+	}
+	result.Error = problem.Detail
+	result.InvalidParams = problem.InvalidParams
+	return result
+}
+
+// readLine returns the next line without its line break. A line longer
+// than limit is consumed without being buffered and reported as too long,
+// so that memory use stays bounded whatever the input.
+func readLine(r *bufio.Reader, limit int) (line []byte, tooLong bool, err error) {
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(line)+len(chunk) > limit+2 { // room for \r\n
+			tooLong, line = true, nil
+		} else if !tooLong {
+			line = append(line, chunk...)
+		}
+
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF) && (len(line) > 0 || tooLong):
+			// last line without a line break
+		case err != nil:
+			return nil, false, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		return line, tooLong || len(line) > limit, nil
+	}
+}
+
+func writeJSON(w io.Writer, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// only report types are written
+		panic(fmt.Sprintf("failed to encode %T: %v", v, err))
+	}
+	w.Write(data)
+}
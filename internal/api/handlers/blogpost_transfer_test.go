@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const importedID = "550e8400-e29b-41d4-a716-446655440000"
+
+func newTestTransferRouter(t *testing.T) (*gin.Engine, *services.BlogPostService) {
+	t.Helper()
+	service := newTestPostService(t)
+	return newTestRouter(t, "/api/v1", NewBlogPostHandler(service).RegisterRoutes), service
+}
+
+func postImport(t *testing.T, router *gin.Engine, query, body string) models.ImportReport {
+	t.Helper()
+	req, _ := http.NewRequest("POST", "/api/v1/posts/import"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", NDJSONContentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var report models.ImportReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to unmarshal report: %v: %s", err, w.Body.String())
+	}
+	return report
+}
+
+func resultsOf(report models.ImportReport) string {
+	var results []string
+	for _, r := range report.Results {
+		results = append(results, r.Result)
+	}
+	return strings.Join(results, ",")
+}
+
+func TestImportPosts_PerLineResults(t *testing.T) {
+	router, service := newTestTransferRouter(t)
+
+	body := strings.Join([]string{
+		`{"id":"` + importedID + `","title":"Go basics","content":"Go is simple.","author":"John Doe","created_at":"2020-01-01T00:00:00Z"}`,
+		`{"title":"","content":"No title.","author":"John Doe","extra":1}`,
+		``,
+		`not json`,
+		`{"title":"Draft","content":"Soon.","author":"Jane Smith","status":"draft"}`,
+	}, "\n")
+
+	report := postImport(t, router, "", body)
+
+	if got := resultsOf(report); got != "created,invalid,invalid,created" {
+		t.Fatalf("expected results created,invalid,invalid,created, got %s", got)
+	}
+	if report.Results[1].Line != 2 || report.Results[2].Line != 4 {
+		t.Errorf("expected line numbers to count blank lines, got %+v", report.Results)
+	}
+	if len(report.Results[1].InvalidParams) != 2 {
+		t.Errorf("expected the unknown and the missing field to be reported, got %+v", report.Results[1].InvalidParams)
+	}
+	if report.Summary.Lines != 4 || report.Summary.Created != 2 || report.Summary.Invalid != 2 {
+		t.Errorf("unexpected summary %+v", report.Summary)
+	}
+
+	post, err := service.GetById(context.Background(), importedID)
+	if err != nil {
+		t.Fatalf("expected the post to keep its ID: %v", err)
+	}
+	if !post.CreatedAt.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected created_at to be kept, got %s", post.CreatedAt)
+	}
+	if report.Results[3].ID == "" {
+		t.Error("expected an ID to be generated for a line without one")
+	}
+}
+
+func TestImportPosts_ConflictPolicies(t *testing.T) {
+	router, service := newTestTransferRouter(t)
+	line := `{"id":"` + importedID + `","title":"Go basics","content":"Go is simple.","author":"John Doe"}`
+	postImport(t, router, "", line)
+
+	changed := strings.Replace(line, "Go basics", "Go basics, revised", 1)
+
+	if got := resultsOf(postImport(t, router, "", changed)); got != "skipped" {
+		t.Errorf("expected skipped by default, got %s", got)
+	}
+	if got := resultsOf(postImport(t, router, "?on_conflict=upsert&dry_run=true", changed)); got != "updated" {
+		t.Errorf("expected updated in dry run, got %s", got)
+	}
+	post, _ := service.GetById(context.Background(), importedID)
+	if post.Title != "Go basics" {
+		t.Errorf("expected a dry run to leave the post untouched, got %s", post.Title)
+	}
+
+	if got := resultsOf(postImport(t, router, "?on_conflict=upsert", changed)); got != "updated" {
+		t.Errorf("expected updated, got %s", got)
+	}
+	post, _ = service.GetById(context.Background(), importedID)
+	if post.Title != "Go basics, revised" || post.Version != 2 {
+		t.Errorf("expected the post to be updated, got %s version %d", post.Title, post.Version)
+	}
+}
+
+func TestImportPosts_DryRunWritesNothing(t *testing.T) {
+	router, service := newTestTransferRouter(t)
+
+	report := postImport(t, router, "?dry_run=1", `{"title":"Go basics","content":"Go is simple.","author":"John Doe"}`)
+
+	if !report.DryRun || resultsOf(report) != "created" {
+		t.Errorf("expected a dry run reporting created, got %+v", report)
+	}
+	if posts, _ := service.GetAll(context.Background()); len(posts) != 0 {
+		t.Errorf("expected no post to be written, got %d", len(posts))
+	}
+}
+
+func TestImportPosts_InvalidOptions(t *testing.T) {
+	router, _ := newTestTransferRouter(t)
+
+	req, _ := http.NewRequest("POST", "/api/v1/posts/import?on_conflict=replace&dry_run=maybe", strings.NewReader(""))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var problem models.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to unmarshal problem: %v", err)
+	}
+	if len(problem.InvalidParams) != 2 {
+		t.Errorf("expected both parameters to be reported, got %+v", problem.InvalidParams)
+	}
+}
+
+func TestExportPosts_RoundTrip(t *testing.T) {
+	router, service := newTestTransferRouter(t)
+	ctx := context.Background()
+	for i, title := range []string{"First", "Second"} {
+		post := &models.BlogPost{
+			ID:        importedID[:len(importedID)-1] + string(rune('1'+i)),
+			Title:     title,
+			Content:   "<b>Content</b>",
+			Author:    "John Doe",
+			CreatedAt: time.Date(2025, 1, i+1, 0, 0, 0, 0, time.UTC),
+		}
+		if _, err := service.Create(ctx, post); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+
+	w := get(router, "/api/v1/posts/export")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != NDJSONContentType {
+		t.Errorf("expected content type %s, got %s", NDJSONContentType, ct)
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"title":"First"`) {
+		t.Fatalf("expected 2 lines oldest first, got %v", lines)
+	}
+
+	other, _ := newTestTransferRouter(t)
+	report := postImport(t, other, "", w.Body.String())
+	if resultsOf(report) != "created,created" {
+		t.Errorf("expected the export to import cleanly, got %+v", report.Results)
+	}
+}
+
+// newTestTransferServer serves the transfer routes over HTTP/1.1 with the
+// given server timeouts
+func newTestTransferServer(t *testing.T, timeout time.Duration) (*httptest.Server, *services.BlogPostService) {
+	t.Helper()
+	router, service := newTestTransferRouter(t)
+	server := httptest.NewUnstartedServer(router)
+	server.Config.ReadTimeout = timeout
+	server.Config.WriteTimeout = timeout
+	server.Start()
+	t.Cleanup(server.Close)
+	return server, service
+}
+
+// slowImport posts n lines to the import endpoint, pausing for pause
+// halfway through
+func slowImport(t *testing.T, server *httptest.Server, n int, pause time.Duration) models.ImportReport {
+	t.Helper()
+	content := strings.Repeat("Imported content. ", 100)
+	body, w := io.Pipe()
+	go func() {
+		for i := range n {
+			if i == n/2 {
+				time.Sleep(pause)
+			}
+			fmt.Fprintf(w, `{"title":"Post %d","content":%q,"author":"John Doe"}`+"\n", i, content)
+		}
+		w.Close()
+	}()
+
+	res, err := http.Post(server.URL+"/api/v1/posts/import", NDJSONContentType, body)
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	defer res.Body.Close()
+	var report models.ImportReport
+	if err := json.NewDecoder(res.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to unmarshal report: %v", err)
+	}
+	return report
+}
+
+func TestImportPosts_OverHTTP(t *testing.T) {
+	server, service := newTestTransferServer(t, time.Minute)
+
+	// the report is written while the body is read, the server must not
+	// discard the rest of the body meanwhile
+	report := slowImport(t, server, 500, 0)
+	if report.Summary.Error != "" || report.Summary.Lines != 500 || report.Summary.Created != 500 {
+		t.Errorf("expected 500 posts to be created, got %+v", report.Summary)
+	}
+	if posts, _ := service.GetAll(context.Background()); len(posts) != 500 {
+		t.Errorf("expected 500 posts, got %d", len(posts))
+	}
+}
+
+func TestTransfers_OutlastServerTimeouts(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	server, service := newTestTransferServer(t, timeout)
+
+	report := slowImport(t, server, 200, 2*timeout)
+	if report.Summary.Error != "" || report.Summary.Created != 200 {
+		t.Errorf("expected the slow upload to be imported, got %+v", report.Summary)
+	}
+
+	// more than the socket buffers hold, so that the export is still
+	// writing when the client resumes reading
+	content := strings.Repeat("Lorem ipsum dolor sit amet. ", 2000)
+	for i := range 200 {
+		post := &models.BlogPost{ID: uuid.NewString(), Title: "Big " + strconv.Itoa(i), Content: content, Author: "John Doe"}
+		if _, err := service.Create(context.Background(), post); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+	res, err := http.Get(server.URL + "/api/v1/posts/export")
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	defer res.Body.Close()
+	time.Sleep(2 * timeout)
+	lines := 0
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		lines++
+	}
+	if err := scanner.Err(); err != nil || lines != 400 {
+		t.Errorf("expected the 400 posts to be exported, got %d lines: %v", lines, err)
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	input := "short\r\n" + strings.Repeat("x", 40) + "\nlast"
+	r := bufio.NewReaderSize(strings.NewReader(input), 16)
+
+	line, tooLong, err := readLine(r, 10)
+	if err != nil || tooLong || string(line) != "short" {
+		t.Errorf("expected 'short', got %q %v %v", line, tooLong, err)
+	}
+	line, tooLong, err = readLine(r, 10)
+	if err != nil || !tooLong || line != nil {
+		t.Errorf("expected a too long line, got %q %v %v", line, tooLong, err)
+	}
+	line, tooLong, err = readLine(r, 10)
+	if err != nil || tooLong || string(line) != "last" {
+		t.Errorf("expected 'last', got %q %v %v", line, tooLong, err)
+	}
+	if _, _, err = readLine(r, 10); err == nil {
+		t.Error("expected EOF after the last line")
+	}
+}
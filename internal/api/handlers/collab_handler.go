@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/collab"
+	"blog-posts-api/internal/config"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// MaxCollabMessageBytes bounds the messages of editors, enough for an
+	// operation inserting a whole post
+	MaxCollabMessageBytes = 4 << 20
+	collabWriteTimeout    = 10 * time.Second
+	collabPingInterval    = 30 * time.Second
+)
+
+type CollabHandler struct {
+	hub *collab.Hub
+	cfg config.CollabConfig
+}
+
+func NewCollabHandler(hub *collab.Hub, cfg config.CollabConfig) *CollabHandler {
+	return &CollabHandler{hub: hub, cfg: cfg}
+}
+
+func (h *CollabHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/posts/:id/collab", h.Collaborate)
+}
+
+// @Summary Edit the content of a post with other editors
+// @Description Upgrades to a WebSocket joining the editing session of the post. Messages are JSON objects with a type:
+// @Description the server sends init (document and revision), op (operations of other editors), ack, presence, saved, error and deleted;
+// @Description editors send op (an ot.js operation based on a revision), cursor and save. The document is saved as a new version of the post shortly after every change.
+// @Tags Blog Posts
+// @Param id path string true "Blog post ID"
+// @Param name query string false "Name shown to the other editors"
+// @Success 101 {string} string "Switching to the WebSocket protocol"
+// @Failure 400 {object} models.Problem "Not a WebSocket request or invalid name"
+// @Failure 404 {object} models.Problem "Blog post not found"
+// @Router /posts/{id}/collab [get]
+func (h *CollabHandler) Collaborate(c *gin.Context) {
+	client, err := h.hub.Join(c.Request.Context(), c.Param("id"), c.Query("name"))
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to join the editing session"))
+		return
+	}
+	defer client.Leave()
+
+	// the connection outlives the timeouts of the server, which would
+	// otherwise stay set once it is hijacked
+	rc := http.NewResponseController(c.Writer)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+	conn, err := websocket.Accept(c.Writer, c.Request, &websocket.AcceptOptions{OriginPatterns: h.cfg.Origins})
+	if err != nil {
+		// Accept replied with the error
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(MaxCollabMessageBytes)
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			var m collab.Message
+			if err := wsjson.Read(ctx, conn, &m); err != nil {
+				return
+			}
+			client.Receive(m)
+		}
+	}()
+
+	ping := time.NewTicker(collabPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case m, ok := <-client.Messages():
+			if !ok {
+				conn.Close(websocket.StatusNormalClosure, "left the editing session")
+				return
+			}
+			if err := writeTimeout(ctx, func(ctx context.Context) error { return wsjson.Write(ctx, conn, m) }); err != nil {
+				return
+			}
+		case <-client.Done():
+			conn.Close(websocket.StatusGoingAway, "the editing session ended")
+			return
+		case <-ping.C:
+			if err := writeTimeout(ctx, conn.Ping); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeTimeout runs a write to an editor, failing when the editor does not
+// read it in time
+func writeTimeout(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, collabWriteTimeout)
+	defer cancel()
+	return fn(ctx)
+}
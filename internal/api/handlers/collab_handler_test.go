@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/collab"
+	"blog-posts-api/internal/config"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// newTestCollabServer serves the editing sessions with server timeouts
+// shorter than the tests, which connections must outlive
+func newTestCollabServer(t *testing.T) (*httptest.Server, *services.BlogPostService) {
+	t.Helper()
+	service := newTestPostService(t, testPosts()[0])
+	hub := collab.NewHub(service, collab.Options{SaveDelay: 10 * time.Millisecond})
+	router := newTestRouter(t, "/api/v1", NewCollabHandler(hub, config.CollabConfig{}).RegisterRoutes)
+
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config.ReadTimeout = 100 * time.Millisecond
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	t.Cleanup(func() {
+		hub.Close(context.Background())
+		srv.Close()
+	})
+	return srv, service
+}
+
+func readCollabMessage(t *testing.T, conn *websocket.Conn, messageType string) collab.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for {
+		var m collab.Message
+		if err := wsjson.Read(ctx, conn, &m); err != nil {
+			t.Fatalf("expected a %s message, got %v", messageType, err)
+		}
+		if m.Type == messageType {
+			return m
+		}
+	}
+}
+
+func TestCollabHandler_Collaborate(t *testing.T) {
+	srv, service := newTestCollabServer(t)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/posts/1/collab?name=Alice"
+
+	ctx := context.Background()
+	conn, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.CloseNow()
+
+	init := readCollabMessage(t, conn, collab.MessageInit)
+	if init.Content == nil || *init.Content != "Go is **simple**." || init.Rev != 0 || init.Version != 1 {
+		t.Fatalf("expected the document, got %+v", init)
+	}
+	if len(init.Clients) != 1 || init.Clients[0].Name != "Alice" {
+		t.Errorf("expected Alice to be editing, got %+v", init.Clients)
+	}
+
+	// outlives the timeouts of the server
+	time.Sleep(200 * time.Millisecond)
+	var op collab.Operation
+	json.Unmarshal([]byte(`[17, " Fast too."]`), &op)
+	if err := wsjson.Write(ctx, conn, collab.Message{Type: collab.MessageOp, Rev: 0, Op: op}); err != nil {
+		t.Fatalf("failed to send the operation: %v", err)
+	}
+	if m := readCollabMessage(t, conn, collab.MessageAck); m.Rev != 1 {
+		t.Errorf("expected revision 1, got %d", m.Rev)
+	}
+	if m := readCollabMessage(t, conn, collab.MessageSaved); m.Version != 2 {
+		t.Errorf("expected version 2, got %d", m.Version)
+	}
+	if post, _ := service.GetById(ctx, "1"); post.Content != "Go is **simple**. Fast too." {
+		t.Errorf("expected the edit to be saved, got %q", post.Content)
+	}
+}
+
+func TestCollabHandler_Errors(t *testing.T) {
+	srv, _ := newTestCollabServer(t)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/posts/"
+
+	if _, resp, err := websocket.Dial(context.Background(), url+"2/collab", nil); err == nil || resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %v", err)
+	}
+	if _, resp, err := websocket.Dial(context.Background(), url+"1/collab?name="+strings.Repeat("a", collab.MaxNameLength+1), nil); err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %v", err)
+	}
+
+	resp, err := http.Get(srv.URL + "/api/v1/posts/1/collab")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired && resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected the upgrade to be refused, got %d", resp.StatusCode)
+	}
+}
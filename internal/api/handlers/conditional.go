@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagFor returns a strong entity tag for a response body
+func etagFor(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// writeConditional writes body with ETag and Last-Modified validators, or
+// 304 Not Modified when the validators sent by the client still match.
+// If-None-Match takes precedence over If-Modified-Since (RFC 9110 13.2.2).
+func writeConditional(c *gin.Context, status int, contentType string, body []byte, lastModified time.Time) {
+	etag := etagFor(body)
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(status, contentType, body)
+}
+
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag)
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// HTTP dates have a one second resolution
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// etagMatches implements the weak comparison used by If-None-Match
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// versionETag returns the entity tag of a version of a blog post, which
+// changes on every update of the post
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersions returns the versions listed in an If-Match header, none
+// for "*" which matches any version. Weak tags never match (RFC 9110
+// 13.1.1), so ok is false when the header lists no version.
+func ifMatchVersions(header string) (versions []int, ok bool) {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return nil, true
+		}
+		if len(candidate) < 2 || candidate[0] != '"' || candidate[len(candidate)-1] != '"' {
+			continue
+		}
+		if v, err := strconv.Atoi(candidate[1 : len(candidate)-1]); err == nil && v > 0 {
+			versions = append(versions, v)
+		}
+	}
+	return versions, len(versions) > 0
+}
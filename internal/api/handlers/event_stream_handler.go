@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/stream"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// EventReset is sent to streams which missed events, because they fell
+// behind the event log or were resumed from an event it no longer holds.
+// Clients should reload the posts they show.
+const EventReset = "reset"
+
+type EventStreamHandler struct {
+	log *stream.Log
+	cfg config.StreamConfig
+}
+
+// NewEventStreamHandler returns a handler streaming the changes made
+// through the service. Call Close to end the open streams.
+func NewEventStreamHandler(s *services.BlogPostService, cfg config.StreamConfig) *EventStreamHandler {
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = 15 * time.Second
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 10 * time.Second
+	}
+	h := &EventStreamHandler{log: stream.NewLog(cfg.LogSize), cfg: cfg}
+	s.Subscribe(h.log.Append)
+	return h
+}
+
+func (h *EventStreamHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/posts/events", h.StreamEvents)
+}
+
+// Close ends the open streams, e.g. as soon as the server shuts down so
+// that clients reconnect to another instance rather than delay the drain
+func (h *EventStreamHandler) Close() {
+	h.log.Close()
+}
+
+// @Summary Stream post changes
+// @Description Server-sent event stream of the post events: post.created, post.updated, post.deleted and post.published.
+// @Description Every event has an id and the data of a webhook delivery. Reconnecting with the Last-Event-ID header resumes the stream after that event;
+// @Description a reset event tells that events were missed and the posts should be reloaded.
+// @Description The author and tag filters apply to the post after the change, deletions are always sent.
+// @Tags Blog Posts
+// @Produce text/event-stream
+// @Param author query string false "Only the changes of posts by this author"
+// @Param tag query string false "Only the changes of posts with this tag"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Success 200 {string} string "Event stream"
+// @Router /posts/events [get]
+func (h *EventStreamHandler) StreamEvents(c *gin.Context) {
+	filter := models.PostFilter{Author: c.Query("author"), Tag: c.Query("tag")}
+	cursor, resumed := h.log.Head(), true
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		if cursor, resumed = h.log.Resume(id); !resumed {
+			cursor = h.log.Head()
+		}
+	}
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	// keep reverse proxies such as nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	rc := http.NewResponseController(c.Writer)
+	// the read timeout of the server would cancel the request once expired,
+	// while the connection is only read to notice the client going away
+	if err := rc.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return
+	}
+	w := &eventWriter{w: c.Writer, rc: rc, timeout: h.cfg.WriteTimeout}
+	var err error
+	if resumed {
+		// sends the headers right away
+		err = w.comment("connected")
+	} else {
+		err = w.event(sse.Event{Event: EventReset, Data: "{}"})
+	}
+
+	heartbeat := time.NewTicker(h.cfg.Heartbeat)
+	defer heartbeat.Stop()
+	ctx := c.Request.Context()
+	for err == nil {
+		entries, next, changed, ok := h.log.Read(cursor)
+		if !ok {
+			// fell behind: the log does not wait for slow clients
+			entries, next = nil, h.log.Head()
+			err = w.event(sse.Event{Event: EventReset, Data: "{}"})
+		}
+		for _, e := range entries {
+			if err != nil {
+				break
+			}
+			if e.Event.Post == nil || filter.Matches(e.Event.Post) {
+				err = w.event(sse.Event{Id: e.ID, Event: string(e.Event.Type), Data: e.Data})
+			}
+		}
+		cursor = next
+		if err != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+			if h.log.Closed() {
+				return
+			}
+		case <-heartbeat.C:
+			err = w.comment("heartbeat")
+		}
+	}
+}
+
+// eventWriter writes server-sent events, flushing each of them and
+// failing when the client does not read them in time
+type eventWriter struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (w *eventWriter) event(e sse.Event) error {
+	return w.write(func() error { return sse.Encode(w.w, e) })
+}
+
+func (w *eventWriter) comment(text string) error {
+	return w.write(func() error {
+		_, err := io.WriteString(w.w, ": "+text+"\n\n")
+		return err
+	})
+}
+
+func (w *eventWriter) write(fn func() error) error {
+	// the deadline also lifts the write timeout of the server, which would
+	// otherwise end every stream
+	if err := w.rc.SetWriteDeadline(time.Now().Add(w.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return w.rc.Flush()
+}
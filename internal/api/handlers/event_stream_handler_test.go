@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/config"
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestStreamServer serves the event stream with server timeouts shorter
+// than the tests, which streams must outlive
+func newTestStreamServer(t *testing.T) (*httptest.Server, *services.BlogPostService, *EventStreamHandler) {
+	t.Helper()
+	service := newTestPostService(t)
+	h := NewEventStreamHandler(service, config.StreamConfig{LogSize: 10, Heartbeat: 50 * time.Millisecond, WriteTimeout: time.Second})
+	router := newTestRouter(t, "/api/v1", h.RegisterRoutes)
+
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config.ReadTimeout = 100 * time.Millisecond
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	t.Cleanup(func() {
+		h.Close()
+		srv.Close()
+	})
+	return srv, service, h
+}
+
+type sseMessage struct {
+	id, event, data, comment string
+}
+
+// openStream connects to the stream and waits for its first message
+func openStream(t *testing.T, url, lastEventID string) (*bufio.Reader, sseMessage, io.Closer) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open the stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	r := bufio.NewReader(resp.Body)
+	return r, readMessage(t, r), resp.Body
+}
+
+func readMessage(t *testing.T, r *bufio.Reader) sseMessage {
+	t.Helper()
+	var m sseMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read the stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return m
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			m.comment = value
+		case "id":
+			m.id = value
+		case "event":
+			m.event = value
+		case "data":
+			m.data = value
+		}
+	}
+}
+
+// readEvent returns the next event, skipping the heartbeats
+func readEvent(t *testing.T, r *bufio.Reader) sseMessage {
+	t.Helper()
+	for {
+		if m := readMessage(t, r); m.comment == "" {
+			return m
+		}
+	}
+}
+
+func newStreamPost(id, author string) *models.BlogPost {
+	return &models.BlogPost{ID: id, Title: "Test Post", Content: "Test content", Author: author}
+}
+
+func TestEventStreamHandler_StreamsFilteredEvents(t *testing.T) {
+	srv, service, _ := newTestStreamServer(t)
+	r, first, body := openStream(t, srv.URL+"/api/v1/posts/events?author=Alice", "")
+	defer body.Close()
+	if first.comment != "connected" {
+		t.Fatalf("expected the connected comment, got %+v", first)
+	}
+
+	// outlive the timeouts of the server
+	if m := readMessage(t, r); m.comment != "heartbeat" {
+		t.Errorf("expected a heartbeat, got %+v", m)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	ctx := t.Context()
+	service.Create(ctx, newStreamPost("1", "Bob"))
+	service.Create(ctx, newStreamPost("2", "Alice"))
+	service.Delete(ctx, "1")
+
+	var got []string
+	for range 3 {
+		m := readEvent(t, r)
+		if m.id == "" || !strings.Contains(m.data, `"type":"`+m.event+`"`) {
+			t.Errorf("expected an event with an ID and its JSON data, got %+v", m)
+		}
+		got = append(got, m.event+" "+m.data[strings.Index(m.data, `"post_id":"`)+11:][:1])
+	}
+	expected := "post.created 2,post.published 2,post.deleted 1"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(got, ","))
+	}
+}
+
+func TestEventStreamHandler_Resume(t *testing.T) {
+	srv, service, _ := newTestStreamServer(t)
+	r, _, body := openStream(t, srv.URL+"/api/v1/posts/events", "")
+	service.Create(t.Context(), newStreamPost("1", "Alice"))
+	created := readEvent(t, r)
+	body.Close()
+	service.Create(t.Context(), newStreamPost("2", "Alice"))
+
+	r, first, body := openStream(t, srv.URL+"/api/v1/posts/events", created.id)
+	defer body.Close()
+	if first.comment != "connected" {
+		t.Fatalf("expected the stream to resume, got %+v", first)
+	}
+	for _, expected := range []string{"post.published", "post.created", "post.published"} {
+		if m := readEvent(t, r); m.event != expected {
+			t.Errorf("expected %s, got %+v", expected, m)
+		}
+	}
+
+	_, first, unknown := openStream(t, srv.URL+"/api/v1/posts/events", "another-process-1")
+	defer unknown.Close()
+	if first.event != EventReset {
+		t.Errorf("expected a reset event for an unknown ID, got %+v", first)
+	}
+}
+
+// gatedWriter passes the writes of the stream to a channel, holding the
+// first event until the gate is closed
+type gatedWriter struct {
+	header http.Header
+	writes chan string
+	gate   chan struct{}
+	once   sync.Once
+}
+
+func (w *gatedWriter) Header() http.Header        { return w.header }
+func (w *gatedWriter) WriteHeader(statusCode int) {}
+func (w *gatedWriter) Flush()                     {}
+
+func (w *gatedWriter) Write(b []byte) (int, error) {
+	return w.WriteString(string(b))
+}
+
+func (w *gatedWriter) WriteString(s string) (int, error) {
+	if s == "id:" {
+		w.once.Do(func() { <-w.gate })
+	}
+	w.writes <- s
+	return len(s), nil
+}
+
+func TestEventStreamHandler_FallsBehind(t *testing.T) {
+	service := newTestPostService(t)
+	h := NewEventStreamHandler(service, config.StreamConfig{LogSize: 10})
+	w := &gatedWriter{header: http.Header{}, writes: make(chan string, 1000), gate: make(chan struct{})}
+	c, _ := gin.CreateTestContext(w)
+	ctx, cancel := context.WithCancel(t.Context())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/posts/events", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.StreamEvents(c)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitForWrite := func(text string) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case s := <-w.writes:
+				if strings.Contains(s, text) {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %q", text)
+			}
+		}
+	}
+	waitForWrite("connected")
+
+	// the stream is held on the first event while the log holds 10 events
+	// and each post makes 2 of them
+	for i := range 10 {
+		service.Create(t.Context(), newStreamPost(string(rune('a'+i)), "Alice"))
+	}
+	close(w.gate)
+	waitForWrite(EventReset)
+}
+
+func TestEventStreamHandler_CloseEndsStreams(t *testing.T) {
+	srv, _, h := newTestStreamServer(t)
+	r, _, body := openStream(t, srv.URL+"/api/v1/posts/events", "")
+	defer body.Close()
+
+	h.Close()
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(r)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected the stream to end, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the stream to end")
+	}
+}
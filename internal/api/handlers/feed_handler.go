@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/feed"
+	"blog-posts-api/internal/render"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeedHandler struct {
+	service *services.BlogPostService
+	site    config.SiteConfig
+	cfg     config.FeedConfig
+}
+
+func NewFeedHandler(s *services.BlogPostService, site config.SiteConfig, cfg config.FeedConfig) *FeedHandler {
+	return &FeedHandler{service: s, site: site, cfg: cfg}
+}
+
+func (h *FeedHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/feed.rss", h.RSS)
+	r.GET("/feed.atom", h.Atom)
+	r.GET("/feed.json", h.JSON)
+}
+
+// RSS serves the latest posts as RSS 2.0. Like the other feeds it accepts
+// the author and tag query parameters to build per-author and per-tag feeds.
+func (h *FeedHandler) RSS(c *gin.Context) {
+	h.serve(c, feed.ContentTypeRSS, (*feed.Feed).RSS)
+}
+
+// Atom serves the latest posts as Atom 1.0
+func (h *FeedHandler) Atom(c *gin.Context) {
+	h.serve(c, feed.ContentTypeAtom, (*feed.Feed).Atom)
+}
+
+// JSON serves the latest posts as JSON Feed 1.1
+func (h *FeedHandler) JSON(c *gin.Context) {
+	h.serve(c, feed.ContentTypeJSON, (*feed.Feed).JSON)
+}
+
+func (h *FeedHandler) serve(c *gin.Context, contentType string, encode func(*feed.Feed) ([]byte, error)) {
+	f, err := h.build(c)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to build the feed"))
+		return
+	}
+
+	body, err := encode(f)
+	if err != nil {
+		c.Error(apperrors.Internal("failed to encode the feed", err))
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	writeConditional(c, http.StatusOK, contentType, body, f.Updated)
+}
+
+func (h *FeedHandler) build(c *gin.Context) (*feed.Feed, error) {
+	filter := models.PostFilter{Author: c.Query("author"), Tag: c.Query("tag"), Status: models.StatusPublished}
+	posts, err := h.service.GetLatest(c.Request.Context(), filter, h.cfg.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &feed.Feed{
+		Title:       h.site.Title,
+		Description: h.site.Description,
+		SiteURL:     h.site.URL + "/",
+		FeedURL:     h.site.URL + c.Request.URL.RequestURI(),
+		Items:       make([]feed.Item, 0, len(posts)),
+	}
+	switch {
+	case filter.Author != "" && filter.Tag != "":
+		f.Title += " - " + filter.Author + " - #" + filter.Tag
+	case filter.Author != "":
+		f.Title += " - " + filter.Author
+	case filter.Tag != "":
+		f.Title += " - #" + filter.Tag
+	}
+
+	for _, p := range posts {
+		html, err := h.service.RenderContent(p)
+		if err != nil {
+			return nil, err
+		}
+
+		item := feed.Item{
+			ID:        p.ID,
+			Title:     p.Title,
+			URL:       postURL(h.site, p),
+			Author:    p.Author,
+			Tags:      p.Tags,
+			Published: p.CreatedAt,
+			Updated:   p.UpdatedAt,
+		}
+		if p.PublishedAt != nil {
+			item.Published = *p.PublishedAt
+		}
+		if h.cfg.FullContent {
+			item.ContentHTML = html
+		} else {
+			item.Summary = render.Excerpt(html, h.cfg.ExcerptLength)
+		}
+		if p.UpdatedAt.After(f.Updated) {
+			f.Updated = p.UpdatedAt
+		}
+		f.Items = append(f.Items, item)
+	}
+
+	return f, nil
+}
+
+// postURL returns the public URL of a post, its page on the HTML frontend.
+// The service keeps slugs unique, so it is the URL given by the theme.
+func postURL(site config.SiteConfig, p *models.BlogPost) string {
+	if p.Slug == "" {
+		return site.URL + "/p/" + p.ID
+	}
+	return site.URL + "/p/" + p.Slug
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/theme"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestFeedRouter(t *testing.T, cfg config.FeedConfig) *gin.Engine {
+	t.Helper()
+	service := newTestPostService(t, testPosts()...)
+	site := config.SiteConfig{URL: "https://blog.example.com", Title: "Blog", Description: "Latest posts"}
+	return newTestRouter(t, "", NewFeedHandler(service, site, cfg).RegisterRoutes)
+}
+
+func getJSONFeed(t *testing.T, router *gin.Engine, target string) map[string]any {
+	t.Helper()
+	req, _ := http.NewRequest("GET", target, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return doc
+}
+
+func itemTitles(doc map[string]any) []string {
+	var titles []string
+	for _, raw := range doc["items"].([]any) {
+		titles = append(titles, raw.(map[string]any)["title"].(string))
+	}
+	return titles
+}
+
+func TestFeedHandler_LatestFirstWithLimit(t *testing.T) {
+	router := newTestFeedRouter(t, config.FeedConfig{Items: 2, FullContent: true})
+
+	doc := getJSONFeed(t, router, "/feed.json")
+
+	if titles := strings.Join(itemTitles(doc), ","); titles != "Go channels,Rust <basics>" {
+		t.Errorf("expected the 2 latest posts, got %s", titles)
+	}
+	if doc["feed_url"] != "https://blog.example.com/feed.json" {
+		t.Errorf("unexpected feed_url %v", doc["feed_url"])
+	}
+}
+
+func TestFeedHandler_Filters(t *testing.T) {
+	router := newTestFeedRouter(t, config.FeedConfig{Items: 10, FullContent: true})
+
+	byTag := getJSONFeed(t, router, "/feed.json?tag=go")
+	if titles := strings.Join(itemTitles(byTag), ","); titles != "Go channels,Go basics" {
+		t.Errorf("expected posts tagged go, got %s", titles)
+	}
+
+	byAuthor := getJSONFeed(t, router, "/feed.json?author=Jane+Smith&tag=go")
+	if titles := strings.Join(itemTitles(byAuthor), ","); titles != "Go channels" {
+		t.Errorf("expected posts by Jane Smith tagged go, got %s", titles)
+	}
+	if byAuthor["title"] != "Blog - Jane Smith - #go" {
+		t.Errorf("unexpected title %v", byAuthor["title"])
+	}
+}
+
+func TestFeedHandler_FullContentAndExcerpt(t *testing.T) {
+	full := getJSONFeed(t, newTestFeedRouter(t, config.FeedConfig{Items: 10, FullContent: true}), "/feed.json?author=John+Doe")
+	item := full["items"].([]any)[0].(map[string]any)
+	if !strings.Contains(item["content_html"].(string), "<strong>simple</strong>") {
+		t.Errorf("expected rendered content, got %v", item["content_html"])
+	}
+
+	excerpt := getJSONFeed(t, newTestFeedRouter(t, config.FeedConfig{Items: 10, ExcerptLength: 10}), "/feed.json?author=John+Doe")
+	item = excerpt["items"].([]any)[0].(map[string]any)
+	if item["content_html"] != nil {
+		t.Errorf("expected no full content, got %v", item["content_html"])
+	}
+	if item["summary"] != "Go is…" {
+		t.Errorf("expected excerpt 'Go is…', got %v", item["summary"])
+	}
+}
+
+func TestFeedHandler_ContentTypes(t *testing.T) {
+	router := newTestFeedRouter(t, config.FeedConfig{Items: 10, FullContent: true})
+
+	for target, contentType := range map[string]string{
+		"/feed.rss":  "application/rss+xml; charset=utf-8",
+		"/feed.atom": "application/atom+xml; charset=utf-8",
+		"/feed.json": "application/feed+json; charset=utf-8",
+	} {
+		req, _ := http.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusOK, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != contentType {
+			t.Errorf("%s: expected content type %s, got %s", target, contentType, ct)
+		}
+	}
+}
+
+func TestFeedHandler_ConditionalGet(t *testing.T) {
+	router := newTestFeedRouter(t, config.FeedConfig{Items: 10, FullContent: true})
+
+	req, _ := http.NewRequest("GET", "/feed.atom", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("expected validators, got ETag=%q Last-Modified=%q", etag, lastModified)
+	}
+
+	req, _ = http.NewRequest("GET", "/feed.atom", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected status %d for matching ETag, got %d", http.StatusNotModified, w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Error("expected an empty body for 304")
+	}
+
+	req, _ = http.NewRequest("GET", "/feed.atom", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected status %d for If-Modified-Since, got %d", http.StatusNotModified, w.Code)
+	}
+
+	req, _ = http.NewRequest("GET", "/feed.atom", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	req.Header.Set("If-Modified-Since", lastModified)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected If-None-Match to take precedence, got %d", w.Code)
+	}
+}
+
+func TestFeedHandler_URLsMatchPages(t *testing.T) {
+	ctx := context.Background()
+	var posts []*models.BlogPost
+	for i, tag := range []string{"go", "rust"} {
+		posts = append(posts, &models.BlogPost{ID: strconv.Itoa(i + 1), Title: "Basics", Content: "Basics.", Author: "Jane Smith", Tags: []string{tag}})
+	}
+	service := newTestPostService(t, posts...)
+	site := config.SiteConfig{URL: "https://blog.example.com", Title: "Blog"}
+	sitemap := NewSitemapHandler(service, site, 0)
+	if err := sitemap.Load(ctx); err != nil {
+		t.Fatalf("failed to load the sitemap: %v", err)
+	}
+	router := newTestRouter(t, "", NewFeedHandler(service, site, config.FeedConfig{Items: 10}).RegisterRoutes, sitemap.RegisterRoutes)
+
+	published, _ := service.GetLatest(ctx, models.PostFilter{Status: models.StatusPublished}, 0)
+	pages, _ := theme.Collect(published)
+	if pages[0].URL == pages[1].URL {
+		t.Fatalf("expected a page per post, got %s twice", pages[0].URL)
+	}
+	urls := map[string]string{}
+	for _, p := range pages {
+		urls[p.ID] = site.URL + p.URL
+	}
+
+	// the feed of a tag only lists one of the posts sharing the title
+	for _, tag := range []string{"go", "rust"} {
+		for _, raw := range getJSONFeed(t, router, "/feed.json?tag="+tag)["items"].([]any) {
+			item := raw.(map[string]any)
+			if id := strings.TrimPrefix(item["id"].(string), "urn:uuid:"); item["url"] != urls[id] {
+				t.Errorf("expected the feed to link %s, got %v", urls[id], item["url"])
+			}
+		}
+	}
+	body := get(router, "/sitemap.xml").Body.String()
+	for _, u := range urls {
+		if !strings.Contains(body, "<loc>"+u+"</loc>") {
+			t.Errorf("expected %s in the sitemap, got %s", u, body)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/gql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxGraphQLBodyBytes bounds GraphQL requests, which carry the post content
+// of mutations
+const MaxGraphQLBodyBytes = middleware.MaxBlogPostBodyBytes + 64<<10
+
+type GraphQLHandler struct {
+	schema *gql.Schema
+}
+
+func NewGraphQLHandler(schema *gql.Schema) *GraphQLHandler {
+	return &GraphQLHandler{schema: schema}
+}
+
+func (h *GraphQLHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/graphql", h.Query)
+	r.POST("/graphql", h.Query)
+}
+
+// Query runs a GraphQL request, sent as a JSON body with POST or as query
+// parameters with GET, which only runs queries. The response has the data
+// and the errors of the request with status 200, as GraphQL clients expect.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req gql.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				c.Error(apperrors.BadRequest("variables must be a JSON object", err))
+				return
+			}
+		}
+	} else {
+		data, err := middleware.ReadBody(c, MaxGraphQLBodyBytes)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.Error(apperrors.BadRequest("invalid GraphQL request", err))
+			return
+		}
+	}
+	if req.Query == "" {
+		c.Error(apperrors.BadRequest("query is required", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.schema.Execute(c.Request.Context(), req, c.Request.Method == http.MethodPost))
+}
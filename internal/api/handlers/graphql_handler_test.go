@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/gql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestGraphQLRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	service := newTestPostService(t, testPosts()[0])
+	schema, err := gql.NewSchema(service, gql.Limits{MaxDepth: 10, MaxComplexity: 2000})
+	if err != nil {
+		t.Fatalf("failed to build the schema: %v", err)
+	}
+	return newTestRouter(t, "", NewGraphQLHandler(schema).RegisterRoutes)
+}
+
+func TestGraphQLHandler_Query(t *testing.T) {
+	router := newTestGraphQLRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		want   string
+	}{
+		{"post", "POST", "/graphql", `{"query":"query($id: ID!) { post(id: $id) { title } }","variables":{"id":"1"}}`, http.StatusOK, `{"data":{"post":{"title":"Go basics"}}}`},
+		{"get", "GET", "/graphql?query=" + url.QueryEscape(`{ post(id: "1") { author { name } } }`), "", http.StatusOK, `{"data":{"post":{"author":{"name":"John Doe"}}}}`},
+		{"get mutation", "GET", "/graphql?query=" + url.QueryEscape(`mutation { deletePost(id: "1") }`), "", http.StatusOK, `"message":"mutations must be sent with POST"`},
+		{"invalid variables", "GET", "/graphql?query=%7Bposts%7D&variables=nope", "", http.StatusBadRequest, `"detail":"variables must be a JSON object"`},
+		{"invalid body", "POST", "/graphql", `nope`, http.StatusBadRequest, `"detail":"invalid GraphQL request"`},
+		{"missing query", "POST", "/graphql", `{}`, http.StatusBadRequest, `"detail":"query is required"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("expected %s in the response, got %s", tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/health"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	registry *health.Registry
+}
+
+func NewHealthHandler(r *health.Registry) *HealthHandler {
+	return &HealthHandler{r}
+}
+
+func (h *HealthHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/livez", h.Livez)
+	r.GET("/readyz", h.Readyz)
+	// kept for existing clients and the compose healthcheck
+	r.GET("/health", h.Readyz)
+}
+
+// Livez reports whether the process is alive, with the result of every
+// liveness check. It is served outside of the versioned API.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	writeReport(c, h.registry.Liveness(c.Request.Context()))
+}
+
+// Readyz reports whether the process can serve traffic, with the result of
+// every readiness check. It fails while the server is draining on shutdown.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	writeReport(c, h.registry.Readiness(c.Request.Context()))
+}
+
+func writeReport(c *gin.Context, report health.Report) {
+	c.Header("Cache-Control", "no-store")
+	if !report.OK() {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/health"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthHandler_Readyz_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := health.NewRegistry()
+	registry.Register(health.Check{Name: "repo", Fn: func(ctx context.Context) error { return nil }})
+	handler := NewHealthHandler(registry)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/readyz", nil)
+
+	handler.Readyz(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var report health.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if report.Status != health.StatusOK {
+		t.Errorf("expected status '%s', got '%s'", health.StatusOK, report.Status)
+	}
+	if report.Checks["repo"].Status != health.StatusOK {
+		t.Errorf("expected repo check status '%s', got '%s'", health.StatusOK, report.Checks["repo"].Status)
+	}
+}
+
+func TestHealthHandler_Readyz_CheckFailing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := health.NewRegistry()
+	registry.Register(health.Check{Name: "repo", Fn: func(ctx context.Context) error { return errors.New("connection refused") }})
+	handler := NewHealthHandler(registry)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/readyz", nil)
+
+	handler.Readyz(c)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	var report health.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if report.Checks["repo"].Error != "connection refused" {
+		t.Errorf("expected error 'connection refused', got '%s'", report.Checks["repo"].Error)
+	}
+}
+
+func TestHealthHandler_Readyz_Draining(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := health.NewRegistry()
+	registry.SetDraining()
+	handler := NewHealthHandler(registry)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/readyz", nil)
+
+	handler.Readyz(c)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestHealthHandler_Livez_IgnoresReadinessChecks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := health.NewRegistry()
+	registry.Register(health.Check{Name: "repo", Probe: health.Readiness, Fn: func(ctx context.Context) error { return errors.New("down") }})
+	handler := NewHealthHandler(registry)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/livez", nil)
+
+	handler.Livez(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
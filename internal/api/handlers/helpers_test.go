@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter returns a router reporting errors as problems, like the
+// server does, with the routes of the handlers registered under prefix
+func newTestRouter(t *testing.T, prefix string, register ...func(r *gin.RouterGroup)) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.Problems())
+	group := router.Group(prefix)
+	for _, fn := range register {
+		fn(group)
+	}
+	return router
+}
+
+// newTestPostService returns a service on an empty in-memory repository
+// holding posts
+func newTestPostService(t *testing.T, posts ...*models.BlogPost) *services.BlogPostService {
+	t.Helper()
+	service := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	for _, p := range posts {
+		if _, err := service.Create(context.Background(), p); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+	return service
+}
+
+// testPosts returns the fixture posts of the handler tests: three
+// published posts created a day apart, oldest first, and a draft that the
+// public pages and feeds never show
+func testPosts() []*models.BlogPost {
+	posts := []*models.BlogPost{
+		{ID: "1", Title: "Go basics", Content: "Go is **simple**.", ContentFormat: models.ContentFormatMarkdown, Author: "John Doe", Tags: []string{"go"}},
+		{ID: "2", Title: "Rust <basics>", Content: "Rust is fast.", Author: "Jane Smith", Tags: []string{"rust"}},
+		{ID: "3", Title: "Go channels", Content: "Channels connect goroutines.", Author: "Jane Smith", Tags: []string{"go"}},
+		{ID: "4", Title: "Go generics", Content: "Coming soon.", Author: "Jane Smith", Tags: []string{"go"}, Status: models.StatusDraft},
+	}
+	for i, p := range posts {
+		p.CreatedAt = time.Date(2025, 1, i+1, 0, 0, 0, 0, time.UTC)
+	}
+	return posts
+}
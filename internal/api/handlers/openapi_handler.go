@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/openapi"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OpenAPIHandler struct {
+	spec *openapi.Spec
+}
+
+func NewOpenAPIHandler(spec *openapi.Spec) *OpenAPIHandler {
+	return &OpenAPIHandler{spec: spec}
+}
+
+func (h *OpenAPIHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/api/openapi.json", h.Document)
+}
+
+// Document serves the OpenAPI 3.1 description of the REST API, which the
+// requests and responses are validated against
+func (h *OpenAPIHandler) Document(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, "application/json", h.spec.JSON())
+}
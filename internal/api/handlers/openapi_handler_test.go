@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/collab"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/openapi"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// specRecorder collects the operations exercised against the spec and the
+// responses that do not match it
+type specRecorder struct {
+	mu         sync.Mutex
+	exercised  map[string]bool
+	mismatches []string
+}
+
+func (r *specRecorder) exercise(spec *openapi.Spec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if op := spec.Operation(c.Request.Method, c.FullPath()); op != nil {
+			r.mu.Lock()
+			r.exercised[op.String()] = true
+			r.mu.Unlock()
+		}
+		c.Next()
+	}
+}
+
+func (r *specRecorder) mismatch(c *gin.Context, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mismatches = append(r.mismatches, err.Error())
+}
+
+// TestOpenAPI_HandlersMatchSpec runs every operation of the spec against
+// the real handlers and fails when a response drifts from the spec, or
+// when a route is missing from it
+func TestOpenAPI_HandlersMatchSpec(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("failed to load the spec: %v", err)
+	}
+
+	service := newTestPostService(t)
+	webhooks := services.NewWebhookService(services.NewInMemoryWebhookRepo(), services.DefaultRetryPolicy)
+	service.Subscribe(func(e services.Event) { webhooks.Enqueue(context.Background(), e) })
+	streams := NewEventStreamHandler(service, config.StreamConfig{LogSize: 10, Heartbeat: time.Second, WriteTimeout: time.Second})
+	hub := collab.NewHub(service, collab.Options{SaveDelay: 10 * time.Millisecond})
+	attachments := newAttachmentService(t, service, 1<<10)
+	authors := services.NewAuthorService(services.NewInMemoryAuthorRepo(), service)
+	users, mailer := newTestUserService()
+
+	recorder := &specRecorder{exercised: map[string]bool{}}
+	checkSpec := func(v1 *gin.RouterGroup) {
+		v1.Use(recorder.exercise(spec), middleware.OpenAPI(spec, middleware.OpenAPIOptions{
+			Requests:           true,
+			Responses:          true,
+			OnResponseMismatch: recorder.mismatch,
+		}))
+	}
+	router := newTestRouter(t, "/api/v1",
+		checkSpec,
+		NewBlogPostHandler(service).RegisterRoutes,
+		NewAuthorHandler(authors).RegisterRoutes,
+		streams.RegisterRoutes,
+		NewCollabHandler(hub, config.CollabConfig{}).RegisterRoutes,
+		NewAttachmentHandler(attachments).RegisterRoutes,
+		NewWebhookHandler(webhooks, users).RegisterRoutes,
+		NewAuthHandler(users).RegisterRoutes,
+	)
+
+	for _, route := range router.Routes() {
+		if spec.Operation(route.Method, route.Path) == nil {
+			t.Errorf("expected %s %s to be described by the spec", route.Method, route.Path)
+		}
+	}
+
+	srv := httptest.NewServer(router)
+	call := func(method, path, contentType, body string, header map[string]string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+"/api/v1"+path, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body = io.NopCloser(strings.NewReader(string(data)))
+		return resp
+	}
+	decode := func(resp *http.Response, v any) {
+		t.Helper()
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("failed to decode the response: %v", err)
+		}
+	}
+	const post = `{"title":"Spec","content":"# Hello","content_format":"markdown","author":"Ann","tags":["go"]}`
+
+	// posts
+	var created models.BlogPost
+	decode(call(http.MethodPost, "/posts", "application/json", post, nil), &created)
+	call(http.MethodPost, "/posts", "application/json", `{"title":"","extra":1}`, nil)
+	call(http.MethodPost, "/posts", "application/json", `{"title":"Draft","content":"draft","author":"Bob","status":"draft"}`, nil)
+	call(http.MethodGet, "/posts", "", "", nil)
+	call(http.MethodGet, "/posts?author=Ann&tag=go&status=published", "", "", nil)
+	call(http.MethodGet, "/posts?page_size=1", "", "", nil)
+	call(http.MethodGet, "/posts?page_size=0", "", "", nil)
+	call(http.MethodGet, "/posts?page_token=invalid", "", "", nil)
+	call(http.MethodGet, "/posts/"+created.ID, "", "", nil)
+	call(http.MethodGet, "/posts/"+created.ID+"?render=html", "", "", nil)
+	call(http.MethodGet, "/posts/"+created.ID, "", "", map[string]string{"Accept": "text/html"})
+	call(http.MethodGet, "/posts/"+created.ID, "", "", map[string]string{"If-None-Match": `"1"`})
+	call(http.MethodGet, "/posts/missing", "", "", nil)
+	call(http.MethodPut, "/posts/"+created.ID, "application/json", post, map[string]string{"If-Match": `"1"`})
+	call(http.MethodPut, "/posts/"+created.ID, "application/json", post, map[string]string{"If-Match": `"1"`})
+	call(http.MethodPut, "/posts/missing", "application/json", post, nil)
+	call(http.MethodPut, "/posts/"+created.ID, "application/json", `{"title":1}`, nil)
+
+	// authors
+	var author models.Author
+	decode(call(http.MethodPost, "/authors", "application/json", `{"name":"Carol","bio":"Writes about Go.","links":["https://github.com/carol"]}`, nil), &author)
+	call(http.MethodPost, "/authors", "application/json", `{"name":"Carol"}`, nil)
+	call(http.MethodPost, "/authors", "application/json", `{"name":"","avatar_url":"ftp://example.com"}`, nil)
+	call(http.MethodPost, "/posts", "application/json", `{"title":"By ID","content":"content","author_id":"`+author.ID+`"}`, nil)
+	call(http.MethodPost, "/posts", "application/json", `{"title":"Nobody","content":"content"}`, nil)
+	call(http.MethodPost, "/posts", "application/json", `{"title":"Unknown","content":"content","author_id":"missing"}`, nil)
+	call(http.MethodGet, "/authors", "", "", nil)
+	call(http.MethodGet, "/authors/"+author.ID, "", "", nil)
+	call(http.MethodGet, "/authors/missing", "", "", nil)
+	call(http.MethodGet, "/authors/"+author.ID+"/posts", "", "", nil)
+	call(http.MethodGet, "/authors/"+author.ID+"/posts?page_size=0", "", "", nil)
+	call(http.MethodGet, "/authors/missing/posts", "", "", nil)
+	call(http.MethodPut, "/authors/"+author.ID, "application/json", `{"name":"Carol Smith","avatar_url":"https://example.com/carol.png"}`, nil)
+	call(http.MethodPut, "/authors/"+author.ID, "application/json", `{"name":"Ann"}`, nil)
+	call(http.MethodPut, "/authors/"+author.ID, "application/json", `{"links":["not a url"]}`, nil)
+	call(http.MethodPut, "/authors/missing", "application/json", `{"name":"Dave"}`, nil)
+	call(http.MethodDelete, "/authors/"+author.ID, "", "", nil)
+	call(http.MethodDelete, "/authors/missing", "", "", nil)
+	call(http.MethodPost, "/authors/backfill", "", "", nil)
+
+	// transfers and batches
+	call(http.MethodGet, "/posts/export", "", "", nil)
+	call(http.MethodPost, "/posts/import", "application/x-ndjson", post+"\n{\"title\":\"\"}\nnot json\n", nil)
+	call(http.MethodPost, "/posts/import?on_conflict=replace", "application/x-ndjson", post, nil)
+	call(http.MethodPost, "/posts/batch", "application/json", `{"operations":[{"op":"create","post":`+post+`},{"op":"delete","id":"missing"}]}`, nil)
+	call(http.MethodPost, "/posts/batch", "application/json", `{"atomic":true,"operations":[{"op":"delete","id":"missing"}]}`, nil)
+	call(http.MethodPost, "/posts/batch", "application/json", `{"operations":[{"op":"rename"}]}`, nil)
+
+	// streams
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/posts/events?author=Ann", nil)
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	cancel()
+	conn, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")+"/api/v1/posts/"+created.ID+"/collab?name=Ann", nil)
+	if err != nil {
+		t.Fatalf("failed to join the editing session: %v", err)
+	}
+	conn.Close(websocket.StatusNormalClosure, "")
+	call(http.MethodGet, "/posts/missing/collab", "", "", map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "dGhlIHNhbXBsZSBub25jZQ=="})
+	call(http.MethodGet, "/posts/"+created.ID+"/collab", "", "", nil)
+
+	// attachments
+	var attachment models.Attachment
+	contentType, body := multipartFile(t, "file", "dot.png", pngImage(t))
+	decode(call(http.MethodPost, "/posts/"+created.ID+"/attachments", contentType, body, nil), &attachment)
+	contentType, body = multipartFile(t, "file", "notes.txt", []byte("plain text"))
+	call(http.MethodPost, "/posts/"+created.ID+"/attachments", contentType, body, nil)
+	contentType, body = multipartFile(t, "file", "large.png", append(pngImage(t), make([]byte, 1<<10)...))
+	call(http.MethodPost, "/posts/"+created.ID+"/attachments", contentType, body, nil)
+	call(http.MethodPost, "/posts/missing/attachments", contentType, body, nil)
+	call(http.MethodPost, "/posts/"+created.ID+"/attachments", "", "", nil)
+	call(http.MethodGet, "/posts/"+created.ID+"/attachments", "", "", nil)
+	call(http.MethodGet, "/posts/missing/attachments", "", "", nil)
+	call(http.MethodGet, "/posts/"+created.ID, "", "", nil)
+	call(http.MethodGet, attachmentPath(attachment), "", "", nil)
+	call(http.MethodGet, attachmentPath(attachment), "", "", map[string]string{"Range": "bytes=0-9"})
+	call(http.MethodGet, attachmentPath(attachment), "", "", map[string]string{"Range": "bytes=0-1,4-5"})
+	call(http.MethodGet, attachmentPath(attachment), "", "", map[string]string{"Range": "bytes=9999-"})
+	call(http.MethodGet, attachmentPath(attachment), "", "", map[string]string{"If-None-Match": `"` + attachment.SHA256 + `"`})
+	call(http.MethodGet, "/posts/"+created.ID+"/attachments/missing", "", "", nil)
+	call(http.MethodHead, attachmentPath(attachment), "", "", nil)
+	call(http.MethodHead, attachmentPath(attachment), "", "", map[string]string{"If-None-Match": `"` + attachment.SHA256 + `"`})
+	call(http.MethodHead, "/posts/"+created.ID+"/attachments/missing", "", "", nil)
+	call(http.MethodGet, attachmentPath(attachment)+"/metadata", "", "", nil)
+	call(http.MethodGet, attachmentPath(attachment)+"/variants/thumbnail", "", "", nil)
+	processImages(attachments)
+	call(http.MethodGet, attachmentPath(attachment)+"/metadata", "", "", nil)
+	call(http.MethodGet, "/posts/"+created.ID+"/attachments/missing/metadata", "", "", nil)
+	variant := attachmentPath(attachment) + "/variants/thumbnail"
+	resp := call(http.MethodGet, variant, "", "", nil)
+	call(http.MethodGet, variant, "", "", map[string]string{"Range": "bytes=0-9"})
+	call(http.MethodGet, variant, "", "", map[string]string{"Range": "bytes=9999-"})
+	call(http.MethodGet, variant, "", "", map[string]string{"If-None-Match": resp.Header.Get("ETag")})
+	call(http.MethodGet, attachmentPath(attachment)+"/variants/huge", "", "", nil)
+	call(http.MethodHead, variant, "", "", nil)
+	call(http.MethodHead, attachmentPath(attachment)+"/variants/huge", "", "", nil)
+	call(http.MethodDelete, attachmentPath(attachment), "", "", nil)
+	call(http.MethodDelete, attachmentPath(attachment), "", "", nil)
+
+	// webhooks
+	signedIn := map[string]string{"Authorization": "Bearer " + newTestAccessToken(t, users, mailer)}
+	call(http.MethodGet, "/webhooks", "", "", nil)
+	var webhook models.WebhookCreated
+	decode(call(http.MethodPost, "/webhooks", "application/json", `{"url":"https://hooks.example.com/blog","events":["post.updated","post.deleted"]}`, signedIn), &webhook)
+	call(http.MethodPost, "/webhooks", "application/json", `{"url":"ftp://example.com","events":["post.moved"]}`, signedIn)
+	call(http.MethodGet, "/webhooks", "", "", signedIn)
+	call(http.MethodGet, "/webhooks/"+webhook.ID, "", "", signedIn)
+	call(http.MethodGet, "/webhooks/missing", "", "", signedIn)
+	call(http.MethodPut, "/webhooks/"+webhook.ID, "application/json", `{"url":"https://hooks.example.com/blog","events":["post.updated","post.deleted"],"active":true}`, signedIn)
+	call(http.MethodPut, "/webhooks/missing", "application/json", `{"url":"https://hooks.example.com/blog","events":["post.updated"]}`, signedIn)
+	call(http.MethodPut, "/posts/"+created.ID, "application/json", post, nil)
+
+	deliveries, _ := webhooks.Deliveries(context.Background(), models.DeliveryFilter{WebhookID: webhook.ID})
+	if len(deliveries) == 0 {
+		t.Fatal("expected the update to be delivered to the webhook")
+	}
+	pending := deliveries[0].ID
+	call(http.MethodPost, "/webhooks/deliveries/"+pending+"/redeliver", "", "", signedIn)
+	webhooks.RecordAttempt(context.Background(), pending, models.WebhookAttempt{At: time.Now(), StatusCode: http.StatusOK, DurationMs: 5})
+	call(http.MethodGet, "/webhooks/"+webhook.ID+"/deliveries", "", "", signedIn)
+	call(http.MethodGet, "/webhooks/"+webhook.ID+"/deliveries?status=pending", "", "", signedIn)
+	call(http.MethodGet, "/webhooks/missing/deliveries", "", "", signedIn)
+	call(http.MethodGet, "/webhooks/deliveries", "", "", signedIn)
+	call(http.MethodGet, "/webhooks/deliveries?status=lost", "", "", signedIn)
+	call(http.MethodGet, "/webhooks/deliveries/"+pending, "", "", signedIn)
+	call(http.MethodGet, "/webhooks/deliveries/missing", "", "", signedIn)
+	call(http.MethodPost, "/webhooks/deliveries/"+pending+"/redeliver", "", "", signedIn)
+	call(http.MethodPost, "/webhooks/deliveries/missing/redeliver", "", "", signedIn)
+
+	// users
+	const account = `{"email":"dana@example.com","password":"correct horse"}`
+	call(http.MethodPost, "/auth/register", "application/json", `{"email":"dana@example.com","password":"correct horse","name":"Dana"}`, nil)
+	call(http.MethodPost, "/auth/register", "application/json", account, nil)
+	call(http.MethodPost, "/auth/register", "application/json", `{"email":"dana","password":"short"}`, nil)
+	call(http.MethodPost, "/auth/token", "application/json", account, nil)
+	call(http.MethodPost, "/auth/verify/resend", "application/json", `{"email":"dana@example.com"}`, nil)
+	call(http.MethodPost, "/auth/verify/resend", "application/json", `{}`, nil)
+	call(http.MethodPost, "/auth/verify", "application/json", `{"token":"`+mailer.lastToken(t)+`"}`, nil)
+	call(http.MethodPost, "/auth/verify", "application/json", `{"token":"unknown"}`, nil)
+	call(http.MethodPost, "/auth/token", "application/json", `{"email":"dana@example.com","password":"wrong horse"}`, nil)
+	call(http.MethodPost, "/auth/token", "application/json", `{"email":"dana@example.com"}`, nil)
+	var token models.AccessToken
+	decode(call(http.MethodPost, "/auth/token", "application/json", account, nil), &token)
+	call(http.MethodGet, "/auth/me", "", "", map[string]string{"Authorization": "Bearer " + token.AccessToken})
+	call(http.MethodGet, "/auth/me", "", "", nil)
+	call(http.MethodPost, "/auth/password/forgot", "application/json", `{"email":"dana@example.com"}`, nil)
+	call(http.MethodPost, "/auth/password/forgot", "application/json", `{"mail":"dana@example.com"}`, nil)
+	call(http.MethodPost, "/auth/password/reset", "application/json", `{"token":"`+mailer.lastToken(t)+`","password":"battery staple"}`, nil)
+	call(http.MethodPost, "/auth/password/reset", "application/json", `{"token":"unknown","password":"battery staple"}`, nil)
+
+	// deletions
+	call(http.MethodDelete, "/webhooks/"+webhook.ID, "", "", signedIn)
+	call(http.MethodDelete, "/webhooks/"+webhook.ID, "", "", signedIn)
+	call(http.MethodDelete, "/posts/"+created.ID, "", "", nil)
+	call(http.MethodDelete, "/posts/"+created.ID, "", "", nil)
+	unused, _ := authors.Create(context.Background(), &models.Author{Name: "Unused"})
+	call(http.MethodDelete, "/authors/"+unused.ID, "", "", nil)
+
+	// responses are validated once the handlers return
+	streams.Close()
+	hub.Close(context.Background())
+	srv.Close()
+
+	for _, op := range spec.Operations() {
+		if !recorder.exercised[op.String()] {
+			t.Errorf("expected %s to be exercised", op)
+		}
+	}
+	for _, mismatch := range recorder.mismatches {
+		t.Error(mismatch)
+	}
+}
+
+func TestOpenAPIHandler_Document(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("failed to load the spec: %v", err)
+	}
+	router := newTestRouter(t, "", NewOpenAPIHandler(spec).RegisterRoutes)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var doc struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("expected a JSON document, got %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("expected OpenAPI 3.1.0, got %q", doc.OpenAPI)
+	}
+	if doc.Paths["/posts/{id}"] == nil {
+		t.Errorf("expected the /posts/{id} path, got %v", doc.Paths)
+	}
+}
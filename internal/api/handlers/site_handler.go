@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/theme"
+	"bytes"
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const htmlContentType = "text/html; charset=utf-8"
+
+// SiteHandler serves the public HTML pages of the blog: the index of the
+// published posts, a page per post and a page per tag. Pages have the same
+// URLs as the static site written by cmd/export-static.
+type SiteHandler struct {
+	service       *services.BlogPostService
+	theme         *theme.Theme
+	site          config.SiteConfig
+	excerptLength int
+}
+
+func NewSiteHandler(s *services.BlogPostService, t *theme.Theme, site config.SiteConfig, excerptLength int) *SiteHandler {
+	if site.PostsPerPage <= 0 {
+		site.PostsPerPage = 10
+	}
+	return &SiteHandler{service: s, theme: t, site: site, excerptLength: excerptLength}
+}
+
+func (h *SiteHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/", h.Index)
+	r.GET("/page/:n", h.Index)
+	r.GET("/p/:slug", h.Post)
+	r.GET("/tags/:tag", h.Tag)
+	r.GET("/tags/:tag/page/:n", h.Tag)
+	r.GET("/assets/*file", h.Asset)
+}
+
+// Index serves a page of the latest published posts
+func (h *SiteHandler) Index(c *gin.Context) {
+	posts, tags, ok := h.collect(c)
+	if !ok {
+		return
+	}
+	page := theme.NewPage(h.site, h.site.Title, "/")
+	page.Tags = tags
+	h.list(c, "index", page, posts)
+}
+
+// Post serves the page of a published post. A post ID redirects to the
+// page of the post, so that links to posts without a slug keep working.
+func (h *SiteHandler) Post(c *gin.Context) {
+	posts, _, ok := h.collect(c)
+	if !ok {
+		return
+	}
+
+	target := "/p/" + c.Param("slug")
+	for _, p := range posts {
+		if p.URL == target {
+			page := theme.NewPage(h.site, p.Title+" - "+h.site.Title, p.URL)
+			page.Post = p
+			page.Type = "article"
+			if err := theme.Render(h.service, []*theme.Post{p}, h.excerptLength); err != nil {
+				c.Error(apperrors.Wrap(err, "failed to render the post"))
+				return
+			}
+			page.Description = p.Excerpt
+			h.render(c, "post", page)
+			return
+		}
+	}
+	for _, p := range posts {
+		if p.ID == c.Param("slug") {
+			c.Redirect(http.StatusMovedPermanently, p.URL)
+			return
+		}
+	}
+	c.Error(apperrors.NotFound("post not found"))
+}
+
+// Tag serves a page of the published posts of a tag
+func (h *SiteHandler) Tag(c *gin.Context) {
+	_, tags, ok := h.collect(c)
+	if !ok {
+		return
+	}
+
+	target := "/tags/" + theme.EscapeSegment(c.Param("tag"))
+	for _, tag := range tags {
+		if tag.URL != target {
+			continue
+		}
+		page := theme.NewPage(h.site, "#"+tag.Name+" - "+h.site.Title, tag.URL)
+		query := "?tag=" + url.QueryEscape(tag.Name)
+		page.Feeds = theme.Feeds{RSS: "/feed.rss" + query, Atom: "/feed.atom" + query, JSON: "/feed.json" + query}
+		page.Tag = tag
+		page.Tags = tags
+		h.list(c, "tag", page, tag.Posts)
+		return
+	}
+	c.Error(apperrors.NotFound("tag not found"))
+}
+
+// Asset serves a file of the theme
+func (h *SiteHandler) Asset(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("file"), "/")
+	data, err := h.theme.Asset(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		c.Error(apperrors.NotFound("asset not found"))
+		return
+	}
+	if err != nil {
+		c.Error(apperrors.Internal("failed to read the asset", err))
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Cache-Control", "public, max-age=3600")
+	writeConditional(c, http.StatusOK, contentType, data, time.Time{})
+}
+
+// collect returns the published posts and their tags, or reports the error
+func (h *SiteHandler) collect(c *gin.Context) ([]*theme.Post, []*theme.Tag, bool) {
+	published, err := h.service.GetLatest(c.Request.Context(), models.PostFilter{Status: models.StatusPublished}, 0)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to list the posts"))
+		return nil, nil, false
+	}
+	posts, tags := theme.Collect(published)
+	return posts, tags, true
+}
+
+// list renders the page of posts selected by the n parameter, the first
+// page when there is none
+func (h *SiteHandler) list(c *gin.Context, name string, page *theme.Page, posts []*theme.Post) {
+	n := 1
+	if param := c.Param("n"); param != "" {
+		var err error
+		if n, err = strconv.Atoi(param); err != nil || n < 2 {
+			// the first page has no number
+			c.Error(apperrors.NotFound("page not found"))
+			return
+		}
+	}
+
+	base := page.Path
+	list, pagination, ok := theme.Paginate(base, posts, h.site.PostsPerPage, n)
+	if !ok {
+		c.Error(apperrors.NotFound("page not found"))
+		return
+	}
+	if err := theme.Render(h.service, list, h.excerptLength); err != nil {
+		c.Error(apperrors.Wrap(err, "failed to render the posts"))
+		return
+	}
+
+	page.Path = theme.PagePath(base, n)
+	page.Canonical = h.site.URL + page.Path
+	page.Posts = list
+	page.Pagination = pagination
+	if n > 1 {
+		page.Title += " - page " + strconv.Itoa(n)
+	}
+	h.render(c, name, page)
+}
+
+func (h *SiteHandler) render(c *gin.Context, name string, page *theme.Page) {
+	var buf bytes.Buffer
+	if err := h.theme.Render(&buf, name, page); err != nil {
+		c.Error(apperrors.Internal("failed to render the page", err))
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=60")
+	writeConditional(c, http.StatusOK, htmlContentType, buf.Bytes(), time.Time{})
+}
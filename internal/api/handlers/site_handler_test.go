@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/theme"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestSiteRouter(t *testing.T, themeDir string) *gin.Engine {
+	t.Helper()
+	service := newTestPostService(t, testPosts()...)
+	th, err := theme.LoadDir(themeDir)
+	if err != nil {
+		t.Fatalf("failed to load the theme: %v", err)
+	}
+	site := config.SiteConfig{URL: "https://blog.example.com", Title: "Blog", Description: "Latest posts", PostsPerPage: 2}
+	return newTestRouter(t, "", NewSiteHandler(service, th, site, 100).RegisterRoutes)
+}
+
+func TestSiteHandler_Index(t *testing.T) {
+	router := newTestSiteRouter(t, "")
+
+	w := get(router, "/")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("expected html, got %s", ct)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `href="/p/go-channels"`) || !strings.Contains(body, "Rust &lt;basics&gt;") {
+		t.Errorf("expected the latest posts, escaped, got %s", body)
+	}
+	if strings.Contains(body, "go-basics") || strings.Contains(body, "go-generics") {
+		t.Errorf("expected older posts on the next page and drafts left out, got %s", body)
+	}
+	if !strings.Contains(body, `rel="next" href="/page/2"`) || !strings.Contains(body, `<link rel="canonical" href="https://blog.example.com/">`) {
+		t.Errorf("expected pagination and canonical links, got %s", body)
+	}
+
+	page := get(router, "/page/2").Body.String()
+	if !strings.Contains(page, `href="/p/go-basics"`) || !strings.Contains(page, `rel="prev" href="/"`) {
+		t.Errorf("unexpected second page %s", page)
+	}
+	for _, target := range []string{"/page/1", "/page/3", "/page/two"} {
+		if code := get(router, target).Code; code != http.StatusNotFound {
+			t.Errorf("expected status %d for %s, got %d", http.StatusNotFound, target, code)
+		}
+	}
+}
+
+func TestSiteHandler_Post(t *testing.T) {
+	router := newTestSiteRouter(t, "")
+
+	w := get(router, "/p/go-basics")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	body := w.Body.String()
+	for _, expected := range []string{
+		"<strong>simple</strong>",
+		`<link rel="canonical" href="https://blog.example.com/p/go-basics">`,
+		`<meta property="og:type" content="article">`,
+		`<meta property="og:title" content="Go basics">`,
+		`<meta property="og:description" content="Go is simple`,
+		`<meta name="twitter:card" content="summary">`,
+		`<a class="tag" href="/tags/go">#go</a>`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %s in the page, got %s", expected, body)
+		}
+	}
+	if w.Header().Get("ETag") == "" {
+		t.Error("expected an ETag")
+	}
+
+	// an ID redirects to the post page
+	if w := get(router, "/p/1"); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/p/go-basics" {
+		t.Errorf("expected a redirect to the post page, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	for _, target := range []string{"/p/go-generics", "/p/4", "/p/unknown"} {
+		if code := get(router, target).Code; code != http.StatusNotFound {
+			t.Errorf("expected status %d for %s, got %d", http.StatusNotFound, target, code)
+		}
+	}
+}
+
+func TestSiteHandler_Tag(t *testing.T) {
+	router := newTestSiteRouter(t, "")
+
+	w := get(router, "/tags/go")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `href="/p/go-channels"`) || !strings.Contains(body, `href="/p/go-basics"`) || strings.Contains(body, `href="/p/rust-basics"`) {
+		t.Errorf("expected the posts of the tag, got %s", body)
+	}
+	if !strings.Contains(body, `href="/feed.atom?tag=go"`) {
+		t.Errorf("expected the tag feed, got %s", body)
+	}
+	if code := get(router, "/tags/java").Code; code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, code)
+	}
+}
+
+func TestSiteHandler_ThemeOverrides(t *testing.T) {
+	dir := t.TempDir()
+	post := `{{define "content"}}<h1 class="custom">{{.Post.Title}}</h1>{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "post.html"), []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "style.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	router := newTestSiteRouter(t, dir)
+
+	if body := get(router, "/p/go-basics").Body.String(); !strings.Contains(body, `<h1 class="custom">Go basics</h1>`) || !strings.Contains(body, "<title>Go basics - Blog</title>") {
+		t.Errorf("expected the overridden post page in the built-in layout, got %s", body)
+	}
+	if w := get(router, "/assets/style.css"); w.Body.String() != "body{}" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/css") {
+		t.Errorf("expected the overridden stylesheet, got %s %s", w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if w := get(router, "/assets/chroma.css"); w.Code != http.StatusOK {
+		t.Errorf("expected the highlighting stylesheet, got %d", w.Code)
+	}
+	for _, target := range []string{"/assets/post.html", "/assets/../layout.html", "/assets/missing.css"} {
+		if code := get(router, target).Code; code != http.StatusNotFound {
+			t.Errorf("expected status %d for %s, got %d", http.StatusNotFound, target, code)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/sitemap"
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SitemapHandler struct {
+	service *services.BlogPostService
+	site    config.SiteConfig
+	index   *sitemap.Index
+	// mu orders the changes of the index, which are read from the service
+	// while holding it
+	mu sync.Mutex
+}
+
+// NewSitemapHandler returns a handler whose sitemap follows the changes
+// made through the service. Call Load once to add the existing posts.
+func NewSitemapHandler(s *services.BlogPostService, site config.SiteConfig, perFile int) *SitemapHandler {
+	h := &SitemapHandler{service: s, site: site, index: sitemap.NewIndex(perFile)}
+	s.Subscribe(h.apply)
+	return h
+}
+
+func (h *SitemapHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/sitemap.xml", h.Sitemap)
+	r.GET("/sitemaps/:file", h.SitemapFile)
+	r.GET("/robots.txt", h.Robots)
+}
+
+// Load adds every published post to the sitemap. It is the only time the
+// sitemap walks the repository, later changes are applied one by one.
+func (h *SitemapHandler) Load(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	posts, err := h.service.GetLatest(ctx, models.PostFilter{Status: models.StatusPublished}, 0)
+	if err != nil {
+		return err
+	}
+	for _, p := range posts {
+		h.index.Set(p.ID, h.entry(p))
+	}
+	return nil
+}
+
+// apply updates the entry of the post of an event. Concurrent changes may
+// be notified out of order, so the event only tells which post changed:
+// its current state is read again, and the last event applied always
+// leaves the entry of the latest version, or no entry once it is deleted.
+func (h *SitemapHandler) apply(e services.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	post, err := h.service.GetById(context.Background(), e.PostID)
+	if err != nil && !apperrors.Is(err, apperrors.KindNotFound) {
+		log.Printf("sitemap: failed to read post %s: %v", e.PostID, err)
+		return
+	}
+	if post == nil || !post.IsPublished() {
+		// deleted or unpublished
+		h.index.Remove(e.PostID)
+		return
+	}
+	h.index.Set(e.PostID, h.entry(post))
+}
+
+func (h *SitemapHandler) entry(p *models.BlogPost) sitemap.Entry {
+	return sitemap.Entry{Loc: postURL(h.site, p), LastMod: p.UpdatedAt}
+}
+
+// Sitemap serves the sitemap of the published posts, or a sitemap index
+// once they do not fit in a single file
+func (h *SitemapHandler) Sitemap(c *gin.Context) {
+	body, lastMod := h.index.Root(h.fileURL)
+	c.Header("Cache-Control", "public, max-age=300")
+	writeConditional(c, http.StatusOK, sitemap.ContentType, body, lastMod)
+}
+
+// SitemapFile serves a file listed in the sitemap index, e.g. /sitemaps/2.xml
+func (h *SitemapHandler) SitemapFile(c *gin.Context) {
+	name, isXML := strings.CutSuffix(c.Param("file"), ".xml")
+	n, err := strconv.Atoi(name)
+	if !isXML || err != nil {
+		c.Error(apperrors.NotFound("sitemap not found"))
+		return
+	}
+
+	body, lastMod, ok := h.index.File(n - 1)
+	if !ok {
+		c.Error(apperrors.NotFound("sitemap not found"))
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	writeConditional(c, http.StatusOK, sitemap.ContentType, body, lastMod)
+}
+
+// fileURL returns the public URL of the sitemap file n, counted from 0
+func (h *SitemapHandler) fileURL(n int) string {
+	return h.site.URL + "/sitemaps/" + strconv.Itoa(n+1) + ".xml"
+}
+
+// Robots serves robots.txt, pointing crawlers to the sitemap and keeping
+// them on the HTML pages rather than the API
+func (h *SitemapHandler) Robots(c *gin.Context) {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	b.WriteString("Disallow: /api/\n")
+	b.WriteString("\n")
+	b.WriteString("Sitemap: " + h.site.URL + "/sitemap.xml\n")
+
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(b.String()))
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestSitemapRouter(t *testing.T, perFile int) (*gin.Engine, *services.BlogPostService) {
+	t.Helper()
+	// created before the handler, picked up by Load
+	service := newTestPostService(t, testPosts()[0])
+	site := config.SiteConfig{URL: "https://blog.example.com"}
+	h := NewSitemapHandler(service, site, perFile)
+	if err := h.Load(context.Background()); err != nil {
+		t.Fatalf("failed to load the sitemap: %v", err)
+	}
+	return newTestRouter(t, "", h.RegisterRoutes), service
+}
+
+func get(router http.Handler, target string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", target, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSitemapHandler_FollowsChanges(t *testing.T) {
+	router, service := newTestSitemapRouter(t, 0)
+	ctx := context.Background()
+
+	draft := &models.BlogPost{ID: "2", Title: "Draft", Content: "Not yet.", Author: "Jane Smith", Status: models.StatusDraft}
+	if _, err := service.Create(ctx, draft); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	second := &models.BlogPost{ID: "3", Title: "Go channels", Content: "Channels.", Author: "Jane Smith"}
+	if _, err := service.Create(ctx, second); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	if err := service.Delete(ctx, "1"); err != nil {
+		t.Fatalf("failed to delete post: %v", err)
+	}
+
+	w := get(router, "/sitemap.xml")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/xml") {
+		t.Errorf("expected xml content type, got %s", ct)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "<loc>https://blog.example.com/p/go-channels</loc>") {
+		t.Errorf("expected the new post in the sitemap, got %s", body)
+	}
+	if strings.Contains(body, "/p/go-basics<") || strings.Contains(body, "/p/draft<") {
+		t.Errorf("expected deleted and draft posts to be left out, got %s", body)
+	}
+	if w.Header().Get("ETag") == "" || w.Header().Get("Last-Modified") == "" {
+		t.Error("expected conditional GET validators")
+	}
+}
+
+func TestSitemapHandler_Unpublish(t *testing.T) {
+	router, service := newTestSitemapRouter(t, 0)
+
+	updated := &models.BlogPost{Title: "Go basics", Content: "Go is simple.", Author: "John Doe", Status: models.StatusDraft}
+	if _, err := service.Update(context.Background(), "1", updated); err != nil {
+		t.Fatalf("failed to update post: %v", err)
+	}
+
+	if body := get(router, "/sitemap.xml").Body.String(); strings.Contains(body, "<url>") {
+		t.Errorf("expected an unpublished post to leave the sitemap, got %s", body)
+	}
+}
+
+func TestSitemapHandler_Index(t *testing.T) {
+	router, service := newTestSitemapRouter(t, 1)
+	second := &models.BlogPost{ID: "2", Title: "Go channels", Content: "Channels.", Author: "Jane Smith"}
+	if _, err := service.Create(context.Background(), second); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	body := get(router, "/sitemap.xml").Body.String()
+	if !strings.Contains(body, "<sitemapindex") || !strings.Contains(body, "<loc>https://blog.example.com/sitemaps/2.xml</loc>") {
+		t.Errorf("expected a sitemap index, got %s", body)
+	}
+
+	w := get(router, "/sitemaps/2.xml")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/p/go-channels</loc>") {
+		t.Errorf("expected the second file, got %d %s", w.Code, w.Body.String())
+	}
+
+	for _, target := range []string{"/sitemaps/3.xml", "/sitemaps/0.xml", "/sitemaps/one.xml", "/sitemaps/1.txt"} {
+		if rec := get(router, target); rec.Code != http.StatusNotFound {
+			t.Errorf("expected status %d for %s, got %d", http.StatusNotFound, target, rec.Code)
+		}
+	}
+}
+
+func TestSitemapHandler_Robots(t *testing.T) {
+	router, _ := newTestSitemapRouter(t, 0)
+
+	w := get(router, "/robots.txt")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Disallow: /api/\n") {
+		t.Errorf("expected robots.txt to keep crawlers off the API, got %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Sitemap: https://blog.example.com/sitemap.xml") {
+		t.Errorf("expected robots.txt to point to the sitemap, got %s", w.Body.String())
+	}
+}
+
+func TestSitemapHandler_OutOfOrderEvents(t *testing.T) {
+	ctx := context.Background()
+	service := newTestPostService(t)
+	h := NewSitemapHandler(service, config.SiteConfig{URL: "https://blog.example.com"}, 0)
+	router := newTestRouter(t, "", h.RegisterRoutes)
+
+	first, err := service.Create(ctx, &models.BlogPost{ID: "1", Title: "Go basics", Content: "Go is simple.", Author: "John Doe"})
+	if err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	stale := *first
+	if _, err := service.Update(ctx, "1", &models.BlogPost{Title: "Go basics", Slug: "go-fundamentals", Content: "Go is simple.", Author: "John Doe"}); err != nil {
+		t.Fatalf("failed to update post: %v", err)
+	}
+	gone, err := service.Create(ctx, &models.BlogPost{ID: "2", Title: "Go channels", Content: "Channels.", Author: "Jane Smith"})
+	if err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	if err := service.Delete(ctx, "2"); err != nil {
+		t.Fatalf("failed to delete post: %v", err)
+	}
+
+	// the events of the earlier versions are notified last
+	h.apply(services.Event{Type: services.EventPostCreated, PostID: "1", Post: &stale})
+	h.apply(services.Event{Type: services.EventPostCreated, PostID: "2", Post: gone})
+
+	body := get(router, "/sitemap.xml").Body.String()
+	if !strings.Contains(body, "/p/go-fundamentals<") || strings.Contains(body, "/p/go-basics<") {
+		t.Errorf("expected the latest version of the updated post, got %s", body)
+	}
+	if strings.Contains(body, "/p/go-channels<") {
+		t.Errorf("expected the deleted post to stay out, got %s", body)
+	}
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/api/validation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxWebhookBodyBytes bounds the size of a webhook request body
+const MaxWebhookBodyBytes = 16 << 10
+
+type WebhookHandler struct {
+	service *services.WebhookService
+	auth    middleware.Authenticator
+}
+
+func NewWebhookHandler(s *services.WebhookService, auth middleware.Authenticator) *WebhookHandler {
+	return &WebhookHandler{s, auth}
+}
+
+// RegisterRoutes registers the webhook routes, which are only served to
+// signed-in users: webhooks send the posts to any URL and hold the secrets
+// signing them
+func (h *WebhookHandler) RegisterRoutes(r *gin.RouterGroup) {
+	webhooks := r.Group("/webhooks", middleware.Authenticate(h.auth), middleware.RequireUser())
+	webhooks.GET("", h.GetAllWebhooks)
+	webhooks.POST("", h.CreateWebhook)
+	webhooks.GET("/deliveries", h.GetDeliveries)
+	webhooks.GET("/deliveries/:id", h.GetDelivery)
+	webhooks.POST("/deliveries/:id/redeliver", h.Redeliver)
+	webhooks.GET("/:id", h.GetWebhook)
+	webhooks.PUT("/:id", h.UpdateWebhook)
+	webhooks.DELETE("/:id", h.DeleteWebhook)
+	webhooks.GET("/:id/deliveries", h.GetWebhookDeliveries)
+}
+
+// @Summary Get all webhooks
+// @Description Retrieves every webhook subscription, oldest first. Secrets are never returned.
+// @Tags Webhooks
+// @Produce json,application/problem+json
+// @Security BearerAuth
+// @Success 200 {array} models.Webhook "List of webhooks"
+// @Failure 401 {object} models.Problem "Missing, invalid or expired access token"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetAllWebhooks(c *gin.Context) {
+	webhooks, err := h.service.GetAll(c.Request.Context())
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve all webhooks"))
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// @Summary Create a webhook
+// @Description Subscribes an endpoint to post events: post.created, post.updated, post.deleted and post.published.
+// @Description Deliveries are signed with the secret, generated unless set, which is only returned in this response.
+// @Tags Webhooks
+// @Accept json
+// @Produce json,application/problem+json
+// @Param webhook body models.WebhookCreate true "Webhook data"
+// @Security BearerAuth
+// @Success 201 {object} models.WebhookCreated "Created webhook along with its secret"
+// @Failure 400 {object} models.Problem "Invalid request body, every invalid field is listed in invalid-params"
+// @Failure 401 {object} models.Problem "Missing, invalid or expired access token"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var body models.WebhookCreate
+	if !decodeWebhookBody(c, &body) {
+		return
+	}
+
+	webhook := body.ToWebhook()
+	created, err := h.service.Create(c.Request.Context(), &webhook)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to create a new webhook"))
+		return
+	}
+	c.JSON(http.StatusCreated, models.WebhookCreated{Webhook: *created, Secret: created.Secret})
+}
+
+// @Summary Get a webhook by ID
+// @Tags Webhooks
+// @Produce json,application/problem+json
+// @Param id path string true "Webhook ID"
+// @Security BearerAuth
+// @Success 200 {object} models.Webhook "Webhook details"
+// @Failure 401 {object} models.Problem "Missing, invalid or expired access token"
+// @Failure 404 {object} models.Problem "Webhook not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	webhook, err := h.service.GetById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve a webhook with a given id"))
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// @Summary Update a webhook
+// @Description Replaces the settings of a webhook. The secret is rotated when set and kept otherwise.
+// @Tags Webhooks
+// @Accept json
+// @Produce json,application/problem+json
+// @Param id path string true "Webhook ID"
+// @Param webhook body models.WebhookUpdate true "Updated webhook data"
+// @Security BearerAuth
+// @Success 200 {object} models.Webhook "Updated webhook"
+// @Failure 400 {object} models.Problem "Invalid request body, every invalid field is listed in invalid-params"
+// @Failure 401 {object} models.Problem "Missing, invalid or expired access token"
+// @Failure 404 {object} models.Problem "Webhook not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var body models.WebhookUpdate
+	if !decodeWebhookBody(c, &body) {
+		return
+	}
+
+	webhook := body.ToWebhook()
+	updated, err := h.service.Update(c.Request.Context(), c.Param("id"), &webhook)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to update a webhook with a given id"))
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// @Summary Delete a webhook
+// @Description Deletes a webhook along with its deliveries
+// @Tags Webhooks
+// @Produce json,application/problem+json
+// @Param id path string true "Webhook ID"
+// @Security BearerAuth
+// @Success 204 "Webhook deleted successfully (no content)"
+// @Failure 401 {object} models.Problem "Missing, invalid or expired access token"
+// @Failure 404 {object} models.Problem "Webhook not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.Error(apperrors.Wrap(err, "failed to delete a webhook with a given id"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get the delivery log of a webhook
+// @Description Retrieves the deliveries of a webhook with their attempts, latest first
+// @Tags Webhooks
+// @Produce json,application/problem+json
+// @Param id path string true "Webhook ID"
+// @Param status query string false "Delivery status" Enums(pending, succeeded, dead)
+// @Security BearerAuth
+// @Success 200 {array} models.WebhookDelivery "Deliveries of the webhook"
+// @Failure 400 {object} models.Problem "Invalid status"
+// @Failure 401 {object} models.Problem "Missing, invalid or expired access token"
+// @Failure 404 {object} models.Problem "Webhook not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	h.deliveries(c, c.Param("id"))
+}
+
+// @Summary Get the deliveries of every webhook
+// @Description Retrieves the deliveries of every webhook with their attempts, latest first.
+// @Description status=dead lists the dead letters: deliveries that exhausted their attempts.
+// @Tags Webhooks
+// @Produce json,application/problem+json
+// @Param status query string false "Delivery status" Enums(pending, succeeded, dead)
+// @Security BearerAuth
+// @Success 200 {array} models.WebhookDelivery "Deliveries"
+// @Failure 400 {object} models.Problem "Invalid status"
+// @Failure 401 {object} models.Problem "Missing, invalid or expired access token"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /webhooks/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	h.deliveries(c, "")
+}
+
+func (h *WebhookHandler) deliveries(c *gin.Context, webhookID string) {
+	filter := models.DeliveryFilter{WebhookID: webhookID, Status: c.Query("status")}
+	switch filter.Status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryDead:
+	default:
+		c.Error(apperrors.Validation("invalid query parameters", apperrors.FieldError{
+			Field:  "status",
+			Reason: "must be one of: pending, succeeded, dead",
+		}))
+		return
+	}
+
+	deliveries, err := h.service.Deliveries(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve the webhook deliveries"))
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*models.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// @Summary Get a webhook delivery by ID
+// @Tags Webhooks
+// @Produce json,application/problem+json
+// @Param id path string true "Delivery ID"
+// @Security BearerAuth
+// @Success 200 {object} models.WebhookDelivery "Delivery with its attempts"
+// @Failure 401 {object} models.Problem "Missing, invalid or expired access token"
+// @Failure 404 {object} models.Problem "Delivery not found"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /webhooks/deliveries/{id} [get]
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	delivery, err := h.service.GetDelivery(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to retrieve a webhook delivery with a given id"))
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+// @Summary Redeliver a webhook delivery
+// @Description Queues a succeeded or dead delivery again with a fresh set of attempts
+// @Tags Webhooks
+// @Produce json,application/problem+json
+// @Param id path string true "Delivery ID"
+// @Security BearerAuth
+// @Success 202 {object} models.WebhookDelivery "Queued delivery"
+// @Failure 401 {object} models.Problem "Missing, invalid or expired access token"
+// @Failure 404 {object} models.Problem "Delivery not found"
+// @Failure 409 {object} models.Problem "Delivery still pending"
+// @Failure 500 {object} models.Problem "Internal server error"
+// @Router /webhooks/deliveries/{id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	delivery, err := h.service.Redeliver(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.Wrap(err, "failed to redeliver a webhook delivery"))
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// decodeWebhookBody decodes and validates a webhook request body into
+// dst, or reports the error
+func decodeWebhookBody(c *gin.Context, dst any) bool {
+	data, err := middleware.ReadBody(c, MaxWebhookBodyBytes)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+	fields, err := validation.DecodeJSON(data, dst)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+	if len(fields) > 0 {
+		c.Error(apperrors.Validation("webhook has invalid fields", fields...))
+		return false
+	}
+	return true
+}
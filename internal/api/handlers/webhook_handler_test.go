@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestWebhookRouter returns the webhook routes, signed in as a user
+func newTestWebhookRouter(t *testing.T) (http.Handler, *services.BlogPostService) {
+	t.Helper()
+	router, posts, token := newTestWebhookRoutes(t)
+	return withAccessToken(router, token), posts
+}
+
+// newTestWebhookRoutes returns the webhook routes along with an access
+// token to call them
+func newTestWebhookRoutes(t *testing.T) (*gin.Engine, *services.BlogPostService, string) {
+	t.Helper()
+	posts := newTestPostService(t)
+	webhooks := services.NewWebhookService(services.NewInMemoryWebhookRepo(), services.DefaultRetryPolicy)
+	posts.Subscribe(func(e services.Event) { webhooks.Enqueue(context.Background(), e) })
+	users, mailer := newTestUserService()
+	token := newTestAccessToken(t, users, mailer)
+	return newTestRouter(t, "/api/v1", NewWebhookHandler(webhooks, users).RegisterRoutes), posts, token
+}
+
+func TestWebhookHandler_RequiresUser(t *testing.T) {
+	router, _, token := newTestWebhookRoutes(t)
+
+	for name, handler := range map[string]http.Handler{
+		"anonymous":     router,
+		"invalid token": withAccessToken(router, token+"x"),
+	} {
+		if w := get(handler, "/api/v1/webhooks"); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: expected status %d with a challenge, got %d", name, http.StatusUnauthorized, w.Code)
+		}
+		if w := postJSON(handler, "/api/v1/webhooks", `{"url":"https://hooks.example.com/blog","events":["post.published"]}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status %d, got %d", name, http.StatusUnauthorized, w.Code)
+		}
+		if w := postJSON(handler, "/api/v1/webhooks/deliveries/1/redeliver", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status %d, got %d", name, http.StatusUnauthorized, w.Code)
+		}
+	}
+	if w := get(withAccessToken(router, token), "/api/v1/webhooks"); w.Code != http.StatusOK {
+		t.Errorf("expected status %d once signed in, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestWebhookHandler_CreateReturnsSecretOnce(t *testing.T) {
+	router, _ := newTestWebhookRouter(t)
+
+	w := postJSON(router, "/api/v1/webhooks", `{"url":"https://hooks.example.com/blog","events":["post.published"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.WebhookCreated
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if created.Secret == "" || !created.Active {
+		t.Errorf("expected an active webhook with its secret, got %+v", created)
+	}
+
+	w = get(router, "/api/v1/webhooks/"+created.ID)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), created.Secret) {
+		t.Errorf("expected the webhook without its secret, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWebhookHandler_InvalidBody(t *testing.T) {
+	router, _ := newTestWebhookRouter(t)
+
+	w := postJSON(router, "/api/v1/webhooks", `{"url":"not a url","events":["post.archived"],"extra":1}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var problem models.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	var names []string
+	for _, p := range problem.InvalidParams {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "extra,url,events" {
+		t.Errorf("expected extra, url and events to be reported, got %v", names)
+	}
+}
+
+func TestWebhookHandler_Deliveries(t *testing.T) {
+	router, posts := newTestWebhookRouter(t)
+	w := postJSON(router, "/api/v1/webhooks", `{"url":"https://hooks.example.com/blog","events":["post.created"]}`)
+	var created models.WebhookCreated
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	posts.Create(t.Context(), &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+
+	w = get(router, "/api/v1/webhooks/"+created.ID+"/deliveries?status=pending")
+	var deliveries []models.WebhookDelivery
+	if err := json.Unmarshal(w.Body.Bytes(), &deliveries); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if w.Code != http.StatusOK || len(deliveries) != 1 || deliveries[0].Event != "post.created" {
+		t.Fatalf("expected a pending delivery, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w = get(router, "/api/v1/webhooks/deliveries?status=dead"); w.Code != http.StatusOK || w.Body.String() != "[]" {
+		t.Errorf("expected an empty dead-letter list, got %d: %s", w.Code, w.Body.String())
+	}
+	if w = get(router, "/api/v1/webhooks/deliveries?status=failed"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown status, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w = postJSON(router, "/api/v1/webhooks/deliveries/"+deliveries[0].ID+"/redeliver", ""); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d to redeliver a pending delivery, got %d", http.StatusConflict, w.Code)
+	}
+	if w = get(router, "/api/v1/webhooks/missing/deliveries"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a missing webhook, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionCookie is the cookie holding the session token of the HTML pages
+const SessionCookie = "session"
+
+const userKey = "user"
+
+// Authenticator resolves the user of the credentials of a request, see
+// services.UserService
+type Authenticator interface {
+	// Authenticate returns the user of an access token
+	Authenticate(ctx context.Context, accessToken string) (*models.User, error)
+	// Session returns the user of a session token
+	Session(ctx context.Context, token string) (*models.User, error)
+}
+
+// Authenticate sets the user of a request, see CurrentUser, from its
+// bearer access token or, on GET and HEAD requests, its session cookie.
+// Browsers send cookies along with cross-site requests, so a cookie only
+// authenticates requests changing nothing. Requests without credentials go
+// on anonymously, those with an invalid access token are rejected.
+func Authenticate(a Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if header := c.GetHeader("Authorization"); header != "" {
+			var user *models.User
+			var err error = apperrors.Unauthorized("only bearer access tokens are accepted")
+			if scheme, token, _ := strings.Cut(header, " "); strings.EqualFold(scheme, "Bearer") {
+				user, err = a.Authenticate(c.Request.Context(), strings.TrimSpace(token))
+			}
+			if err != nil {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			c.Set(userKey, user)
+			c.Next()
+			return
+		}
+
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			if token, err := c.Cookie(SessionCookie); err == nil && token != "" {
+				if user, err := a.Session(c.Request.Context(), token); err == nil {
+					c.Set(userKey, user)
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireUser rejects the requests Authenticate found no user for
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CurrentUser(c) == nil {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.Error(apperrors.Unauthorized("authentication required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentUser returns the user set by Authenticate, nil for anonymous
+// requests
+func CurrentUser(c *gin.Context) *models.User {
+	if user, ok := c.Get(userKey); ok {
+		return user.(*models.User)
+	}
+	return nil
+}
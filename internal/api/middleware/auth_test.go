@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAuthenticator knows a single access token and session
+type fakeAuthenticator struct{}
+
+var fakeUser = &models.User{ID: "1", Email: "ann@example.com"}
+
+func (fakeAuthenticator) Authenticate(_ context.Context, token string) (*models.User, error) {
+	if token == "good-token" {
+		return fakeUser, nil
+	}
+	return nil, apperrors.Unauthorized("invalid token")
+}
+
+func (fakeAuthenticator) Session(_ context.Context, token string) (*models.User, error) {
+	if token == "good-session" {
+		return fakeUser, nil
+	}
+	return nil, apperrors.Unauthorized("invalid session")
+}
+
+func newAuthRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Problems())
+	whoami := func(c *gin.Context) {
+		if user := CurrentUser(c); user != nil {
+			c.String(http.StatusOK, user.ID)
+			return
+		}
+		c.String(http.StatusOK, "anonymous")
+	}
+	router.Any("/optional", Authenticate(fakeAuthenticator{}), whoami)
+	router.Any("/required", Authenticate(fakeAuthenticator{}), RequireUser(), whoami)
+	return router
+}
+
+func TestAuthenticate(t *testing.T) {
+	router := newAuthRouter()
+	cases := []struct {
+		name          string
+		method, path  string
+		authorization string
+		session       string
+		status        int
+		body          string
+	}{
+		{"anonymous", http.MethodGet, "/optional", "", "", http.StatusOK, "anonymous"},
+		{"access token", http.MethodPost, "/optional", "Bearer good-token", "", http.StatusOK, "1"},
+		{"lowercase scheme", http.MethodGet, "/required", "bearer good-token", "", http.StatusOK, "1"},
+		{"invalid access token", http.MethodGet, "/optional", "Bearer bad-token", "", http.StatusUnauthorized, ""},
+		{"basic credentials", http.MethodGet, "/optional", "Basic YW5uOnB3", "", http.StatusUnauthorized, ""},
+		{"session on a read", http.MethodGet, "/required", "", "good-session", http.StatusOK, "1"},
+		{"session on a change", http.MethodPost, "/optional", "", "good-session", http.StatusOK, "anonymous"},
+		{"invalid session", http.MethodGet, "/optional", "", "bad-session", http.StatusOK, "anonymous"},
+		{"required", http.MethodGet, "/required", "", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		if tc.session != "" {
+			req.AddCookie(&http.Cookie{Name: SessionCookie, Value: tc.session})
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, w.Code)
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.body, w.Body.String())
+		}
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: expected a WWW-Authenticate header", tc.name)
+		}
+	}
+}
@@ -1,39 +1,62 @@
 package middleware
 
 import (
+	"blog-posts-api/internal/api/apperrors"
 	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/validation"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ValidateBlogPostBody() gin.HandlerFunc {
+// MaxBlogPostBodyBytes bounds the size of a blog post request body. It
+// leaves room for JSON escaping on top of the content size limit.
+const MaxBlogPostBodyBytes = 2 << 20
+
+// ValidateBlogPostBody decodes the request body into T, rejecting unknown
+// fields, then sanitizes and validates it with the rules declared on T.
+// Every invalid field is reported at once.
+func ValidateBlogPostBody[T models.BlogPostCreate | models.BlogPostUpdate]() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var post models.BlogPost
-		if err := c.ShouldBindJSON(&post); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body provided"})
+		data, err := ReadBody(c, MaxBlogPostBodyBytes)
+		if err != nil {
+			c.Error(err)
 			c.Abort()
 			return
 		}
-		if post.Title == "" || strings.TrimSpace(post.Title) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "missing title field"})
-			c.Abort()
-			return
-		}
-		if post.Content == "" || strings.TrimSpace(post.Content) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "missing content field"})
+
+		var body T
+		fields, err := validation.DecodeJSON(data, &body)
+		if err != nil {
+			c.Error(err)
 			c.Abort()
 			return
 		}
-		if post.Author == "" || strings.TrimSpace(post.Author) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "missing author field"})
+		if len(fields) > 0 {
+			c.Error(apperrors.Validation("blog post has invalid fields", fields...))
 			c.Abort()
 			return
 		}
 
 		// save the validated data in the context to use it later in handlers
-		c.Set("validatedPost", post)
+		c.Set("validatedPost", body)
 		c.Next()
 	}
 }
+
+// ReadBody reads the whole request body, failing with a bad request error
+// past limit bytes
+func ReadBody(c *gin.Context, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+	if err == nil {
+		return data, nil
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return nil, apperrors.BadRequest(fmt.Sprintf("body must be at most %d bytes long", maxErr.Limit), err)
+	}
+	return nil, apperrors.BadRequest("invalid body provided", err)
+}
@@ -6,17 +6,27 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 )
 
+func invalidParamNames(p models.Problem) []string {
+	names := make([]string, 0, len(p.InvalidParams))
+	for _, param := range p.InvalidParams {
+		names = append(names, param.Name)
+	}
+	return names
+}
+
 func TestValidateBlogPostBody_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Create a test router with the middleware
 	router := gin.New()
-	router.POST("/test", ValidateBlogPostBody(), func(c *gin.Context) {
+	router.POST("/test", ValidateBlogPostBody[models.BlogPostCreate](), func(c *gin.Context) {
 		// This handler will only be called if middleware succeeds
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
@@ -55,8 +65,9 @@ func TestValidateBlogPostBody_InvalidJSON(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	middleware := ValidateBlogPostBody()
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
 	middleware(c)
+	WriteProblem(c)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -67,14 +78,14 @@ func TestValidateBlogPostBody_InvalidJSON(t *testing.T) {
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "invalid body provided" {
-		t.Errorf("expected error 'invalid body provided', got '%s'", response["error"])
+	if response.Detail != "invalid body provided" {
+		t.Errorf("expected detail 'invalid body provided', got '%s'", response.Detail)
 	}
 }
 
@@ -89,8 +100,9 @@ func TestValidateBlogPostBody_MissingTitle(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	middleware := ValidateBlogPostBody()
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
 	middleware(c)
+	WriteProblem(c)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -101,14 +113,14 @@ func TestValidateBlogPostBody_MissingTitle(t *testing.T) {
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "missing title field" {
-		t.Errorf("expected error 'missing title field', got '%s'", response["error"])
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"title"}) {
+		t.Errorf("expected invalid params [title], got %v", names)
 	}
 }
 
@@ -123,8 +135,9 @@ func TestValidateBlogPostBody_EmptyTitle(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	middleware := ValidateBlogPostBody()
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
 	middleware(c)
+	WriteProblem(c)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -135,14 +148,14 @@ func TestValidateBlogPostBody_EmptyTitle(t *testing.T) {
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "missing title field" {
-		t.Errorf("expected error 'missing title field', got '%s'", response["error"])
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"title"}) {
+		t.Errorf("expected invalid params [title], got %v", names)
 	}
 }
 
@@ -157,8 +170,9 @@ func TestValidateBlogPostBody_MissingContent(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	middleware := ValidateBlogPostBody()
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
 	middleware(c)
+	WriteProblem(c)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -169,14 +183,14 @@ func TestValidateBlogPostBody_MissingContent(t *testing.T) {
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "missing content field" {
-		t.Errorf("expected error 'missing content field', got '%s'", response["error"])
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"content"}) {
+		t.Errorf("expected invalid params [content], got %v", names)
 	}
 }
 
@@ -191,8 +205,9 @@ func TestValidateBlogPostBody_EmptyContent(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	middleware := ValidateBlogPostBody()
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
 	middleware(c)
+	WriteProblem(c)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -203,14 +218,14 @@ func TestValidateBlogPostBody_EmptyContent(t *testing.T) {
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "missing content field" {
-		t.Errorf("expected error 'missing content field', got '%s'", response["error"])
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"content"}) {
+		t.Errorf("expected invalid params [content], got %v", names)
 	}
 }
 
@@ -225,8 +240,9 @@ func TestValidateBlogPostBody_MissingAuthor(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	middleware := ValidateBlogPostBody()
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
 	middleware(c)
+	WriteProblem(c)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -237,14 +253,14 @@ func TestValidateBlogPostBody_MissingAuthor(t *testing.T) {
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "missing author field" {
-		t.Errorf("expected error 'missing author field', got '%s'", response["error"])
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"author"}) {
+		t.Errorf("expected invalid params [author], got %v", names)
 	}
 }
 
@@ -259,8 +275,9 @@ func TestValidateBlogPostBody_EmptyAuthor(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	middleware := ValidateBlogPostBody()
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
 	middleware(c)
+	WriteProblem(c)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -271,14 +288,14 @@ func TestValidateBlogPostBody_EmptyAuthor(t *testing.T) {
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "missing author field" {
-		t.Errorf("expected error 'missing author field', got '%s'", response["error"])
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"author"}) {
+		t.Errorf("expected invalid params [author], got %v", names)
 	}
 }
 
@@ -293,8 +310,9 @@ func TestValidateBlogPostBody_AllFieldsEmpty(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	middleware := ValidateBlogPostBody()
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
 	middleware(c)
+	WriteProblem(c)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -304,15 +322,15 @@ func TestValidateBlogPostBody_AllFieldsEmpty(t *testing.T) {
 		t.Error("expected request to be aborted")
 	}
 
-	// Verify error response (should return the first validation error - title)
-	var response map[string]string
+	// Verify error response (should list every invalid field)
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "missing title field" {
-		t.Errorf("expected error 'missing title field', got '%s'", response["error"])
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"title", "content", "author"}) {
+		t.Errorf("expected invalid params [title content author], got %v", names)
 	}
 }
 
@@ -327,8 +345,9 @@ func TestValidateBlogPostBody_EmptyJSON(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	middleware := ValidateBlogPostBody()
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
 	middleware(c)
+	WriteProblem(c)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -339,41 +358,156 @@ func TestValidateBlogPostBody_EmptyJSON(t *testing.T) {
 	}
 
 	// Verify error response
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if response["error"] != "missing title field" {
-		t.Errorf("expected error 'missing title field', got '%s'", response["error"])
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"title", "content", "author"}) {
+		t.Errorf("expected invalid params [title content author], got %v", names)
 	}
 }
 
-func TestValidateBlogPostBody_ExtraFields(t *testing.T) {
+func TestValidateBlogPostBody_UnknownFields(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	// Create a test router with the middleware
-	router := gin.New()
-	router.POST("/test", ValidateBlogPostBody(), func(c *gin.Context) {
-		// Check if validated post is set in context and extra fields are ignored
-		postInterface, exists := c.Get("validatedPost")
-		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "no validated post"})
-			return
-		}
-
-		post := postInterface.(models.BlogPost)
-		c.JSON(http.StatusOK, post)
-	})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
 
-	// JSON with extra fields that should be ignored
+	// JSON with extra fields that must be rejected, including the server-assigned id
 	body := `{
+		"id":"550e8400-e29b-41d4-a716-446655440000",
 		"title":"Test Title",
 		"content":"Test Content",
 		"author":"Test Author",
-		"extraField":"should be ignored",
-		"anotherField":123
+		"extraField":"should be rejected"
+	}`
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	c.Request = req
+
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
+	middleware(c)
+	WriteProblem(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var response models.Problem
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"extraField", "id"}) {
+		t.Errorf("expected invalid params [extraField id], got %v", names)
+	}
+}
+
+func TestValidateBlogPostBody_InvalidTypeReportedWithOtherFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	body := `{"title":123,"content":"Test Content","author":""}`
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	c.Request = req
+
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
+	middleware(c)
+	WriteProblem(c)
+
+	var response models.Problem
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	// title is reported once, as mistyped, not also as missing
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"title", "author"}) {
+		t.Errorf("expected invalid params [title author], got %v", names)
+	}
+}
+
+func TestValidateBlogPostBody_TooLong(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	post := models.BlogPostUpdate{
+		Title:   strings.Repeat("é", 201), // counted in characters, not bytes
+		Content: "Test Content",
+		Author:  strings.Repeat("a", 101),
+	}
+	body, _ := json.Marshal(post)
+	req, _ := http.NewRequest("PUT", "/", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	c.Request = req
+
+	middleware := ValidateBlogPostBody[models.BlogPostUpdate]()
+	middleware(c)
+	WriteProblem(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var response models.Problem
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if names := invalidParamNames(response); !reflect.DeepEqual(names, []string{"title", "author"}) {
+		t.Errorf("expected invalid params [title author], got %v", names)
+	}
+	if response.InvalidParams[0].Reason != "must be at most 200 characters long" {
+		t.Errorf("unexpected reason '%s'", response.InvalidParams[0].Reason)
+	}
+}
+
+func TestValidateBlogPostBody_BodyTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	body := `{"title":"Test Title","author":"Test Author","content":"` + strings.Repeat("a", MaxBlogPostBodyBytes) + `"}`
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	c.Request = req
+
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
+	middleware(c)
+	WriteProblem(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if !c.IsAborted() {
+		t.Error("expected request to be aborted")
+	}
+}
+
+func TestValidateBlogPostBody_Sanitizes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/test", ValidateBlogPostBody[models.BlogPostCreate](), func(c *gin.Context) {
+		post := c.MustGet("validatedPost").(models.BlogPostCreate)
+		c.JSON(http.StatusOK, post)
+	})
+
+	// decomposed "é", a NUL byte, a line break in the title and CRLF line endings
+	body := `{
+		"title":"  Cafe\u0301\nMenu\u0000  ",
+		"content":"line 1\r\nline\u0007 2",
+		"author":"Test Author"
 	}`
 	req, _ := http.NewRequest("POST", "/test", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -382,24 +516,20 @@ func TestValidateBlogPostBody_ExtraFields(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
 	}
 
-	// Verify the validated post
-	var post models.BlogPost
+	var post models.BlogPostCreate
 	err := json.Unmarshal(w.Body.Bytes(), &post)
 	if err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if post.Title != "Test Title" {
-		t.Errorf("expected title 'Test Title', got %s", post.Title)
-	}
-	if post.Content != "Test Content" {
-		t.Errorf("expected content 'Test Content', got %s", post.Content)
+	if post.Title != "Café Menu" {
+		t.Errorf("expected title 'Café Menu', got %q", post.Title)
 	}
-	if post.Author != "Test Author" {
-		t.Errorf("expected author 'Test Author', got %s", post.Author)
+	if post.Content != "line 1\nline 2" {
+		t.Errorf("expected content %q, got %q", "line 1\nline 2", post.Content)
 	}
 }
 
@@ -419,8 +549,9 @@ func TestValidateBlogPostBody_WhitespaceFields(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	middleware := ValidateBlogPostBody()
+	middleware := ValidateBlogPostBody[models.BlogPostCreate]()
 	middleware(c)
+	WriteProblem(c)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -430,9 +561,6 @@ func TestValidateBlogPostBody_WhitespaceFields(t *testing.T) {
 		t.Error("expected request to be aborted")
 	}
 
-	// Note: This test assumes the current validation only checks for empty strings,
-	// not trimmed strings. If you want to trim whitespace, you'd need to modify
-	// the middleware to use strings.TrimSpace()
 }
 
 func TestValidateBlogPostBody_UnicodeContent(t *testing.T) {
@@ -440,7 +568,7 @@ func TestValidateBlogPostBody_UnicodeContent(t *testing.T) {
 
 	// Create a test router with the middleware
 	router := gin.New()
-	router.POST("/test", ValidateBlogPostBody(), func(c *gin.Context) {
+	router.POST("/test", ValidateBlogPostBody[models.BlogPostCreate](), func(c *gin.Context) {
 		// Check if validated post is set in context with Unicode content
 		postInterface, exists := c.Get("validatedPost")
 		if !exists {
@@ -448,7 +576,7 @@ func TestValidateBlogPostBody_UnicodeContent(t *testing.T) {
 			return
 		}
 
-		post := postInterface.(models.BlogPost)
+		post := postInterface.(models.BlogPostCreate)
 		c.JSON(http.StatusOK, post)
 	})
 
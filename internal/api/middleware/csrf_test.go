@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCSRFRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Problems(), CSRF(CSRFOptions{Origin: "https://blog.example.com"}))
+	router.GET("/form", func(c *gin.Context) {
+		c.String(http.StatusOK, CSRFToken(c))
+	})
+	router.POST("/form", func(c *gin.Context) {
+		c.String(http.StatusOK, "accepted "+c.PostForm("name"))
+	})
+	return router
+}
+
+func TestCSRF(t *testing.T) {
+	router := newCSRFRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/form", nil))
+	token := w.Body.String()
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CSRFCookie || cookies[0].Value != token || !cookies[0].HttpOnly {
+		t.Fatalf("expected the token in an HTTP-only cookie, got %v", cookies)
+	}
+
+	post := func(form url.Values, cookie, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:8080/form", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: cookie})
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	valid := url.Values{CSRFField: {token}, "name": {"Ann"}}
+	for _, origin := range []string{"", "http://localhost:8080", "https://blog.example.com"} {
+		if w := post(valid, token, origin); w.Code != http.StatusOK || w.Body.String() != "accepted Ann" {
+			t.Errorf("expected a form from %q to be accepted, got %d: %s", origin, w.Code, w.Body.String())
+		}
+	}
+	if w := post(valid, token, "https://evil.example.com"); w.Code != http.StatusForbidden {
+		t.Errorf("expected a cross-origin form to be rejected, got %d", w.Code)
+	}
+	if w := post(url.Values{"name": {"Ann"}}, token, ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected a form without token to be rejected, got %d", w.Code)
+	}
+	if w := post(valid, "", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected a form without cookie to be rejected, got %d", w.Code)
+	}
+	other := strings.Repeat("a", len(token))
+	if w := post(url.Values{CSRFField: {other}}, token, ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected another token to be rejected, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/form", nil)
+	req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: token})
+	req.Header.Set(CSRFHeader, token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the token to be accepted in a header, got %d", w.Code)
+	}
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"blog-posts-api/internal/openapi"
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxValidatedBodyBytes bounds the request and response bodies validated
+// against the API description. Larger requests are rejected, while larger
+// responses are sent without checking their body.
+const MaxValidatedBodyBytes = 8 << 20
+
+// OpenAPIOptions selects what the OpenAPI middleware validates
+type OpenAPIOptions struct {
+	// Requests rejects the requests not matching the description with a
+	// validation problem, before they reach the handlers
+	Requests bool
+	// Responses checks the responses of the handlers, including problems
+	Responses bool
+	// OnResponseMismatch is called with the responses not matching the
+	// description, which are sent anyway. They are logged unless set.
+	OnResponseMismatch func(c *gin.Context, err error)
+}
+
+// OpenAPI validates the requests and responses of the routes described by
+// the spec, other routes are left alone. It renders the errors of the
+// handlers itself so that the problems are validated too.
+func OpenAPI(spec *openapi.Spec, opts OpenAPIOptions) gin.HandlerFunc {
+	onMismatch := opts.OnResponseMismatch
+	if onMismatch == nil {
+		onMismatch = func(c *gin.Context, err error) {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+	}
+
+	return func(c *gin.Context) {
+		op := spec.Operation(c.Request.Method, c.FullPath())
+		if op == nil || (!opts.Requests && !opts.Responses) {
+			c.Next()
+			return
+		}
+
+		if opts.Requests {
+			if err := validateRequest(c, op); err != nil {
+				c.Error(err)
+				c.Abort()
+				WriteProblem(c)
+				return
+			}
+		}
+		if !opts.Responses {
+			c.Next()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		WriteProblem(c)
+		c.Writer = recorder.ResponseWriter
+
+		if recorder.hijacked {
+			return
+		}
+		var body []byte
+		if recorder.capture && !recorder.overflow {
+			body = recorder.body.Bytes()
+		}
+		if err := op.ValidateResponse(recorder.Status(), recorder.Header(), body); err != nil {
+			onMismatch(c, err)
+		}
+	}
+}
+
+// validateRequest checks the request, reading the JSON body and putting
+// it back for the handlers
+func validateRequest(c *gin.Context, op *openapi.Operation) error {
+	var body []byte
+	if op.HasJSONBody() && isJSONContent(c.Request.Header.Get("Content-Type")) {
+		var err error
+		if body, err = ReadBody(c, MaxValidatedBodyBytes); err != nil {
+			return err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	params := make(map[string]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = p.Value
+	}
+	return op.ValidateRequest(c.Request, params, body)
+}
+
+// isJSONContent tells whether a request body is JSON, bodies without a
+// content type are assumed to be
+func isJSONContent(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// responseRecorder keeps a copy of the JSON bodies written through it, up
+// to MaxValidatedBodyBytes. Streams and upgraded connections are passed
+// through untouched.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	decided  bool
+	capture  bool
+	overflow bool
+	hijacked bool
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.record(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *responseRecorder) record(data []byte) {
+	if !r.decided {
+		r.decided = true
+		r.capture = isJSONContent(r.Header().Get("Content-Type")) && r.Header().Get("Content-Type") != ""
+	}
+	if !r.capture || r.overflow {
+		return
+	}
+	if r.body.Len()+len(data) > MaxValidatedBodyBytes {
+		r.overflow = true
+		r.body = bytes.Buffer{}
+		return
+	}
+	r.body.Write(data)
+}
+
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return r.ResponseWriter.Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
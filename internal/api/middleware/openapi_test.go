@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/openapi"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestOpenAPIRouter(t *testing.T, opts OpenAPIOptions, handler gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("failed to load the spec: %v", err)
+	}
+	router := gin.New()
+	router.Use(Problems())
+	v1 := router.Group("/api/v1", OpenAPI(spec, opts))
+	v1.POST("/posts", handler)
+	v1.GET("/undocumented", handler)
+	return router
+}
+
+func TestOpenAPI_RejectsInvalidRequests(t *testing.T) {
+	called := false
+	router := newTestOpenAPIRouter(t, OpenAPIOptions{Requests: true}, func(c *gin.Context) {
+		called = true
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/posts", strings.NewReader(`{"title":"Go","author":1,"extra":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if called {
+		t.Error("expected the handler not to be called")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	var problem models.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if want := []string{"author", "content", "extra"}; !reflect.DeepEqual(invalidParamNames(problem), want) {
+		t.Errorf("expected invalid params %v, got %v", want, invalidParamNames(problem))
+	}
+}
+
+func TestOpenAPI_PassesValidRequests(t *testing.T) {
+	const body = `{"title":"Go","content":"hello","author":"Ann"}`
+	var got string
+	router := newTestOpenAPIRouter(t, OpenAPIOptions{Requests: true}, func(c *gin.Context) {
+		data, _ := io.ReadAll(c.Request.Body)
+		got = string(data)
+		c.Status(http.StatusNoContent)
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/posts", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got != body {
+		t.Errorf("expected the handler to read the body %q, got %q", body, got)
+	}
+}
+
+func TestOpenAPI_ReportsResponseMismatches(t *testing.T) {
+	var mismatches []string
+	opts := OpenAPIOptions{Responses: true, OnResponseMismatch: func(c *gin.Context, err error) {
+		mismatches = append(mismatches, err.Error())
+	}}
+	router := newTestOpenAPIRouter(t, opts, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"data": gin.H{"id": "1"}})
+	})
+
+	for _, route := range [][2]string{{http.MethodPost, "/api/v1/posts"}, {http.MethodGet, "/api/v1/undocumented"}} {
+		req, _ := http.NewRequest(route[0], route[1], strings.NewReader(`{}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Errorf("expected the response to be sent anyway, got %d", w.Code)
+		}
+	}
+
+	// undocumented routes are not checked
+	if len(mismatches) != 1 || !strings.Contains(mismatches[0], "POST /posts: 201") || !strings.Contains(mismatches[0], "id is required") {
+		t.Errorf("expected a single mismatch of POST /posts, got %v", mismatches)
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problems renders the errors attached to the context with c.Error as
+// RFC 7807 problem details. Handlers and middlewares report failures
+// with c.Error and return, and this middleware picks the status code.
+func Problems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		WriteProblem(c)
+	}
+}
+
+// WriteProblem renders the last error attached to the context, unless
+// a response has already been written
+func WriteProblem(c *gin.Context) {
+	err := c.Errors.Last()
+	if err == nil || c.Writer.Written() {
+		return
+	}
+
+	problem := NewProblem(err.Err)
+	problem.Instance = c.Request.URL.Path
+	if problem.Status >= http.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err.Err)
+	}
+
+	// gin keeps an explicitly set content type when rendering JSON
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(problem.Status, problem)
+}
+
+// NewProblem maps a domain error to problem details
+func NewProblem(err error) models.Problem {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		appErr = apperrors.Internal("internal server error", err)
+	}
+
+	p := models.Problem{Detail: appErr.Message, Status: appErr.Kind.HTTPStatus()}
+	switch appErr.Kind {
+	case apperrors.KindBadRequest:
+		p.Type, p.Title = "/problems/bad-request", "Bad request"
+	case apperrors.KindValidation:
+		p.Type, p.Title = "/problems/validation-error", "Validation failed"
+	case apperrors.KindNotFound:
+		p.Type, p.Title = "/problems/not-found", "Resource not found"
+	case apperrors.KindConflict:
+		p.Type, p.Title = "/problems/conflict", "Conflict"
+	case apperrors.KindForbidden:
+		p.Type, p.Title = "/problems/forbidden", "Forbidden"
+	case apperrors.KindPreconditionFailed:
+		p.Type, p.Title = "/problems/precondition-failed", "Precondition failed"
+	case apperrors.KindUnauthorized:
+		p.Type, p.Title = "/problems/unauthorized", "Unauthorized"
+	default:
+		p.Type, p.Title = "about:blank", http.StatusText(p.Status)
+	}
+
+	for _, f := range appErr.Fields {
+		p.InvalidParams = append(p.InvalidParams, models.InvalidParam{Name: f.Field, Reason: f.Reason})
+	}
+	return p
+}
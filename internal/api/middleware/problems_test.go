@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func performProblemRequest(t *testing.T, err error) (*httptest.ResponseRecorder, models.Problem) {
+	t.Helper()
+
+	router := gin.New()
+	router.Use(Problems())
+	router.GET("/test", func(c *gin.Context) {
+		c.Error(err)
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var problem models.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return w, problem
+}
+
+func TestProblems_StatusMapping(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{apperrors.NotFound("not here"), http.StatusNotFound},
+		{apperrors.Conflict("already there"), http.StatusConflict},
+		{apperrors.Forbidden("not yours"), http.StatusForbidden},
+		{apperrors.Unauthorized("who are you"), http.StatusUnauthorized},
+		{apperrors.BadRequest("bad json", nil), http.StatusBadRequest},
+		{apperrors.Validation("invalid"), http.StatusBadRequest},
+		{fmt.Errorf("wrapped: %w", apperrors.NotFound("not here")), http.StatusNotFound},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		w, problem := performProblemRequest(t, tc.err)
+		if w.Code != tc.status {
+			t.Errorf("%v: expected status %d, got %d", tc.err, tc.status, w.Code)
+		}
+		if problem.Status != tc.status {
+			t.Errorf("%v: expected problem status %d, got %d", tc.err, tc.status, problem.Status)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+			t.Errorf("%v: expected content type %s, got %s", tc.err, ProblemContentType, ct)
+		}
+	}
+}
+
+func TestProblems_InternalErrorIsHidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	_, problem := performProblemRequest(t, errors.New("pq: password authentication failed"))
+
+	if problem.Detail != "internal server error" {
+		t.Errorf("expected generic detail, got '%s'", problem.Detail)
+	}
+	if problem.Type != "about:blank" {
+		t.Errorf("expected type 'about:blank', got '%s'", problem.Type)
+	}
+	if problem.Instance != "/test" {
+		t.Errorf("expected instance '/test', got '%s'", problem.Instance)
+	}
+}
+
+func TestProblems_ValidationListsFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	err := apperrors.Validation("blog post has invalid fields",
+		apperrors.FieldError{Field: "title", Reason: "is required"},
+		apperrors.FieldError{Field: "author", Reason: "is required"},
+	)
+	_, problem := performProblemRequest(t, err)
+
+	if len(problem.InvalidParams) != 2 {
+		t.Fatalf("expected 2 invalid params, got %d", len(problem.InvalidParams))
+	}
+	if problem.InvalidParams[1].Name != "author" || problem.InvalidParams[1].Reason != "is required" {
+		t.Errorf("unexpected invalid param %+v", problem.InvalidParams[1])
+	}
+}
+
+func TestProblems_WrittenResponseIsKept(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Problems())
+	router.GET("/test", func(c *gin.Context) {
+		c.Error(errors.New("logged only"))
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Processing states of the variants of an image attachment
+const (
+	ProcessingPending   = "pending"
+	ProcessingSucceeded = "succeeded"
+	ProcessingFailed    = "failed"
+)
+
+// Attachment represents a file uploaded to a blog post, e.g. an image
+// embedded in its content. The file itself is kept in a blob store.
+type Attachment struct {
+	ID       string `json:"id" example:"3f1c2b7a-9d8e-4f6a-b5c4-d3e2f1a0b9c8"`
+	Filename string `json:"filename" example:"diagram.png"`
+	// ContentType is sniffed from the content, whatever the client sent
+	ContentType string `json:"content_type" example:"image/png"`
+	Size        int64  `json:"size" example:"48213"`
+	// SHA256 is the hex-encoded checksum of the content, also its ETag
+	SHA256 string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	// URL is the path the content is served at
+	URL string `json:"url" example:"/api/v1/posts/550e8400-e29b-41d4-a716-446655440000/attachments/3f1c2b7a-9d8e-4f6a-b5c4-d3e2f1a0b9c8"`
+	// Processing is the state of the resized variants of an image, empty
+	// for other files
+	Processing string `json:"processing,omitempty" enums:"pending,succeeded,failed" example:"succeeded"`
+	// ProcessingError tells why the image could not be processed
+	ProcessingError string              `json:"processing_error,omitempty" example:"image is too large to process"`
+	Variants        []AttachmentVariant `json:"variants,omitempty"`
+	CreatedAt       time.Time           `json:"created_at" example:"2025-01-02T10:00:00Z"`
+}
+
+// AttachmentVariant represents a resized copy of an image attachment,
+// re-encoded without the metadata of the original
+type AttachmentVariant struct {
+	Name        string `json:"name" example:"thumbnail"`
+	ContentType string `json:"content_type" example:"image/jpeg"`
+	Width       int    `json:"width" example:"200"`
+	Height      int    `json:"height" example:"200"`
+	Size        int64  `json:"size" example:"8121"`
+	SHA256      string `json:"sha256" example:"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"`
+	URL         string `json:"url" example:"/api/v1/posts/550e8400-e29b-41d4-a716-446655440000/attachments/3f1c2b7a-9d8e-4f6a-b5c4-d3e2f1a0b9c8/variants/thumbnail"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Author represents the author of blog posts. Posts refer to their author
+// by ID and carry its name, kept up to date when the author is renamed.
+type Author struct {
+	ID   string `json:"id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Name string `json:"name" example:"John Doe"`
+	Bio  string `json:"bio" example:"Backend developer writing about Go."`
+	// AvatarURL is the URL of a picture of the author, if any
+	AvatarURL string `json:"avatar_url" example:"https://example.com/avatars/jdoe.png"`
+	// Links are the pages of the author elsewhere, e.g. social profiles
+	Links     []string  `json:"links" example:"https://github.com/jdoe,https://mastodon.social/@jdoe"`
+	CreatedAt time.Time `json:"created_at" example:"2025-01-01T10:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2025-01-02T10:00:00Z"`
+}
+
+// AuthorBackfill represents the outcome of linking the posts to their
+// authors
+type AuthorBackfill struct {
+	// Linked is the number of posts linked to their author
+	Linked int `json:"linked" example:"42"`
+}
+
+// AuthorCreate represents the request body for creating an author
+type AuthorCreate struct {
+	Name      string   `json:"name" binding:"required" maxLength:"100" sanitize:"nfc,singleline,trim" validate:"required,maxrunes=100" example:"John Doe"`
+	Bio       string   `json:"bio" maxLength:"2000" sanitize:"nfc,stripctl,trim" validate:"maxrunes=2000" example:"Backend developer writing about Go."`
+	AvatarURL string   `json:"avatar_url" maxLength:"2048" sanitize:"trim" validate:"maxbytes=2048,httpurl" example:"https://example.com/avatars/jdoe.png"`
+	Links     []string `json:"links" maxItems:"10" sanitize:"trim" validate:"maxitems=10,maxbytes=2048,httpurl" example:"https://github.com/jdoe,https://mastodon.social/@jdoe"`
+}
+
+// AuthorUpdate represents the request body for updating an author
+type AuthorUpdate struct {
+	Name      string   `json:"name" binding:"required" maxLength:"100" sanitize:"nfc,singleline,trim" validate:"required,maxrunes=100" example:"John Doe"`
+	Bio       string   `json:"bio" maxLength:"2000" sanitize:"nfc,stripctl,trim" validate:"maxrunes=2000" example:"Backend developer writing about Go and databases."`
+	AvatarURL string   `json:"avatar_url" maxLength:"2048" sanitize:"trim" validate:"maxbytes=2048,httpurl" example:"https://example.com/avatars/jdoe.png"`
+	Links     []string `json:"links" maxItems:"10" sanitize:"trim" validate:"maxitems=10,maxbytes=2048,httpurl" example:"https://github.com/jdoe"`
+}
+
+// CreateBody returns the client-editable fields of the author
+func (a Author) CreateBody() AuthorCreate {
+	return AuthorCreate{Name: a.Name, Bio: a.Bio, AvatarURL: a.AvatarURL, Links: slices.Clone(a.Links)}
+}
+
+// UpdateBody returns the client-editable fields of the author
+func (a Author) UpdateBody() AuthorUpdate {
+	return AuthorUpdate{Name: a.Name, Bio: a.Bio, AvatarURL: a.AvatarURL, Links: slices.Clone(a.Links)}
+}
+
+// ToAuthor converts the request body into an author without an ID
+func (b AuthorCreate) ToAuthor() Author {
+	return Author{Name: b.Name, Bio: b.Bio, AvatarURL: b.AvatarURL, Links: b.Links}
+}
+
+// ToAuthor converts the request body into an author without an ID
+func (b AuthorUpdate) ToAuthor() Author {
+	return Author{Name: b.Name, Bio: b.Bio, AvatarURL: b.AvatarURL, Links: b.Links}
+}
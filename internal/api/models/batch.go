@@ -0,0 +1,34 @@
+package models
+
+import "encoding/json"
+
+// BatchRequest represents the request body of a batch of operations
+type BatchRequest struct {
+	// Atomic applies every operation or none, otherwise each operation is applied on its own
+	Atomic     bool             `json:"atomic" example:"true"`
+	Operations []BatchOperation `json:"operations" binding:"required" maxItems:"100" validate:"required,maxitems=100"`
+}
+
+// BatchOperation represents an operation of a batch. The id is required to
+// update and delete, and optional to create.
+type BatchOperation struct {
+	Op string `json:"op" binding:"required" enums:"create,update,delete" example:"update"`
+	ID string `json:"id,omitempty" format:"uuid" sanitize:"trim,lower" validate:"uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	// Post is a BlogPostCreate to create and a BlogPostUpdate to update
+	Post json.RawMessage `json:"post,omitempty" swaggertype:"object"`
+}
+
+// BatchResult represents the outcome of an operation, with the status code
+// the operation would have had as a single request
+type BatchResult struct {
+	Index  int       `json:"index" example:"0"`
+	Status int       `json:"status" example:"200"`
+	Data   *BlogPost `json:"data,omitempty"`
+	Error  *Problem  `json:"error,omitempty"`
+}
+
+// BatchResponse represents the response of a batch of operations
+type BatchResponse struct {
+	Atomic  bool          `json:"atomic" example:"true"`
+	Results []BatchResult `json:"results"`
+}
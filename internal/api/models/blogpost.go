@@ -1,25 +1,144 @@
 package models
 
-// BlogPost represents a base blog post entity
+import (
+	"slices"
+	"time"
+)
+
+// Supported formats of the blog post content
+const (
+	ContentFormatPlain    = "plain"
+	ContentFormatMarkdown = "markdown"
+)
+
+// Publication states of a blog post, drafts are left out of feeds and sitemaps
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+)
+
+// BlogPost represents a base blog post entity. Author is the name of the
+// author referred to by AuthorID, kept up to date when it is renamed.
 type BlogPost struct {
-	ID      string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	Title   string `json:"title" example:"Getting Started with Go"`
-	Content string `json:"content" example:"Go is a programming language developed by Google..."`
-	Author  string `json:"author" example:"John Doe"`
+	ID    string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title string `json:"title" example:"Getting Started with Go"`
+	// Slug identifies the post in public URLs, derived from the title unless
+	// set and unique among the posts
+	Slug          string   `json:"slug" example:"getting-started-with-go"`
+	Content       string   `json:"content" example:"Go is a programming language developed by Google..."`
+	ContentFormat string   `json:"content_format" enums:"plain,markdown" example:"markdown"`
+	Author        string   `json:"author" example:"John Doe"`
+	AuthorID      string   `json:"author_id,omitempty" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Tags          []string `json:"tags" example:"go,tutorial"`
+	Status        string   `json:"status" enums:"draft,published" example:"published"`
+	// PublishedAt is set by the service the first time the post is published
+	PublishedAt *time.Time `json:"published_at,omitempty" example:"2025-01-01T10:00:00Z"`
+	// Attachments are managed by the attachment endpoints and kept by
+	// updates of the post
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// Version is incremented by the repository on every update
+	Version   int       `json:"version" example:"1"`
+	CreatedAt time.Time `json:"created_at" example:"2025-01-01T10:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2025-01-02T10:00:00Z"`
+}
+
+// PostFilter selects blog posts by author, tag and/or status, empty fields
+// match any post. Author is the name of the author and AuthorID its ID.
+// Authors and Tags select the posts of any of the authors or with any of
+// the tags, e.g. to load several groups of posts in one query.
+type PostFilter struct {
+	Author   string
+	AuthorID string
+	Tag      string
+	Status   string
+	Authors  []string
+	Tags     []string
+}
+
+// IsZero tells whether the filter matches every post
+func (f PostFilter) IsZero() bool {
+	return f.Author == "" && f.AuthorID == "" && f.Tag == "" && f.Status == "" && len(f.Authors) == 0 && len(f.Tags) == 0
+}
+
+// Matches tells whether the post passes the filter
+func (f PostFilter) Matches(p *BlogPost) bool {
+	if f.Author != "" && p.Author != f.Author {
+		return false
+	}
+	if f.AuthorID != "" && p.AuthorID != f.AuthorID {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(p.Tags, f.Tag) {
+		return false
+	}
+	if f.Status != "" && p.Status != f.Status {
+		return false
+	}
+	if len(f.Authors) > 0 && !slices.Contains(f.Authors, p.Author) {
+		return false
+	}
+	if len(f.Tags) > 0 && !slices.ContainsFunc(p.Tags, func(tag string) bool { return slices.Contains(f.Tags, tag) }) {
+		return false
+	}
+	return true
+}
+
+// IsPublished tells whether the post is publicly visible
+func (p *BlogPost) IsPublished() bool {
+	return p.Status == StatusPublished
 }
 
-// BlogPostCreate represents the request body for creating a blog post
+// RenderedBlogPost represents a blog post along with its content rendered to sanitized HTML
+type RenderedBlogPost struct {
+	BlogPost
+	ContentHTML string `json:"content_html" example:"<h1 id=\"getting-started\">Getting started</h1>"`
+}
+
+// BlogPostCreate represents the request body for creating a blog post. The
+// author is given by ID, the name being ignored then, or by name: posts by
+// a new name get a new author.
 type BlogPostCreate struct {
-	Title   string `json:"title" binding:"required" example:"Getting Started with Go"`
-	Content string `json:"content" binding:"required" example:"Go is a programming language developed by Google. It's designed to be simple, efficient, and reliable. In this post, we'll explore the basics of Go programming and why it's becoming increasingly popular among developers."`
-	Author  string `json:"author" binding:"required" example:"John Doe"`
+	Title         string   `json:"title" binding:"required" maxLength:"200" sanitize:"nfc,singleline,trim" validate:"required,maxrunes=200" example:"Getting Started with Go"`
+	Slug          string   `json:"slug" maxLength:"100" sanitize:"trim,lower" validate:"maxrunes=100,slug" example:"getting-started-with-go"`
+	Content       string   `json:"content" binding:"required" sanitize:"nfc,stripctl" validate:"required,maxbytes=1048576" example:"Go is a programming language developed by Google. It's designed to be simple, efficient, and reliable. In this post, we'll explore the basics of Go programming and why it's becoming increasingly popular among developers."`
+	ContentFormat string   `json:"content_format" enums:"plain,markdown" default:"plain" sanitize:"trim" validate:"oneof=plain markdown" example:"markdown"`
+	Author        string   `json:"author" maxLength:"100" sanitize:"nfc,singleline,trim" validate:"requiredwithout=author_id,maxrunes=100" example:"John Doe"`
+	AuthorID      string   `json:"author_id" maxLength:"64" sanitize:"trim" validate:"maxbytes=64" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Tags          []string `json:"tags" maxItems:"10" sanitize:"nfc,singleline,trim,lower" validate:"maxitems=10,maxrunes=50,pathsegment" example:"go,tutorial"`
+	Status        string   `json:"status" enums:"draft,published" default:"published" sanitize:"trim,lower" validate:"oneof=draft published" example:"published"`
 }
 
-// BlogPostUpdate represents the request body for updating a blog post
+// BlogPostUpdate represents the request body for updating a blog post, the
+// author is given like for BlogPostCreate
 type BlogPostUpdate struct {
-	Title   string `json:"title" binding:"required" example:"Advanced Go Programming Techniques"`
-	Content string `json:"content" binding:"required" example:"Building on the fundamentals, this post explores advanced Go programming patterns including goroutines, channels, and interfaces. We'll look at practical examples of concurrent programming and best practices for writing efficient Go code."`
-	Author  string `json:"author" binding:"required" example:"Jane Smith"`
+	Title         string   `json:"title" binding:"required" maxLength:"200" sanitize:"nfc,singleline,trim" validate:"required,maxrunes=200" example:"Advanced Go Programming Techniques"`
+	Slug          string   `json:"slug" maxLength:"100" sanitize:"trim,lower" validate:"maxrunes=100,slug" example:"getting-started-with-go"`
+	Content       string   `json:"content" binding:"required" sanitize:"nfc,stripctl" validate:"required,maxbytes=1048576" example:"Building on the fundamentals, this post explores advanced Go programming patterns including goroutines, channels, and interfaces. We'll look at practical examples of concurrent programming and best practices for writing efficient Go code."`
+	ContentFormat string   `json:"content_format" enums:"plain,markdown" default:"plain" sanitize:"trim" validate:"oneof=plain markdown" example:"markdown"`
+	Author        string   `json:"author" maxLength:"100" sanitize:"nfc,singleline,trim" validate:"requiredwithout=author_id,maxrunes=100" example:"Jane Smith"`
+	AuthorID      string   `json:"author_id" maxLength:"64" sanitize:"trim" validate:"maxbytes=64" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Tags          []string `json:"tags" maxItems:"10" sanitize:"nfc,singleline,trim,lower" validate:"maxitems=10,maxrunes=50,pathsegment" example:"go,tutorial"`
+	Status        string   `json:"status" enums:"draft,published" default:"published" sanitize:"trim,lower" validate:"oneof=draft published" example:"published"`
+}
+
+// CreateBody returns the client-editable fields of the post
+func (p BlogPost) CreateBody() BlogPostCreate {
+	return BlogPostCreate{Title: p.Title, Slug: p.Slug, Content: p.Content, ContentFormat: p.ContentFormat, Author: p.Author, AuthorID: p.AuthorID, Tags: p.Tags, Status: p.Status}
+}
+
+// UpdateBody returns the client-editable fields of the post
+func (p BlogPost) UpdateBody() BlogPostUpdate {
+	return BlogPostUpdate{Title: p.Title, Slug: p.Slug, Content: p.Content, ContentFormat: p.ContentFormat, Author: p.Author, AuthorID: p.AuthorID, Tags: p.Tags, Status: p.Status}
+}
+
+// ToBlogPost converts the request body into a blog post without an ID
+func (b BlogPostCreate) ToBlogPost() BlogPost {
+	return BlogPost{Title: b.Title, Slug: b.Slug, Content: b.Content, ContentFormat: b.ContentFormat, Author: b.Author, AuthorID: b.AuthorID, Tags: b.Tags, Status: b.Status}
+}
+
+// ToBlogPost converts the request body into a blog post without an ID
+func (b BlogPostUpdate) ToBlogPost() BlogPost {
+	return BlogPost{Title: b.Title, Slug: b.Slug, Content: b.Content, ContentFormat: b.ContentFormat, Author: b.Author, AuthorID: b.AuthorID, Tags: b.Tags, Status: b.Status}
 }
 
 // BlogPostResponse represents the response structure for blog post operations
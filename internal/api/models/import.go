@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// BlogPostImport represents a line of an NDJSON import. Lines written by
+// the export are accepted as is: the server-managed version, updated_at and
+// attachments fields are ignored, while the ID and the creation and
+// publication dates are kept. The files of the attachments are not part of
+// the export. Authors are found by name, or created, since their IDs differ
+// from a server to another: author_id is ignored.
+type BlogPostImport struct {
+	ID            string     `json:"id" format:"uuid" sanitize:"trim,lower" validate:"uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title         string     `json:"title" binding:"required" maxLength:"200" sanitize:"nfc,singleline,trim" validate:"required,maxrunes=200" example:"Getting Started with Go"`
+	Slug          string     `json:"slug" maxLength:"100" sanitize:"trim,lower" validate:"maxrunes=100,slug" example:"getting-started-with-go"`
+	Content       string     `json:"content" binding:"required" sanitize:"nfc,stripctl" validate:"required,maxbytes=1048576" example:"Go is a programming language developed by Google..."`
+	ContentFormat string     `json:"content_format" enums:"plain,markdown" default:"plain" sanitize:"trim" validate:"oneof=plain markdown" example:"markdown"`
+	Author        string     `json:"author" binding:"required" maxLength:"100" sanitize:"nfc,singleline,trim" validate:"required,maxrunes=100" example:"John Doe"`
+	Tags          []string   `json:"tags" maxItems:"10" sanitize:"nfc,singleline,trim,lower" validate:"maxitems=10,maxrunes=50,pathsegment" example:"go,tutorial"`
+	Status        string     `json:"status" enums:"draft,published" default:"published" sanitize:"trim,lower" validate:"oneof=draft published" example:"published"`
+	CreatedAt     time.Time  `json:"created_at" example:"2025-01-01T10:00:00Z"`
+	PublishedAt   *time.Time `json:"published_at" example:"2025-01-01T10:00:00Z"`
+	Version       int        `json:"version" swaggerignore:"true"`
+	UpdatedAt     time.Time  `json:"updated_at" swaggerignore:"true"`
+	Attachments   []any      `json:"attachments" swaggerignore:"true"`
+	AuthorID      string     `json:"author_id" swaggerignore:"true"`
+}
+
+// ToBlogPost converts the import line into a blog post, the ID is empty
+// when the line has none
+func (b BlogPostImport) ToBlogPost() BlogPost {
+	return BlogPost{
+		ID:            b.ID,
+		Title:         b.Title,
+		Slug:          b.Slug,
+		Content:       b.Content,
+		ContentFormat: b.ContentFormat,
+		Author:        b.Author,
+		Tags:          b.Tags,
+		Status:        b.Status,
+		CreatedAt:     b.CreatedAt,
+		PublishedAt:   b.PublishedAt,
+	}
+}
+
+// Outcomes of an imported line
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportInvalid = "invalid"
+	ImportFailed  = "failed"
+)
+
+// ImportResult reports what happened to a line of an import
+type ImportResult struct {
+	Line          int            `json:"line" example:"1"`
+	ID            string         `json:"id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Result        string         `json:"result" enums:"created,updated,skipped,invalid,failed" example:"created"`
+	Error         string         `json:"error,omitempty" example:"blog post has invalid fields"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// ImportSummary counts the lines of an import by result
+type ImportSummary struct {
+	Lines   int `json:"lines" example:"3"`
+	Created int `json:"created" example:"1"`
+	Updated int `json:"updated" example:"1"`
+	Skipped int `json:"skipped" example:"0"`
+	Invalid int `json:"invalid" example:"1"`
+	Failed  int `json:"failed" example:"0"`
+	// Error is set when the import stopped before the end of the stream
+	Error string `json:"error,omitempty" example:""`
+}
+
+// Add counts a line result
+func (s *ImportSummary) Add(r ImportResult) {
+	s.Lines++
+	switch r.Result {
+	case ImportCreated:
+		s.Created++
+	case ImportUpdated:
+		s.Updated++
+	case ImportSkipped:
+		s.Skipped++
+	case ImportInvalid:
+		s.Invalid++
+	case ImportFailed:
+		s.Failed++
+	}
+}
+
+// ImportReport represents the response of an import. The results are
+// streamed as the lines are processed.
+type ImportReport struct {
+	DryRun  bool           `json:"dry_run" example:"false"`
+	Results []ImportResult `json:"results"`
+	Summary ImportSummary  `json:"summary"`
+}
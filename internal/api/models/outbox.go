@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// OutboxEvent is a change of a blog post recorded in the outbox of the
+// repository, in the transaction of the change itself
+type OutboxEvent struct {
+	ID string
+	// Sequence orders the events of an outbox, it is set by the repository
+	Sequence   int64
+	Type       string
+	PostID     string
+	Post       *BlogPost
+	OccurredAt time.Time
+}
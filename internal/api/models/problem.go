@@ -0,0 +1,18 @@
+package models
+
+// Problem represents an RFC 7807 problem details response
+// served with the application/problem+json content type
+type Problem struct {
+	Type          string         `json:"type" example:"/problems/validation-error"`
+	Title         string         `json:"title" example:"Validation failed"`
+	Status        int            `json:"status" example:"400"`
+	Detail        string         `json:"detail,omitempty" example:"blog post has invalid fields"`
+	Instance      string         `json:"instance,omitempty" example:"/api/v1/posts"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// InvalidParam describes why a single request field was rejected
+type InvalidParam struct {
+	Name   string `json:"name" example:"title"`
+	Reason string `json:"reason" example:"is required"`
+}
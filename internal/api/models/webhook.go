@@ -0,0 +1,138 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Delivery states of a webhook event, dead deliveries exhausted their
+// attempts and are only sent again when redelivered
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// Webhook represents a subscription of an HTTP endpoint to post events
+type Webhook struct {
+	ID          string   `json:"id" example:"9b2f6c1e-3d4a-4f5b-8c6d-7e8f9a0b1c2d"`
+	URL         string   `json:"url" example:"https://hooks.example.com/blog"`
+	Events      []string `json:"events" example:"post.published,post.deleted"`
+	Description string   `json:"description" example:"Search indexer"`
+	// Active webhooks receive events, inactive ones are kept but skipped
+	Active bool `json:"active" example:"true"`
+	// Secret signs the deliveries, it is only returned on creation
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at" example:"2025-01-01T10:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2025-01-02T10:00:00Z"`
+}
+
+// WebhookCreated represents a created webhook along with its signing secret
+type WebhookCreated struct {
+	Webhook
+	Secret string `json:"secret" example:"whsec_4f9d2c0b7a1e4b8f9c3d6e2a5b7c9d1f"`
+}
+
+// WebhookCreate represents the request body for creating a webhook
+type WebhookCreate struct {
+	URL         string   `json:"url" binding:"required" maxLength:"2048" sanitize:"trim" validate:"required,maxbytes=2048,httpurl" example:"https://hooks.example.com/blog"`
+	Events      []string `json:"events" binding:"required" enums:"post.created,post.updated,post.deleted,post.published" sanitize:"trim,lower" validate:"required,maxitems=4,oneof=post.created post.updated post.deleted post.published" example:"post.published,post.deleted"`
+	Description string   `json:"description" maxLength:"200" sanitize:"nfc,singleline,trim" validate:"maxrunes=200" example:"Search indexer"`
+	// Secret signs the deliveries, a random one is generated unless set
+	Secret string `json:"secret" minLength:"16" maxLength:"256" validate:"minbytes=16,maxbytes=256" example:"whsec_4f9d2c0b7a1e4b8f9c3d6e2a5b7c9d1f"`
+	Active *bool  `json:"active" default:"true" example:"true"`
+}
+
+// WebhookUpdate represents the request body for updating a webhook
+type WebhookUpdate struct {
+	URL         string   `json:"url" binding:"required" maxLength:"2048" sanitize:"trim" validate:"required,maxbytes=2048,httpurl" example:"https://hooks.example.com/blog"`
+	Events      []string `json:"events" binding:"required" enums:"post.created,post.updated,post.deleted,post.published" sanitize:"trim,lower" validate:"required,maxitems=4,oneof=post.created post.updated post.deleted post.published" example:"post.published"`
+	Description string   `json:"description" maxLength:"200" sanitize:"nfc,singleline,trim" validate:"maxrunes=200" example:"Search indexer"`
+	// Secret rotates the signing secret, the current one is kept unless set
+	Secret string `json:"secret" minLength:"16" maxLength:"256" validate:"minbytes=16,maxbytes=256" example:"whsec_0a1b2c3d4e5f60718293a4b5c6d7e8f9"`
+	Active *bool  `json:"active" default:"true" example:"false"`
+}
+
+// ToWebhook converts the request body into a webhook without an ID
+func (b WebhookCreate) ToWebhook() Webhook {
+	return Webhook{URL: b.URL, Events: b.Events, Description: b.Description, Secret: b.Secret, Active: b.Active == nil || *b.Active}
+}
+
+// ToWebhook converts the request body into a webhook without an ID
+func (b WebhookUpdate) ToWebhook() Webhook {
+	return Webhook{URL: b.URL, Events: b.Events, Description: b.Description, Secret: b.Secret, Active: b.Active == nil || *b.Active}
+}
+
+// WebhookEvent represents the JSON body POSTed to webhooks. The ID is the
+// same for every webhook receiving the event, so receivers can use it to
+// discard duplicates.
+type WebhookEvent struct {
+	ID         string           `json:"id" example:"0f8e6a3c-5b2d-4e1f-9a7c-6d5e4f3a2b1c"`
+	Type       string           `json:"type" enums:"post.created,post.updated,post.deleted,post.published" example:"post.published"`
+	OccurredAt time.Time        `json:"occurred_at" example:"2025-01-02T10:00:00Z"`
+	Data       WebhookEventData `json:"data"`
+}
+
+// WebhookEventData holds the post an event is about, the post is omitted
+// for deletions
+type WebhookEventData struct {
+	PostID string    `json:"post_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Post   *BlogPost `json:"post,omitempty"`
+}
+
+// WebhookDelivery represents the delivery of an event to a webhook along
+// with the log of its attempts
+type WebhookDelivery struct {
+	ID        string `json:"id" example:"c3d4e5f6-a7b8-4c9d-8e0f-1a2b3c4d5e6f"`
+	WebhookID string `json:"webhook_id" example:"9b2f6c1e-3d4a-4f5b-8c6d-7e8f9a0b1c2d"`
+	EventID   string `json:"event_id" example:"0f8e6a3c-5b2d-4e1f-9a7c-6d5e4f3a2b1c"`
+	Event     string `json:"event" example:"post.published"`
+	Status    string `json:"status" enums:"pending,succeeded,dead" example:"pending"`
+	// Payload is the WebhookEvent sent as the request body
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	// Failures counts the failed attempts since the delivery was queued or
+	// redelivered
+	Failures int `json:"failures" example:"1"`
+	// NextAttemptAt is set while the delivery is pending
+	NextAttemptAt *time.Time       `json:"next_attempt_at,omitempty" example:"2025-01-02T10:00:02Z"`
+	Attempts      []WebhookAttempt `json:"attempts"`
+	CreatedAt     time.Time        `json:"created_at" example:"2025-01-02T10:00:00Z"`
+	UpdatedAt     time.Time        `json:"updated_at" example:"2025-01-02T10:00:01Z"`
+}
+
+// WebhookAttempt represents a single attempt to deliver an event, with
+// the response status or the error that made it fail
+type WebhookAttempt struct {
+	At         time.Time `json:"at" example:"2025-01-02T10:00:01Z"`
+	StatusCode int       `json:"status_code,omitempty" example:"503"`
+	Error      string    `json:"error,omitempty" example:"unexpected status 503"`
+	DurationMs int64     `json:"duration_ms" example:"120"`
+}
+
+// DeliveryFilter selects webhook deliveries by webhook and/or status,
+// empty fields match any delivery
+type DeliveryFilter struct {
+	WebhookID string
+	Status    string
+}
+
+// Matches tells whether the delivery passes the filter
+func (f DeliveryFilter) Matches(d *WebhookDelivery) bool {
+	if f.WebhookID != "" && d.WebhookID != f.WebhookID {
+		return false
+	}
+	if f.Status != "" && d.Status != f.Status {
+		return false
+	}
+	return true
+}
+
+// CreateBody returns the client-editable fields of the webhook
+func (w Webhook) CreateBody() WebhookCreate {
+	return WebhookCreate{URL: w.URL, Events: w.Events, Description: w.Description, Secret: w.Secret, Active: &w.Active}
+}
+
+// UpdateBody returns the client-editable fields of the webhook
+func (w Webhook) UpdateBody() WebhookUpdate {
+	return WebhookUpdate{URL: w.URL, Events: w.Events, Description: w.Description, Secret: w.Secret, Active: &w.Active}
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"blog-posts-api/internal/api/models"
+	"context"
+)
+
+// AuthorRepo stores the authors of blog posts. Names are unique, since
+// posts may refer to their author by name: Create and Update fail with a
+// conflict error when another author has the name.
+type AuthorRepo interface {
+	Create(ctx context.Context, author *models.Author) (*models.Author, error)
+	GetAll(ctx context.Context) ([]*models.Author, error)
+	GetById(ctx context.Context, id string) (*models.Author, error)
+	GetByName(ctx context.Context, name string) (*models.Author, error)
+	Update(ctx context.Context, id string, updated *models.Author) (*models.Author, error)
+	Delete(ctx context.Context, id string) error
+}
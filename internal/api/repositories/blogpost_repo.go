@@ -5,6 +5,10 @@ import (
 	"context"
 )
 
+// BlogPostRepo stores blog posts. Implementations set the version of a
+// created post to 1 and increment it on every update, since cached
+// derived data (e.g. rendered HTML) is keyed by version. Update keeps the
+// creation time of the stored post.
 type BlogPostRepo interface {
 	Create(ctx context.Context, post *models.BlogPost) (*models.BlogPost, error)
 	GetAll(ctx context.Context) ([]*models.BlogPost, error)
@@ -12,3 +16,53 @@ type BlogPostRepo interface {
 	Update(ctx context.Context, id string, updated *models.BlogPost) (*models.BlogPost, error)
 	Delete(ctx context.Context, id string) error
 }
+
+// FilteredBlogPostRepo is a BlogPostRepo able to select the posts matching
+// a filter itself, rather than returning every post to filter. A SQL
+// backend implements it with a WHERE clause over indexed columns.
+type FilteredBlogPostRepo interface {
+	BlogPostRepo
+	// Find returns the posts matching the filter, in no particular order
+	Find(ctx context.Context, filter models.PostFilter) ([]*models.BlogPost, error)
+}
+
+// SlugBlogPostRepo is a BlogPostRepo able to look a post up by its slug,
+// which the service does on every write to keep slugs unique. A SQL
+// backend implements it with an index on the slug column.
+type SlugBlogPostRepo interface {
+	BlogPostRepo
+	// GetBySlug returns a post with the slug, or a not found error
+	GetBySlug(ctx context.Context, slug string) (*models.BlogPost, error)
+}
+
+// TxBlogPostRepo is a BlogPostRepo able to apply several changes
+// atomically. A SQL backend implements it with a database transaction.
+type TxBlogPostRepo interface {
+	BlogPostRepo
+	// WithinTx runs fn against a repository whose changes are committed
+	// when fn returns nil and rolled back when it returns an error. Other
+	// callers never observe a partially applied transaction.
+	WithinTx(ctx context.Context, fn func(tx BlogPostRepo) error) error
+}
+
+// OutboxRepo is a TxBlogPostRepo recording the events of the changes made
+// in its transactions in an outbox, so that no event is lost if the process
+// stops right after a commit. The repository passed to WithinTx implements
+// OutboxTx. A SQL backend writes the events to an outbox table within the
+// transaction of the change.
+type OutboxRepo interface {
+	TxBlogPostRepo
+	// PendingEvents returns up to limit events not published yet, in the
+	// order they were recorded
+	PendingEvents(ctx context.Context, limit int) ([]*models.OutboxEvent, error)
+	// MarkPublished removes published events from the outbox
+	MarkPublished(ctx context.Context, ids ...string) error
+}
+
+// OutboxTx is the repository of an OutboxRepo transaction
+type OutboxTx interface {
+	BlogPostRepo
+	// AddEvents records events in the outbox, they become pending once the
+	// transaction commits
+	AddEvents(ctx context.Context, events ...*models.OutboxEvent) error
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"blog-posts-api/internal/api/models"
+	"context"
+	"time"
+)
+
+// UserRepo stores the user accounts. Email addresses are unique: Create
+// fails with a conflict error when another user has the address.
+type UserRepo interface {
+	Create(ctx context.Context, user *models.User) (*models.User, error)
+	GetById(ctx context.Context, id string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Update(ctx context.Context, id string, updated *models.User) (*models.User, error)
+}
+
+// TokenRepo stores the tokens handed to users, by the hash of the token
+type TokenRepo interface {
+	Create(ctx context.Context, token *models.UserToken) error
+	// Get returns a token, expired or not, or a not found error
+	Get(ctx context.Context, hash string) (*models.UserToken, error)
+	Delete(ctx context.Context, hash string) error
+	// DeleteUser deletes the tokens of a user with the given purpose
+	DeleteUser(ctx context.Context, userID, purpose string) error
+	// DeleteExpired deletes the tokens expired at now
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"blog-posts-api/internal/api/models"
+	"context"
+	"time"
+)
+
+// WebhookRepo stores webhooks and the deliveries of events to them.
+// Deleting a webhook deletes its deliveries. Implementations may drop the
+// oldest finished deliveries to bound their size, never pending ones.
+type WebhookRepo interface {
+	Create(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	GetAll(ctx context.Context) ([]*models.Webhook, error)
+	GetById(ctx context.Context, id string) (*models.Webhook, error)
+	Update(ctx context.Context, id string, updated *models.Webhook) (*models.Webhook, error)
+	Delete(ctx context.Context, id string) error
+
+	// AddDeliveries stores new deliveries, skipping those of an event the
+	// webhook already has a delivery of
+	AddDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ListDeliveries returns the deliveries matching the filter, latest first
+	ListDeliveries(ctx context.Context, filter models.DeliveryFilter) ([]*models.WebhookDelivery, error)
+	// DueDeliveries returns up to limit pending deliveries whose next
+	// attempt is due at now, earliest first
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error)
+}
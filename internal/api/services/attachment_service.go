@@ -0,0 +1,505 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/repositories"
+	"blog-posts-api/internal/blob"
+	"blog-posts-api/internal/imagemeta"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+)
+
+// ErrAttachmentNotFound is returned for attachments missing from their post
+var ErrAttachmentNotFound = apperrors.NotFound("attachment not found")
+
+// ErrVariantNotFound is returned for variants an attachment does not have,
+// including those of images not processed yet
+var ErrVariantNotFound = apperrors.NotFound("variant not found")
+
+// DefaultAttachmentTypes are the content types accepted unless configured.
+// SVG images are left out since they can carry scripts.
+var DefaultAttachmentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf"}
+
+// sniffBytes is the number of leading bytes the content type is sniffed from
+const sniffBytes = 3072
+
+// maxFilenameRunes bounds the stored file names
+const maxFilenameRunes = 255
+
+// AttachmentOptions configures the attachments of blog posts
+type AttachmentOptions struct {
+	// MaxBytes bounds the size of a file
+	MaxBytes int64
+	// Types lists the accepted content types, DefaultAttachmentTypes when
+	// empty
+	Types []string
+	// URLPrefix is the path of the posts the URL of an attachment is
+	// built from, e.g. /api/v1/posts
+	URLPrefix string
+	// ImageTypes lists the content types of the images queued to be
+	// processed into variants, none are when empty
+	ImageTypes []string
+}
+
+// ImageJob identifies an image attachment waiting for its variants
+type ImageJob struct {
+	PostID       string
+	AttachmentID string
+}
+
+// AttachmentService stores the files uploaded to blog posts in a blob
+// store, and their metadata on the posts
+type AttachmentService struct {
+	posts *BlogPostService
+	store blob.Store
+	opts  AttachmentOptions
+
+	// mu guards the queue of the images to process
+	mu      sync.Mutex
+	pending []ImageJob
+	inQueue map[ImageJob]bool
+	queued  chan struct{}
+}
+
+func NewAttachmentService(posts *BlogPostService, store blob.Store, opts AttachmentOptions) *AttachmentService {
+	if len(opts.Types) == 0 {
+		opts.Types = DefaultAttachmentTypes
+	}
+	return &AttachmentService{
+		posts:   posts,
+		store:   store,
+		opts:    opts,
+		inQueue: map[ImageJob]bool{},
+		queued:  make(chan struct{}, 1),
+	}
+}
+
+// MaxBytes returns the maximum size of a file
+func (s *AttachmentService) MaxBytes() int64 {
+	return s.opts.MaxBytes
+}
+
+// blobKey returns the key of the file of an attachment, under the prefix
+// of the files of its post
+func blobKey(postID, id string) string {
+	return postFilesPrefix(postID) + "/" + id
+}
+
+func postFilesPrefix(postID string) string {
+	return "posts/" + postID
+}
+
+// variantKey returns the key of the file of a variant, next to the one of
+// its attachment
+func variantKey(postID, id, name string) string {
+	return blobKey(postID, id) + "-" + name
+}
+
+// Upload stores a file and attaches it to a post, as a new version of the
+// post. The content type is sniffed from the content and must be one of
+// the accepted types. Images are stored without their metadata.
+func (s *AttachmentService) Upload(ctx context.Context, postID, filename string, content io.Reader) (*models.Attachment, error) {
+	if _, err := s.posts.GetById(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffBytes)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, apperrors.BadRequest("failed to read the file", err)
+	}
+	head = head[:n]
+	if n == 0 {
+		return nil, apperrors.Validation("attachment is invalid", apperrors.FieldError{Field: "file", Reason: "is required"})
+	}
+	contentType, ok := s.acceptedType(mimetype.Detect(head))
+	if !ok {
+		return nil, apperrors.Validation("attachment is invalid", apperrors.FieldError{
+			Field:  "file",
+			Reason: "must be one of: " + strings.Join(s.opts.Types, ", "),
+		})
+	}
+
+	attachment := &models.Attachment{
+		ID:          uuid.New().String(),
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		CreatedAt:   time.Now().UTC(),
+	}
+	attachment.URL = s.opts.URLPrefix + "/" + postID + "/attachments/" + attachment.ID
+	if slices.Contains(s.opts.ImageTypes, contentType) {
+		attachment.Processing = models.ProcessingPending
+	}
+
+	hash := sha256.New()
+	limited := &limitReader{r: io.MultiReader(bytes.NewReader(head), content), remaining: s.opts.MaxBytes}
+	stored, wait := stripMetadata(limited, contentType)
+	key := blobKey(postID, attachment.ID)
+	attachment.Size, err = s.store.Put(ctx, key, io.TeeReader(stored, hash))
+	wait()
+	if err != nil {
+		if limited.exceeded {
+			return nil, apperrors.Validation("attachment is invalid", apperrors.FieldError{
+				Field:  "file",
+				Reason: fmt.Sprintf("must be at most %d bytes long", s.opts.MaxBytes),
+			})
+		}
+		if limited.err != nil {
+			return nil, apperrors.BadRequest("failed to read the file", limited.err)
+		}
+		if errors.Is(err, imagemeta.ErrMalformed) {
+			return nil, apperrors.Validation("attachment is invalid", apperrors.FieldError{Field: "file", Reason: "must be a well-formed image"})
+		}
+		return nil, fmt.Errorf("failed to store the attachment: %w", err)
+	}
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	if err := s.posts.addAttachment(ctx, postID, *attachment); err != nil {
+		// the post may have been deleted meanwhile
+		s.deleteFile(key)
+		return nil, err
+	}
+	if attachment.Processing == models.ProcessingPending {
+		s.enqueue(ImageJob{PostID: postID, AttachmentID: attachment.ID})
+	}
+	return attachment, nil
+}
+
+// stripMetadata returns the content of an image without its metadata, e.g.
+// the GPS position of a photo, since the original is served as stored.
+// Other content is returned as is. wait returns once content is no longer
+// read, after the returned reader is read or abandoned.
+func stripMetadata(content io.Reader, contentType string) (stripped io.Reader, wait func()) {
+	if !imagemeta.Strips(contentType) {
+		return content, func() {}
+	}
+	r, w := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := imagemeta.Strip(w, content, contentType)
+		if err == nil {
+			// what follows the image is dropped, but still counts towards
+			// the size of the file
+			_, err = io.Copy(io.Discard, content)
+		}
+		w.CloseWithError(err)
+	}()
+	return r, func() {
+		// unblocks the writes of an abandoned stripping
+		r.Close()
+		<-done
+	}
+}
+
+// acceptedType returns the accepted type a sniffed type is, or one of its
+// aliases or parents, e.g. image/jpeg
+func (s *AttachmentService) acceptedType(detected *mimetype.MIME) (string, bool) {
+	for m := detected; m != nil; m = m.Parent() {
+		for _, t := range s.opts.Types {
+			if m.Is(t) {
+				mediaType, _, err := mime.ParseMediaType(t)
+				return mediaType, err == nil
+			}
+		}
+	}
+	return "", false
+}
+
+// cleanFilename keeps the base name of an uploaded file name, without
+// control characters and with a bounded length
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" {
+		name = ""
+	}
+	if utf8.RuneCountInString(name) > maxFilenameRunes {
+		name = string([]rune(name)[:maxFilenameRunes])
+	}
+	return strings.TrimSpace(name)
+}
+
+// List returns the attachments of a post, oldest first
+func (s *AttachmentService) List(ctx context.Context, postID string) ([]models.Attachment, error) {
+	post, err := s.posts.GetById(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	return post.Attachments, nil
+}
+
+// Get returns an attachment of a post
+func (s *AttachmentService) Get(ctx context.Context, postID, id string) (*models.Attachment, error) {
+	post, err := s.posts.GetById(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(post.Attachments, func(a models.Attachment) bool { return a.ID == id })
+	if i < 0 {
+		return nil, ErrAttachmentNotFound
+	}
+	a := post.Attachments[i]
+	return &a, nil
+}
+
+// Open returns an attachment of a post along with its file, which the
+// caller must close
+func (s *AttachmentService) Open(ctx context.Context, postID, id string) (*models.Attachment, *blob.Blob, error) {
+	attachment, err := s.Get(ctx, postID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := s.store.Open(ctx, blobKey(postID, id))
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open the attachment: %w", err)
+	}
+	return attachment, file, nil
+}
+
+// OpenVariant returns an attachment and one of its variants along with
+// the file of the variant, which the caller must close
+func (s *AttachmentService) OpenVariant(ctx context.Context, postID, id, name string) (*models.Attachment, *models.AttachmentVariant, *blob.Blob, error) {
+	attachment, err := s.Get(ctx, postID, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	i := slices.IndexFunc(attachment.Variants, func(v models.AttachmentVariant) bool { return v.Name == name })
+	if i < 0 {
+		return nil, nil, nil, ErrVariantNotFound
+	}
+	file, err := s.store.Open(ctx, variantKey(postID, id, name))
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open the variant: %w", err)
+	}
+	return attachment, &attachment.Variants[i], file, nil
+}
+
+// Delete detaches a file from a post, as a new version of the post, and
+// removes it from the store along with its variants
+func (s *AttachmentService) Delete(ctx context.Context, postID, id string) error {
+	removed, err := s.posts.removeAttachment(ctx, postID, id)
+	if err != nil {
+		return err
+	}
+	s.deleteFile(blobKey(postID, id))
+	s.deleteVariants(postID, id, removed.Variants)
+	return nil
+}
+
+func (s *AttachmentService) deleteVariants(postID, id string, variants []models.AttachmentVariant) {
+	for _, v := range variants {
+		s.deleteFile(variantKey(postID, id, v.Name))
+	}
+}
+
+// QueuedImages receives a value when images were queued, so that a
+// processor waiting for the next poll can start right away
+func (s *AttachmentService) QueuedImages() <-chan struct{} {
+	return s.queued
+}
+
+// NextImages removes up to limit images from the queue, oldest first
+func (s *AttachmentService) NextImages(limit int) []ImageJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := min(limit, len(s.pending))
+	jobs := slices.Clone(s.pending[:n])
+	s.pending = slices.Delete(s.pending, 0, n)
+	for _, job := range jobs {
+		delete(s.inQueue, job)
+	}
+	return jobs
+}
+
+// RequeuePending queues the images the stored posts still have pending,
+// e.g. those of a previous run of the server
+func (s *AttachmentService) RequeuePending(ctx context.Context) error {
+	return s.posts.Export(ctx, func(post *models.BlogPost) error {
+		for _, a := range post.Attachments {
+			if a.Processing == models.ProcessingPending {
+				s.enqueue(ImageJob{PostID: post.ID, AttachmentID: a.ID})
+			}
+		}
+		return nil
+	})
+}
+
+func (s *AttachmentService) enqueue(job ImageJob) {
+	s.mu.Lock()
+	if !s.inQueue[job] {
+		s.inQueue[job] = true
+		s.pending = append(s.pending, job)
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+}
+
+// StoreVariant stores a variant of an image along with its checksum, and
+// returns its metadata for FinishProcessing
+func (s *AttachmentService) StoreVariant(ctx context.Context, job ImageJob, name, contentType string, width, height int, content io.Reader) (*models.AttachmentVariant, error) {
+	hash := sha256.New()
+	size, err := s.store.Put(ctx, variantKey(job.PostID, job.AttachmentID, name), io.TeeReader(content, hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store the %s variant: %w", name, err)
+	}
+	return &models.AttachmentVariant{
+		Name:        name,
+		ContentType: contentType,
+		Width:       width,
+		Height:      height,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		URL:         s.opts.URLPrefix + "/" + job.PostID + "/attachments/" + job.AttachmentID + "/variants/" + name,
+	}, nil
+}
+
+// FinishProcessing records the variants of an image as a new version of
+// the post, or that it could not be processed when procErr is set. The
+// message of procErr is shown to clients. The given variants are removed
+// from the store when they are not recorded: on failure, or when the
+// attachment was deleted meanwhile.
+func (s *AttachmentService) FinishProcessing(ctx context.Context, job ImageJob, variants []models.AttachmentVariant, procErr error) error {
+	if procErr != nil {
+		s.deleteVariants(job.PostID, job.AttachmentID, variants)
+		variants = nil
+	}
+	err := s.posts.editAttachment(ctx, job.PostID, job.AttachmentID, func(a *models.Attachment) {
+		a.Variants = variants
+		a.Processing = models.ProcessingSucceeded
+		a.ProcessingError = ""
+		if procErr != nil {
+			a.Processing = models.ProcessingFailed
+			a.ProcessingError = procErr.Error()
+		}
+	})
+	if err != nil && procErr == nil {
+		s.deleteVariants(job.PostID, job.AttachmentID, variants)
+	}
+	return err
+}
+
+// deleteFile removes a file no post refers to, a failure only leaves an
+// orphan file behind
+func (s *AttachmentService) deleteFile(key string) {
+	if err := s.store.Delete(context.Background(), key); err != nil {
+		log.Printf("failed to delete the attachment %s: %v", key, err)
+	}
+}
+
+// Notify removes the files of deleted posts in the background, it is
+// meant to be subscribed to the blog post service
+func (s *AttachmentService) Notify(e Event) {
+	if e.Type != EventPostDeleted {
+		return
+	}
+	go func() {
+		if err := s.store.DeletePrefix(context.Background(), postFilesPrefix(e.PostID)); err != nil {
+			log.Printf("failed to delete the attachments of post %s: %v", e.PostID, err)
+		}
+	}()
+}
+
+// addAttachment appends an attachment to the stored post
+func (s *BlogPostService) addAttachment(ctx context.Context, postID string, attachment models.Attachment) error {
+	return s.editAttachments(ctx, postID, func(attachments []models.Attachment) ([]models.Attachment, error) {
+		return append(slices.Clone(attachments), attachment), nil
+	})
+}
+
+// removeAttachment removes an attachment from the stored post and returns
+// it
+func (s *BlogPostService) removeAttachment(ctx context.Context, postID, id string) (models.Attachment, error) {
+	var removed models.Attachment
+	err := s.editAttachments(ctx, postID, func(attachments []models.Attachment) ([]models.Attachment, error) {
+		i := slices.IndexFunc(attachments, func(a models.Attachment) bool { return a.ID == id })
+		if i < 0 {
+			return nil, ErrAttachmentNotFound
+		}
+		removed = attachments[i]
+		return slices.Delete(slices.Clone(attachments), i, i+1), nil
+	})
+	return removed, err
+}
+
+// editAttachment changes an attachment of the stored post
+func (s *BlogPostService) editAttachment(ctx context.Context, postID, id string, edit func(*models.Attachment)) error {
+	return s.editAttachments(ctx, postID, func(attachments []models.Attachment) ([]models.Attachment, error) {
+		i := slices.IndexFunc(attachments, func(a models.Attachment) bool { return a.ID == id })
+		if i < 0 {
+			return nil, ErrAttachmentNotFound
+		}
+		attachments = slices.Clone(attachments)
+		edit(&attachments[i])
+		return attachments, nil
+	})
+}
+
+// editAttachments stores a new version of a post with its attachments
+// changed by edit, which must not modify the slice it is given
+func (s *BlogPostService) editAttachments(ctx context.Context, postID string, edit func([]models.Attachment) ([]models.Attachment, error)) error {
+	return s.editPost(ctx, postID, func(_ repositories.BlogPostRepo, post *models.BlogPost) error {
+		attachments, err := edit(post.Attachments)
+		post.Attachments = attachments
+		return err
+	})
+}
+
+// limitReader fails once more than remaining bytes are read. It keeps the
+// errors of the underlying reader to tell them from those of the store.
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+	err       error
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	// read one byte past the limit to tell a file of exactly the limit
+	// from a larger one
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		l.exceeded = true
+		return n, errors.New("file too large")
+	}
+	if err != nil && err != io.EOF {
+		l.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/blob"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+// pdfContent is the start of a PDF document, enough to sniff it
+var pdfContent = []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+
+func newTestAttachmentService(t *testing.T, maxBytes int64) (*AttachmentService, *BlogPostService) {
+	t.Helper()
+	posts := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create the store: %v", err)
+	}
+	if _, err := posts.Create(context.Background(), &models.BlogPost{ID: "1", Title: "Post", Content: "content", Author: "Ann"}); err != nil {
+		t.Fatalf("failed to create the post: %v", err)
+	}
+	return NewAttachmentService(posts, store, AttachmentOptions{MaxBytes: maxBytes, URLPrefix: "/api/v1/posts"}), posts
+}
+
+func TestAttachmentService_Upload(t *testing.T) {
+	service, posts := newTestAttachmentService(t, int64(len(pdfContent)))
+	var events []Event
+	posts.Subscribe(func(e Event) { events = append(events, e) })
+
+	// a file of exactly the limit is accepted
+	attachment, err := service.Upload(context.Background(), "1", "report.pdf", bytes.NewReader(pdfContent))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if attachment.ContentType != "application/pdf" || attachment.URL != "/api/v1/posts/1/attachments/"+attachment.ID {
+		t.Errorf("expected a PDF attachment, got %+v", attachment)
+	}
+	if len(events) != 1 || events[0].Type != EventPostUpdated {
+		t.Errorf("expected a post.updated event, got %+v", events)
+	}
+
+	_, file, err := service.Open(context.Background(), "1", attachment.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer file.Close()
+	if data, _ := io.ReadAll(file); !bytes.Equal(data, pdfContent) {
+		t.Errorf("expected the stored content, got %q", data)
+	}
+
+	_, err = service.Upload(context.Background(), "1", "report.pdf", bytes.NewReader(append(pdfContent, ' ')))
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindValidation {
+		t.Errorf("expected a validation error past the limit, got %v", err)
+	}
+	if list, _ := service.List(context.Background(), "1"); len(list) != 1 {
+		t.Errorf("expected the rejected file not to be attached, got %+v", list)
+	}
+}
+
+func TestAttachmentService_StripsImageMetadata(t *testing.T) {
+	service, _ := newTestAttachmentService(t, 1<<10)
+	var img bytes.Buffer
+	png.Encode(&img, image.NewGray(image.Rect(0, 0, 4, 4)))
+	encoded := img.Bytes()
+	// a text chunk after the IHDR chunk, with a checksum the stripping
+	// does not check
+	text := []byte("\x00\x00\x00\x0atEXtGPS 48.85N\x00\x00\x00\x00")
+	photo := append(append(append([]byte{}, encoded[:33]...), text...), encoded[33:]...)
+
+	attachment, err := service.Upload(context.Background(), "1", "photo.png", bytes.NewReader(photo))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, file, err := service.Open(context.Background(), "1", attachment.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer file.Close()
+	data, _ := io.ReadAll(file)
+	sum := sha256.Sum256(data)
+	if !bytes.Equal(data, encoded) || attachment.Size != int64(len(encoded)) || attachment.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the image to be stored without its metadata, got %q and %+v", data, attachment)
+	}
+
+	// what follows the image still counts towards the limit
+	_, err = service.Upload(context.Background(), "1", "photo.png", bytes.NewReader(append(encoded, make([]byte, 1<<10)...)))
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindValidation {
+		t.Errorf("expected a validation error past the limit, got %v", err)
+	}
+	_, err = service.Upload(context.Background(), "1", "photo.png", bytes.NewReader(encoded[:40]))
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindValidation {
+		t.Errorf("expected a validation error for a truncated image, got %v", err)
+	}
+	if list, _ := service.List(context.Background(), "1"); len(list) != 1 {
+		t.Errorf("expected the rejected files not to be attached, got %+v", list)
+	}
+}
+
+func TestAttachmentService_ConfiguredTypes(t *testing.T) {
+	posts := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	posts.Create(context.Background(), &models.BlogPost{ID: "1", Title: "Post", Content: "content", Author: "Ann"})
+	store, _ := blob.NewLocalStore(t.TempDir())
+	service := NewAttachmentService(posts, store, AttachmentOptions{MaxBytes: 1 << 10, Types: []string{"text/plain"}})
+
+	// text files are sniffed as text/plain; charset=utf-8
+	attachment, err := service.Upload(context.Background(), "1", "notes.txt", strings.NewReader("plain notes"))
+	if err != nil || attachment.ContentType != "text/plain" {
+		t.Fatalf("expected a text attachment, got %+v, %v", attachment, err)
+	}
+	if _, err := service.Upload(context.Background(), "1", "report.pdf", bytes.NewReader(pdfContent)); err == nil {
+		t.Error("expected PDF documents to be rejected")
+	}
+}
+
+func TestCleanFilename(t *testing.T) {
+	cases := map[string]string{
+		"photo.png":                "photo.png",
+		"../../etc/passwd":         "passwd",
+		`C:\Users\ann\photo.png`:   "photo.png",
+		"bad\"name\r\n.png":        "badname.png",
+		"":                         "",
+		"/":                        "",
+		"  spaced.png  ":           "spaced.png",
+		strings.Repeat("é", 300):   strings.Repeat("é", maxFilenameRunes),
+		"dir/\x00null\x7fbyte.png": "nullbyte.png",
+	}
+	for name, want := range cases {
+		if got := cleanFilename(name); got != want {
+			t.Errorf("cleanFilename(%q): expected %q, got %q", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/models"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+type InMemoryAuthorRepo struct {
+	mu      sync.RWMutex
+	authors map[string]models.Author
+	// byName maps the names of the authors to their IDs
+	byName map[string]string
+}
+
+func NewInMemoryAuthorRepo() *InMemoryAuthorRepo {
+	return &InMemoryAuthorRepo{
+		authors: make(map[string]models.Author),
+		byName:  make(map[string]string),
+	}
+}
+
+func (s *InMemoryAuthorRepo) Create(ctx context.Context, author *models.Author) (*models.Author, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if author == nil {
+		return nil, errors.New("author cannot be nil")
+	}
+	if author.ID == "" {
+		return nil, errors.New("author ID cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.byName[author.Name]; exists {
+		return nil, ErrAuthorNameTaken
+	}
+	s.authors[author.ID] = cloneAuthor(*author)
+	s.byName[author.Name] = author.ID
+	return author, nil
+}
+
+func (s *InMemoryAuthorRepo) GetAll(ctx context.Context) ([]*models.Author, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	authors := make([]*models.Author, 0, len(s.authors))
+	for _, a := range s.authors {
+		a = cloneAuthor(a)
+		authors = append(authors, &a)
+	}
+	return authors, nil
+}
+
+func (s *InMemoryAuthorRepo) GetById(ctx context.Context, id string) (*models.Author, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, exists := s.authors[id]
+	if !exists {
+		return nil, ErrAuthorNotFound
+	}
+	a = cloneAuthor(a)
+	return &a, nil
+}
+
+func (s *InMemoryAuthorRepo) GetByName(ctx context.Context, name string) (*models.Author, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, exists := s.byName[name]
+	if !exists {
+		return nil, ErrAuthorNotFound
+	}
+	a := cloneAuthor(s.authors[id])
+	return &a, nil
+}
+
+func (s *InMemoryAuthorRepo) Update(ctx context.Context, id string, updated *models.Author) (*models.Author, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if updated == nil {
+		return nil, errors.New("updated author cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, exists := s.authors[id]
+	if !exists {
+		return nil, ErrAuthorNotFound
+	}
+	if other, taken := s.byName[updated.Name]; taken && other != id {
+		return nil, ErrAuthorNameTaken
+	}
+	updated.ID = id
+	updated.CreatedAt = existing.CreatedAt
+	delete(s.byName, existing.Name)
+	s.authors[id] = cloneAuthor(*updated)
+	s.byName[updated.Name] = id
+	return updated, nil
+}
+
+func (s *InMemoryAuthorRepo) Delete(ctx context.Context, id string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, exists := s.authors[id]
+	if !exists {
+		return ErrAuthorNotFound
+	}
+	delete(s.authors, id)
+	delete(s.byName, existing.Name)
+	return nil
+}
+
+// cloneAuthor copies the links of an author so that callers cannot modify
+// the stored ones
+func cloneAuthor(a models.Author) models.Author {
+	a.Links = slices.Clone(a.Links)
+	return a
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/repositories"
+	"blog-posts-api/internal/api/validation"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAuthorNotFound = apperrors.NotFound("author not found")
+	// ErrAuthorNameTaken is returned when creating or renaming an author
+	// after another one
+	ErrAuthorNameTaken = apperrors.Conflict("an author with this name already exists")
+	// ErrAuthorHasPosts is returned when deleting the author of posts
+	ErrAuthorHasPosts = apperrors.Conflict("author still has posts")
+)
+
+// AuthorService manages the authors of blog posts. Posts created or updated
+// through the post service refer to their author by ID, or by name for
+// clients predating authors: the first post by a name creates its author.
+// Renaming an author renames it on its posts.
+type AuthorService struct {
+	repo  repositories.AuthorRepo
+	posts *BlogPostService
+}
+
+// NewAuthorService returns the service of the authors of the posts of the
+// given service, which links its posts to them from then on. Without an
+// author service the author of a post is free text.
+func NewAuthorService(r repositories.AuthorRepo, posts *BlogPostService) *AuthorService {
+	s := &AuthorService{repo: r, posts: posts}
+	posts.authors = s
+	return s
+}
+
+func (s *AuthorService) Create(ctx context.Context, author *models.Author) (*models.Author, error) {
+	if author == nil {
+		return nil, apperrors.BadRequest("author cannot be nil", nil)
+	}
+	body := author.CreateBody()
+	if err := validation.Struct(&body); err != nil {
+		return nil, err
+	}
+	created := body.ToAuthor()
+	created.ID = author.ID
+	if created.ID == "" {
+		created.ID = uuid.New().String()
+	}
+	created.Links = normalizeTags(created.Links)
+	created.CreatedAt = time.Now().UTC()
+	created.UpdatedAt = created.CreatedAt
+	return s.repo.Create(ctx, &created)
+}
+
+// GetAll returns every author, sorted by name
+func (s *AuthorService) GetAll(ctx context.Context) ([]*models.Author, error) {
+	authors, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(authors, func(a, b *models.Author) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return authors, nil
+}
+
+func (s *AuthorService) GetById(ctx context.Context, id string) (*models.Author, error) {
+	return s.repo.GetById(ctx, id)
+}
+
+// Update replaces the profile of an author. A new name is set on the posts
+// of the author, as new versions of them.
+func (s *AuthorService) Update(ctx context.Context, id string, author *models.Author) (*models.Author, error) {
+	if author == nil {
+		return nil, apperrors.BadRequest("updated author cannot be nil", nil)
+	}
+	body := author.UpdateBody()
+	if err := validation.Struct(&body); err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := body.ToAuthor()
+	updated.Links = normalizeTags(updated.Links)
+	updated.UpdatedAt = time.Now().UTC()
+	stored, err := s.repo.Update(ctx, id, &updated)
+	if err != nil {
+		return nil, err
+	}
+	if stored.Name != existing.Name {
+		if err := s.posts.renameAuthor(ctx, id, stored.Name); err != nil {
+			return nil, apperrors.Wrap(err, "failed to rename the author on its posts")
+		}
+	}
+	return stored, nil
+}
+
+// Delete removes an author, which must not have posts anymore. The posts
+// are checked within a change of the posts, so that none is linked to the
+// author before it is deleted.
+func (s *AuthorService) Delete(ctx context.Context, id string) error {
+	_, err := s.posts.write(ctx, func(repo repositories.BlogPostRepo) ([]Event, error) {
+		if _, err := s.repo.GetById(ctx, id); err != nil {
+			return nil, err
+		}
+		posts, err := findPosts(ctx, repo, models.PostFilter{AuthorID: id})
+		if err != nil {
+			return nil, err
+		}
+		if len(posts) > 0 {
+			return nil, ErrAuthorHasPosts
+		}
+		return nil, s.repo.Delete(ctx, id)
+	})
+	return err
+}
+
+// Posts returns a page of the posts of an author, newest first, see
+// BlogPostService.GetPage
+func (s *AuthorService) Posts(ctx context.Context, id, pageToken string, pageSize int) ([]*models.BlogPost, string, error) {
+	if _, err := s.repo.GetById(ctx, id); err != nil {
+		return nil, "", err
+	}
+	return s.posts.GetPage(ctx, models.PostFilter{AuthorID: id}, pageToken, pageSize)
+}
+
+// Backfill links the posts stored before authors existed to the author
+// named after their author field, creating the authors missing, and
+// returns the number of posts linked. Each linked post gets a new version.
+// Posts already linked are left alone, so running it again is harmless.
+func (s *AuthorService) Backfill(ctx context.Context) (int, error) {
+	var ids []string
+	err := s.posts.Export(ctx, func(post *models.BlogPost) error {
+		if post.AuthorID == "" && post.Author != "" {
+			ids = append(ids, post.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	linked := 0
+	for _, id := range ids {
+		err := s.posts.editPost(ctx, id, func(repo repositories.BlogPostRepo, post *models.BlogPost) error {
+			if post.AuthorID != "" || post.Author == "" {
+				return errUnchanged
+			}
+			return s.link(ctx, repo, post)
+		})
+		switch {
+		case err == nil:
+			linked++
+		case apperrors.Is(err, apperrors.KindNotFound):
+			// deleted meanwhile
+		default:
+			return linked, err
+		}
+	}
+	return linked, nil
+}
+
+// link sets the author of a post from its author ID or, without one, from
+// its name, creating the author the first time a name is used. The author
+// is created within the change made through repo, and deleted if the
+// change fails.
+func (s *AuthorService) link(ctx context.Context, repo repositories.BlogPostRepo, post *models.BlogPost) error {
+	if post.AuthorID != "" {
+		author, err := s.repo.GetById(ctx, post.AuthorID)
+		if apperrors.Is(err, apperrors.KindNotFound) {
+			return errUnknownAuthor
+		}
+		if err != nil {
+			return err
+		}
+		post.Author = author.Name
+		return nil
+	}
+
+	author, err := s.repo.GetByName(ctx, post.Author)
+	if apperrors.Is(err, apperrors.KindNotFound) {
+		author, err = s.Create(ctx, &models.Author{Name: post.Author})
+		if err == nil {
+			if tx, ok := repo.(*linkingTx); ok {
+				tx.createdAuthors = append(tx.createdAuthors, author.ID)
+			}
+		} else if apperrors.Is(err, apperrors.KindConflict) {
+			// created meanwhile by another post
+			author, err = s.repo.GetByName(ctx, post.Author)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	post.AuthorID = author.ID
+	return nil
+}
+
+// errUnknownAuthor is returned for posts referring to an author that does
+// not exist
+var errUnknownAuthor = apperrors.Validation("request has invalid fields", apperrors.FieldError{
+	Field:  "author_id",
+	Reason: "must refer to an existing author",
+})
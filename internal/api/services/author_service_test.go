@@ -0,0 +1,220 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func newTestAuthorService() (*BlogPostService, *AuthorService) {
+	posts := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	return posts, NewAuthorService(NewInMemoryAuthorRepo(), posts)
+}
+
+func newTestPost(id, author string) *models.BlogPost {
+	return &models.BlogPost{ID: id, Title: "Test Post", Content: "Test content", Author: author}
+}
+
+func TestAuthorService_Create(t *testing.T) {
+	_, service := newTestAuthorService()
+	ctx := context.Background()
+
+	created, err := service.Create(ctx, &models.Author{
+		Name:  "  Ann  ",
+		Links: []string{"https://github.com/ann", "", "https://github.com/ann"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if created.ID == "" || created.Name != "Ann" || !reflect.DeepEqual(created.Links, []string{"https://github.com/ann"}) {
+		t.Errorf("expected a sanitized author, got %+v", created)
+	}
+
+	if _, err := service.Create(ctx, &models.Author{Name: "Ann"}); !apperrors.Is(err, apperrors.KindConflict) {
+		t.Errorf("expected a conflict for a taken name, got %v", err)
+	}
+	_, err = service.Create(ctx, &models.Author{AvatarURL: "avatar.png", Links: []string{"ann"}})
+	if !apperrors.Is(err, apperrors.KindValidation) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if fields := err.(*apperrors.Error).Fields; len(fields) != 3 {
+		t.Errorf("expected name, avatar_url and links to be reported, got %+v", fields)
+	}
+}
+
+func TestAuthorService_LinksPosts(t *testing.T) {
+	posts, service := newTestAuthorService()
+	ctx := context.Background()
+
+	// a post by a new name creates its author, the next ones reuse it
+	first, err := posts.Create(ctx, newTestPost("1", "Ann"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second, _ := posts.Create(ctx, newTestPost("2", "Ann"))
+	if first.AuthorID == "" || second.AuthorID != first.AuthorID {
+		t.Errorf("expected both posts to refer to the same author, got %q and %q", first.AuthorID, second.AuthorID)
+	}
+	if authors, _ := service.GetAll(ctx); len(authors) != 1 || authors[0].Name != "Ann" {
+		t.Errorf("expected a single author, got %v", authors)
+	}
+
+	// an author ID takes precedence over the name
+	bob, _ := service.Create(ctx, &models.Author{Name: "Bob"})
+	byID := newTestPost("1", "ignored")
+	byID.AuthorID = bob.ID
+	updated, err := posts.Update(ctx, first.ID, byID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if updated.AuthorID != bob.ID || updated.Author != "Bob" {
+		t.Errorf("expected the post to be by Bob, got %q (%s)", updated.Author, updated.AuthorID)
+	}
+
+	unknown := newTestPost("3", "")
+	unknown.AuthorID = "missing"
+	if _, err := posts.Create(ctx, unknown); !apperrors.Is(err, apperrors.KindValidation) {
+		t.Errorf("expected a validation error for an unknown author, got %v", err)
+	}
+	if _, err := posts.Create(ctx, newTestPost("4", "")); !apperrors.Is(err, apperrors.KindValidation) {
+		t.Errorf("expected a validation error for a post without author, got %v", err)
+	}
+}
+
+func TestAuthorService_Rename(t *testing.T) {
+	posts, service := newTestAuthorService()
+	ctx := context.Background()
+	post, _ := posts.Create(ctx, newTestPost("1", "Ann"))
+	other, _ := posts.Create(ctx, newTestPost("2", "Bob"))
+
+	var events []Event
+	posts.Subscribe(func(e Event) { events = append(events, e) })
+	renamed, err := service.Update(ctx, post.AuthorID, &models.Author{Name: "Ann Smith", Bio: "Writes about Go."})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if renamed.Name != "Ann Smith" || renamed.Bio != "Writes about Go." {
+		t.Errorf("expected the author to be updated, got %+v", renamed)
+	}
+
+	stored, _ := posts.GetById(ctx, post.ID)
+	if stored.Author != "Ann Smith" || stored.Version != 2 {
+		t.Errorf("expected a new version of the post with the new name, got %q at version %d", stored.Author, stored.Version)
+	}
+	if unchanged, _ := posts.GetById(ctx, other.ID); unchanged.Version != 1 {
+		t.Errorf("expected the posts of other authors to be left alone, got version %d", unchanged.Version)
+	}
+	if len(events) != 1 || events[0].Type != EventPostUpdated || events[0].PostID != post.ID {
+		t.Errorf("expected an update event for the post, got %+v", events)
+	}
+
+	// posts by the new name now go to the renamed author
+	again, _ := posts.Create(ctx, newTestPost("3", "Ann Smith"))
+	if again.AuthorID != post.AuthorID {
+		t.Errorf("expected the renamed author, got %q", again.AuthorID)
+	}
+	if _, err := service.Update(ctx, post.AuthorID, &models.Author{Name: "Bob"}); !apperrors.Is(err, apperrors.KindConflict) {
+		t.Errorf("expected a conflict for a taken name, got %v", err)
+	}
+}
+
+func TestAuthorService_Delete(t *testing.T) {
+	posts, service := newTestAuthorService()
+	ctx := context.Background()
+	post, _ := posts.Create(ctx, newTestPost("1", "Ann"))
+
+	if err := service.Delete(ctx, post.AuthorID); !apperrors.Is(err, apperrors.KindConflict) {
+		t.Errorf("expected a conflict while the author has posts, got %v", err)
+	}
+	posts.Delete(ctx, post.ID)
+	if err := service.Delete(ctx, post.AuthorID); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := service.Delete(ctx, post.AuthorID); !apperrors.Is(err, apperrors.KindNotFound) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestAuthorService_RollsBackCreatedAuthors(t *testing.T) {
+	posts, service := newTestAuthorService()
+	ctx := context.Background()
+
+	_, err := posts.Batch(ctx, []BatchOp{
+		{Kind: BatchCreate, Post: newTestPost("1", "Dave")},
+		{Kind: BatchUpdate, ID: "missing", Post: newTestPost("", "Dave")},
+	}, true)
+	if err == nil {
+		t.Fatalf("expected the batch to fail")
+	}
+	if _, err := service.repo.GetByName(ctx, "Dave"); !apperrors.Is(err, apperrors.KindNotFound) {
+		t.Errorf("expected the author to be rolled back with the batch, got %v", err)
+	}
+
+	// a post created later still gets its author
+	post, err := posts.Create(ctx, newTestPost("2", "Dave"))
+	if err != nil || post.AuthorID == "" {
+		t.Errorf("expected the post to be linked to a new author, got %+v: %v", post, err)
+	}
+}
+
+func TestAuthorService_Posts(t *testing.T) {
+	posts, service := newTestAuthorService()
+	ctx := context.Background()
+	var ids []string
+	for i := range 3 {
+		p, _ := posts.Create(ctx, newTestPost(strconv.Itoa(i+1), "Ann"))
+		ids = append(ids, p.ID)
+	}
+	posts.Create(ctx, newTestPost("4", "Bob"))
+	ann, _ := posts.GetById(ctx, ids[0])
+
+	page, next, err := service.Posts(ctx, ann.AuthorID, "", 2)
+	if err != nil || len(page) != 2 || next == "" {
+		t.Fatalf("expected a first page of 2 posts, got %d posts, %q: %v", len(page), next, err)
+	}
+	last, next, _ := service.Posts(ctx, ann.AuthorID, next, 2)
+	if len(last) != 1 || next != "" {
+		t.Errorf("expected a last page of 1 post, got %d posts, %q", len(last), next)
+	}
+	if _, _, err := service.Posts(ctx, "missing", "", 0); !apperrors.Is(err, apperrors.KindNotFound) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestAuthorService_Backfill(t *testing.T) {
+	posts := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+	// posts stored before authors existed
+	var ids []string
+	for i, name := range []string{"Ann", "Bob", "Ann"} {
+		p, _ := posts.Create(ctx, newTestPost(strconv.Itoa(i+1), name))
+		if p.AuthorID != "" {
+			t.Fatalf("expected a free-text author, got %q", p.AuthorID)
+		}
+		ids = append(ids, p.ID)
+	}
+	service := NewAuthorService(NewInMemoryAuthorRepo(), posts)
+	bob, _ := service.Create(ctx, &models.Author{Name: "Bob", Bio: "Existing author"})
+
+	linked, err := service.Backfill(ctx)
+	if err != nil || linked != 3 {
+		t.Fatalf("expected 3 posts to be linked, got %d: %v", linked, err)
+	}
+	authors, _ := service.GetAll(ctx)
+	if len(authors) != 2 {
+		t.Errorf("expected an author per distinct name, got %v", authors)
+	}
+	first, _ := posts.GetById(ctx, ids[0])
+	second, _ := posts.GetById(ctx, ids[1])
+	third, _ := posts.GetById(ctx, ids[2])
+	if first.AuthorID == "" || third.AuthorID != first.AuthorID || second.AuthorID != bob.ID {
+		t.Errorf("expected the posts to refer to their authors, got %q, %q and %q", first.AuthorID, second.AuthorID, third.AuthorID)
+	}
+
+	if linked, err := service.Backfill(ctx); err != nil || linked != 0 {
+		t.Errorf("expected nothing left to link, got %d: %v", linked, err)
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/repositories"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MaxBatchOperations bounds the number of operations of a batch
+const MaxBatchOperations = 100
+
+// BatchOpKind names the change made by a batch operation
+type BatchOpKind string
+
+const (
+	BatchCreate BatchOpKind = "create"
+	BatchUpdate BatchOpKind = "update"
+	BatchDelete BatchOpKind = "delete"
+)
+
+// BatchOp is an operation of a batch. ID is required to update and delete,
+// and optional to create; Post is required to create and update.
+type BatchOp struct {
+	Kind BatchOpKind
+	ID   string
+	Post *models.BlogPost
+}
+
+// BatchResult is the outcome of a batch operation: the stored post, nil for
+// deletions, or the error that prevented the operation
+type BatchResult struct {
+	Post *models.BlogPost
+	Err  error
+}
+
+// ErrAlreadyExists is returned when creating a post with the ID of an existing one
+var ErrAlreadyExists = apperrors.Conflict("blog post already exists")
+
+// ErrAtomicBatchUnsupported is returned for atomic batches when the
+// repository has no transaction support
+var ErrAtomicBatchUnsupported = apperrors.BadRequest("atomic batches are not supported by the blog post repository", nil)
+
+// Batch applies the operations in order. Atomic batches either apply every
+// operation or none: the first failure rolls the batch back and is returned
+// with the index of the operation. Otherwise every operation is attempted
+// and its outcome is reported in the result of the same index.
+//
+// Listeners are notified of atomic changes once they are committed.
+func (s *BlogPostService) Batch(ctx context.Context, ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	if len(ops) > MaxBatchOperations {
+		return nil, apperrors.Validation("batch is too large", apperrors.FieldError{
+			Field:  "operations",
+			Reason: fmt.Sprintf("must have at most %d items", MaxBatchOperations),
+		})
+	}
+	if !atomic {
+		return s.batchBestEffort(ctx, ops), nil
+	}
+
+	txRepo, ok := s.repo.(repositories.TxBlogPostRepo)
+	if !ok {
+		return nil, ErrAtomicBatchUnsupported
+	}
+
+	var results []BatchResult
+	events, err := s.withinTx(ctx, txRepo, func(tx repositories.BlogPostRepo) ([]Event, error) {
+		results = make([]BatchResult, 0, len(ops))
+		events := make([]Event, 0, len(ops))
+		for i, op := range ops {
+			post, opEvents, err := s.apply(ctx, tx, op)
+			if err != nil {
+				return nil, apperrors.Nest(apperrors.Wrap(err, "failed to apply the batch"),
+					fmt.Sprintf("operations[%d]", i), fmt.Sprintf("operation %d: ", i))
+			}
+			results = append(results, BatchResult{Post: post})
+			events = append(events, opEvents...)
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.events.notify(events...)
+	return results, nil
+}
+
+func (s *BlogPostService) batchBestEffort(ctx context.Context, ops []BatchOp) []BatchResult {
+	results := make([]BatchResult, len(ops))
+	for i, op := range ops {
+		var post *models.BlogPost
+		events, err := s.write(ctx, func(repo repositories.BlogPostRepo) ([]Event, error) {
+			var events []Event
+			var err error
+			post, events, err = s.apply(ctx, repo, op)
+			return events, err
+		})
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Post = post
+		s.events.notify(events...)
+	}
+	return results
+}
+
+// apply runs a single operation against repo and returns the events to
+// publish once the change is visible
+func (s *BlogPostService) apply(ctx context.Context, repo repositories.BlogPostRepo, op BatchOp) (*models.BlogPost, []Event, error) {
+	if err := validateOp(op); err != nil {
+		return nil, nil, err
+	}
+
+	switch op.Kind {
+	case BatchCreate:
+		op.Post.ID = op.ID
+		if op.Post.ID == "" {
+			op.Post.ID = uuid.New().String()
+		} else if _, err := repo.GetById(ctx, op.ID); err == nil {
+			return nil, nil, ErrAlreadyExists
+		} else if !apperrors.Is(err, apperrors.KindNotFound) {
+			return nil, nil, err
+		}
+		created, events, err := s.create(ctx, repo, op.Post)
+		if err != nil {
+			return nil, nil, apperrors.Nest(err, "post", "")
+		}
+		return created, events, nil
+	case BatchUpdate:
+		updated, events, err := s.update(ctx, repo, op.ID, 0, op.Post)
+		if err != nil {
+			return nil, nil, apperrors.Nest(err, "post", "")
+		}
+		return updated, events, nil
+	default:
+		if err := repo.Delete(ctx, op.ID); err != nil {
+			return nil, nil, err
+		}
+		return nil, []Event{newEvent(EventPostDeleted, op.ID, nil)}, nil
+	}
+}
+
+func validateOp(op BatchOp) error {
+	var fields []apperrors.FieldError
+	switch op.Kind {
+	case BatchCreate, BatchUpdate:
+		if op.Post == nil {
+			fields = append(fields, apperrors.FieldError{Field: "post", Reason: "is required"})
+		}
+	case BatchDelete:
+	default:
+		fields = append(fields, apperrors.FieldError{Field: "op", Reason: "must be one of: create, update, delete"})
+	}
+	if op.ID == "" && (op.Kind == BatchUpdate || op.Kind == BatchDelete) {
+		fields = append(fields, apperrors.FieldError{Field: "id", Reason: "is required"})
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation("invalid batch operation", fields...)
+	}
+	return nil
+}
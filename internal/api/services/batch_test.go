@@ -0,0 +1,109 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/repositories"
+	"context"
+	"errors"
+	"testing"
+)
+
+func newBatchPost(title string) *models.BlogPost {
+	return &models.BlogPost{Title: title, Content: "Test content", Author: "Test Author"}
+}
+
+func TestBlogPostService_Batch_AtomicRollsBack(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+	service.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+
+	var events []Event
+	service.Subscribe(func(e Event) { events = append(events, e) })
+
+	_, err := service.Batch(ctx, []BatchOp{
+		{Kind: BatchUpdate, ID: "1", Post: newBatchPost("Updated")},
+		{Kind: BatchCreate, Post: newBatchPost("")},
+	}, true)
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindValidation {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if len(appErr.Fields) != 1 || appErr.Fields[0].Field != "operations[1].post.title" {
+		t.Errorf("expected the field of the failing operation, got %+v", appErr.Fields)
+	}
+	if stored, _ := service.GetById(ctx, "1"); stored.Title != "Test Post" {
+		t.Errorf("expected the update to be rolled back, got %s", stored.Title)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected no event for a rolled back batch, got %v", events)
+	}
+}
+
+func TestBlogPostService_Batch_AtomicCommits(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+	service.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+
+	var events []Event
+	service.Subscribe(func(e Event) { events = append(events, e) })
+
+	results, err := service.Batch(ctx, []BatchOp{
+		{Kind: BatchCreate, ID: "2", Post: newBatchPost("Second")},
+		{Kind: BatchUpdate, ID: "2", Post: newBatchPost("Second, revised")},
+		{Kind: BatchDelete, ID: "1"},
+	}, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(results) != 3 || results[1].Post.Version != 2 || results[2].Post != nil {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if len(events) != 4 || events[1].Type != EventPostPublished || events[3].Type != EventPostDeleted {
+		t.Errorf("expected 4 events once committed, got %v", events)
+	}
+	if _, err := service.GetById(ctx, "1"); err != ErrNotFound {
+		t.Errorf("expected post 1 to be deleted, got %v", err)
+	}
+}
+
+func TestBlogPostService_Batch_BestEffort(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+	service.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+
+	results, err := service.Batch(ctx, []BatchOp{
+		{Kind: BatchDelete, ID: "missing"},
+		{Kind: BatchCreate, ID: "1", Post: newBatchPost("Duplicate")},
+		{Kind: "archive", ID: "1"},
+		{Kind: BatchUpdate, ID: "1", Post: newBatchPost("Updated")},
+	}, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	kinds := []apperrors.Kind{apperrors.KindNotFound, apperrors.KindConflict, apperrors.KindValidation}
+	for i, kind := range kinds {
+		if !apperrors.Is(results[i].Err, kind) {
+			t.Errorf("expected operation %d to fail with %s, got %v", i, kind, results[i].Err)
+		}
+	}
+	if results[3].Err != nil || results[3].Post.Title != "Updated" {
+		t.Errorf("expected the last operation to succeed, got %+v", results[3])
+	}
+}
+
+type plainRepo struct {
+	repositories.BlogPostRepo
+}
+
+func TestBlogPostService_Batch_AtomicUnsupported(t *testing.T) {
+	service := NewBlogPostService(plainRepo{NewInMemoryStoreBlogPostRepo()})
+
+	_, err := service.Batch(context.Background(), []BatchOp{{Kind: BatchDelete, ID: "1"}}, true)
+	if err != ErrAtomicBatchUnsupported {
+		t.Errorf("expected ErrAtomicBatchUnsupported, got %v", err)
+	}
+}
@@ -2,19 +2,28 @@ package services
 
 import (
 	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/repositories"
 	"context"
 	"errors"
+	"slices"
 	"sync"
 )
 
+// InMemoryStoreBlogPostRepo is an OutboxRepo, a FilteredBlogPostRepo and a
+// SlugBlogPostRepo keeping the posts and the outbox in memory
 type InMemoryStoreBlogPostRepo struct {
 	mu    sync.RWMutex
 	posts map[string]models.BlogPost
+	slugs slugIndex
+	// outbox holds the events of committed transactions until published
+	outbox   []models.OutboxEvent
+	sequence int64
 }
 
 func NewInMemoryStoreBlogPostRepo() *InMemoryStoreBlogPostRepo {
 	return &InMemoryStoreBlogPostRepo{
 		posts: make(map[string]models.BlogPost),
+		slugs: slugIndex{},
 	}
 }
 
@@ -36,6 +45,8 @@ func (s *InMemoryStoreBlogPostRepo) Create(ctx context.Context, post *models.Blo
 		return nil, errors.New("post ID cannot be empty")
 	}
 
+	post.Version = 1
+	s.slugs.set(post.ID, s.posts[post.ID].Slug, post.Slug)
 	s.posts[post.ID] = *post
 	return post, nil
 }
@@ -58,6 +69,43 @@ func (s *InMemoryStoreBlogPostRepo) GetAll(ctx context.Context) ([]*models.BlogP
 	return posts, nil
 }
 
+// Find copies only the posts matching the filter
+func (s *InMemoryStoreBlogPostRepo) Find(ctx context.Context, filter models.PostFilter) ([]*models.BlogPost, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []*models.BlogPost
+	for _, post := range s.posts {
+		if filter.Matches(&post) {
+			posts = append(posts, &post)
+		}
+	}
+	return posts, nil
+}
+
+// GetBySlug returns a post with the slug through the index of the slugs
+func (s *InMemoryStoreBlogPostRepo) GetBySlug(ctx context.Context, slug string) (*models.BlogPost, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for id := range s.slugs[slug] {
+		post := s.posts[id]
+		return &post, nil
+	}
+	return nil, ErrNotFound
+}
+
 func (s *InMemoryStoreBlogPostRepo) GetById(
 	ctx context.Context,
 	id string,
@@ -94,11 +142,14 @@ func (s *InMemoryStoreBlogPostRepo) Update(
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	_, exists := s.posts[id]
+	existing, exists := s.posts[id]
 	if !exists {
 		return nil, ErrNotFound
 	}
 	updated.ID = id
+	updated.Version = existing.Version + 1
+	updated.CreatedAt = existing.CreatedAt
+	s.slugs.set(id, existing.Slug, updated.Slug)
 	s.posts[id] = *updated
 	return updated, nil
 }
@@ -112,9 +163,275 @@ func (s *InMemoryStoreBlogPostRepo) Delete(ctx context.Context, id string) error
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if _, exists := s.posts[id]; !exists {
+	existing, exists := s.posts[id]
+	if !exists {
 		return ErrNotFound
 	}
+	s.slugs.set(id, existing.Slug, "")
 	delete(s.posts, id)
 	return nil
 }
+
+// Ping reports whether the store can serve requests. The in-memory store
+// has no connection to lose, so it only honors the context.
+func (s *InMemoryStoreBlogPostRepo) Ping(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	return nil
+}
+
+// WithinTx runs fn against a transaction staging its changes and events,
+// applied to the store when fn succeeds. The store is locked for the
+// duration of the transaction, which is cheap enough for the single writes
+// and short batches it serves.
+func (s *InMemoryStoreBlogPostRepo) WithinTx(ctx context.Context, fn func(tx repositories.BlogPostRepo) error) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &inMemoryTx{posts: s.posts, slugs: s.slugs, changes: map[string]*models.BlogPost{}}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	for id, post := range tx.changes {
+		if post == nil {
+			s.slugs.set(id, s.posts[id].Slug, "")
+			delete(s.posts, id)
+		} else {
+			s.slugs.set(id, s.posts[id].Slug, post.Slug)
+			s.posts[id] = *post
+		}
+	}
+	for _, e := range tx.events {
+		s.sequence++
+		e.Sequence = s.sequence
+		s.outbox = append(s.outbox, *e)
+	}
+	return nil
+}
+
+// PendingEvents returns up to limit events of the outbox, oldest first. A
+// limit <= 0 returns every event.
+func (s *InMemoryStoreBlogPostRepo) PendingEvents(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := len(s.outbox)
+	if limit > 0 {
+		n = min(n, limit)
+	}
+	events := make([]*models.OutboxEvent, n)
+	for i := range events {
+		e := s.outbox[i]
+		events[i] = &e
+	}
+	return events, nil
+}
+
+// MarkPublished removes events from the outbox, unknown IDs are ignored
+func (s *InMemoryStoreBlogPostRepo) MarkPublished(ctx context.Context, ids ...string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outbox = slices.DeleteFunc(s.outbox, func(e models.OutboxEvent) bool {
+		return slices.Contains(ids, e.ID)
+	})
+	return nil
+}
+
+// slugIndex holds the IDs of the posts having each slug
+type slugIndex map[string]map[string]bool
+
+// set moves a post from its old slug to its new one, an empty slug being
+// none
+func (idx slugIndex) set(id, old, slug string) {
+	if ids := idx[old]; ids != nil {
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(idx, old)
+		}
+	}
+	if slug == "" {
+		return
+	}
+	if idx[slug] == nil {
+		idx[slug] = map[string]bool{}
+	}
+	idx[slug][id] = true
+}
+
+// inMemoryTx reads the posts of the locked store through the changes of
+// the transaction, a nil change being a deletion
+type inMemoryTx struct {
+	posts   map[string]models.BlogPost
+	slugs   slugIndex
+	changes map[string]*models.BlogPost
+	events  []*models.OutboxEvent
+}
+
+func (tx *inMemoryTx) get(id string) (models.BlogPost, bool) {
+	if post, changed := tx.changes[id]; changed {
+		if post == nil {
+			return models.BlogPost{}, false
+		}
+		return *post, true
+	}
+	post, exists := tx.posts[id]
+	return post, exists
+}
+
+func (tx *inMemoryTx) Create(ctx context.Context, post *models.BlogPost) (*models.BlogPost, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, errors.New("post cannot be nil")
+	}
+	if post.ID == "" {
+		return nil, errors.New("post ID cannot be empty")
+	}
+
+	post.Version = 1
+	stored := *post
+	tx.changes[post.ID] = &stored
+	return post, nil
+}
+
+func (tx *inMemoryTx) GetAll(ctx context.Context) ([]*models.BlogPost, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	posts := make([]*models.BlogPost, 0, len(tx.posts))
+	for id := range tx.posts {
+		if _, changed := tx.changes[id]; !changed {
+			post := tx.posts[id]
+			posts = append(posts, &post)
+		}
+	}
+	for _, changed := range tx.changes {
+		if changed != nil {
+			post := *changed
+			posts = append(posts, &post)
+		}
+	}
+	return posts, nil
+}
+
+// Find copies only the posts matching the filter, as changed by the
+// transaction
+func (tx *inMemoryTx) Find(ctx context.Context, filter models.PostFilter) ([]*models.BlogPost, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var posts []*models.BlogPost
+	for id, post := range tx.posts {
+		if _, changed := tx.changes[id]; !changed && filter.Matches(&post) {
+			posts = append(posts, &post)
+		}
+	}
+	for _, changed := range tx.changes {
+		if changed != nil && filter.Matches(changed) {
+			post := *changed
+			posts = append(posts, &post)
+		}
+	}
+	return posts, nil
+}
+
+// GetBySlug returns a post with the slug, as changed by the transaction
+func (tx *inMemoryTx) GetBySlug(ctx context.Context, slug string) (*models.BlogPost, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, changed := range tx.changes {
+		if changed != nil && changed.Slug == slug {
+			post := *changed
+			return &post, nil
+		}
+	}
+	for id := range tx.slugs[slug] {
+		if _, changed := tx.changes[id]; !changed {
+			post := tx.posts[id]
+			return &post, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (tx *inMemoryTx) GetById(ctx context.Context, id string) (*models.BlogPost, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	post, exists := tx.get(id)
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &post, nil
+}
+
+func (tx *inMemoryTx) Update(ctx context.Context, id string, updated *models.BlogPost) (*models.BlogPost, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errors.New("updated post cannot be nil")
+	}
+
+	existing, exists := tx.get(id)
+	if !exists {
+		return nil, ErrNotFound
+	}
+	updated.ID = id
+	updated.Version = existing.Version + 1
+	updated.CreatedAt = existing.CreatedAt
+	stored := *updated
+	tx.changes[id] = &stored
+	return updated, nil
+}
+
+func (tx *inMemoryTx) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, exists := tx.get(id); !exists {
+		return ErrNotFound
+	}
+	tx.changes[id] = nil
+	return nil
+}
+
+// AddEvents stages events, appended to the outbox on commit
+func (tx *inMemoryTx) AddEvents(ctx context.Context, events ...*models.OutboxEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, e := range events {
+		staged := *e
+		if staged.Post != nil {
+			post := *staged.Post
+			staged.Post = &post
+		}
+		tx.events = append(tx.events, &staged)
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/repositories"
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -220,6 +222,9 @@ func TestInMemoryStoreBlogPostRepo_Update_Success(t *testing.T) {
 	if stored.Title != "Updated Title" {
 		t.Errorf("expected stored title 'Updated Title', got %s", stored.Title)
 	}
+	if stored.Version != 2 {
+		t.Errorf("expected version 2 after one update, got %d", stored.Version)
+	}
 }
 
 func TestInMemoryStoreBlogPostRepo_Update_ContextCanceled(t *testing.T) {
@@ -461,3 +466,129 @@ func TestInMemoryStoreBlogPostRepo_ConcurrentReadWrite(t *testing.T) {
 		t.Errorf("expected ID 'test-post', got %s", post.ID)
 	}
 }
+
+func TestInMemoryStoreBlogPostRepo_Ping(t *testing.T) {
+	repo := NewInMemoryStoreBlogPostRepo()
+
+	if err := repo.Ping(context.Background()); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // cancel the context
+	if err := repo.Ping(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled error, got %v", err)
+	}
+}
+
+func TestInMemoryStoreBlogPostRepo_WithinTx(t *testing.T) {
+	repo := NewInMemoryStoreBlogPostRepo()
+	ctx := context.Background()
+	repo.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post"})
+
+	err := repo.WithinTx(ctx, func(tx repositories.BlogPostRepo) error {
+		if _, err := tx.Create(ctx, &models.BlogPost{ID: "2", Title: "Second"}); err != nil {
+			return err
+		}
+		return tx.Delete(ctx, "1")
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := repo.GetById(ctx, "1"); err != ErrNotFound {
+		t.Errorf("expected the deletion to be committed, got %v", err)
+	}
+	if _, err := repo.GetById(ctx, "2"); err != nil {
+		t.Errorf("expected the creation to be committed, got %v", err)
+	}
+}
+
+func TestInMemoryStoreBlogPostRepo_WithinTx_Rollback(t *testing.T) {
+	repo := NewInMemoryStoreBlogPostRepo()
+	ctx := context.Background()
+	repo.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post"})
+
+	failure := errors.New("second operation failed")
+	err := repo.WithinTx(ctx, func(tx repositories.BlogPostRepo) error {
+		if _, err := tx.Update(ctx, "1", &models.BlogPost{Title: "Updated"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if err != failure {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+
+	stored, _ := repo.GetById(ctx, "1")
+	if stored.Title != "Test Post" || stored.Version != 1 {
+		t.Errorf("expected the update to be rolled back, got %s version %d", stored.Title, stored.Version)
+	}
+}
+
+func TestInMemoryStoreBlogPostRepo_GetBySlug(t *testing.T) {
+	repo := NewInMemoryStoreBlogPostRepo()
+	ctx := context.Background()
+	repo.Create(ctx, &models.BlogPost{ID: "1", Slug: "first"})
+	repo.Create(ctx, &models.BlogPost{ID: "2", Slug: "second"})
+	repo.Update(ctx, "1", &models.BlogPost{Slug: "renamed"})
+	repo.Delete(ctx, "2")
+
+	slugOf := func(repo repositories.SlugBlogPostRepo, slug string) string {
+		post, err := repo.GetBySlug(ctx, slug)
+		if err != nil {
+			return err.Error()
+		}
+		return post.ID
+	}
+	for slug, want := range map[string]string{"renamed": "1", "first": ErrNotFound.Error(), "second": ErrNotFound.Error()} {
+		if got := slugOf(repo, slug); got != want {
+			t.Errorf("expected %q for %s, got %q", want, slug, got)
+		}
+	}
+
+	repo.WithinTx(ctx, func(tx repositories.BlogPostRepo) error {
+		tx.Create(ctx, &models.BlogPost{ID: "3", Slug: "third"})
+		tx.Update(ctx, "1", &models.BlogPost{Slug: "first"})
+		indexed := tx.(repositories.SlugBlogPostRepo)
+		if slugOf(indexed, "third") != "3" || slugOf(indexed, "first") != "1" || slugOf(indexed, "renamed") == "1" {
+			t.Errorf("expected the slugs as changed by the transaction")
+		}
+		return nil
+	})
+	if slugOf(repo, "third") != "3" || slugOf(repo, "first") != "1" || slugOf(repo, "renamed") == "1" {
+		t.Errorf("expected the slugs as committed")
+	}
+}
+
+func TestInMemoryStoreBlogPostRepo_Outbox(t *testing.T) {
+	repo := NewInMemoryStoreBlogPostRepo()
+	ctx := context.Background()
+
+	repo.WithinTx(ctx, func(tx repositories.BlogPostRepo) error {
+		tx.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post"})
+		return tx.(repositories.OutboxTx).AddEvents(ctx, &models.OutboxEvent{ID: "e1", Type: "post.created", PostID: "1"})
+	})
+	repo.WithinTx(ctx, func(tx repositories.BlogPostRepo) error {
+		tx.(repositories.OutboxTx).AddEvents(ctx, &models.OutboxEvent{ID: "e2", Type: "post.deleted", PostID: "1"})
+		return errors.New("rolled back")
+	})
+	repo.WithinTx(ctx, func(tx repositories.BlogPostRepo) error {
+		return tx.(repositories.OutboxTx).AddEvents(ctx, &models.OutboxEvent{ID: "e3", Type: "post.updated", PostID: "1"})
+	})
+
+	events, err := repo.PendingEvents(ctx, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(events) != 2 || events[0].ID != "e1" || events[1].ID != "e3" || events[0].Sequence >= events[1].Sequence {
+		t.Fatalf("expected the committed events in order, got %+v", events)
+	}
+
+	if err := repo.MarkPublished(ctx, "e1", "unknown"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if events, _ = repo.PendingEvents(ctx, 1); len(events) != 1 || events[0].ID != "e3" {
+		t.Errorf("expected only e3 to be pending, got %+v", events)
+	}
+}
@@ -1,40 +1,506 @@
 package services
 
 import (
+	"blog-posts-api/internal/api/apperrors"
 	"blog-posts-api/internal/api/models"
 	"blog-posts-api/internal/api/repositories"
+	"blog-posts-api/internal/api/validation"
+	"blog-posts-api/internal/render"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 var (
-	ErrNotFound = errors.New("blog post not found")
+	ErrNotFound = apperrors.NotFound("blog post not found")
+	// ErrVersionConflict is returned by UpdateVersion when the post changed
+	// since the version it was based on
+	ErrVersionConflict = apperrors.Conflict("blog post was changed since this version")
 )
 
+// renderCacheSize bounds the number of rendered contents kept in memory
+const renderCacheSize = 512
+
 type BlogPostService struct {
-	repo repositories.BlogPostRepo
+	repo     repositories.BlogPostRepo
+	renderer *render.Renderer
+	rendered *render.Cache
+	events   listeners
+	// authors, when set by NewAuthorService, links the posts to their
+	// author
+	authors *AuthorService
 }
 
 func NewBlogPostService(r repositories.BlogPostRepo) *BlogPostService {
-	return &BlogPostService{r}
+	return &BlogPostService{
+		repo:     r,
+		renderer: render.NewRenderer(),
+		rendered: render.NewCache(renderCacheSize),
+	}
+}
+
+// Subscribe registers a listener notified synchronously after every
+// successful create, update and delete. Listeners must be fast and must not
+// make changes through the service; concurrent changes may be notified out
+// of order. They are not notified of changes committed
+// by a process that stopped right after: consumers that must see every
+// change read the outbox of the repository instead (see internal/outbox).
+func (s *BlogPostService) Subscribe(fn Listener) {
+	s.events.add(fn)
 }
 
 func (s *BlogPostService) Create(ctx context.Context, post *models.BlogPost) (*models.BlogPost, error) {
-	return s.repo.Create(ctx, post)
+	var created *models.BlogPost
+	events, err := s.write(ctx, func(repo repositories.BlogPostRepo) ([]Event, error) {
+		var events []Event
+		var err error
+		created, events, err = s.create(ctx, repo, post)
+		return events, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.events.notify(events...)
+	return created, nil
+}
+
+// create stores a new post and returns the events to publish once the
+// change is visible
+func (s *BlogPostService) create(ctx context.Context, repo repositories.BlogPostRepo, post *models.BlogPost) (*models.BlogPost, []Event, error) {
+	if err := ValidateCreate(post); err != nil {
+		return nil, nil, err
+	}
+	if err := s.linkAuthor(ctx, repo, post); err != nil {
+		return nil, nil, err
+	}
+	now := time.Now().UTC()
+	// importers may keep the original publication date
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = now
+	}
+	post.UpdatedAt = now
+	if post.Slug == "" {
+		post.Slug = defaultSlug(post)
+	}
+	slug, err := uniqueSlug(ctx, repo, post.ID, post.Slug)
+	if err != nil {
+		return nil, nil, err
+	}
+	post.Slug = slug
+	// attachments are only added once the post exists, with their files
+	post.Attachments = nil
+	if !post.IsPublished() {
+		post.PublishedAt = nil
+	} else if post.PublishedAt == nil {
+		publishedAt := post.CreatedAt
+		post.PublishedAt = &publishedAt
+	}
+	created, err := repo.Create(ctx, post)
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, changeEvents(EventPostCreated, created, false), nil
 }
 
 func (s *BlogPostService) GetAll(ctx context.Context) ([]*models.BlogPost, error) {
 	return s.repo.GetAll(ctx)
 }
 
+// GetLatest returns up to limit posts matching the filter, most recently
+// created first. A limit <= 0 returns every matching post. The repository
+// selects the posts itself when it is a FilteredBlogPostRepo.
+func (s *BlogPostService) GetLatest(ctx context.Context, filter models.PostFilter, limit int) ([]*models.BlogPost, error) {
+	matching, err := findPosts(ctx, s.repo, filter)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		if !matching[i].CreatedAt.Equal(matching[j].CreatedAt) {
+			return matching[i].CreatedAt.After(matching[j].CreatedAt)
+		}
+		return matching[i].ID < matching[j].ID
+	})
+
+	if limit > 0 && len(matching) > limit {
+		matching = matching[:limit]
+	}
+	return matching, nil
+}
+
+// findPosts returns the posts of repo matching the filter, in no particular
+// order, selected by the repository itself when it is a
+// FilteredBlogPostRepo
+func findPosts(ctx context.Context, repo repositories.BlogPostRepo, filter models.PostFilter) ([]*models.BlogPost, error) {
+	if filtered, ok := repo.(repositories.FilteredBlogPostRepo); ok && !filter.IsZero() {
+		return filtered.Find(ctx, filter)
+	}
+	posts, err := repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(posts, func(p *models.BlogPost) bool { return !filter.Matches(p) }), nil
+}
+
 func (s *BlogPostService) GetById(ctx context.Context, id string) (*models.BlogPost, error) {
 	return s.repo.GetById(ctx, id)
 }
 
 func (s *BlogPostService) Update(ctx context.Context, id string, post *models.BlogPost) (*models.BlogPost, error) {
-	return s.repo.Update(ctx, id, post)
+	return s.UpdateVersion(ctx, id, 0, post)
+}
+
+// UpdateVersion is like Update but fails with ErrVersionConflict unless the
+// stored post is at the given version, so that concurrent editors do not
+// overwrite each other. A zero version updates any version.
+func (s *BlogPostService) UpdateVersion(ctx context.Context, id string, version int, post *models.BlogPost) (*models.BlogPost, error) {
+	var updated *models.BlogPost
+	events, err := s.write(ctx, func(repo repositories.BlogPostRepo) ([]Event, error) {
+		var events []Event
+		var err error
+		updated, events, err = s.update(ctx, repo, id, version, post)
+		return events, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.events.notify(events...)
+	return updated, nil
+}
+
+// update replaces a stored post, at the given version unless zero, and
+// returns the events to publish once the change is visible
+func (s *BlogPostService) update(ctx context.Context, repo repositories.BlogPostRepo, id string, version int, post *models.BlogPost) (*models.BlogPost, []Event, error) {
+	if err := ValidateUpdate(post); err != nil {
+		return nil, nil, err
+	}
+	existing, err := repo.GetById(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version != 0 && existing.Version != version {
+		return nil, nil, ErrVersionConflict
+	}
+	if err := s.linkAuthor(ctx, repo, post); err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC()
+	post.UpdatedAt = now
+	post.Attachments = existing.Attachments
+	// a slug is part of public URLs, it only changes when asked to
+	if post.Slug == "" {
+		post.Slug = existing.Slug
+	}
+	if post.Slug == "" {
+		post.ID = id
+		post.Slug = defaultSlug(post)
+	}
+	if post.Slug, err = uniqueSlug(ctx, repo, id, post.Slug); err != nil {
+		return nil, nil, err
+	}
+	post.PublishedAt = nil
+	if post.IsPublished() {
+		// republishing a post keeps its original publication date
+		publishedAt := now
+		if existing.PublishedAt != nil {
+			publishedAt = *existing.PublishedAt
+		}
+		post.PublishedAt = &publishedAt
+	}
+	updated, err := repo.Update(ctx, id, post)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, changeEvents(EventPostUpdated, updated, existing.IsPublished()), nil
+}
+
+// ConflictPolicy tells an import what to do with a post whose ID already exists
+type ConflictPolicy string
+
+const (
+	ConflictSkip   ConflictPolicy = "skip"
+	ConflictUpsert ConflictPolicy = "upsert"
+)
+
+type ImportOptions struct {
+	OnConflict ConflictPolicy
+	// DryRun validates the post and reports the outcome without writing it
+	DryRun bool
+}
+
+// Import creates a post, keeping its ID and dates if set, or applies the
+// conflict policy when a post with the same ID exists. It returns the
+// outcome as one of models.ImportCreated, ImportUpdated and ImportSkipped.
+func (s *BlogPostService) Import(ctx context.Context, post *models.BlogPost, opts ImportOptions) (string, error) {
+	if post == nil {
+		return "", apperrors.BadRequest("post cannot be nil", nil)
+	}
+	if post.ID == "" {
+		post.ID = uuid.New().String()
+	}
+
+	_, err := s.repo.GetById(ctx, post.ID)
+	if err != nil && !apperrors.Is(err, apperrors.KindNotFound) {
+		return "", err
+	}
+	exists := err == nil
+
+	switch {
+	case !exists && opts.DryRun:
+		return models.ImportCreated, ValidateCreate(post)
+	case !exists:
+		_, err = s.Create(ctx, post)
+		return models.ImportCreated, err
+	case opts.OnConflict != ConflictUpsert:
+		return models.ImportSkipped, nil
+	case opts.DryRun:
+		return models.ImportUpdated, ValidateUpdate(post)
+	default:
+		_, err = s.Update(ctx, post.ID, post)
+		return models.ImportUpdated, err
+	}
+}
+
+// Export calls fn with every post, oldest first, and stops at the first
+// error returned by fn
+func (s *BlogPostService) Export(ctx context.Context, fn func(*models.BlogPost) error) error {
+	posts, err := s.GetLatest(ctx, models.PostFilter{}, 0)
+	if err != nil {
+		return err
+	}
+	for i := len(posts) - 1; i >= 0; i-- {
+		if err := fn(posts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateCreate applies the rules of models.BlogPostCreate to a post,
+// sanitizing its fields in place. Non-HTTP callers (importers, batch jobs)
+// get the same rules as the API through the service.
+func ValidateCreate(post *models.BlogPost) error {
+	if post == nil {
+		return apperrors.BadRequest("post cannot be nil", nil)
+	}
+	body := post.CreateBody()
+	if err := validation.Struct(&body); err != nil {
+		return err
+	}
+	applyEditable(post, body.ToBlogPost())
+	return nil
+}
+
+// ValidateUpdate applies the rules of models.BlogPostUpdate to a post,
+// sanitizing its fields in place
+func ValidateUpdate(post *models.BlogPost) error {
+	if post == nil {
+		return apperrors.BadRequest("updated post cannot be nil", nil)
+	}
+	body := post.UpdateBody()
+	if err := validation.Struct(&body); err != nil {
+		return err
+	}
+	applyEditable(post, body.ToBlogPost())
+	return nil
+}
+
+// applyEditable copies the sanitized client-editable fields onto the post
+// and fills in their defaults
+func applyEditable(post *models.BlogPost, sanitized models.BlogPost) {
+	post.Title = sanitized.Title
+	post.Content = sanitized.Content
+	post.ContentFormat = sanitized.ContentFormat
+	if post.ContentFormat == "" {
+		post.ContentFormat = models.ContentFormatPlain
+	}
+	post.Slug = sanitized.Slug
+	post.Author = sanitized.Author
+	post.AuthorID = sanitized.AuthorID
+	post.Tags = normalizeTags(sanitized.Tags)
+	post.Status = sanitized.Status
+	if post.Status == "" {
+		post.Status = models.StatusPublished
+	}
+}
+
+// linkAuthor links a post to its author within the change made through
+// repo, see AuthorService
+func (s *BlogPostService) linkAuthor(ctx context.Context, repo repositories.BlogPostRepo, post *models.BlogPost) error {
+	if s.authors == nil {
+		if post.AuthorID != "" {
+			return errUnknownAuthor
+		}
+		return nil
+	}
+	return s.authors.link(ctx, repo, post)
+}
+
+// renameAuthor sets the new name of an author on its posts
+func (s *BlogPostService) renameAuthor(ctx context.Context, authorID, name string) error {
+	posts, err := s.GetLatest(ctx, models.PostFilter{AuthorID: authorID}, 0)
+	if err != nil {
+		return err
+	}
+	for _, p := range posts {
+		err := s.editPost(ctx, p.ID, func(_ repositories.BlogPostRepo, post *models.BlogPost) error {
+			if post.AuthorID != authorID || post.Author == name {
+				return errUnchanged
+			}
+			post.Author = name
+			return nil
+		})
+		if err != nil && !apperrors.Is(err, apperrors.KindNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// errUnchanged is returned by the edits of editPost leaving the post as is
+var errUnchanged = errors.New("unchanged")
+
+// editPost stores a new version of a post changed by edit, unless edit
+// returns errUnchanged, in which case nil is returned. edit is given the
+// repository of the change.
+func (s *BlogPostService) editPost(ctx context.Context, id string, edit func(repo repositories.BlogPostRepo, post *models.BlogPost) error) error {
+	events, err := s.write(ctx, func(repo repositories.BlogPostRepo) ([]Event, error) {
+		existing, err := repo.GetById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		post := *existing
+		if err := edit(repo, &post); err != nil {
+			return nil, err
+		}
+		post.UpdatedAt = time.Now().UTC()
+		updated, err := repo.Update(ctx, id, &post)
+		if err != nil {
+			return nil, err
+		}
+		return changeEvents(EventPostUpdated, updated, existing.IsPublished()), nil
+	})
+	if errors.Is(err, errUnchanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.events.notify(events...)
+	return nil
+}
+
+// defaultSlug derives the slug of a post from its title, falling back to
+// its ID for titles without any ASCII letter or digit
+func defaultSlug(post *models.BlogPost) string {
+	if slug := Slugify(post.Title); slug != "" {
+		return slug
+	}
+	return post.ID
+}
+
+// uniqueSlug returns slug, or slug with the first free -2, -3... suffix
+// when another post uses it, so that every post has a page of its own at
+// /p/<slug>. It reads repo within the transaction of the change.
+func uniqueSlug(ctx context.Context, repo repositories.BlogPostRepo, id, slug string) (string, error) {
+	unique := slug
+	for n := 2; ; n++ {
+		post, err := postBySlug(ctx, repo, unique)
+		if apperrors.Is(err, apperrors.KindNotFound) || err == nil && post.ID == id {
+			return unique, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		suffix := "-" + strconv.Itoa(n)
+		unique = strings.TrimRight(slug[:min(len(slug), maxSlugLength-len(suffix))], "-") + suffix
+	}
+}
+
+// postBySlug looks a post up by its slug, in the index of the repository
+// when it has one
+func postBySlug(ctx context.Context, repo repositories.BlogPostRepo, slug string) (*models.BlogPost, error) {
+	if indexed, ok := repo.(repositories.SlugBlogPostRepo); ok {
+		return indexed.GetBySlug(ctx, slug)
+	}
+	posts, err := repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		if p.Slug == slug {
+			return p, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// normalizeTags drops empty and duplicate tags, keeping the first occurrence order
+func normalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
 }
 
 func (s *BlogPostService) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+	events, err := s.write(ctx, func(repo repositories.BlogPostRepo) ([]Event, error) {
+		if err := repo.Delete(ctx, id); err != nil {
+			return nil, err
+		}
+		return []Event{newEvent(EventPostDeleted, id, nil)}, nil
+	})
+	if err != nil {
+		return err
+	}
+	s.events.notify(events...)
+	return nil
+}
+
+// GetRendered returns a post along with its content rendered to sanitized
+// HTML. The rendered output is cached per content, see RenderContent.
+func (s *BlogPostService) GetRendered(ctx context.Context, id string) (*models.RenderedBlogPost, error) {
+	post, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := s.RenderContent(post)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RenderedBlogPost{BlogPost: *post, ContentHTML: html}, nil
+}
+
+// RenderContent renders the content of a post to sanitized HTML, reusing
+// the cached output of the same content. The cache is keyed by a hash of
+// the content rather than by post ID and version, which start over when a
+// post is deleted and created again with the same ID.
+func (s *BlogPostService) RenderContent(post *models.BlogPost) (string, error) {
+	sum := sha256.Sum256([]byte(post.Content))
+	key := post.ContentFormat + "/" + hex.EncodeToString(sum[:])
+	if html, ok := s.rendered.Get(key); ok {
+		return html, nil
+	}
+
+	html, err := s.renderer.Render(post.ContentFormat, post.Content)
+	if err != nil {
+		return "", apperrors.Internal("failed to render blog post content", err)
+	}
+	s.rendered.Put(key, html)
+	return html, nil
 }
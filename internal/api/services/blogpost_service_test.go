@@ -0,0 +1,505 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBlogPostService_Create_Validates(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+
+	post := &models.BlogPost{
+		ID:      "1",
+		Title:   strings.Repeat("a", 201),
+		Content: "Test content",
+	}
+
+	_, err := service.Create(ctx, post)
+	if !apperrors.Is(err, apperrors.KindValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+
+	// nothing must have been stored
+	if _, err := service.GetById(ctx, "1"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestBlogPostService_Create_Sanitizes(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+
+	post := &models.BlogPost{
+		ID:      "1",
+		Title:   "  Test\nPost  ",
+		Content: "Test\r\ncontent\u0000",
+		Author:  "Test Author",
+	}
+
+	created, err := service.Create(ctx, post)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if created.Title != "Test Post" {
+		t.Errorf("expected title 'Test Post', got %q", created.Title)
+	}
+	if created.Content != "Test\ncontent" {
+		t.Errorf("expected content %q, got %q", "Test\ncontent", created.Content)
+	}
+}
+
+func TestBlogPostService_Update_Validates(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+
+	_, err := service.Update(ctx, "1", &models.BlogPost{Title: "Test Post"})
+	if !apperrors.Is(err, apperrors.KindValidation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestBlogPostService_Create_DefaultsContentFormat(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+
+	created, err := service.Create(context.Background(), &models.BlogPost{
+		ID:      "1",
+		Title:   "Test Post",
+		Content: "Test content",
+		Author:  "Test Author",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if created.ContentFormat != models.ContentFormatPlain {
+		t.Errorf("expected content format %q, got %q", models.ContentFormatPlain, created.ContentFormat)
+	}
+}
+
+func TestBlogPostService_Create_RejectsUnknownContentFormat(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+
+	_, err := service.Create(context.Background(), &models.BlogPost{
+		ID:            "1",
+		Title:         "Test Post",
+		Content:       "Test content",
+		ContentFormat: "asciidoc",
+		Author:        "Test Author",
+	})
+	if !apperrors.Is(err, apperrors.KindValidation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestBlogPostService_GetRendered_CachedPerContent(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+
+	post := &models.BlogPost{
+		ID:            "1",
+		Title:         "Test Post",
+		Content:       "# First",
+		ContentFormat: models.ContentFormatMarkdown,
+		Author:        "Test Author",
+	}
+	if _, err := service.Create(ctx, post); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	first, err := service.GetRendered(ctx, "1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(first.ContentHTML, "First") {
+		t.Errorf("expected rendered content, got %s", first.ContentHTML)
+	}
+	if _, err := service.GetRendered(ctx, "1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if service.rendered.Len() != 1 {
+		t.Errorf("expected 1 cached version, got %d", service.rendered.Len())
+	}
+
+	updated := &models.BlogPost{
+		Title:         "Test Post",
+		Content:       "# Second",
+		ContentFormat: models.ContentFormatMarkdown,
+		Author:        "Test Author",
+	}
+	if _, err := service.Update(ctx, "1", updated); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	second, err := service.GetRendered(ctx, "1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(second.ContentHTML, "Second") {
+		t.Errorf("expected content of the new version, got %s", second.ContentHTML)
+	}
+
+	// a post created again with the same ID starts over at version 1
+	if err := service.Delete(ctx, "1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	post.Content = "# Third"
+	if _, err := service.Create(ctx, post); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	third, err := service.GetRendered(ctx, "1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if third.Version != 1 || !strings.Contains(third.ContentHTML, "Third") {
+		t.Errorf("expected the content of the new post, got version %d and %s", third.Version, third.ContentHTML)
+	}
+}
+
+func TestBlogPostService_Create_NormalizesTags(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+
+	created, err := service.Create(context.Background(), &models.BlogPost{
+		ID:      "1",
+		Title:   "Test Post",
+		Content: "Test content",
+		Author:  "Test Author",
+		Tags:    []string{" Go ", "go", "", "Tutorial"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if strings.Join(created.Tags, ",") != "go,tutorial" {
+		t.Errorf("expected tags [go tutorial], got %v", created.Tags)
+	}
+	if created.CreatedAt.IsZero() || created.UpdatedAt.IsZero() {
+		t.Error("expected timestamps to be set")
+	}
+}
+
+func TestBlogPostService_Update_KeepsCreatedAt(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+
+	createdAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := service.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author", CreatedAt: createdAt})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	updated, err := service.Update(ctx, "1", &models.BlogPost{Title: "Updated", Content: "Updated content", Author: "Test Author"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !updated.CreatedAt.Equal(createdAt) {
+		t.Errorf("expected created_at %s to be kept, got %s", createdAt, updated.CreatedAt)
+	}
+	if !updated.UpdatedAt.After(createdAt) {
+		t.Errorf("expected updated_at to be refreshed, got %s", updated.UpdatedAt)
+	}
+}
+
+func TestBlogPostService_UpdateVersion(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+	service.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+
+	updated, err := service.UpdateVersion(ctx, "1", 1, &models.BlogPost{Title: "Updated", Content: "Test content", Author: "Test Author"})
+	if err != nil || updated.Version != 2 {
+		t.Fatalf("expected version 2, got %v and %v", updated, err)
+	}
+	if _, err := service.UpdateVersion(ctx, "1", 1, &models.BlogPost{Title: "Stale", Content: "Test content", Author: "Test Author"}); err != ErrVersionConflict {
+		t.Errorf("expected ErrVersionConflict, got %v", err)
+	}
+	if post, _ := service.GetById(ctx, "1"); post.Title != "Updated" {
+		t.Errorf("expected the stale update to be rejected, got %q", post.Title)
+	}
+}
+
+func TestBlogPostService_GetLatest(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+
+	for i, author := range []string{"Alice", "Bob", "Alice"} {
+		_, err := service.Create(ctx, &models.BlogPost{
+			ID:        fmt.Sprintf("%d", i+1),
+			Title:     fmt.Sprintf("Post %d", i+1),
+			Content:   "Test content",
+			Author:    author,
+			CreatedAt: time.Date(2025, 1, i+1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	posts, err := service.GetLatest(ctx, models.PostFilter{Author: "Alice"}, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != "3" || posts[1].ID != "1" {
+		t.Errorf("expected posts 3 and 1, got %v", posts)
+	}
+
+	posts, err = service.GetLatest(ctx, models.PostFilter{}, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(posts) != 1 || posts[0].ID != "3" {
+		t.Errorf("expected only post 3, got %v", posts)
+	}
+}
+
+func TestBlogPostService_GetPage(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"1", "2", "3"} {
+		service.Create(ctx, &models.BlogPost{ID: id, Title: "Test Post", Content: "Test content", Author: "Test Author", CreatedAt: base.Add(time.Duration(i) * time.Hour)})
+	}
+
+	page, next, err := service.GetPage(ctx, models.PostFilter{}, "", 2)
+	if err != nil || len(page) != 2 || page[0].ID != "3" || next == "" {
+		t.Fatalf("expected posts 3 and 2 and a next page, got %v, %q and %v", page, next, err)
+	}
+	// a newer post does not shift the next page
+	service.Create(ctx, &models.BlogPost{ID: "4", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+	page, next, err = service.GetPage(ctx, models.PostFilter{}, next, 2)
+	if err != nil || len(page) != 1 || page[0].ID != "1" || next != "" {
+		t.Errorf("expected post 1 on the last page, got %v, %q and %v", page, next, err)
+	}
+
+	if _, _, err := service.GetPage(ctx, models.PostFilter{}, "not a token", 2); !apperrors.Is(err, apperrors.KindBadRequest) {
+		t.Errorf("expected a bad request error, got %v", err)
+	}
+}
+
+func TestBlogPostService_PublishedAt(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+
+	draft, err := service.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author", Status: models.StatusDraft})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if draft.PublishedAt != nil {
+		t.Errorf("expected a draft to have no published_at, got %v", draft.PublishedAt)
+	}
+
+	published, err := service.Update(ctx, "1", &models.BlogPost{Title: "Test Post", Content: "Test content", Author: "Test Author", Status: models.StatusPublished})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if published.PublishedAt == nil {
+		t.Fatal("expected published_at to be set on publication")
+	}
+	publishedAt := *published.PublishedAt
+
+	edited, err := service.Update(ctx, "1", &models.BlogPost{Title: "Edited", Content: "Test content", Author: "Test Author"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if edited.Status != models.StatusPublished {
+		t.Errorf("expected status to default to published, got %s", edited.Status)
+	}
+	if edited.PublishedAt == nil || !edited.PublishedAt.Equal(publishedAt) {
+		t.Errorf("expected published_at %s to be kept, got %v", publishedAt, edited.PublishedAt)
+	}
+}
+
+func TestBlogPostService_RejectsUnknownStatus(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+
+	_, err := service.Create(context.Background(), &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author", Status: "archived"})
+	if !apperrors.Is(err, apperrors.KindValidation) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
+
+func TestBlogPostService_NotifiesListeners(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+
+	var events []Event
+	service.Subscribe(func(e Event) { events = append(events, e) })
+
+	post := &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"}
+	if _, err := service.Create(ctx, post); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Update(ctx, "1", &models.BlogPost{Title: "Updated", Content: "Test content", Author: "Test Author"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Update(ctx, "missing", &models.BlogPost{Title: "Updated", Content: "Test content", Author: "Test Author"}); err == nil {
+		t.Fatal("expected an error for a missing post")
+	}
+	if err := service.Delete(ctx, "1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	// posts are published by default
+	expected := []EventType{EventPostCreated, EventPostPublished, EventPostUpdated, EventPostDeleted}
+	if !slices.Equal(types, expected) {
+		t.Fatalf("expected events %v, got %v", expected, types)
+	}
+	if events[2].Post == nil || events[2].Post.Version != 2 {
+		t.Errorf("expected the update event to carry the stored post, got %+v", events[2].Post)
+	}
+	if events[3].Post != nil || events[3].PostID != "1" {
+		t.Errorf("expected the delete event to carry only the ID, got %+v", events[3])
+	}
+}
+
+func TestBlogPostService_PublishedEvent(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+
+	var types []EventType
+	service.Subscribe(func(e Event) { types = append(types, e.Type) })
+
+	draft := func() *models.BlogPost {
+		return &models.BlogPost{Title: "Test Post", Content: "Test content", Author: "Test Author", Status: models.StatusDraft}
+	}
+	published := func() *models.BlogPost {
+		return &models.BlogPost{Title: "Test Post", Content: "Test content", Author: "Test Author", Status: models.StatusPublished}
+	}
+	service.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author", Status: models.StatusDraft})
+	service.Update(ctx, "1", draft())
+	service.Update(ctx, "1", published())
+	// already published
+	service.Update(ctx, "1", published())
+	service.Update(ctx, "1", draft())
+	// republished
+	service.Update(ctx, "1", published())
+
+	expected := []EventType{
+		EventPostCreated, EventPostUpdated,
+		EventPostUpdated, EventPostPublished,
+		EventPostUpdated, EventPostUpdated,
+		EventPostUpdated, EventPostPublished,
+	}
+	if !slices.Equal(types, expected) {
+		t.Errorf("expected events %v, got %v", expected, types)
+	}
+}
+
+func TestBlogPostService_RecordsOutboxEvents(t *testing.T) {
+	repo := NewInMemoryStoreBlogPostRepo()
+	service := NewBlogPostService(repo)
+	ctx := context.Background()
+
+	var notified []Event
+	service.Subscribe(func(e Event) { notified = append(notified, e) })
+
+	service.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+	service.Update(ctx, "missing", &models.BlogPost{Title: "Updated", Content: "Test content", Author: "Test Author"})
+	service.Delete(ctx, "1")
+
+	records, err := repo.PendingEvents(ctx, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(records) != len(notified) {
+		t.Fatalf("expected %d outbox events, got %d", len(notified), len(records))
+	}
+	for i, record := range records {
+		if e := EventFromOutbox(record); e.ID != notified[i].ID || e.Type != notified[i].Type || e.PostID != notified[i].PostID {
+			t.Errorf("expected outbox event %d to be %+v, got %+v", i, notified[i], e)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	for title, expected := range map[string]string{
+		"Getting Started with Go":   "getting-started-with-go",
+		"Crème brûlée, in 3 steps!": "creme-brulee-in-3-steps",
+		"  --Go--  ":                "go",
+		"日本語":                       "",
+		strings.Repeat("a", 150):    strings.Repeat("a", 100),
+	} {
+		if got := Slugify(title); got != expected {
+			t.Errorf("expected slug %q for %q, got %q", expected, title, got)
+		}
+	}
+}
+
+func TestBlogPostService_Slug(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+
+	created, err := service.Create(ctx, &models.BlogPost{ID: "1", Title: "Getting Started", Content: "Test content", Author: "Test Author"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if created.Slug != "getting-started" {
+		t.Errorf("expected a slug derived from the title, got %q", created.Slug)
+	}
+
+	updated, err := service.Update(ctx, "1", &models.BlogPost{Title: "Renamed", Content: "Test content", Author: "Test Author"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if updated.Slug != "getting-started" {
+		t.Errorf("expected the slug to be kept on rename, got %q", updated.Slug)
+	}
+
+	_, err = service.Create(ctx, &models.BlogPost{ID: "2", Title: "Test", Slug: "Not a slug!", Content: "Test content", Author: "Test Author"})
+	if !apperrors.Is(err, apperrors.KindValidation) {
+		t.Errorf("expected a validation error for an invalid slug, got %v", err)
+	}
+}
+
+func TestBlogPostService_Slug_Unique(t *testing.T) {
+	service := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+	create := func(id, title, slug string) *models.BlogPost {
+		t.Helper()
+		post, err := service.Create(ctx, &models.BlogPost{ID: id, Title: title, Slug: slug, Content: "Test content", Author: "Test Author"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return post
+	}
+
+	create("1", "Getting Started", "")
+	if post := create("2", "Getting Started", ""); post.Slug != "getting-started-2" {
+		t.Errorf("expected a suffix for a derived slug, got %q", post.Slug)
+	}
+	if post := create("3", "Other", "getting-started"); post.Slug != "getting-started-3" {
+		t.Errorf("expected a suffix for a chosen slug, got %q", post.Slug)
+	}
+
+	// a post keeps its own slug, and can't take the slug of another one
+	updated, err := service.Update(ctx, "1", &models.BlogPost{Title: "Renamed", Content: "Test content", Author: "Test Author"})
+	if err != nil || updated.Slug != "getting-started" {
+		t.Errorf("expected the slug to be kept, got %q (%v)", updated.Slug, err)
+	}
+	updated, err = service.Update(ctx, "3", &models.BlogPost{Title: "Other", Slug: "getting-started-2", Content: "Test content", Author: "Test Author"})
+	if err != nil || updated.Slug != "getting-started-2-2" {
+		t.Errorf("expected the slug of another post to be refused, got %q (%v)", updated.Slug, err)
+	}
+
+	// posts created by the same transaction see each other
+	results, err := service.Batch(ctx, []BatchOp{
+		{Kind: BatchCreate, Post: &models.BlogPost{ID: "6", Title: "Batched", Content: "Test content", Author: "Test Author"}},
+		{Kind: BatchCreate, Post: &models.BlogPost{ID: "7", Title: "Batched", Content: "Test content", Author: "Test Author"}},
+	}, true)
+	if err != nil || results[0].Post.Slug != "batched" || results[1].Post.Slug != "batched-2" {
+		t.Errorf("expected distinct slugs within a batch, got %+v (%v)", results, err)
+	}
+
+	long := strings.Repeat("a", 98) + "-b"
+	create("4", "Long", long)
+	if post := create("5", "Long", long); len(post.Slug) > 100 || post.Slug != strings.Repeat("a", 98)+"-2" {
+		t.Errorf("expected the suffix to fit in 100 characters, got %q", post.Slug)
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType names a change made to a blog post
+type EventType string
+
+const (
+	EventPostCreated EventType = "post.created"
+	EventPostUpdated EventType = "post.updated"
+	EventPostDeleted EventType = "post.deleted"
+	// EventPostPublished follows the created or updated event of a post
+	// becoming published, i.e. created as published or updated from a draft
+	EventPostPublished EventType = "post.published"
+)
+
+// EventTypes lists every event type, in the order they are documented
+var EventTypes = []EventType{EventPostCreated, EventPostUpdated, EventPostDeleted, EventPostPublished}
+
+// Event describes a change committed to the repository. Post holds the
+// stored post and is nil for deletions; listeners must not modify it.
+type Event struct {
+	// ID is unique per event, e.g. for receivers to discard duplicates
+	ID         string
+	Type       EventType
+	PostID     string
+	Post       *models.BlogPost
+	OccurredAt time.Time
+}
+
+func newEvent(t EventType, id string, post *models.BlogPost) Event {
+	return Event{ID: uuid.New().String(), Type: t, PostID: id, Post: post, OccurredAt: time.Now().UTC()}
+}
+
+// Listener is notified of the changes made through the service
+type Listener func(Event)
+
+type listeners struct {
+	mu  sync.RWMutex
+	fns []Listener
+}
+
+func (l *listeners) add(fn Listener) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fns = append(l.fns, fn)
+}
+
+func (l *listeners) notify(events ...Event) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, e := range events {
+		for _, fn := range l.fns {
+			fn(e)
+		}
+	}
+}
+
+// changeEvents returns the events of a created or updated post, wasPublished
+// telling whether the post was published before the change
+func changeEvents(t EventType, post *models.BlogPost, wasPublished bool) []Event {
+	events := []Event{newEvent(t, post.ID, post)}
+	if post.IsPublished() && !wasPublished {
+		events = append(events, newEvent(EventPostPublished, post.ID, post))
+	}
+	return events
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/repositories"
+	"context"
+	"log"
+)
+
+// write runs fn against the repository and returns the events of the
+// changes it made. With an OutboxRepo, fn runs in a transaction recording
+// the events in the outbox, so that they are published by a relay even if
+// the process stops before the listeners are notified.
+func (s *BlogPostService) write(ctx context.Context, fn func(repo repositories.BlogPostRepo) ([]Event, error)) ([]Event, error) {
+	if outbox, ok := s.repo.(repositories.OutboxRepo); ok {
+		return s.withinTx(ctx, outbox, fn)
+	}
+	tx := &linkingTx{BlogPostRepo: s.repo}
+	events, err := fn(tx)
+	if err != nil {
+		s.unlink(ctx, tx)
+	}
+	return events, err
+}
+
+// withinTx runs fn in a transaction of repo, recording the events it
+// returns when the transaction has an outbox. The authors created to link
+// the posts of a transaction rolled back are deleted before the rollback,
+// while no other change can link a post to them.
+func (s *BlogPostService) withinTx(ctx context.Context, repo repositories.TxBlogPostRepo, fn func(tx repositories.BlogPostRepo) ([]Event, error)) ([]Event, error) {
+	var events []Event
+	err := repo.WithinTx(ctx, func(raw repositories.BlogPostRepo) (err error) {
+		tx := &linkingTx{BlogPostRepo: raw}
+		defer func() {
+			if err != nil {
+				s.unlink(ctx, tx)
+			}
+		}()
+		if events, err = fn(tx); err != nil {
+			return err
+		}
+		outbox, ok := raw.(repositories.OutboxTx)
+		if !ok {
+			return nil
+		}
+		records := make([]*models.OutboxEvent, len(events))
+		for i, e := range events {
+			records[i] = &models.OutboxEvent{ID: e.ID, Type: string(e.Type), PostID: e.PostID, Post: e.Post, OccurredAt: e.OccurredAt}
+		}
+		return outbox.AddEvents(ctx, records...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// linkingTx is the repository of a change, keeping the authors created to
+// link its posts
+type linkingTx struct {
+	repositories.BlogPostRepo
+	createdAuthors []string
+}
+
+// Find selects the posts like the repository of the change does
+func (tx *linkingTx) Find(ctx context.Context, filter models.PostFilter) ([]*models.BlogPost, error) {
+	return findPosts(ctx, tx.BlogPostRepo, filter)
+}
+
+// GetBySlug looks the post up like the repository of the change does
+func (tx *linkingTx) GetBySlug(ctx context.Context, slug string) (*models.BlogPost, error) {
+	return postBySlug(ctx, tx.BlogPostRepo, slug)
+}
+
+// unlink deletes the authors created for a failed change
+func (s *BlogPostService) unlink(ctx context.Context, tx *linkingTx) {
+	if s.authors == nil {
+		return
+	}
+	// the change may have failed because ctx is done
+	ctx = context.WithoutCancel(ctx)
+	for _, id := range tx.createdAuthors {
+		if err := s.authors.repo.Delete(ctx, id); err != nil && !apperrors.Is(err, apperrors.KindNotFound) {
+			log.Printf("authors: failed to delete author %s created by a failed change: %v", id, err)
+		}
+	}
+}
+
+// EventFromOutbox returns the event recorded in an outbox
+func EventFromOutbox(e *models.OutboxEvent) Event {
+	return Event{ID: e.ID, Type: EventType(e.Type), PostID: e.PostID, Post: e.Post, OccurredAt: e.OccurredAt}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes of GetPage
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 100
+)
+
+// GetPage returns a page of the posts matching the filter in the order of
+// GetLatest, see Paginate
+func (s *BlogPostService) GetPage(ctx context.Context, filter models.PostFilter, pageToken string, pageSize int) ([]*models.BlogPost, string, error) {
+	if _, err := decodePageToken(pageToken); err != nil {
+		return nil, "", err
+	}
+	posts, err := s.GetLatest(ctx, filter, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	return Paginate(posts, pageToken, pageSize)
+}
+
+// Paginate returns a page of posts in the order of GetLatest, along with
+// the token of the next page, empty on the last one. Pages continue after
+// the last post of the previous page, so that posts created meanwhile do
+// not shift them. A pageSize <= 0 means DefaultPageSize, and is at most
+// MaxPageSize.
+func Paginate(posts []*models.BlogPost, pageToken string, pageSize int) ([]*models.BlogPost, string, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	pageSize = min(pageSize, MaxPageSize)
+
+	after, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, "", err
+	}
+	start := 0
+	if after != nil {
+		for start < len(posts) && !after.precedes(posts[start]) {
+			start++
+		}
+	}
+	posts = posts[start:]
+	if len(posts) <= pageSize {
+		return posts, "", nil
+	}
+	posts = posts[:pageSize]
+	last := posts[pageSize-1]
+	return posts, pagePosition{createdAt: last.CreatedAt, id: last.ID}.token(), nil
+}
+
+// pagePosition is the position of a post in the order of GetLatest
+type pagePosition struct {
+	createdAt time.Time
+	id        string
+}
+
+// precedes tells whether the post comes after the position
+func (p pagePosition) precedes(post *models.BlogPost) bool {
+	if !post.CreatedAt.Equal(p.createdAt) {
+		return post.CreatedAt.Before(p.createdAt)
+	}
+	return post.ID > p.id
+}
+
+func (p pagePosition) token() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(p.createdAt.UnixNano(), 10) + "/" + p.id))
+}
+
+// decodePageToken returns the position of a page token, nil for the first
+// page
+func decodePageToken(token string) (*pagePosition, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid page token", err)
+	}
+	nanos, id, ok := strings.Cut(string(data), "/")
+	if !ok {
+		return nil, apperrors.BadRequest("invalid page token", errors.New("missing post ID"))
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid page token", err)
+	}
+	return &pagePosition{createdAt: time.Unix(0, n).UTC(), id: id}, nil
+}
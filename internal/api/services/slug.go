@@ -0,0 +1,39 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxSlugLength matches the slug validation rule of the request bodies
+const maxSlugLength = 100
+
+// Slugify derives a URL slug from a title: accents are dropped, letters
+// are lowercased and every other run of characters becomes a hyphen, e.g.
+// "Crème brûlée, in 3 steps!" becomes "creme-brulee-in-3-steps". It returns
+// an empty string when the title has no ASCII letter or digit.
+func Slugify(title string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range norm.NFKD.String(title) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining accent left by the decomposition
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			pendingHyphen = true
+		}
+		if b.Len() >= maxSlugLength {
+			break
+		}
+	}
+	return strings.TrimRight(b.String()[:min(b.Len(), maxSlugLength)], "-")
+}
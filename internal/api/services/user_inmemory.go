@@ -0,0 +1,194 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/models"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type InMemoryUserRepo struct {
+	mu    sync.RWMutex
+	users map[string]models.User
+	// byEmail maps the email addresses of the users to their IDs
+	byEmail map[string]string
+}
+
+func NewInMemoryUserRepo() *InMemoryUserRepo {
+	return &InMemoryUserRepo{
+		users:   make(map[string]models.User),
+		byEmail: make(map[string]string),
+	}
+}
+
+func (s *InMemoryUserRepo) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if user == nil {
+		return nil, errors.New("user cannot be nil")
+	}
+	if user.ID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.byEmail[user.Email]; exists {
+		return nil, ErrEmailTaken
+	}
+	s.users[user.ID] = *user
+	s.byEmail[user.Email] = user.ID
+	return user, nil
+}
+
+func (s *InMemoryUserRepo) GetById(ctx context.Context, id string) (*models.User, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, exists := s.users[id]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	return &u, nil
+}
+
+func (s *InMemoryUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, exists := s.byEmail[email]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	u := s.users[id]
+	return &u, nil
+}
+
+func (s *InMemoryUserRepo) Update(ctx context.Context, id string, updated *models.User) (*models.User, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if updated == nil {
+		return nil, errors.New("updated user cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, exists := s.users[id]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	if other, taken := s.byEmail[updated.Email]; taken && other != id {
+		return nil, ErrEmailTaken
+	}
+	updated.ID = id
+	updated.CreatedAt = existing.CreatedAt
+	delete(s.byEmail, existing.Email)
+	s.users[id] = *updated
+	s.byEmail[updated.Email] = id
+	return updated, nil
+}
+
+type InMemoryTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]models.UserToken
+}
+
+func NewInMemoryTokenRepo() *InMemoryTokenRepo {
+	return &InMemoryTokenRepo{tokens: make(map[string]models.UserToken)}
+}
+
+func (s *InMemoryTokenRepo) Create(ctx context.Context, token *models.UserToken) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	if token == nil || token.Hash == "" {
+		return errors.New("token hash cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token.Hash] = *token
+	return nil
+}
+
+func (s *InMemoryTokenRepo) Get(ctx context.Context, hash string) (*models.UserToken, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, exists := s.tokens[hash]
+	if !exists {
+		return nil, ErrTokenNotFound
+	}
+	return &t, nil
+}
+
+func (s *InMemoryTokenRepo) Delete(ctx context.Context, hash string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, hash)
+	return nil
+}
+
+func (s *InMemoryTokenRepo) DeleteUser(ctx context.Context, userID, purpose string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, t := range s.tokens {
+		if t.UserID == userID && t.Purpose == purpose {
+			delete(s.tokens, hash)
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryTokenRepo) DeleteExpired(ctx context.Context, now time.Time) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, t := range s.tokens {
+		if !now.Before(t.ExpiresAt) {
+			delete(s.tokens, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/repositories"
+	"blog-posts-api/internal/api/validation"
+	"blog-posts-api/internal/auth"
+	"blog-posts-api/internal/mail"
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUserNotFound  = apperrors.NotFound("user not found")
+	ErrTokenNotFound = apperrors.NotFound("token not found")
+	// ErrEmailTaken is returned by the repositories when storing an
+	// address twice
+	ErrEmailTaken = apperrors.Conflict("an account with this email address already exists")
+	// ErrInvalidCredentials is returned for a wrong email address or
+	// password alike, so that logins do not tell which accounts exist
+	ErrInvalidCredentials = apperrors.Unauthorized("invalid email address or password")
+	// ErrEmailNotVerified is returned when logging in before opening the
+	// verification link
+	ErrEmailNotVerified = apperrors.Forbidden("email address not verified")
+	// ErrUnauthenticated is returned for missing, invalid or expired
+	// sessions and access tokens
+	ErrUnauthenticated = apperrors.Unauthorized("authentication required")
+)
+
+// errInvalidToken is returned for unknown or expired email link tokens
+var errInvalidToken = apperrors.Validation("request has invalid fields", apperrors.FieldError{
+	Field:  "token",
+	Reason: "is invalid or expired",
+})
+
+// UserOptions holds the settings of the user accounts
+type UserOptions struct {
+	// PasswordHash is the algorithm of new password hashes, auth.Argon2id
+	// unless set. Hashes of the other algorithm are replaced on login.
+	PasswordHash string
+	// SiteURL is the base URL of the links sent by email, without a
+	// trailing slash
+	SiteURL string
+	// JWTSecret signs the access tokens, they are only valid until restart
+	// when empty
+	JWTSecret []byte
+	// AccessTokenTTL is the lifetime of access tokens, an hour unless set
+	AccessTokenTTL time.Duration
+	// SessionTTL is the lifetime of sessions, two weeks unless set
+	SessionTTL time.Duration
+	// VerificationTTL is the lifetime of verification links, two days
+	// unless set
+	VerificationTTL time.Duration
+	// ResetTTL is the lifetime of password reset links, an hour unless set
+	ResetTTL time.Duration
+}
+
+// UserService manages user accounts: registration with a verified email
+// address, login, sessions, access tokens and password resets. The links
+// verifying addresses and resetting passwords are sent by the mailer.
+type UserService struct {
+	users  repositories.UserRepo
+	tokens repositories.TokenRepo
+	mailer mail.Mailer
+	opts   UserOptions
+
+	// dummyHash is checked against when logging in to an unknown account,
+	// so that it takes as long as with a wrong password
+	dummyHash     string
+	dummyHashOnce sync.Once
+}
+
+func NewUserService(users repositories.UserRepo, tokens repositories.TokenRepo, mailer mail.Mailer, opts UserOptions) *UserService {
+	if opts.PasswordHash == "" {
+		opts.PasswordHash = auth.Argon2id
+	}
+	if len(opts.JWTSecret) == 0 {
+		opts.JWTSecret = []byte(auth.NewToken())
+	}
+	if opts.AccessTokenTTL <= 0 {
+		opts.AccessTokenTTL = time.Hour
+	}
+	if opts.SessionTTL <= 0 {
+		opts.SessionTTL = 14 * 24 * time.Hour
+	}
+	if opts.VerificationTTL <= 0 {
+		opts.VerificationTTL = 48 * time.Hour
+	}
+	if opts.ResetTTL <= 0 {
+		opts.ResetTTL = time.Hour
+	}
+	return &UserService{users: users, tokens: tokens, mailer: mailer, opts: opts}
+}
+
+// SessionTTL returns the lifetime of sessions
+func (s *UserService) SessionTTL() time.Duration {
+	return s.opts.SessionTTL
+}
+
+// Register creates an account and sends the link verifying its email
+// address. For a taken address, the account is returned as if created but
+// is not stored, and the owner of the address is told by email instead, so
+// that the response does not tell which accounts exist.
+func (s *UserService) Register(ctx context.Context, body *models.UserRegister) (*models.User, error) {
+	if body == nil {
+		return nil, apperrors.BadRequest("user cannot be nil", nil)
+	}
+	if err := validation.Struct(body); err != nil {
+		return nil, err
+	}
+	hash, err := auth.HashPassword(body.Password, s.opts.PasswordHash)
+	if err != nil {
+		return nil, apperrors.Internal("failed to hash the password", err)
+	}
+
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:                uuid.New().String(),
+		Email:             body.Email,
+		Name:              body.Name,
+		PasswordHash:      hash,
+		PasswordChangedAt: now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	created, err := s.users.Create(ctx, user)
+	if errors.Is(err, ErrEmailTaken) {
+		if err := s.sendAccountExists(ctx, body.Email); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	user = created
+	if err := s.sendVerification(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ResendVerification sends a new verification link to an unverified
+// account. Unknown and verified addresses are ignored, so that the
+// response does not tell which accounts exist.
+func (s *UserService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.users.GetByEmail(ctx, email)
+	if apperrors.Is(err, apperrors.KindNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	return s.sendVerification(ctx, user)
+}
+
+func (s *UserService) sendVerification(ctx context.Context, user *models.User) error {
+	token, err := s.newToken(ctx, user.ID, models.TokenVerification, s.opts.VerificationTTL)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, user, "Verify your email address",
+		"Open this link to verify your email address:\n\n"+s.link("/verify", token)+"\n\nThe link expires in "+s.opts.VerificationTTL.String()+".\n")
+}
+
+// sendAccountExists tells the owner of an address that it was registered
+// again, pointing to the login and password reset pages
+func (s *UserService) sendAccountExists(ctx context.Context, email string) error {
+	owner, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, owner, "You already have an account",
+		"Someone tried to register with your email address, which already has an account. Log in here:\n\n"+
+			s.opts.SiteURL+"/login\n\nIf you forgot your password, choose a new one here:\n\n"+s.opts.SiteURL+"/forgot-password"+
+			"\n\nIf you did not try to register, ignore this email.\n")
+}
+
+// VerifyEmail marks the address of the account of a verification token as
+// verified
+func (s *UserService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	user, err := s.useToken(ctx, token, models.TokenVerification)
+	if err != nil {
+		return nil, err
+	}
+	if user.EmailVerified {
+		return user, nil
+	}
+	user.EmailVerified = true
+	user.UpdatedAt = time.Now().UTC()
+	return s.users.Update(ctx, user.ID, user)
+}
+
+// Login checks the credentials of a verified account. A hash made with
+// other settings than the current ones is replaced.
+func (s *UserService) Login(ctx context.Context, credentials models.Credentials) (*models.User, error) {
+	if err := validation.Struct(&credentials); err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByEmail(ctx, credentials.Email)
+	if apperrors.Is(err, apperrors.KindNotFound) {
+		s.checkDummyPassword(credentials.Password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	ok, err := auth.CheckPassword(user.PasswordHash, credentials.Password)
+	if err != nil {
+		return nil, apperrors.Internal("failed to check the password", err)
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	if auth.NeedsRehash(user.PasswordHash, s.opts.PasswordHash) {
+		if hash, err := auth.HashPassword(credentials.Password, s.opts.PasswordHash); err == nil {
+			user.PasswordHash = hash
+			if updated, err := s.users.Update(ctx, user.ID, user); err == nil {
+				user = updated
+			}
+		}
+	}
+	return user, nil
+}
+
+func (s *UserService) checkDummyPassword(password string) {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = auth.HashPassword(auth.NewToken(), s.opts.PasswordHash)
+	})
+	auth.CheckPassword(s.dummyHash, password)
+}
+
+// StartSession returns the token of a new session of a user, to be set as
+// a cookie
+func (s *UserService) StartSession(ctx context.Context, userID string) (string, error) {
+	return s.newToken(ctx, userID, models.TokenSession, s.opts.SessionTTL)
+}
+
+// Session returns the user of a session token
+func (s *UserService) Session(ctx context.Context, token string) (*models.User, error) {
+	stored, err := s.tokens.Get(ctx, auth.HashToken(token))
+	if apperrors.Is(err, apperrors.KindNotFound) {
+		return nil, ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.Purpose != models.TokenSession || !time.Now().Before(stored.ExpiresAt) {
+		return nil, ErrUnauthenticated
+	}
+	return s.activeUser(ctx, stored.UserID)
+}
+
+// EndSession deletes a session, unknown tokens are ignored
+func (s *UserService) EndSession(ctx context.Context, token string) error {
+	return s.tokens.Delete(ctx, auth.HashToken(token))
+}
+
+// IssueAccessToken returns a JWT authenticating the API requests of a user
+func (s *UserService) IssueAccessToken(user *models.User) (models.AccessToken, error) {
+	now := time.Now()
+	token, err := auth.SignJWT(s.opts.JWTSecret, auth.Claims{
+		Issuer:    s.opts.SiteURL,
+		Subject:   user.ID,
+		Email:     user.Email,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.opts.AccessTokenTTL).Unix(),
+	})
+	if err != nil {
+		return models.AccessToken{}, apperrors.Internal("failed to sign the access token", err)
+	}
+	return models.AccessToken{AccessToken: token, TokenType: "Bearer", ExpiresIn: int(s.opts.AccessTokenTTL.Seconds())}, nil
+}
+
+// Authenticate returns the user of an access token. Tokens issued before
+// the last password change of the user are rejected.
+func (s *UserService) Authenticate(ctx context.Context, accessToken string) (*models.User, error) {
+	claims, err := auth.ParseJWT(s.opts.JWTSecret, accessToken, s.opts.SiteURL, time.Now())
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	user, err := s.activeUser(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if claims.IssuedAt < user.PasswordChangedAt.Unix() {
+		return nil, ErrUnauthenticated
+	}
+	return user, nil
+}
+
+// activeUser returns a user able to log in
+func (s *UserService) activeUser(ctx context.Context, id string) (*models.User, error) {
+	user, err := s.users.GetById(ctx, id)
+	if apperrors.Is(err, apperrors.KindNotFound) {
+		return nil, ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.EmailVerified {
+		return nil, ErrUnauthenticated
+	}
+	return user, nil
+}
+
+// RequestPasswordReset sends a password reset link to an account. Unknown
+// addresses are ignored, so that the response does not tell which
+// accounts exist.
+func (s *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.users.GetByEmail(ctx, email)
+	if apperrors.Is(err, apperrors.KindNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	token, err := s.newToken(ctx, user.ID, models.TokenPasswordReset, s.opts.ResetTTL)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, user, "Reset your password",
+		"Open this link to choose a new password:\n\n"+s.link("/reset-password", token)+
+			"\n\nThe link expires in "+s.opts.ResetTTL.String()+". If you did not ask for it, ignore this email.\n")
+}
+
+// ResetPassword sets the password of the account of a reset token. The
+// sessions and access tokens of the account are revoked, and the address
+// counts as verified since the link was opened.
+func (s *UserService) ResetPassword(ctx context.Context, body models.PasswordReset) error {
+	if err := validation.Struct(&body); err != nil {
+		return err
+	}
+	user, err := s.useToken(ctx, body.Token, models.TokenPasswordReset)
+	if err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(body.Password, s.opts.PasswordHash)
+	if err != nil {
+		return apperrors.Internal("failed to hash the password", err)
+	}
+
+	now := time.Now().UTC()
+	user.PasswordHash = hash
+	user.PasswordChangedAt = now
+	user.EmailVerified = true
+	user.UpdatedAt = now
+	if _, err := s.users.Update(ctx, user.ID, user); err != nil {
+		return err
+	}
+	if err := s.tokens.DeleteUser(ctx, user.ID, models.TokenSession); err != nil {
+		return err
+	}
+	return s.tokens.DeleteUser(ctx, user.ID, models.TokenPasswordReset)
+}
+
+// newToken stores a token of a user, deleting the expired ones, and
+// returns it
+func (s *UserService) newToken(ctx context.Context, userID, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now().UTC()
+	if err := s.tokens.DeleteExpired(ctx, now); err != nil {
+		return "", err
+	}
+	token := auth.NewToken()
+	err := s.tokens.Create(ctx, &models.UserToken{
+		Hash:      auth.HashToken(token),
+		Purpose:   purpose,
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// useToken consumes a single-use token and returns its user
+func (s *UserService) useToken(ctx context.Context, token, purpose string) (*models.User, error) {
+	hash := auth.HashToken(token)
+	stored, err := s.tokens.Get(ctx, hash)
+	if apperrors.Is(err, apperrors.KindNotFound) {
+		return nil, errInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.Purpose != purpose || !time.Now().Before(stored.ExpiresAt) {
+		return nil, errInvalidToken
+	}
+	if err := s.tokens.Delete(ctx, hash); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetById(ctx, stored.UserID)
+	if apperrors.Is(err, apperrors.KindNotFound) {
+		return nil, errInvalidToken
+	}
+	return user, err
+}
+
+// link returns the URL of a page of the site carrying a token
+func (s *UserService) link(path, token string) string {
+	return s.opts.SiteURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func (s *UserService) send(ctx context.Context, user *models.User, subject, text string) error {
+	greeting := "Hello,\n\n"
+	if user.Name != "" {
+		greeting = "Hello " + user.Name + ",\n\n"
+	}
+	if err := s.mailer.Send(ctx, mail.Message{To: user.Email, Subject: subject, Text: greeting + text}); err != nil {
+		return apperrors.Internal("failed to send the email", err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/auth"
+	"blog-posts-api/internal/mail"
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMailer keeps the emails instead of sending them
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+var linkPattern = regexp.MustCompile(`https?://\S+`)
+
+// lastLink returns the path and token of the link of the last email
+func (m *recordingMailer) lastLink(t *testing.T) (string, string) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) == 0 {
+		t.Fatal("expected an email")
+	}
+	u, err := url.Parse(linkPattern.FindString(m.messages[len(m.messages)-1].Text))
+	if err != nil {
+		t.Fatalf("expected a link, got %v", err)
+	}
+	return u.Path, u.Query().Get("token")
+}
+
+func newTestUserService(opts UserOptions) (*UserService, *recordingMailer, *InMemoryTokenRepo) {
+	mailer := &recordingMailer{}
+	tokens := NewInMemoryTokenRepo()
+	opts.SiteURL = "https://blog.example.com"
+	return NewUserService(NewInMemoryUserRepo(), tokens, mailer, opts), mailer, tokens
+}
+
+// registerVerified registers a user and opens the link sent to it
+func registerVerified(t *testing.T, s *UserService, mailer *recordingMailer, email, password string) *models.User {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := s.Register(ctx, &models.UserRegister{Email: email, Password: password}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, token := mailer.lastLink(t)
+	user, err := s.VerifyEmail(ctx, token)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return user
+}
+
+func TestUserService_Register(t *testing.T) {
+	s, mailer, _ := newTestUserService(UserOptions{})
+	ctx := context.Background()
+
+	user, err := s.Register(ctx, &models.UserRegister{Email: " Ann@Example.com ", Password: "correct horse", Name: "Ann"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.Email != "ann@example.com" || user.EmailVerified || user.PasswordHash == "correct horse" {
+		t.Errorf("expected an unverified user with a hashed password, got %+v", user)
+	}
+	if msg := mailer.messages[0]; msg.To != "ann@example.com" || !strings.HasPrefix(msg.Text, "Hello Ann,") {
+		t.Errorf("expected a verification email, got %+v", msg)
+	}
+	path, token := mailer.lastLink(t)
+	if path != "/verify" || token == "" {
+		t.Errorf("expected a verification link, got %s with %q", path, token)
+	}
+
+	// a taken address looks registered, and its owner is told by email
+	again, err := s.Register(ctx, &models.UserRegister{Email: "ann@example.com", Password: "another one", Name: "Eve"})
+	if err != nil || again.ID == user.ID || again.Email != "ann@example.com" || again.EmailVerified {
+		t.Errorf("expected an unverified user as for a new address, got %+v and %v", again, err)
+	}
+	if _, err := s.users.GetById(ctx, again.ID); !apperrors.Is(err, apperrors.KindNotFound) {
+		t.Errorf("expected the account not to be stored, got %v", err)
+	}
+	if msg := mailer.messages[len(mailer.messages)-1]; msg.To != "ann@example.com" || !strings.HasPrefix(msg.Text, "Hello Ann,") || strings.Contains(msg.Text, "token=") {
+		t.Errorf("expected the owner to be told about the account, got %+v", msg)
+	}
+	_, err = s.Register(ctx, &models.UserRegister{Email: "ann", Password: "short"})
+	if !apperrors.Is(err, apperrors.KindValidation) || len(err.(*apperrors.Error).Fields) != 2 {
+		t.Errorf("expected email and password to be reported, got %v", err)
+	}
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	s, mailer, tokens := newTestUserService(UserOptions{})
+	ctx := context.Background()
+	s.Register(ctx, &models.UserRegister{Email: "ann@example.com", Password: "correct horse"})
+	_, first := mailer.lastLink(t)
+
+	if _, err := s.Login(ctx, models.Credentials{Email: "ann@example.com", Password: "correct horse"}); !apperrors.Is(err, apperrors.KindForbidden) {
+		t.Errorf("expected an unverified user not to log in, got %v", err)
+	}
+
+	// a new link does not invalidate the previous one
+	if err := s.ResendVerification(ctx, "ann@example.com"); err != nil || len(mailer.messages) != 2 {
+		t.Fatalf("expected a second email, got %d emails and %v", len(mailer.messages), err)
+	}
+	user, err := s.VerifyEmail(ctx, first)
+	if err != nil || !user.EmailVerified {
+		t.Fatalf("expected a verified user, got %+v and %v", user, err)
+	}
+	if _, err := s.VerifyEmail(ctx, first); !apperrors.Is(err, apperrors.KindValidation) {
+		t.Errorf("expected a used token to be rejected, got %v", err)
+	}
+	if err := s.ResendVerification(ctx, "ann@example.com"); err != nil || len(mailer.messages) != 2 {
+		t.Errorf("expected no email for a verified address, got %d emails and %v", len(mailer.messages), err)
+	}
+	if err := s.ResendVerification(ctx, "bob@example.com"); err != nil {
+		t.Errorf("expected unknown addresses to be ignored, got %v", err)
+	}
+
+	expired := auth.NewToken()
+	tokens.Create(ctx, &models.UserToken{Hash: auth.HashToken(expired), Purpose: models.TokenVerification, UserID: user.ID, ExpiresAt: time.Now().Add(-time.Second)})
+	if _, err := s.VerifyEmail(ctx, expired); !apperrors.Is(err, apperrors.KindValidation) {
+		t.Errorf("expected an expired token to be rejected, got %v", err)
+	}
+}
+
+func TestUserService_Login(t *testing.T) {
+	s, mailer, _ := newTestUserService(UserOptions{})
+	ctx := context.Background()
+	user := registerVerified(t, s, mailer, "ann@example.com", "correct horse")
+
+	if logged, err := s.Login(ctx, models.Credentials{Email: "ANN@example.com", Password: "correct horse"}); err != nil || logged.ID != user.ID {
+		t.Errorf("expected the user, got %+v and %v", logged, err)
+	}
+	for _, c := range []models.Credentials{{Email: "ann@example.com", Password: "wrong horse"}, {Email: "bob@example.com", Password: "correct horse"}} {
+		if _, err := s.Login(ctx, c); err != ErrInvalidCredentials {
+			t.Errorf("expected invalid credentials for %+v, got %v", c, err)
+		}
+	}
+}
+
+func TestUserService_RehashesOnLogin(t *testing.T) {
+	s, mailer, _ := newTestUserService(UserOptions{PasswordHash: auth.Bcrypt})
+	ctx := context.Background()
+	registerVerified(t, s, mailer, "ann@example.com", "correct horse")
+
+	s.opts.PasswordHash = auth.Argon2id
+	user, err := s.Login(ctx, models.Credentials{Email: "ann@example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("expected a bcrypt hash to still be accepted, got %v", err)
+	}
+	if !strings.HasPrefix(user.PasswordHash, "$argon2id$") {
+		t.Errorf("expected the hash to be replaced, got %s", user.PasswordHash)
+	}
+	if _, err := s.Login(ctx, models.Credentials{Email: "ann@example.com", Password: "correct horse"}); err != nil {
+		t.Errorf("expected the new hash to be accepted, got %v", err)
+	}
+}
+
+func TestUserService_Sessions(t *testing.T) {
+	s, mailer, tokens := newTestUserService(UserOptions{})
+	ctx := context.Background()
+	user := registerVerified(t, s, mailer, "ann@example.com", "correct horse")
+
+	token, err := s.StartSession(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got, err := s.Session(ctx, token); err != nil || got.ID != user.ID {
+		t.Errorf("expected the user of the session, got %+v and %v", got, err)
+	}
+	if stored, _ := tokens.Get(ctx, token); stored != nil {
+		t.Error("expected only the hash of the token to be stored")
+	}
+	s.EndSession(ctx, token)
+	if _, err := s.Session(ctx, token); err != ErrUnauthenticated {
+		t.Errorf("expected an ended session to be rejected, got %v", err)
+	}
+
+	// the token of an email link is not a session
+	s.RequestPasswordReset(ctx, "ann@example.com")
+	_, reset := mailer.lastLink(t)
+	if _, err := s.Session(ctx, reset); err != ErrUnauthenticated {
+		t.Errorf("expected a reset token to be rejected, got %v", err)
+	}
+}
+
+func TestUserService_AccessTokens(t *testing.T) {
+	s, mailer, _ := newTestUserService(UserOptions{JWTSecret: []byte("secret")})
+	ctx := context.Background()
+	user := registerVerified(t, s, mailer, "ann@example.com", "correct horse")
+
+	token, err := s.IssueAccessToken(user)
+	if err != nil || token.TokenType != "Bearer" || token.ExpiresIn != 3600 {
+		t.Fatalf("expected a bearer token valid for an hour, got %+v and %v", token, err)
+	}
+	if got, err := s.Authenticate(ctx, token.AccessToken); err != nil || got.ID != user.ID {
+		t.Errorf("expected the user of the token, got %+v and %v", got, err)
+	}
+
+	other, _, _ := newTestUserService(UserOptions{JWTSecret: []byte("other")})
+	if _, err := other.Authenticate(ctx, token.AccessToken); err != ErrUnauthenticated {
+		t.Errorf("expected a token signed with another secret to be rejected, got %v", err)
+	}
+	stale, _ := auth.SignJWT([]byte("secret"), auth.Claims{
+		Issuer:    "https://blog.example.com",
+		Subject:   user.ID,
+		IssuedAt:  user.PasswordChangedAt.Add(-time.Minute).Unix(),
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+	if _, err := s.Authenticate(ctx, stale); err != ErrUnauthenticated {
+		t.Errorf("expected a token issued before the password change to be rejected, got %v", err)
+	}
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	s, mailer, _ := newTestUserService(UserOptions{})
+	ctx := context.Background()
+	user := registerVerified(t, s, mailer, "ann@example.com", "correct horse")
+	session, _ := s.StartSession(ctx, user.ID)
+
+	if err := s.RequestPasswordReset(ctx, "bob@example.com"); err != nil || len(mailer.messages) != 1 {
+		t.Errorf("expected unknown addresses to be ignored, got %d emails and %v", len(mailer.messages), err)
+	}
+	if err := s.RequestPasswordReset(ctx, "ann@example.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	path, token := mailer.lastLink(t)
+	if path != "/reset-password" {
+		t.Errorf("expected a reset link, got %s", path)
+	}
+
+	if err := s.ResetPassword(ctx, models.PasswordReset{Token: token, Password: "short"}); !apperrors.Is(err, apperrors.KindValidation) {
+		t.Errorf("expected a short password to be rejected, got %v", err)
+	}
+	if err := s.ResetPassword(ctx, models.PasswordReset{Token: token, Password: "battery staple"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := s.ResetPassword(ctx, models.PasswordReset{Token: token, Password: "battery staple"}); !apperrors.Is(err, apperrors.KindValidation) {
+		t.Errorf("expected a used token to be rejected, got %v", err)
+	}
+
+	if _, err := s.Login(ctx, models.Credentials{Email: "ann@example.com", Password: "correct horse"}); err != ErrInvalidCredentials {
+		t.Errorf("expected the old password to be rejected, got %v", err)
+	}
+	if _, err := s.Login(ctx, models.Credentials{Email: "ann@example.com", Password: "battery staple"}); err != nil {
+		t.Errorf("expected the new password to be accepted, got %v", err)
+	}
+	if _, err := s.Session(ctx, session); err != ErrUnauthenticated {
+		t.Errorf("expected the sessions to be revoked, got %v", err)
+	}
+}
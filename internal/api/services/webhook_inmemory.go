@@ -0,0 +1,282 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/models"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFinishedDeliveries bounds the succeeded and dead deliveries kept per
+// webhook by the in-memory store, the oldest are dropped first
+const maxFinishedDeliveries = 1000
+
+type InMemoryWebhookRepo struct {
+	mu       sync.RWMutex
+	webhooks map[string]models.Webhook
+	// deliveries are kept in creation order, per webhook in byWebhook
+	deliveries map[string]models.WebhookDelivery
+	byWebhook  map[string][]string
+}
+
+func NewInMemoryWebhookRepo() *InMemoryWebhookRepo {
+	return &InMemoryWebhookRepo{
+		webhooks:   make(map[string]models.Webhook),
+		deliveries: make(map[string]models.WebhookDelivery),
+		byWebhook:  make(map[string][]string),
+	}
+}
+
+func (s *InMemoryWebhookRepo) Create(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if webhook == nil {
+		return nil, errors.New("webhook cannot be nil")
+	}
+	if webhook.ID == "" {
+		return nil, errors.New("webhook ID cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhooks[webhook.ID] = cloneWebhook(*webhook)
+	return webhook, nil
+}
+
+func (s *InMemoryWebhookRepo) GetAll(ctx context.Context) ([]*models.Webhook, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	webhooks := make([]*models.Webhook, 0, len(s.webhooks))
+	for _, w := range s.webhooks {
+		w = cloneWebhook(w)
+		webhooks = append(webhooks, &w)
+	}
+	return webhooks, nil
+}
+
+func (s *InMemoryWebhookRepo) GetById(ctx context.Context, id string) (*models.Webhook, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, exists := s.webhooks[id]
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+	w = cloneWebhook(w)
+	return &w, nil
+}
+
+func (s *InMemoryWebhookRepo) Update(ctx context.Context, id string, updated *models.Webhook) (*models.Webhook, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if updated == nil {
+		return nil, errors.New("updated webhook cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, exists := s.webhooks[id]
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+	updated.ID = id
+	updated.CreatedAt = existing.CreatedAt
+	s.webhooks[id] = cloneWebhook(*updated)
+	return updated, nil
+}
+
+func (s *InMemoryWebhookRepo) Delete(ctx context.Context, id string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.webhooks[id]; !exists {
+		return ErrWebhookNotFound
+	}
+	delete(s.webhooks, id)
+	for _, deliveryID := range s.byWebhook[id] {
+		delete(s.deliveries, deliveryID)
+	}
+	delete(s.byWebhook, id)
+	return nil
+}
+
+// AddDeliveries stores new deliveries, skipping those of deleted webhooks
+// and of events a webhook already has
+func (s *InMemoryWebhookRepo) AddDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range deliveries {
+		if _, exists := s.webhooks[d.WebhookID]; !exists {
+			continue
+		}
+		if slices.ContainsFunc(s.byWebhook[d.WebhookID], func(id string) bool { return s.deliveries[id].EventID == d.EventID }) {
+			continue
+		}
+		s.deliveries[d.ID] = cloneDelivery(*d)
+		s.byWebhook[d.WebhookID] = append(s.byWebhook[d.WebhookID], d.ID)
+		s.prune(d.WebhookID)
+	}
+	return nil
+}
+
+// prune drops the oldest finished deliveries of a webhook past
+// maxFinishedDeliveries
+func (s *InMemoryWebhookRepo) prune(webhookID string) {
+	ids := s.byWebhook[webhookID]
+	finished := 0
+	for _, id := range ids {
+		if s.deliveries[id].Status != models.DeliveryPending {
+			finished++
+		}
+	}
+	if finished <= maxFinishedDeliveries {
+		return
+	}
+
+	kept := ids[:0]
+	for _, id := range ids {
+		if finished > maxFinishedDeliveries && s.deliveries[id].Status != models.DeliveryPending {
+			delete(s.deliveries, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.byWebhook[webhookID] = kept
+}
+
+func (s *InMemoryWebhookRepo) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, exists := s.deliveries[id]
+	if !exists {
+		return nil, ErrDeliveryNotFound
+	}
+	d = cloneDelivery(d)
+	return &d, nil
+}
+
+func (s *InMemoryWebhookRepo) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.deliveries[delivery.ID]; !exists {
+		return ErrDeliveryNotFound
+	}
+	s.deliveries[delivery.ID] = cloneDelivery(*delivery)
+	return nil
+}
+
+func (s *InMemoryWebhookRepo) ListDeliveries(ctx context.Context, filter models.DeliveryFilter) ([]*models.WebhookDelivery, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var deliveries []*models.WebhookDelivery
+	for _, d := range s.deliveries {
+		if filter.Matches(&d) {
+			d = cloneDelivery(d)
+			deliveries = append(deliveries, &d)
+		}
+	}
+	slices.SortFunc(deliveries, func(a, b *models.WebhookDelivery) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(b.ID, a.ID)
+	})
+	return deliveries, nil
+}
+
+func (s *InMemoryWebhookRepo) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var due []*models.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.Status == models.DeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			d = cloneDelivery(d)
+			due = append(due, &d)
+		}
+	}
+	slices.SortFunc(due, func(a, b *models.WebhookDelivery) int {
+		if c := a.NextAttemptAt.Compare(*b.NextAttemptAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// cloneWebhook copies the slices of a webhook so that callers cannot
+// modify the stored one
+func cloneWebhook(w models.Webhook) models.Webhook {
+	w.Events = slices.Clone(w.Events)
+	return w
+}
+
+// cloneDelivery copies the slices and pointers of a delivery so that
+// callers cannot modify the stored one
+func cloneDelivery(d models.WebhookDelivery) models.WebhookDelivery {
+	d.Payload = slices.Clone(d.Payload)
+	d.Attempts = slices.Clone(d.Attempts)
+	if d.NextAttemptAt != nil {
+		next := *d.NextAttemptAt
+		d.NextAttemptAt = &next
+	}
+	return d
+}
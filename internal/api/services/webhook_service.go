@@ -0,0 +1,287 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/repositories"
+	"blog-posts-api/internal/api/validation"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWebhookNotFound  = apperrors.NotFound("webhook not found")
+	ErrDeliveryNotFound = apperrors.NotFound("webhook delivery not found")
+	// ErrDeliveryPending is returned when redelivering a delivery that is
+	// still being retried
+	ErrDeliveryPending = apperrors.Conflict("webhook delivery is still pending")
+)
+
+// RetryPolicy tells when failed deliveries are attempted again. The n-th
+// retry waits Backoff * 2^(n-1), at most MaxBackoff, and a delivery is dead
+// after MaxAttempts failed attempts.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// DefaultRetryPolicy spreads 8 attempts over about 20 minutes
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 8, Backoff: 10 * time.Second, MaxBackoff: time.Hour}
+
+// Delay returns the time to wait after the given number of failed attempts
+func (p RetryPolicy) Delay(failures int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < failures && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// WebhookService manages webhook subscriptions and the deliveries of post
+// events to them. Fed with events by Enqueue, e.g. from the outbox relay,
+// it records a pending delivery per matching webhook; sending them is left
+// to a dispatcher (see internal/webhook) reporting back with RecordAttempt.
+type WebhookService struct {
+	repo   repositories.WebhookRepo
+	policy RetryPolicy
+	queued chan struct{}
+}
+
+func NewWebhookService(r repositories.WebhookRepo, policy RetryPolicy) *WebhookService {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if policy.Backoff <= 0 {
+		policy.Backoff = DefaultRetryPolicy.Backoff
+	}
+	if policy.MaxBackoff < policy.Backoff {
+		policy.MaxBackoff = max(policy.Backoff, DefaultRetryPolicy.MaxBackoff)
+	}
+	return &WebhookService{repo: r, policy: policy, queued: make(chan struct{}, 1)}
+}
+
+func (s *WebhookService) Create(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	if webhook == nil {
+		return nil, apperrors.BadRequest("webhook cannot be nil", nil)
+	}
+	body := webhook.CreateBody()
+	if err := validation.Struct(&body); err != nil {
+		return nil, err
+	}
+	created := body.ToWebhook()
+	created.ID = webhook.ID
+	if created.ID == "" {
+		created.ID = uuid.New().String()
+	}
+	if created.Secret == "" {
+		created.Secret = newSecret()
+	}
+	created.Events = normalizeTags(created.Events)
+	created.CreatedAt = time.Now().UTC()
+	created.UpdatedAt = created.CreatedAt
+	return s.repo.Create(ctx, &created)
+}
+
+// GetAll returns every webhook, oldest first
+func (s *WebhookService) GetAll(ctx context.Context) ([]*models.Webhook, error) {
+	webhooks, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(webhooks, func(a, b *models.Webhook) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return webhooks, nil
+}
+
+func (s *WebhookService) GetById(ctx context.Context, id string) (*models.Webhook, error) {
+	return s.repo.GetById(ctx, id)
+}
+
+// Update replaces the settings of a webhook, keeping its secret unless a
+// new one is set
+func (s *WebhookService) Update(ctx context.Context, id string, webhook *models.Webhook) (*models.Webhook, error) {
+	if webhook == nil {
+		return nil, apperrors.BadRequest("updated webhook cannot be nil", nil)
+	}
+	body := webhook.UpdateBody()
+	if err := validation.Struct(&body); err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := body.ToWebhook()
+	if updated.Secret == "" {
+		updated.Secret = existing.Secret
+	}
+	updated.Events = normalizeTags(updated.Events)
+	updated.UpdatedAt = time.Now().UTC()
+	return s.repo.Update(ctx, id, &updated)
+}
+
+// Delete removes a webhook along with its deliveries
+func (s *WebhookService) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Deliveries returns the deliveries matching the filter, latest first. The
+// dead deliveries form the dead-letter list.
+func (s *WebhookService) Deliveries(ctx context.Context, filter models.DeliveryFilter) ([]*models.WebhookDelivery, error) {
+	if filter.WebhookID != "" {
+		if _, err := s.repo.GetById(ctx, filter.WebhookID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.ListDeliveries(ctx, filter)
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	return s.repo.GetDelivery(ctx, id)
+}
+
+// Redeliver queues a finished delivery again with a fresh set of attempts,
+// e.g. once the receiver of a dead delivery has been fixed
+func (s *WebhookService) Redeliver(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status == models.DeliveryPending {
+		return nil, ErrDeliveryPending
+	}
+
+	now := time.Now().UTC()
+	delivery.Status = models.DeliveryPending
+	delivery.Failures = 0
+	delivery.NextAttemptAt = &now
+	delivery.UpdatedAt = now
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	s.wake()
+	return delivery, nil
+}
+
+// Enqueue records a pending delivery of the event for every active webhook
+// subscribed to it, and never waits for the deliveries. Enqueuing an event
+// again skips the webhooks that already have it, so that events published
+// at least once are delivered once.
+func (s *WebhookService) Enqueue(ctx context.Context, e Event) error {
+	webhooks, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	var deliveries []*models.WebhookDelivery
+	for _, w := range webhooks {
+		if !w.Active || !slices.Contains(w.Events, string(e.Type)) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = MarshalEvent(e); err != nil {
+				return err
+			}
+		}
+		now := time.Now().UTC()
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			ID:            uuid.New().String(),
+			WebhookID:     w.ID,
+			EventID:       e.ID,
+			Event:         string(e.Type),
+			Status:        models.DeliveryPending,
+			Payload:       payload,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := s.repo.AddDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	s.wake()
+	return nil
+}
+
+// Due returns up to limit deliveries to attempt now, earliest first
+func (s *WebhookService) Due(ctx context.Context, limit int) ([]*models.WebhookDelivery, error) {
+	return s.repo.DueDeliveries(ctx, time.Now().UTC(), limit)
+}
+
+// RecordAttempt logs an attempt of a pending delivery, failed unless its
+// Error is empty. Failed deliveries are scheduled for a retry following the
+// retry policy, or marked dead once out of attempts.
+func (s *WebhookService) RecordAttempt(ctx context.Context, id string, attempt models.WebhookAttempt) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.UpdatedAt = time.Now().UTC()
+	delivery.NextAttemptAt = nil
+	switch {
+	case attempt.Error == "":
+		delivery.Status = models.DeliverySucceeded
+	case delivery.Failures+1 >= s.policy.MaxAttempts:
+		delivery.Failures++
+		delivery.Status = models.DeliveryDead
+	default:
+		delivery.Failures++
+		next := attempt.At.Add(s.policy.Delay(delivery.Failures)).UTC()
+		delivery.NextAttemptAt = &next
+	}
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Queued receives a value when deliveries were queued, so that a
+// dispatcher waiting for the next poll can start right away
+func (s *WebhookService) Queued() <-chan struct{} {
+	return s.queued
+}
+
+func (s *WebhookService) wake() {
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+}
+
+// MarshalEvent returns the JSON form of an event, a models.WebhookEvent, as
+// sent to webhooks and other consumers
+func MarshalEvent(e Event) ([]byte, error) {
+	return json.Marshal(models.WebhookEvent{
+		ID:         e.ID,
+		Type:       string(e.Type),
+		OccurredAt: e.OccurredAt,
+		Data:       models.WebhookEventData{PostID: e.PostID, Post: e.Post},
+	})
+}
+
+// newSecret returns a random signing secret
+func newSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestWebhook(events ...string) *models.Webhook {
+	return &models.Webhook{URL: "https://hooks.example.com/blog", Events: events, Active: true}
+}
+
+func TestWebhookService_Create(t *testing.T) {
+	service := NewWebhookService(NewInMemoryWebhookRepo(), DefaultRetryPolicy)
+	ctx := context.Background()
+
+	created, err := service.Create(ctx, newTestWebhook("post.published", "post.published"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if created.ID == "" || !strings.HasPrefix(created.Secret, "whsec_") {
+		t.Errorf("expected an ID and a generated secret, got %+v", created)
+	}
+	if len(created.Events) != 1 {
+		t.Errorf("expected duplicate events to be dropped, got %v", created.Events)
+	}
+
+	_, err = service.Create(ctx, &models.Webhook{URL: "ftp://example.com", Events: []string{"post.archived"}, Secret: "short"})
+	var names []string
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindValidation {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	for _, f := range appErr.Fields {
+		names = append(names, f.Field)
+	}
+	if strings.Join(names, ",") != "url,events,secret" {
+		t.Errorf("expected url, events and secret to be reported, got %v", names)
+	}
+}
+
+func TestWebhookService_UpdateKeepsSecret(t *testing.T) {
+	service := NewWebhookService(NewInMemoryWebhookRepo(), DefaultRetryPolicy)
+	ctx := context.Background()
+	created, _ := service.Create(ctx, newTestWebhook("post.created"))
+
+	updated, err := service.Update(ctx, created.ID, newTestWebhook("post.deleted"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if updated.Secret != created.Secret || !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("expected the secret and creation time to be kept, got %+v", updated)
+	}
+
+	rotated := newTestWebhook("post.deleted")
+	rotated.Secret = "a-brand-new-secret"
+	if updated, _ = service.Update(ctx, created.ID, rotated); updated.Secret != rotated.Secret {
+		t.Errorf("expected the secret to be rotated, got %q", updated.Secret)
+	}
+
+	if _, err := service.Update(ctx, "missing", newTestWebhook("post.deleted")); err != ErrWebhookNotFound {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+}
+
+func TestWebhookService_EnqueuesPostEvents(t *testing.T) {
+	webhooks := NewWebhookService(NewInMemoryWebhookRepo(), DefaultRetryPolicy)
+	posts := NewBlogPostService(NewInMemoryStoreBlogPostRepo())
+	ctx := context.Background()
+	posts.Subscribe(func(e Event) { webhooks.Enqueue(ctx, e) })
+
+	published, _ := webhooks.Create(ctx, newTestWebhook("post.published"))
+	deleted, _ := webhooks.Create(ctx, newTestWebhook("post.deleted"))
+	inactive := newTestWebhook("post.published", "post.deleted")
+	inactive.Active = false
+	inactive, _ = webhooks.Create(ctx, inactive)
+
+	posts.Create(ctx, &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+	posts.Delete(ctx, "1")
+
+	select {
+	case <-webhooks.Queued():
+	default:
+		t.Error("expected the dispatcher to be woken up")
+	}
+
+	for _, tc := range []struct {
+		webhook *models.Webhook
+		event   string
+	}{{published, "post.published"}, {deleted, "post.deleted"}, {inactive, ""}} {
+		deliveries, err := webhooks.Deliveries(ctx, models.DeliveryFilter{WebhookID: tc.webhook.ID})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if tc.event == "" {
+			if len(deliveries) != 0 {
+				t.Errorf("expected no delivery for an inactive webhook, got %d", len(deliveries))
+			}
+			continue
+		}
+		if len(deliveries) != 1 || deliveries[0].Event != tc.event || deliveries[0].Status != models.DeliveryPending {
+			t.Fatalf("expected a pending %s delivery, got %+v", tc.event, deliveries)
+		}
+
+		var payload models.WebhookEvent
+		if err := json.Unmarshal(deliveries[0].Payload, &payload); err != nil {
+			t.Fatalf("failed to unmarshal payload: %v", err)
+		}
+		if payload.Type != tc.event || payload.Data.PostID != "1" || payload.ID == "" || payload.ID != deliveries[0].EventID {
+			t.Errorf("unexpected payload %+v", payload)
+		}
+		if (payload.Data.Post == nil) != (tc.event == "post.deleted") {
+			t.Errorf("expected the post only for non-deletions, got %+v", payload.Data.Post)
+		}
+	}
+
+	due, _ := webhooks.Due(ctx, 0)
+	if len(due) != 2 {
+		t.Errorf("expected 2 due deliveries, got %d", len(due))
+	}
+}
+
+func TestWebhookService_RecordAttempt(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute}
+	service := NewWebhookService(NewInMemoryWebhookRepo(), policy)
+	ctx := context.Background()
+	webhook, _ := service.Create(ctx, newTestWebhook("post.deleted"))
+	service.Enqueue(ctx, newEvent(EventPostDeleted, "1", nil))
+	deliveries, _ := service.Deliveries(ctx, models.DeliveryFilter{})
+	id := deliveries[0].ID
+
+	at := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	delivery, err := service.RecordAttempt(ctx, id, models.WebhookAttempt{At: at, StatusCode: 503, Error: "unexpected status 503"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if delivery.Status != models.DeliveryPending || delivery.Failures != 1 || !delivery.NextAttemptAt.Equal(at.Add(time.Second)) {
+		t.Errorf("expected a retry after a second, got %+v", delivery)
+	}
+	delivery, _ = service.RecordAttempt(ctx, id, models.WebhookAttempt{At: at, Error: "connection refused"})
+	if !delivery.NextAttemptAt.Equal(at.Add(2 * time.Second)) {
+		t.Errorf("expected the backoff to double, got %v", delivery.NextAttemptAt)
+	}
+	delivery, _ = service.RecordAttempt(ctx, id, models.WebhookAttempt{At: at, Error: "connection refused"})
+	if delivery.Status != models.DeliveryDead || delivery.NextAttemptAt != nil || len(delivery.Attempts) != 3 {
+		t.Errorf("expected a dead delivery after 3 attempts, got %+v", delivery)
+	}
+
+	dead, _ := service.Deliveries(ctx, models.DeliveryFilter{Status: models.DeliveryDead})
+	if len(dead) != 1 || dead[0].WebhookID != webhook.ID {
+		t.Errorf("expected the delivery in the dead-letter list, got %+v", dead)
+	}
+
+	delivery, err = service.Redeliver(ctx, id)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if delivery.Status != models.DeliveryPending || delivery.Failures != 0 || len(delivery.Attempts) != 3 {
+		t.Errorf("expected a pending delivery keeping its log, got %+v", delivery)
+	}
+	if _, err := service.Redeliver(ctx, id); err != ErrDeliveryPending {
+		t.Errorf("expected ErrDeliveryPending, got %v", err)
+	}
+
+	delivery, _ = service.RecordAttempt(ctx, id, models.WebhookAttempt{At: at, StatusCode: 204})
+	if delivery.Status != models.DeliverySucceeded || delivery.NextAttemptAt != nil {
+		t.Errorf("expected a succeeded delivery, got %+v", delivery)
+	}
+}
+
+func TestWebhookService_DeleteDropsDeliveries(t *testing.T) {
+	service := NewWebhookService(NewInMemoryWebhookRepo(), DefaultRetryPolicy)
+	ctx := context.Background()
+	webhook, _ := service.Create(ctx, newTestWebhook("post.deleted"))
+	service.Enqueue(ctx, newEvent(EventPostDeleted, "1", nil))
+
+	if err := service.Delete(ctx, webhook.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if deliveries, _ := service.Deliveries(ctx, models.DeliveryFilter{}); len(deliveries) != 0 {
+		t.Errorf("expected the deliveries to be deleted, got %d", len(deliveries))
+	}
+	if _, err := service.Deliveries(ctx, models.DeliveryFilter{WebhookID: webhook.ID}); err != ErrWebhookNotFound {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for failures, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 50: 5 * time.Second} {
+		if got := policy.Delay(failures); got != expected {
+			t.Errorf("expected a delay of %s after %d failures, got %s", expected, failures, got)
+		}
+	}
+}
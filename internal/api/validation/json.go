@@ -0,0 +1,61 @@
+package validation
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// DecodeJSON decodes a JSON object into the struct pointed to by dst, then
+// sanitizes and validates it like Struct. It returns every unknown,
+// mistyped and invalid field at once; a malformed document is returned as a
+// bad request error instead.
+func DecodeJSON(data []byte, dst any) ([]apperrors.FieldError, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil || raw == nil {
+		return nil, apperrors.BadRequest("invalid body provided", err)
+	}
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	known := JSONFields(dst)
+	var fields []apperrors.FieldError
+	for _, name := range names {
+		if !known[name] {
+			fields = append(fields, apperrors.FieldError{Field: name, Reason: "is not allowed"})
+			continue
+		}
+		// decode field by field so that one mistyped field does not hide the others
+		single, _ := json.Marshal(map[string]json.RawMessage{name: raw[name]})
+		if err := json.Unmarshal(single, dst); err != nil {
+			fields = append(fields, apperrors.FieldError{Field: name, Reason: "has an invalid type"})
+		}
+	}
+
+	if err := Struct(dst); err != nil {
+		var appErr *apperrors.Error
+		if errors.As(err, &appErr) {
+			fields = mergeFieldErrors(fields, appErr.Fields)
+		}
+	}
+	return fields, nil
+}
+
+// mergeFieldErrors appends the errors of fields not reported yet
+func mergeFieldErrors(reported, more []apperrors.FieldError) []apperrors.FieldError {
+	seen := make(map[string]bool, len(reported))
+	for _, f := range reported {
+		seen[f.Field] = true
+	}
+	for _, f := range more {
+		if !seen[f.Field] {
+			reported = append(reported, f)
+		}
+	}
+	return reported
+}
@@ -0,0 +1,231 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	RegisterRule("required", required)
+	RegisterRule("maxrunes", maxRunes)
+	RegisterRule("maxbytes", maxBytes)
+	RegisterRule("minbytes", minBytes)
+	RegisterRule("oneof", oneOf)
+	RegisterRule("maxitems", maxItems)
+	RegisterRule("uuid", isUUID)
+	RegisterRule("slug", isSlug)
+	RegisterRule("pathsegment", isPathSegment)
+	RegisterRule("httpurl", isHTTPURL)
+	RegisterRule("email", isEmail)
+
+	RegisterSanitizer("trim", strings.TrimSpace)
+	RegisterSanitizer("lower", strings.ToLower)
+	RegisterSanitizer("nfc", norm.NFC.String)
+	RegisterSanitizer("stripctl", StripControl)
+	RegisterSanitizer("singleline", SingleLine)
+}
+
+func required(v reflect.Value, _ string) string {
+	if v.IsZero() {
+		return "is required"
+	}
+	if v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "" {
+		return "is required"
+	}
+	if v.Kind() == reflect.Slice && v.Len() == 0 {
+		return "is required"
+	}
+	return ""
+}
+
+// requiredWithout requires a value unless the other field, named other,
+// has one
+func requiredWithout(v, otherValue reflect.Value, other string) string {
+	if required(otherValue, "") == "" {
+		return ""
+	}
+	if required(v, "") != "" {
+		return "is required unless " + other + " is set"
+	}
+	return ""
+}
+
+func maxRunes(v reflect.Value, param string) string {
+	limit := mustAtoi("maxrunes", param)
+	return eachString(v, func(s string) string {
+		if utf8.RuneCountInString(s) > limit {
+			return fmt.Sprintf("must be at most %d characters long", limit)
+		}
+		return ""
+	})
+}
+
+func maxBytes(v reflect.Value, param string) string {
+	limit := mustAtoi("maxbytes", param)
+	return eachString(v, func(s string) string {
+		if len(s) > limit {
+			return fmt.Sprintf("must be at most %d bytes long", limit)
+		}
+		return ""
+	})
+}
+
+// minBytes accepts empty values, combine it with required if needed
+func minBytes(v reflect.Value, param string) string {
+	limit := mustAtoi("minbytes", param)
+	return eachString(v, func(s string) string {
+		if s != "" && len(s) < limit {
+			return fmt.Sprintf("must be at least %d bytes long", limit)
+		}
+		return ""
+	})
+}
+
+func maxItems(v reflect.Value, param string) string {
+	limit := mustAtoi("maxitems", param)
+	if v.Kind() == reflect.Slice && v.Len() > limit {
+		return fmt.Sprintf("must have at most %d items", limit)
+	}
+	return ""
+}
+
+// eachString applies a string check to a string value, or to every
+// element of a string slice
+func eachString(v reflect.Value, check func(s string) string) string {
+	if v.Kind() != reflect.Slice {
+		return check(v.String())
+	}
+	for i := 0; i < v.Len(); i++ {
+		if reason := check(v.Index(i).String()); reason != "" {
+			return fmt.Sprintf("item %d %s", i, reason)
+		}
+	}
+	return ""
+}
+
+// oneOf accepts empty values, combine it with required if needed.
+// Allowed values are separated by spaces, e.g. oneof=plain markdown.
+func oneOf(v reflect.Value, param string) string {
+	allowed := strings.Fields(param)
+	return eachString(v, func(s string) string {
+		if s == "" || slices.Contains(allowed, s) {
+			return ""
+		}
+		return fmt.Sprintf("must be one of: %s", strings.Join(allowed, ", "))
+	})
+}
+
+// isUUID accepts empty values, combine it with required if needed. Only
+// the canonical lowercase form is accepted, as IDs are compared as strings.
+func isUUID(v reflect.Value, _ string) string {
+	s := v.String()
+	if s == "" {
+		return ""
+	}
+	if u, err := uuid.Parse(s); err != nil || u.String() != s {
+		return "must be a lowercase UUID"
+	}
+	return ""
+}
+
+// isPathSegment rejects the names . and .., which can't name a page of
+// their own: they are dot segments in a URL path and a file path
+func isPathSegment(v reflect.Value, _ string) string {
+	return eachString(v, func(s string) string {
+		if s == "." || s == ".." {
+			return "must not be . or .."
+		}
+		return ""
+	})
+}
+
+// isSlug accepts empty values and lowercase ASCII words joined by single
+// hyphens, e.g. getting-started-with-go
+func isSlug(v reflect.Value, _ string) string {
+	s := v.String()
+	if s == "" {
+		return ""
+	}
+	for _, word := range strings.Split(s, "-") {
+		if word == "" || strings.TrimLeft(word, "abcdefghijklmnopqrstuvwxyz0123456789") != "" {
+			return "must contain only lowercase letters, digits and single hyphens"
+		}
+	}
+	return ""
+}
+
+// isHTTPURL accepts empty values and absolute http or https URLs with a host
+func isHTTPURL(v reflect.Value, _ string) string {
+	return eachString(v, func(s string) string {
+		if s == "" {
+			return ""
+		}
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an absolute http or https URL"
+		}
+		return ""
+	})
+}
+
+// isEmail accepts empty values and bare email addresses, without display
+// name or comments, e.g. john@example.com
+func isEmail(v reflect.Value, _ string) string {
+	return eachString(v, func(s string) string {
+		if s == "" {
+			return ""
+		}
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Name != "" || addr.Address != s || !strings.Contains(s[strings.LastIndex(s, "@"):], ".") {
+			return "must be an email address"
+		}
+		return ""
+	})
+}
+
+// StripControl removes control characters except newlines and tabs, and
+// normalizes CRLF line endings to LF
+func StripControl(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// SingleLine replaces line breaks and tabs with spaces and removes every
+// other control character
+func SingleLine(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			return ' '
+		case unicode.IsControl(r) || r == utf8.RuneError:
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func mustAtoi(rule, param string) int {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		panic(fmt.Sprintf("validation: invalid %s parameter %q", rule, param))
+	}
+	return n
+}
@@ -0,0 +1,218 @@
+// Package validation sanitizes and validates request structs according to
+// their struct tags, so that HTTP handlers and non-HTTP callers (importers,
+// batch jobs) share the same rules.
+//
+// Two tags are read on string and string slice fields:
+//
+//	sanitize:"nfc,stripctl,trim"         applied in order, before validation
+//	validate:"required,maxrunes=200"    every rule is checked
+//
+// Sanitizers and string rules apply to every element of a string slice.
+// Field names in errors are taken from the json tag. The requiredwithout
+// rule compares two fields: requiredwithout=author_id requires the field
+// unless author_id is set.
+package validation
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// RuleFunc checks a field value against a rule parameter and returns the
+// reason why the value is invalid, or an empty string
+type RuleFunc func(v reflect.Value, param string) string
+
+// SanitizeFunc rewrites a string field value
+type SanitizeFunc func(s string) string
+
+var (
+	registryMu sync.RWMutex
+	rules      = map[string]RuleFunc{}
+	sanitizers = map[string]SanitizeFunc{}
+
+	typeCache sync.Map // reflect.Type -> *structInfo
+)
+
+// RegisterRule makes a rule available to validate tags. It is meant to be
+// called from init functions.
+func RegisterRule(name string, fn RuleFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	rules[name] = fn
+}
+
+// RegisterSanitizer makes a sanitizer available to sanitize tags. It is
+// meant to be called from init functions.
+func RegisterSanitizer(name string, fn SanitizeFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	sanitizers[name] = fn
+}
+
+type ruleRef struct {
+	name  string
+	param string
+	fn    RuleFunc
+	// other is the index of the field named by the parameter of
+	// requiredwithout
+	other int
+}
+
+type fieldInfo struct {
+	index      int
+	name       string
+	sanitizers []SanitizeFunc
+	rules      []ruleRef
+}
+
+type structInfo struct {
+	fields []fieldInfo
+	names  map[string]bool
+}
+
+// Struct sanitizes the string fields of the struct pointed to by v in
+// place, then validates every field. It returns an apperrors validation
+// error listing every invalid field, or nil.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: expected a pointer to a struct, got %T", v))
+	}
+	rv = rv.Elem()
+	info := infoFor(rv.Type())
+
+	var fields []apperrors.FieldError
+	for _, f := range info.fields {
+		fv := rv.Field(f.index)
+		switch {
+		case fv.Kind() == reflect.String:
+			fv.SetString(f.sanitize(fv.String()))
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+			for i := 0; i < fv.Len(); i++ {
+				fv.Index(i).SetString(f.sanitize(fv.Index(i).String()))
+			}
+		}
+		for _, r := range f.rules {
+			var reason string
+			if r.name == "requiredwithout" {
+				reason = requiredWithout(fv, rv.Field(r.other), r.param)
+			} else {
+				reason = r.fn(fv, r.param)
+			}
+			if reason != "" {
+				fields = append(fields, apperrors.FieldError{Field: f.name, Reason: reason})
+				// one reason per field is enough, e.g. no length error for a missing field
+				break
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperrors.Validation("request has invalid fields", fields...)
+	}
+	return nil
+}
+
+func (f fieldInfo) sanitize(s string) string {
+	for _, fn := range f.sanitizers {
+		s = fn(s)
+	}
+	return s
+}
+
+// JSONFields returns the set of json field names of the struct type of v
+func JSONFields(v any) map[string]bool {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return infoFor(t).names
+}
+
+func infoFor(t reflect.Type) *structInfo {
+	if cached, ok := typeCache.Load(t); ok {
+		return cached.(*structInfo)
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	info := &structInfo{names: map[string]bool{}}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+		info.names[name] = true
+
+		f := fieldInfo{index: i, name: name}
+		for _, s := range splitTag(sf.Tag.Get("sanitize")) {
+			fn, ok := sanitizers[s]
+			if !ok {
+				panic(fmt.Sprintf("validation: unknown sanitizer %q on %s.%s", s, t.Name(), sf.Name))
+			}
+			f.sanitizers = append(f.sanitizers, fn)
+		}
+		for _, r := range splitTag(sf.Tag.Get("validate")) {
+			name, param, _ := strings.Cut(r, "=")
+			if name == "requiredwithout" {
+				other, ok := fieldIndex(t, param)
+				if !ok {
+					panic(fmt.Sprintf("validation: unknown field %q in requiredwithout on %s.%s", param, t.Name(), sf.Name))
+				}
+				f.rules = append(f.rules, ruleRef{name: name, param: param, other: other})
+				continue
+			}
+			fn, ok := rules[name]
+			if !ok {
+				panic(fmt.Sprintf("validation: unknown rule %q on %s.%s", name, t.Name(), sf.Name))
+			}
+			f.rules = append(f.rules, ruleRef{name: name, param: param, fn: fn})
+		}
+		if len(f.sanitizers) > 0 || len(f.rules) > 0 {
+			info.fields = append(info.fields, f)
+		}
+	}
+
+	actual, _ := typeCache.LoadOrStore(t, info)
+	return actual.(*structInfo)
+}
+
+// fieldIndex returns the index of the field of t with the given json name
+func fieldIndex(t reflect.Type, name string) (int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if sf := t.Field(i); sf.IsExported() && jsonName(sf) == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+func splitTag(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	parts := strings.Split(tag, ",")
+	out := parts[:0]
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
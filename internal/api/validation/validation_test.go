@@ -0,0 +1,302 @@
+package validation
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testBody struct {
+	Name    string `json:"name" sanitize:"nfc,singleline,trim" validate:"required,maxrunes=5"`
+	Text    string `json:"text" sanitize:"stripctl" validate:"maxbytes=4"`
+	Ignored string `json:"-"`
+	Plain   string
+}
+
+func fieldNames(err error) []string {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		return nil
+	}
+	names := make([]string, 0, len(appErr.Fields))
+	for _, f := range appErr.Fields {
+		names = append(names, f.Field)
+	}
+	return names
+}
+
+func TestStruct_Valid(t *testing.T) {
+	body := testBody{Name: "  Go  ", Text: "ok"}
+
+	if err := Struct(&body); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if body.Name != "Go" {
+		t.Errorf("expected trimmed name 'Go', got %q", body.Name)
+	}
+}
+
+func TestStruct_ReportsEveryField(t *testing.T) {
+	body := testBody{Name: "", Text: "too long"}
+
+	err := Struct(&body)
+	if !apperrors.Is(err, apperrors.KindValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if names := fieldNames(err); !reflect.DeepEqual(names, []string{"name", "text"}) {
+		t.Errorf("expected fields [name text], got %v", names)
+	}
+}
+
+func TestStruct_SanitizesBeforeValidating(t *testing.T) {
+	// only control characters and spaces left after sanitizing
+	body := testBody{Name: " \u0000\u0007\n "}
+
+	err := Struct(&body)
+	if names := fieldNames(err); !reflect.DeepEqual(names, []string{"name"}) {
+		t.Errorf("expected fields [name], got %v", names)
+	}
+}
+
+func TestStruct_CountsCharactersAfterNormalization(t *testing.T) {
+	// "é" as "e" + combining acute accent is 6 runes before NFC, 5 after
+	body := testBody{Name: "cafés"}
+
+	if err := Struct(&body); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if body.Name != "cafés" {
+		t.Errorf("expected NFC name 'cafés', got %q", body.Name)
+	}
+}
+
+func TestStruct_PanicsOnNonPointer(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a non-pointer argument")
+		}
+	}()
+	Struct(testBody{})
+}
+
+func TestRegisterRule(t *testing.T) {
+	RegisterRule("nogo", func(v reflect.Value, _ string) string {
+		if strings.Contains(v.String(), "go") {
+			return "must not mention go"
+		}
+		return ""
+	})
+	type body struct {
+		Topic string `json:"topic" validate:"nogo"`
+	}
+
+	err := Struct(&body{Topic: "let's go"})
+	if names := fieldNames(err); !reflect.DeepEqual(names, []string{"topic"}) {
+		t.Errorf("expected fields [topic], got %v", names)
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	fields := JSONFields(&testBody{})
+
+	expected := map[string]bool{"name": true, "text": true, "Plain": true}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("expected %v, got %v", expected, fields)
+	}
+}
+
+func TestStruct_StringSlices(t *testing.T) {
+	type body struct {
+		Tags []string `json:"tags" sanitize:"trim,lower" validate:"maxitems=2,maxrunes=3"`
+	}
+
+	b := body{Tags: []string{" GO ", "Web"}}
+	if err := Struct(&b); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(b.Tags, []string{"go", "web"}) {
+		t.Errorf("expected sanitized tags [go web], got %v", b.Tags)
+	}
+
+	if names := fieldNames(Struct(&body{Tags: []string{"a", "b", "c"}})); !reflect.DeepEqual(names, []string{"tags"}) {
+		t.Errorf("expected too many items to be reported, got %v", names)
+	}
+	if names := fieldNames(Struct(&body{Tags: []string{"golang"}})); !reflect.DeepEqual(names, []string{"tags"}) {
+		t.Errorf("expected too long item to be reported, got %v", names)
+	}
+}
+
+func TestStruct_PathSegment(t *testing.T) {
+	type body struct {
+		Tags []string `json:"tags" sanitize:"trim" validate:"pathsegment"`
+	}
+
+	if err := Struct(&body{Tags: []string{"c++", "...", ".net"}}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	for _, tag := range []string{".", " .. "} {
+		if names := fieldNames(Struct(&body{Tags: []string{"go", tag}})); !reflect.DeepEqual(names, []string{"tags"}) {
+			t.Errorf("expected %q to be reported, got %v", tag, names)
+		}
+	}
+}
+
+func TestStripControl(t *testing.T) {
+	got := StripControl("a\r\nb\tc\u0000d\u001be")
+	if got != "a\nb\tcde" {
+		t.Errorf("expected %q, got %q", "a\nb\tcde", got)
+	}
+}
+
+func TestSingleLine(t *testing.T) {
+	got := SingleLine("a\nb\tc\u0000d")
+	if got != "a b cd" {
+		t.Errorf("expected %q, got %q", "a b cd", got)
+	}
+}
+
+func TestStruct_UUID(t *testing.T) {
+	type body struct {
+		ID string `json:"id" validate:"uuid"`
+	}
+
+	for id, valid := range map[string]bool{
+		"":                                       true,
+		"550e8400-e29b-41d4-a716-446655440000":   true,
+		"550E8400-E29B-41D4-A716-446655440000":   false,
+		"{550e8400-e29b-41d4-a716-446655440000}": false,
+		"42":                                     false,
+	} {
+		if err := Struct(&body{ID: id}); (err == nil) != valid {
+			t.Errorf("expected %q valid=%t, got %v", id, valid, err)
+		}
+	}
+}
+
+func TestStruct_OneOfSlice(t *testing.T) {
+	type body struct {
+		Events []string `json:"events" validate:"oneof=a b"`
+	}
+
+	if err := Struct(&body{Events: []string{"a", "b"}}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if names := fieldNames(Struct(&body{Events: []string{"a", "c"}})); !reflect.DeepEqual(names, []string{"events"}) {
+		t.Errorf("expected an unknown item to be reported, got %v", names)
+	}
+}
+
+func TestStruct_MinBytes(t *testing.T) {
+	type body struct {
+		Secret string `json:"secret" validate:"minbytes=4"`
+	}
+
+	for secret, valid := range map[string]bool{"": true, "abcd": true, "abc": false} {
+		if err := Struct(&body{Secret: secret}); (err == nil) != valid {
+			t.Errorf("expected %q valid=%t, got %v", secret, valid, err)
+		}
+	}
+}
+
+func TestStruct_HTTPURL(t *testing.T) {
+	type body struct {
+		URL string `json:"url" validate:"httpurl"`
+	}
+
+	for u, valid := range map[string]bool{
+		"":                              true,
+		"https://example.com/hooks?a=1": true,
+		"http://localhost:9000":         true,
+		"ftp://example.com":             false,
+		"/hooks":                        false,
+		"https://":                      false,
+	} {
+		if err := Struct(&body{URL: u}); (err == nil) != valid {
+			t.Errorf("expected %q valid=%t, got %v", u, valid, err)
+		}
+	}
+}
+
+func TestStruct_HTTPURLSlice(t *testing.T) {
+	type body struct {
+		Links []string `json:"links" validate:"httpurl"`
+	}
+
+	if err := Struct(&body{Links: []string{"https://github.com/jdoe", ""}}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if names := fieldNames(Struct(&body{Links: []string{"https://github.com/jdoe", "jdoe"}})); !reflect.DeepEqual(names, []string{"links"}) {
+		t.Errorf("expected an invalid item to be reported, got %v", names)
+	}
+}
+
+func TestStruct_Email(t *testing.T) {
+	type body struct {
+		Email string `json:"email" validate:"email"`
+	}
+
+	for _, email := range []string{"", "john@example.com", "john.doe+blog@mail.example.co.uk"} {
+		if err := Struct(&body{Email: email}); err != nil {
+			t.Errorf("expected %q to be valid, got %v", email, err)
+		}
+	}
+	for _, email := range []string{"john", "john@localhost", "John <john@example.com>", "john@example.com (John)", " john@example.com"} {
+		if err := Struct(&body{Email: email}); err == nil {
+			t.Errorf("expected %q to be invalid", email)
+		}
+	}
+}
+
+func TestStruct_RequiredWithout(t *testing.T) {
+	type body struct {
+		Author   string `json:"author" sanitize:"trim" validate:"requiredwithout=author_id,maxrunes=5"`
+		AuthorID string `json:"author_id"`
+	}
+
+	for _, b := range []body{{Author: "Ann"}, {AuthorID: "1"}, {Author: "Ann", AuthorID: "1"}} {
+		if err := Struct(&b); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", b, err)
+		}
+	}
+	var appErr *apperrors.Error
+	if !errors.As(Struct(&body{Author: "  "}), &appErr) || len(appErr.Fields) != 1 ||
+		appErr.Fields[0] != (apperrors.FieldError{Field: "author", Reason: "is required unless author_id is set"}) {
+		t.Errorf("expected the author to be required, got %v", appErr)
+	}
+	if names := fieldNames(Struct(&body{Author: "Annabel", AuthorID: "1"})); !reflect.DeepEqual(names, []string{"author"}) {
+		t.Errorf("expected the other rules to apply, got %v", names)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown field")
+		}
+	}()
+	type unknown struct {
+		Author string `json:"author" validate:"requiredwithout=missing"`
+	}
+	Struct(&unknown{})
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var body testBody
+	fields, err := DecodeJSON([]byte(`{"name":7,"text":"too long","extra":true}`), &body)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Field)
+	}
+	// a mistyped field is reported once, not also as missing
+	if !reflect.DeepEqual(names, []string{"extra", "name", "text"}) {
+		t.Errorf("expected fields [extra name text], got %v", names)
+	}
+
+	if _, err := DecodeJSON([]byte(`[1]`), &body); !apperrors.Is(err, apperrors.KindBadRequest) {
+		t.Errorf("expected a bad request for a non-object, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPassword(t *testing.T) {
+	for _, algorithm := range []string{Argon2id, Bcrypt} {
+		hash, err := HashPassword("correct horse", algorithm)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", algorithm, err)
+		}
+		if ok, err := CheckPassword(hash, "correct horse"); !ok || err != nil {
+			t.Errorf("%s: expected the password to match, got %t and %v", algorithm, ok, err)
+		}
+		if ok, err := CheckPassword(hash, "battery staple"); ok || err != nil {
+			t.Errorf("%s: expected another password not to match, got %t and %v", algorithm, ok, err)
+		}
+		if NeedsRehash(hash, algorithm) {
+			t.Errorf("%s: expected a current hash not to need a rehash", algorithm)
+		}
+	}
+
+	hash, _ := HashPassword("correct horse", Argon2id)
+	if other, _ := HashPassword("correct horse", Argon2id); other == hash {
+		t.Error("expected hashes to be salted")
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("expected a PHC string, got %s", hash)
+	}
+	if !NeedsRehash(hash, Bcrypt) {
+		t.Error("expected an argon2id hash to need a rehash into bcrypt")
+	}
+	weaker := strings.Replace(hash, "t=2", "t=1", 1)
+	if !NeedsRehash(weaker, Argon2id) {
+		t.Error("expected a hash with other parameters to need a rehash")
+	}
+	if ok, _ := CheckPassword(weaker, "correct horse"); ok {
+		t.Error("expected the parameters of the hash to be used")
+	}
+	if _, err := CheckPassword("$argon2id$v=19$m=abc", "correct horse"); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("expected an unknown hash error, got %v", err)
+	}
+}
+
+func TestJWT(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1_700_000_000, 0)
+	claims := Claims{Issuer: "https://blog.example.com", Subject: "42", Email: "ann@example.com", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}
+
+	token, err := SignJWT(secret, claims)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	parsed, err := ParseJWT(secret, token, "https://blog.example.com", now)
+	if err != nil || parsed != claims {
+		t.Errorf("expected the claims back, got %+v and %v", parsed, err)
+	}
+
+	header, rest, _ := strings.Cut(token, ".")
+	payload, signature, _ := strings.Cut(rest, ".")
+	cases := map[string]string{
+		"another secret":  mustSign(t, []byte("other"), claims),
+		"tampered claims": header + "." + encodeSegment([]byte(`{"sub":"1","exp":9999999999}`)) + "." + signature,
+		"alg none":        encodeSegment([]byte(`{"alg":"none"}`)) + "." + payload + ".",
+		"malformed":       "not-a-token",
+	}
+	for name, token := range cases {
+		if _, err := ParseJWT(secret, token, "", now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected an invalid token error, got %v", name, err)
+		}
+	}
+	if _, err := ParseJWT(secret, token, "https://other.example.com", now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected an invalid token error for another issuer, got %v", err)
+	}
+	if _, err := ParseJWT(secret, token, "", now.Add(time.Hour)); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("expected an expired token error, got %v", err)
+	}
+}
+
+func mustSign(t *testing.T, secret []byte, claims Claims) string {
+	t.Helper()
+	token, err := SignJWT(secret, claims)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return token
+}
+
+func TestToken(t *testing.T) {
+	token := NewToken()
+	if len(token) != TokenLength || token == NewToken() {
+		t.Errorf("expected a random token of 43 characters, got %q", token)
+	}
+	if HashToken(token) != HashToken(token) || HashToken(token) == token {
+		t.Error("expected a stable hash differing from the token")
+	}
+}
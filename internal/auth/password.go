@@ -0,0 +1,138 @@
+// Package auth holds the primitives of user authentication: password
+// hashes, random tokens and the JWT access tokens of the API.
+//
+// Passwords are hashed with argon2id by default, encoded in the PHC string
+// format:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<base64 salt>$<base64 key>
+//
+// or with bcrypt. Both kinds of hashes are verified, so that the algorithm
+// can be changed without invalidating the stored passwords: NeedsRehash
+// tells when a hash should be replaced after a successful login.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+// Argon2 parameters of new hashes, the first of the OWASP recommendations
+const (
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// BcryptCost is the cost of new bcrypt hashes
+const BcryptCost = 12
+
+// MaxPasswordBytes is the length beyond which bcrypt ignores a password,
+// and so the maximum length of every password
+const MaxPasswordBytes = 72
+
+// ErrUnknownHash is returned for hashes of an unknown format
+var ErrUnknownHash = errors.New("auth: unknown password hash format")
+
+// HashPassword hashes a password with the algorithm, Argon2id or Bcrypt
+func HashPassword(password, algorithm string) (string, error) {
+	switch algorithm {
+	case Argon2id:
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Time, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	case Bcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost)
+		return string(hash), err
+	default:
+		return "", fmt.Errorf("auth: unknown password hashing algorithm %q", algorithm)
+	}
+}
+
+// CheckPassword tells whether password matches hash, of either algorithm
+func CheckPassword(hash, password string) (bool, error) {
+	if isBcrypt(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	p, err := parseArgon2(hash)
+	if err != nil {
+		return false, err
+	}
+	key := argon2.IDKey([]byte(password), p.salt, p.time, p.memory, p.threads, uint32(len(p.key)))
+	return subtle.ConstantTimeCompare(key, p.key) == 1, nil
+}
+
+// NeedsRehash tells whether hash was made with another algorithm or other
+// parameters than new hashes of the algorithm
+func NeedsRehash(hash, algorithm string) bool {
+	switch algorithm {
+	case Bcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != BcryptCost
+	case Argon2id:
+		p, err := parseArgon2(hash)
+		return err != nil || p.memory != argon2Memory || p.time != argon2Time || p.threads != argon2Threads ||
+			len(p.salt) != argon2SaltLen || len(p.key) != argon2KeyLen
+	default:
+		return false
+	}
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+type argon2Hash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func parseArgon2(hash string) (argon2Hash, error) {
+	var p argon2Hash
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != Argon2id {
+		return p, ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return p, ErrUnknownHash
+	}
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, ErrUnknownHash
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(p.key) == 0 {
+		return p, ErrUnknownHash
+	}
+	return p, nil
+}
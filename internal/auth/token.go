@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// TokenLength is the length of the tokens of NewToken
+const TokenLength = 43
+
+// NewToken returns a random URL-safe token of 256 bits, e.g. for sessions
+// and the links sent by email
+func NewToken() string {
+	b := make([]byte, 32)
+	// rand.Read never returns an error on supported platforms
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// HashToken returns the SHA-256 of a token, which is what is stored so
+// that the tokens cannot be used by whoever reads the store
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
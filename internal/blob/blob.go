@@ -0,0 +1,36 @@
+// Package blob stores files such as post attachments by key, e.g.
+// posts/<post id>/<attachment id>.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned by Open for keys without a blob
+var ErrNotFound = errors.New("blob not found")
+
+// Store stores blobs by key. Keys are slash-separated paths without empty,
+// "." or ".." elements.
+type Store interface {
+	// Put stores the content of r under key, replacing the blob stored
+	// under it. Nothing is stored when r fails.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open returns the blob stored under key, or ErrNotFound
+	Open(ctx context.Context, key string) (*Blob, error)
+	// Delete removes the blob stored under key, if any
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes every blob whose key starts with prefix followed
+	// by a slash, e.g. the attachments of a post
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Blob is an open blob, which the caller must close. It seeks so that it
+// can be served with range requests.
+type Blob struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
@@ -0,0 +1,104 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(filepath.Join(t.TempDir(), "blobs"))
+	if err != nil {
+		t.Fatalf("failed to create the store: %v", err)
+	}
+	ctx := context.Background()
+
+	if n, err := store.Put(ctx, "posts/1/a", strings.NewReader("hello")); err != nil || n != 5 {
+		t.Fatalf("expected 5 bytes to be stored, got %d, %v", n, err)
+	}
+	store.Put(ctx, "posts/1/b", strings.NewReader("world"))
+	store.Put(ctx, "posts/10/c", strings.NewReader("other post"))
+
+	b, err := store.Open(ctx, "posts/1/a")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	data, _ := io.ReadAll(b)
+	b.Close()
+	if string(data) != "hello" || b.Size != 5 {
+		t.Errorf("expected the stored blob, got %q of %d bytes", data, b.Size)
+	}
+
+	if err := store.Delete(ctx, "posts/1/a"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := store.Delete(ctx, "posts/1/a"); err != nil {
+		t.Errorf("expected deleting a missing blob to succeed, got %v", err)
+	}
+	if _, err := store.Open(ctx, "posts/1/a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := store.DeletePrefix(ctx, "posts/1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := store.Open(ctx, "posts/1/b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the blobs of the prefix to be deleted, got %v", err)
+	}
+	if b, err := store.Open(ctx, "posts/10/c"); err != nil {
+		t.Errorf("expected the blobs of other prefixes to be kept, got %v", err)
+	} else {
+		b.Close()
+	}
+	if _, err := store.Open(ctx, "posts/10"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected directories not to be blobs, got %v", err)
+	}
+}
+
+// failingReader fails after some content
+type failingReader struct{ read bool }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, errors.New("connection reset")
+	}
+	r.read = true
+	return copy(p, "partial"), nil
+}
+
+func TestLocalStore_FailedPutStoresNothing(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := NewLocalStore(dir)
+	ctx := context.Background()
+	store.Put(ctx, "posts/1/a", strings.NewReader("complete"))
+
+	if _, err := store.Put(ctx, "posts/1/a", &failingReader{}); err == nil {
+		t.Fatal("expected the reader error")
+	}
+	b, err := store.Open(ctx, "posts/1/a")
+	if err != nil {
+		t.Fatalf("expected the previous blob to be kept, got %v", err)
+	}
+	data, _ := io.ReadAll(b)
+	b.Close()
+	if string(data) != "complete" {
+		t.Errorf("expected the previous content, got %q", data)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "posts", "1"))
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary file to be left, got %v", entries)
+	}
+}
+
+func TestLocalStore_InvalidKeys(t *testing.T) {
+	store, _ := NewLocalStore(t.TempDir())
+	for _, key := range []string{"", "../escape", "/abs", "posts/../../x", "posts//a", "posts/./a", `posts\a`, "posts/a/"} {
+		if _, err := store.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package collab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// ErrNotConcurrent is returned when transforming operations which do not
+// apply to the same document
+var ErrNotConcurrent = errors.New("operations do not apply to the same document")
+
+// maxComponentLen bounds the retains and deletes of decoded operations,
+// far above the length of any post, so that their sums can't overflow
+const maxComponentLen = 1 << 30
+
+// Component is a step of an Operation, exactly one of its fields is set
+type Component struct {
+	// Retain keeps the next characters
+	Retain int
+	// Insert adds text at the current position
+	Insert string
+	// Delete removes the next characters
+	Delete int
+}
+
+// Operation is an edit of a whole document, its components walking through
+// the document from start to end. Lengths are counted in Unicode code
+// points. Operations are values: building one from another never changes
+// the other.
+//
+// It is encoded in the ot.js format: an array of positive integers
+// (retain), strings (insert) and negative integers (delete), e.g.
+// [5, "brave new ", -3, 12].
+type Operation []Component
+
+// Retain returns the operation followed by a retain of n characters
+func (o Operation) Retain(n int) Operation {
+	if n <= 0 {
+		return o
+	}
+	if last := len(o) - 1; last >= 0 && o[last].Retain > 0 && o[last].Retain <= math.MaxInt-n {
+		return append(o[:last:last], Component{Retain: o[last].Retain + n})
+	}
+	return append(o, Component{Retain: n})
+}
+
+// Insert returns the operation followed by an insertion of s. Insertions
+// are kept before the deletions at the same position, so that equivalent
+// operations have the same components.
+func (o Operation) Insert(s string) Operation {
+	if s == "" {
+		return o
+	}
+	last := len(o) - 1
+	if last >= 0 && o[last].Insert != "" {
+		return append(o[:last:last], Component{Insert: o[last].Insert + s})
+	}
+	if last >= 0 && o[last].Delete > 0 {
+		if last > 0 && o[last-1].Insert != "" {
+			return append(o[:last-1:last-1], Component{Insert: o[last-1].Insert + s}, o[last])
+		}
+		return append(o[:last:last], Component{Insert: s}, o[last])
+	}
+	return append(o, Component{Insert: s})
+}
+
+// Delete returns the operation followed by a deletion of n characters
+func (o Operation) Delete(n int) Operation {
+	if n <= 0 {
+		return o
+	}
+	if last := len(o) - 1; last >= 0 && o[last].Delete > 0 && o[last].Delete <= math.MaxInt-n {
+		return append(o[:last:last], Component{Delete: o[last].Delete + n})
+	}
+	return append(o, Component{Delete: n})
+}
+
+// BaseLen returns the length of the documents the operation applies to, or
+// -1 when it overflows an int: such an operation applies to no document
+func (o Operation) BaseLen() int {
+	n := 0
+	for _, c := range o {
+		if c.Retain+c.Delete > math.MaxInt-n {
+			return -1
+		}
+		n += c.Retain + c.Delete
+	}
+	return n
+}
+
+// TargetLen returns the length of the documents the operation results in,
+// or -1 when it overflows an int
+func (o Operation) TargetLen() int {
+	n := 0
+	for _, c := range o {
+		l := c.Retain + utf8.RuneCountInString(c.Insert)
+		if l > math.MaxInt-n {
+			return -1
+		}
+		n += l
+	}
+	return n
+}
+
+// IsNoop tells whether the operation leaves documents unchanged
+func (o Operation) IsNoop() bool {
+	for _, c := range o {
+		if c.Retain == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the document edited by the operation
+func (o Operation) Apply(doc string) (string, error) {
+	text := []rune(doc)
+	if n := o.BaseLen(); n != len(text) {
+		return "", fmt.Errorf("operation applies to %d characters, the document has %d", n, len(text))
+	}
+
+	out := make([]rune, 0, max(o.TargetLen(), 0))
+	pos := 0
+	for _, c := range o {
+		// a matching length is not enough for components built by hand,
+		// e.g. with a negative length
+		if c.Retain < 0 || c.Delete < 0 || c.Retain > len(text)-pos || c.Delete > len(text)-pos {
+			return "", fmt.Errorf("operation goes past the end of the document at %d", pos)
+		}
+		switch {
+		case c.Retain > 0:
+			out = append(out, text[pos:pos+c.Retain]...)
+			pos += c.Retain
+		case c.Insert != "":
+			out = append(out, []rune(c.Insert)...)
+		default:
+			pos += c.Delete
+		}
+	}
+	return string(out), nil
+}
+
+// Transform returns a' and b' such that applying a then b' makes the same
+// document as applying b then a', for operations a and b made concurrently
+// on the same document. At the same position, the insertion of a comes
+// first.
+func Transform(a, b Operation) (Operation, Operation, error) {
+	if n := a.BaseLen(); n < 0 || n != b.BaseLen() {
+		return nil, nil, ErrNotConcurrent
+	}
+
+	var a2, b2 Operation
+	i, j := 0, 0
+	var ca, cb Component
+	next := func(o Operation, k *int) Component {
+		if *k >= len(o) {
+			return Component{}
+		}
+		*k++
+		return o[*k-1]
+	}
+	ca, cb = next(a, &i), next(b, &j)
+	for ca != (Component{}) || cb != (Component{}) {
+		if ca.Insert != "" {
+			a2 = a2.Insert(ca.Insert)
+			b2 = b2.Retain(utf8.RuneCountInString(ca.Insert))
+			ca = next(a, &i)
+			continue
+		}
+		if cb.Insert != "" {
+			a2 = a2.Retain(utf8.RuneCountInString(cb.Insert))
+			b2 = b2.Insert(cb.Insert)
+			cb = next(b, &j)
+			continue
+		}
+		if ca == (Component{}) || cb == (Component{}) {
+			return nil, nil, ErrNotConcurrent
+		}
+
+		n := min(ca.Retain+ca.Delete, cb.Retain+cb.Delete)
+		switch {
+		case ca.Retain > 0 && cb.Retain > 0:
+			a2 = a2.Retain(n)
+			b2 = b2.Retain(n)
+		case ca.Delete > 0 && cb.Retain > 0:
+			a2 = a2.Delete(n)
+		case ca.Retain > 0 && cb.Delete > 0:
+			b2 = b2.Delete(n)
+		}
+		// both deleting the same characters leaves nothing to do
+		if ca = consume(ca, n); ca == (Component{}) {
+			ca = next(a, &i)
+		}
+		if cb = consume(cb, n); cb == (Component{}) {
+			cb = next(b, &j)
+		}
+	}
+	return a2, b2, nil
+}
+
+// consume returns what is left of a retain or delete once n characters
+// are processed
+func consume(c Component, n int) Component {
+	if c.Retain > 0 {
+		return Component{Retain: c.Retain - n}
+	}
+	return Component{Delete: c.Delete - n}
+}
+
+// TransformIndex returns the position of a cursor at index once the
+// operation is applied. Text inserted at the cursor goes before it.
+func (o Operation) TransformIndex(index int) int {
+	moved, left := index, index
+	for _, c := range o {
+		switch {
+		case c.Retain > 0:
+			left -= c.Retain
+		case c.Insert != "":
+			moved += utf8.RuneCountInString(c.Insert)
+		default:
+			moved -= min(left, c.Delete)
+			left -= c.Delete
+		}
+		if left < 0 {
+			break
+		}
+	}
+	return moved
+}
+
+// Diff returns an operation turning from into to, replacing what lies
+// between their common prefix and suffix
+func Diff(from, to string) Operation {
+	a, b := []rune(from), []rune(to)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var o Operation
+	return o.Retain(prefix).
+		Insert(string(b[prefix : len(b)-suffix])).
+		Delete(len(a) - prefix - suffix).
+		Retain(suffix)
+}
+
+func (o Operation) MarshalJSON() ([]byte, error) {
+	parts := make([]any, len(o))
+	for i, c := range o {
+		switch {
+		case c.Retain > 0:
+			parts[i] = c.Retain
+		case c.Insert != "":
+			parts[i] = c.Insert
+		default:
+			parts[i] = -c.Delete
+		}
+	}
+	return json.Marshal(parts)
+}
+
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return err
+	}
+
+	var op Operation
+	for _, part := range parts {
+		if bytes.HasPrefix(part, []byte(`"`)) {
+			var s string
+			if err := json.Unmarshal(part, &s); err != nil {
+				return err
+			}
+			if s == "" {
+				return errors.New("empty insertion")
+			}
+			op = op.Insert(s)
+			continue
+		}
+		var n int
+		if err := json.Unmarshal(part, &n); err != nil {
+			return fmt.Errorf("invalid component %s", part)
+		}
+		if n > maxComponentLen || n < -maxComponentLen {
+			return fmt.Errorf("component %d is longer than any document", n)
+		}
+		switch {
+		case n > 0:
+			op = op.Retain(n)
+		case n < 0:
+			op = op.Delete(-n)
+		default:
+			return errors.New("empty retain")
+		}
+	}
+	*o = op
+	return nil
+}
@@ -0,0 +1,201 @@
+package collab
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestOperation_Apply(t *testing.T) {
+	var op Operation
+	op = op.Retain(6).Insert("brave new ").Delete(5).Insert("wörld")
+
+	doc, err := op.Apply("hello world")
+	if err != nil || doc != "hello brave new wörld" {
+		t.Errorf("expected hello brave new wörld, got %q and %v", doc, err)
+	}
+	if _, err := op.Apply("hello"); err == nil {
+		t.Error("expected an error for a document of another length")
+	}
+}
+
+func TestOperation_Canonical(t *testing.T) {
+	var a, b Operation
+	a = a.Retain(1).Delete(2).Insert("x").Retain(1).Retain(2)
+	b = b.Retain(1).Insert("x").Delete(1).Delete(1).Retain(3)
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	if string(ja) != `[1,"x",-2,3]` || string(ja) != string(jb) {
+		t.Errorf("expected equivalent operations to have the same components, got %s and %s", ja, jb)
+	}
+
+	// building from a shared operation leaves it as is
+	base := Operation{}.Retain(1).Insert("a")
+	_ = base.Insert("b")
+	_ = base[:1].Retain(5)
+	if base[0].Retain != 1 || base[1].Insert != "a" {
+		t.Errorf("expected the operation to be unchanged, got %v", base)
+	}
+}
+
+func TestOperation_JSON(t *testing.T) {
+	var op Operation
+	if err := json.Unmarshal([]byte(`[2, "ab", -1, "c", 3]`), &op); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	data, _ := json.Marshal(op)
+	if string(data) != `[2,"abc",-1,3]` {
+		t.Errorf("expected the canonical form, got %s", data)
+	}
+	for _, invalid := range []string{`[0]`, `[""]`, `[1.5]`, `{"retain":1}`, `[true]`} {
+		if err := json.Unmarshal([]byte(invalid), &op); err == nil {
+			t.Errorf("expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestOperation_Overflow(t *testing.T) {
+	var op Operation
+	if err := json.Unmarshal([]byte(`[9223372036854775807,"x",9223372036854775807,"x",3]`), &op); err == nil {
+		t.Error("expected components longer than any document to be rejected")
+	}
+	if err := json.Unmarshal([]byte(`[-9223372036854775808]`), &op); err == nil {
+		t.Error("expected the smallest int to be rejected")
+	}
+
+	// lengths wrapping around to the length of the document
+	hostile := Operation{{Retain: math.MaxInt}, {Insert: "x"}, {Retain: math.MaxInt}, {Insert: "x"}, {Retain: 3}}
+	if n := hostile.BaseLen(); n != -1 {
+		t.Errorf("expected an overflowing base length to be -1, got %d", n)
+	}
+	if _, err := hostile.Apply("a"); err == nil {
+		t.Error("expected the operation not to apply")
+	}
+	if _, _, err := Transform(hostile, hostile); err != ErrNotConcurrent {
+		t.Errorf("expected %v, got %v", ErrNotConcurrent, err)
+	}
+	if n := (Operation{}).Retain(math.MaxInt).Retain(math.MaxInt).Delete(math.MaxInt).Delete(1).BaseLen(); n != -1 {
+		t.Errorf("expected merged components not to wrap around, got %d", n)
+	}
+
+	// components built by hand with negative lengths
+	if _, err := (Operation{{Retain: 5}, {Delete: -3}, {Retain: -1}}).Apply("a"); err == nil {
+		t.Error("expected negative lengths not to apply")
+	}
+}
+
+func TestTransform(t *testing.T) {
+	doc := "hello world"
+	for name, tc := range map[string]struct {
+		a, b     Operation
+		expected string
+	}{
+		"inserts at the same position": {
+			a:        Operation{}.Retain(5).Insert(" A").Retain(6),
+			b:        Operation{}.Retain(5).Insert(" B").Retain(6),
+			expected: "hello A B world",
+		},
+		"overlapping deletes": {
+			a:        Operation{}.Retain(2).Delete(5).Retain(4),
+			b:        Operation{}.Retain(4).Delete(5).Retain(2),
+			expected: "held",
+		},
+		"insert in a deleted range": {
+			a:        Operation{}.Delete(6).Retain(5),
+			b:        Operation{}.Retain(3).Insert("p").Retain(8),
+			expected: "pworld",
+		},
+	} {
+		a2, b2, err := Transform(tc.a, tc.b)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		ab, _ := tc.a.Apply(doc)
+		ab, _ = b2.Apply(ab)
+		ba, _ := tc.b.Apply(doc)
+		ba, _ = a2.Apply(ba)
+		if ab != tc.expected || ba != tc.expected {
+			t.Errorf("%s: expected %q, got %q and %q", name, tc.expected, ab, ba)
+		}
+	}
+
+	if _, _, err := Transform(Operation{}.Retain(1), Operation{}.Retain(2)); err != ErrNotConcurrent {
+		t.Errorf("expected ErrNotConcurrent, got %v", err)
+	}
+}
+
+// randomOperation returns a random edit of doc
+func randomOperation(r *rand.Rand, doc string) Operation {
+	var op Operation
+	left := utf8.RuneCountInString(doc)
+	for left > 0 {
+		n := 1 + r.Intn(min(left, 5))
+		switch r.Intn(3) {
+		case 0:
+			op = op.Retain(n)
+			left -= n
+		case 1:
+			op = op.Delete(n)
+			left -= n
+		default:
+			op = op.Insert([]string{"a", "é", "🙂", "xyz"}[r.Intn(4)])
+		}
+	}
+	if r.Intn(2) == 0 {
+		op = op.Insert("end")
+	}
+	return op
+}
+
+func TestTransform_Converges(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for range 500 {
+		doc := "The quick brown 🦊 jumps over the lazy dog"
+		a, b := randomOperation(r, doc), randomOperation(r, doc)
+		a2, b2, err := Transform(a, b)
+		if err != nil {
+			t.Fatalf("expected no error transforming %v and %v, got %v", a, b, err)
+		}
+		ab, err := a.Apply(doc)
+		if err == nil {
+			ab, err = b2.Apply(ab)
+		}
+		ba, err2 := b.Apply(doc)
+		if err2 == nil {
+			ba, err2 = a2.Apply(ba)
+		}
+		if err != nil || err2 != nil || ab != ba {
+			t.Fatalf("expected %v and %v to converge, got %q (%v) and %q (%v)", a, b, ab, err, ba, err2)
+		}
+	}
+}
+
+func TestOperation_TransformIndex(t *testing.T) {
+	op := Operation{}.Retain(2).Insert("abc").Delete(3).Retain(5)
+	for index, expected := range map[int]int{0: 0, 2: 5, 3: 5, 5: 5, 7: 7, 10: 10} {
+		if got := op.TransformIndex(index); got != expected {
+			t.Errorf("expected index %d to move to %d, got %d", index, expected, got)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	for _, tc := range [][2]string{
+		{"hello world", "hello brave world"},
+		{"hello world", ""},
+		{"", "new"},
+		{"aaa", "aa"},
+		{"crème brûlée", "crème brulée"},
+	} {
+		from, to := tc[0], tc[1]
+		op := Diff(from, to)
+		if got, err := op.Apply(from); err != nil || got != to {
+			t.Errorf("expected the diff of %q to make %q, got %q and %v", from, to, got, err)
+		}
+	}
+	if !Diff("same", "same").IsNoop() {
+		t.Error("expected no change for equal documents")
+	}
+}
@@ -0,0 +1,585 @@
+// Package collab lets several editors change the content of a post at once.
+// Editors of a post join a session holding the document, and send their
+// edits as operations (see Operation) based on the revision of the document
+// they last saw. The session transforms each operation against the ones
+// applied since that revision, applies it and forwards it to the other
+// editors, following the server side of ot.js: every editor converges on
+// the same document.
+//
+// The document is saved through BlogPostService.UpdateVersion shortly after
+// it changed and when the last editor leaves. Changes of the post made
+// meanwhile, e.g. through the REST API, are merged into the document as an
+// operation of the server.
+package collab
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Types of the messages exchanged with editors
+const (
+	// MessageInit is the first message of an editor: its client ID, the
+	// document, its revision and the editors present
+	MessageInit = "init"
+	// MessageOp carries an operation based on a revision. Editors send it
+	// with the revision of the document they edited, and receive those of
+	// the other editors with the revision they result in.
+	MessageOp = "op"
+	// MessageAck tells an editor its operation was applied as revision Rev
+	MessageAck = "ack"
+	// MessageCursor carries the position of the cursor of an editor, based
+	// on a revision
+	MessageCursor = "cursor"
+	// MessagePresence lists the editors present and their cursors
+	MessagePresence = "presence"
+	// MessageSave asks for the document to be saved right away
+	MessageSave = "save"
+	// MessageSaved tells that the document of revision Rev was saved as
+	// version Version of the post
+	MessageSaved = "saved"
+	// MessageError reports an invalid message or a failed save. Editors
+	// whose document can no longer be synced are dropped after it.
+	MessageError = "error"
+	// MessageDeleted tells that the post was deleted, ending the session
+	MessageDeleted = "deleted"
+)
+
+// Message is exchanged with editors as JSON
+type Message struct {
+	Type     string     `json:"type"`
+	ClientID string     `json:"client_id,omitempty"`
+	Rev      int        `json:"rev"`
+	Op       Operation  `json:"op,omitempty"`
+	Content  *string    `json:"content,omitempty"`
+	Version  int        `json:"version,omitempty"`
+	Position *int       `json:"position,omitempty"`
+	Clients  []Presence `json:"clients,omitempty"`
+	Message  string     `json:"message,omitempty"`
+}
+
+// Presence describes an editor of a session
+type Presence struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Cursor is the position of the cursor at the current revision, if known
+	Cursor *int `json:"cursor,omitempty"`
+}
+
+// MaxNameLength bounds the display name of an editor, in characters
+const MaxNameLength = 100
+
+// Options tune a Hub
+type Options struct {
+	// SaveDelay is the time between the first unsaved change of a document
+	// and its save
+	SaveDelay time.Duration
+	// MaxHistory is the number of past operations kept to transform the
+	// operations of editors lagging behind. Operations based on an older
+	// revision are rejected.
+	MaxHistory int
+	// SendBuffer is the number of messages queued for an editor. Editors
+	// not reading their messages fast enough are dropped.
+	SendBuffer int
+}
+
+var (
+	ErrClosed = errors.New("collaborative editing is shutting down")
+)
+
+// Hub holds the editing session of every post being edited
+type Hub struct {
+	posts *services.BlogPostService
+	opts  Options
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	closed   bool
+	running  sync.WaitGroup
+}
+
+// NewHub returns a hub saving documents through the service, and merging
+// the changes of the posts made through it
+func NewHub(posts *services.BlogPostService, opts Options) *Hub {
+	if opts.SaveDelay <= 0 {
+		opts.SaveDelay = 2 * time.Second
+	}
+	if opts.MaxHistory <= 0 {
+		opts.MaxHistory = 1000
+	}
+	if opts.SendBuffer <= 0 {
+		opts.SendBuffer = 256
+	}
+	h := &Hub{posts: posts, opts: opts, sessions: map[string]*session{}}
+	posts.Subscribe(h.notify)
+	return h
+}
+
+// Join adds an editor to the session of a post, starting it if needed
+func (h *Hub) Join(ctx context.Context, postID, name string) (*Client, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Anonymous"
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return nil, apperrors.BadRequest("name is too long", nil)
+	}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil, ErrClosed
+	}
+	s := h.sessions[postID]
+	if s == nil {
+		post, err := h.posts.GetById(ctx, postID)
+		if err != nil {
+			h.mu.Unlock()
+			return nil, err
+		}
+		s = h.start(post)
+	}
+	s.refs++
+	h.mu.Unlock()
+
+	c := &Client{ID: uuid.New().String(), Name: name, session: s, send: make(chan Message, h.opts.SendBuffer)}
+	select {
+	case s.inbox <- envelope{client: c, msg: Message{Type: "join"}}:
+		return c, nil
+	case <-s.done:
+		h.release(s)
+		return nil, ErrClosed
+	}
+}
+
+// Close ends every session, saving the documents, and waits for them to
+// stop. Editors get their messages channel closed.
+func (h *Hub) Close(ctx context.Context) error {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		for _, s := range h.sessions {
+			h.end(s)
+		}
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// start runs the session of a post, h.mu being held
+func (h *Hub) start(post *models.BlogPost) *session {
+	s := &session{
+		hub:     h,
+		postID:  post.ID,
+		clients: map[*Client]*Presence{},
+		inbox:   make(chan envelope, 64),
+		changed: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		doc:     post.Content,
+		saved:   post,
+	}
+	s.savedVersion.Store(int64(post.Version))
+	h.sessions[post.ID] = s
+	h.running.Add(1)
+	go s.run()
+	return s
+}
+
+// release drops a reference to a session, stopping it with the last one
+func (h *Hub) release(s *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s.refs--; s.refs == 0 {
+		h.end(s)
+	}
+}
+
+// end stops a session, h.mu being held. Editors joining the post later get
+// a new session.
+func (h *Hub) end(s *session) {
+	if h.sessions[s.postID] == s {
+		delete(h.sessions, s.postID)
+	}
+	if !s.ended {
+		s.ended = true
+		close(s.stop)
+	}
+}
+
+// notify tells the session of a changed post to merge the change, unless
+// the session saved it itself
+func (h *Hub) notify(e services.Event) {
+	h.mu.Lock()
+	s := h.sessions[e.PostID]
+	h.mu.Unlock()
+	if s == nil || (e.Post != nil && int64(e.Post.Version) <= s.savedVersion.Load()) {
+		return
+	}
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Client is an editor of a session
+type Client struct {
+	ID      string
+	Name    string
+	session *session
+	send    chan Message
+	left    sync.Once
+}
+
+// Messages returns the messages for the editor, closed when the editor is
+// dropped from the session
+func (c *Client) Messages() <-chan Message {
+	return c.send
+}
+
+// Done is closed when the session ended, e.g. when the hub is closed
+func (c *Client) Done() <-chan struct{} {
+	return c.session.done
+}
+
+// Receive passes a message of the editor to the session
+func (c *Client) Receive(m Message) {
+	select {
+	case c.session.inbox <- envelope{client: c, msg: m}:
+	case <-c.session.done:
+	}
+}
+
+// Leave removes the editor from the session
+func (c *Client) Leave() {
+	c.left.Do(func() {
+		c.Receive(Message{Type: "leave"})
+		c.session.hub.release(c.session)
+	})
+}
+
+type envelope struct {
+	client *Client
+	msg    Message
+}
+
+// session is the editing session of a post. Its state is owned by the
+// goroutine of run, the other goroutines talk to it through channels.
+type session struct {
+	hub    *Hub
+	postID string
+	// refs counts the editors joined and not left, ended tells whether stop
+	// is closed, both guarded by hub.mu
+	refs  int
+	ended bool
+
+	inbox   chan envelope
+	changed chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+
+	clients map[*Client]*Presence
+	doc     string
+	// rev is the revision of doc, history holding the operations of the
+	// revisions from rev-len(history) to rev
+	rev     int
+	history []Operation
+	// saved is the post as last saved or loaded, whose content is the
+	// document of revision savedRev
+	saved        *models.BlogPost
+	savedRev     int
+	savedVersion atomic.Int64
+	saveTimer    *time.Timer
+}
+
+func (s *session) run() {
+	defer s.hub.running.Done()
+	defer close(s.done)
+
+	for {
+		var save <-chan time.Time
+		if s.saveTimer != nil {
+			save = s.saveTimer.C
+		}
+		select {
+		case env := <-s.inbox:
+			s.handle(env.client, env.msg)
+		case <-s.changed:
+			s.sync(nil)
+		case <-save:
+			s.saveTimer = nil
+			s.sync(s.saved)
+		case <-s.stop:
+			if s.rev != s.savedRev {
+				s.sync(s.saved)
+			}
+			for c := range s.clients {
+				close(c.send)
+			}
+			return
+		}
+	}
+}
+
+func (s *session) handle(c *Client, m Message) {
+	_, joined := s.clients[c]
+	switch {
+	case m.Type == "join":
+		s.clients[c] = &Presence{ID: c.ID, Name: c.Name}
+		s.send(c, Message{Type: MessageInit, ClientID: c.ID, Rev: s.rev, Content: &s.doc, Version: s.saved.Version, Clients: s.presence()})
+		s.broadcast(c, Message{Type: MessagePresence, Rev: s.rev, Clients: s.presence()})
+	case !joined:
+		// dropped meanwhile
+	case m.Type == "leave":
+		delete(s.clients, c)
+		close(c.send)
+		s.broadcast(nil, Message{Type: MessagePresence, Rev: s.rev, Clients: s.presence()})
+	case m.Type == MessageOp:
+		s.applyClientOp(c, m)
+	case m.Type == MessageCursor:
+		if m.Position == nil {
+			s.send(c, Message{Type: MessageError, Rev: s.rev, Message: "cursor position is required"})
+			return
+		}
+		position, ok := s.transformIndex(*m.Position, m.Rev)
+		if !ok {
+			return
+		}
+		s.clients[c].Cursor = &position
+		s.broadcast(c, Message{Type: MessagePresence, Rev: s.rev, Clients: s.presence()})
+	case m.Type == MessageSave:
+		s.sync(s.saved)
+	default:
+		s.send(c, Message{Type: MessageError, Rev: s.rev, Message: "unknown message type " + m.Type})
+	}
+}
+
+// applyClientOp transforms the operation of an editor against the ones
+// applied since its revision, and applies it
+func (s *session) applyClientOp(c *Client, m Message) {
+	op, err := s.rebase(m.Op, m.Rev)
+	if err == nil {
+		err = s.apply(op, c)
+	}
+	if err != nil {
+		// the editor can no longer sync its document
+		s.send(c, Message{Type: MessageError, Rev: s.rev, Message: err.Error()})
+		s.drop(c)
+		return
+	}
+	s.send(c, Message{Type: MessageAck, Rev: s.rev})
+}
+
+// rebase transforms an operation based on revision rev into one based on
+// the current revision
+func (s *session) rebase(op Operation, rev int) (Operation, error) {
+	first := s.rev - len(s.history)
+	if rev < first || rev > s.rev {
+		return nil, errors.New("revision is unknown, reload the document")
+	}
+	for _, applied := range s.history[rev-first:] {
+		var err error
+		if op, _, err = Transform(op, applied); err != nil {
+			return nil, err
+		}
+	}
+	return op, nil
+}
+
+// apply applies an operation based on the current revision and forwards
+// it to the editors other than author, a nil author being the server
+func (s *session) apply(op Operation, author *Client) error {
+	doc, err := op.Apply(s.doc)
+	if err != nil {
+		return err
+	}
+	s.doc = doc
+	s.rev++
+	s.history = append(s.history, op)
+	// operations older than the saved document are kept to merge the
+	// changes made outside of the session
+	if drop := min(len(s.history)-s.hub.opts.MaxHistory, s.savedRev-(s.rev-len(s.history))); drop > 0 {
+		s.history = s.history[drop:]
+	}
+	for _, p := range s.clients {
+		if p.Cursor != nil {
+			*p.Cursor = op.TransformIndex(*p.Cursor)
+		}
+	}
+
+	var clientID string
+	if author != nil {
+		clientID = author.ID
+	}
+	s.broadcast(author, Message{Type: MessageOp, ClientID: clientID, Rev: s.rev, Op: op})
+	if s.saveTimer == nil {
+		s.saveTimer = time.NewTimer(s.hub.opts.SaveDelay)
+	}
+	return nil
+}
+
+// transformIndex returns the position at the current revision of a cursor
+// at revision rev
+func (s *session) transformIndex(position, rev int) (int, bool) {
+	first := s.rev - len(s.history)
+	if rev < first || rev > s.rev || position < 0 {
+		return 0, false
+	}
+	for _, applied := range s.history[rev-first:] {
+		position = applied.TransformIndex(position)
+	}
+	return min(position, utf8.RuneCountInString(s.doc)), true
+}
+
+// sync saves the document over the latest version of the post, merging the
+// changes made to the post since the session saved it. latest is fetched
+// when nil.
+func (s *session) sync(latest *models.BlogPost) {
+	if s.saveTimer != nil {
+		s.saveTimer.Stop()
+		s.saveTimer = nil
+	}
+
+	ctx := context.Background()
+	for attempt := 0; attempt < 3; attempt++ {
+		if latest == nil {
+			var err error
+			if latest, err = s.hub.posts.GetById(ctx, s.postID); err != nil {
+				s.fail(err)
+				return
+			}
+		}
+
+		// the change of the post, based on the saved document
+		merge, err := s.rebase(Diff(s.saved.Content, latest.Content), s.savedRev)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		doc, err := merge.Apply(s.doc)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+
+		stored := latest
+		if doc != latest.Content {
+			post := *latest
+			post.Content = doc
+			stored, err = s.hub.posts.UpdateVersion(ctx, s.postID, latest.Version, &post)
+			if errors.Is(err, services.ErrVersionConflict) {
+				latest = nil
+				continue
+			}
+			if err != nil {
+				s.fail(err)
+				return
+			}
+		}
+
+		if !merge.IsNoop() {
+			s.apply(merge, nil)
+		}
+		// the service may have normalized the content
+		if stored.Content != s.doc {
+			s.apply(Diff(s.doc, stored.Content), nil)
+		}
+		s.saved, s.savedRev = stored, s.rev
+		s.savedVersion.Store(int64(stored.Version))
+		if s.saveTimer != nil {
+			s.saveTimer.Stop()
+			s.saveTimer = nil
+		}
+		s.broadcast(nil, Message{Type: MessageSaved, Rev: s.rev, Version: stored.Version})
+		return
+	}
+	s.fail(errors.New("the post keeps changing, try again"))
+}
+
+// fail reports a failed save to the editors, ending the session if the
+// post was deleted
+func (s *session) fail(err error) {
+	if errors.Is(err, services.ErrNotFound) {
+		s.broadcast(nil, Message{Type: MessageDeleted, Rev: s.rev})
+		for c := range s.clients {
+			s.drop(c)
+		}
+		s.hub.mu.Lock()
+		s.hub.end(s)
+		s.hub.mu.Unlock()
+		return
+	}
+	log.Printf("collab: failed to save post %s: %v", s.postID, err)
+	s.broadcast(nil, Message{Type: MessageError, Rev: s.rev, Message: "failed to save: " + err.Error()})
+	if s.saveTimer == nil {
+		s.saveTimer = time.NewTimer(s.hub.opts.SaveDelay)
+	}
+}
+
+func (s *session) presence() []Presence {
+	list := make([]Presence, 0, len(s.clients))
+	for _, p := range s.clients {
+		presence := *p
+		if p.Cursor != nil {
+			cursor := *p.Cursor
+			presence.Cursor = &cursor
+		}
+		list = append(list, presence)
+	}
+	slices.SortFunc(list, func(a, b Presence) int { return strings.Compare(a.ID, b.ID) })
+	return list
+}
+
+// send queues a message for an editor, dropping the editor if its queue is
+// full: a slow editor never holds back the session
+func (s *session) send(c *Client, m Message) {
+	if _, ok := s.clients[c]; !ok {
+		return
+	}
+	select {
+	case c.send <- m:
+	default:
+		s.drop(c)
+	}
+}
+
+func (s *session) broadcast(except *Client, m Message) {
+	for c := range s.clients {
+		if c != except {
+			s.send(c, m)
+		}
+	}
+}
+
+// drop removes an editor from the session, closing its messages
+func (s *session) drop(c *Client) {
+	if _, ok := s.clients[c]; !ok {
+		return
+	}
+	delete(s.clients, c)
+	close(c.send)
+	s.broadcast(nil, Message{Type: MessagePresence, Rev: s.rev, Clients: s.presence()})
+}
@@ -0,0 +1,200 @@
+package collab
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func newTestHub(t *testing.T, saveDelay time.Duration) (*Hub, *services.BlogPostService) {
+	t.Helper()
+	posts := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	posts.Create(context.Background(), &models.BlogPost{ID: "1", Title: "Test Post", Content: "hello world", Author: "Test Author"})
+	hub := NewHub(posts, Options{SaveDelay: saveDelay})
+	t.Cleanup(func() { hub.Close(context.Background()) })
+	return hub, posts
+}
+
+func join(t *testing.T, hub *Hub, name string) *Client {
+	t.Helper()
+	c, err := hub.Join(context.Background(), "1", name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if m := receive(t, c, MessageInit); m.ClientID != c.ID || m.Content == nil {
+		t.Fatalf("expected an init message, got %+v", m)
+	}
+	return c
+}
+
+// receive returns the next message of the given type, skipping the others
+func receive(t *testing.T, c *Client, messageType string) Message {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case m, ok := <-c.Messages():
+			if !ok {
+				t.Fatalf("expected a %s message, the client was dropped", messageType)
+			}
+			if m.Type == messageType {
+				return m
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for a %s message", messageType)
+		}
+	}
+}
+
+func operation(t *testing.T, data string) Operation {
+	t.Helper()
+	var op Operation
+	if err := json.Unmarshal([]byte(data), &op); err != nil {
+		t.Fatalf("invalid operation %s: %v", data, err)
+	}
+	return op
+}
+
+func TestSession_ConcurrentEdits(t *testing.T) {
+	hub, posts := newTestHub(t, time.Hour)
+	alice := join(t, hub, "Alice")
+	bob := join(t, hub, "Bob")
+	if m := receive(t, alice, MessagePresence); len(m.Clients) != 2 {
+		t.Errorf("expected Alice to see 2 editors, got %+v", m.Clients)
+	}
+
+	// both edit revision 0
+	alice.Receive(Message{Type: MessageOp, Rev: 0, Op: operation(t, `[5, " A", 6]`)})
+	bob.Receive(Message{Type: MessageOp, Rev: 0, Op: operation(t, `[5, " B", 6]`)})
+
+	// the operation received last goes first at the same position
+	if m := receive(t, alice, MessageAck); m.Rev != 1 {
+		t.Errorf("expected Alice's operation to make revision 1, got %d", m.Rev)
+	}
+	if m := receive(t, bob, MessageOp); m.ClientID != alice.ID || m.Rev != 1 {
+		t.Errorf("expected Bob to get Alice's operation, got %+v", m)
+	}
+	if m := receive(t, alice, MessageOp); m.ClientID != bob.ID || m.Rev != 2 {
+		t.Errorf("expected Alice to get Bob's operation, got %+v", m)
+	} else if data, _ := json.Marshal(m.Op); string(data) != `[5," B",8]` {
+		t.Errorf("expected Bob's operation transformed, got %s", data)
+	}
+
+	bob.Receive(Message{Type: MessageCursor, Rev: 1, Position: new(int)})
+	if m := receive(t, alice, MessagePresence); m.Clients[0].Cursor == nil && m.Clients[1].Cursor == nil {
+		t.Errorf("expected Bob's cursor, got %+v", m.Clients)
+	}
+
+	alice.Receive(Message{Type: MessageSave})
+	if m := receive(t, bob, MessageSaved); m.Version != 2 || m.Rev != 2 {
+		t.Errorf("expected revision 2 to be saved as version 2, got %+v", m)
+	}
+	stored, _ := posts.GetById(context.Background(), "1")
+	if stored.Content != "hello B A world" || stored.Version != 2 {
+		t.Errorf("expected the merged document, got %q version %d", stored.Content, stored.Version)
+	}
+}
+
+func TestSession_SavesAfterDelay(t *testing.T) {
+	hub, posts := newTestHub(t, 10*time.Millisecond)
+	alice := join(t, hub, "Alice")
+
+	alice.Receive(Message{Type: MessageOp, Rev: 0, Op: operation(t, `[11, "!"]`)})
+	receive(t, alice, MessageSaved)
+	if stored, _ := posts.GetById(context.Background(), "1"); stored.Content != "hello world!" {
+		t.Errorf("expected the document to be saved, got %q", stored.Content)
+	}
+}
+
+func TestSession_MergesExternalChanges(t *testing.T) {
+	hub, posts := newTestHub(t, time.Hour)
+	alice := join(t, hub, "Alice")
+	alice.Receive(Message{Type: MessageOp, Rev: 0, Op: operation(t, `["Oh, ", 11]`)})
+	receive(t, alice, MessageAck)
+
+	// changed through the API meanwhile
+	posts.Update(context.Background(), "1", &models.BlogPost{Title: "Renamed", Content: "hello world!", Author: "Test Author"})
+
+	m := receive(t, alice, MessageOp)
+	if data, _ := json.Marshal(m.Op); m.ClientID != "" || string(data) != `[15,"!"]` {
+		t.Errorf("expected the change as an operation of the server, got %+v %s", m, data)
+	}
+	receive(t, alice, MessageSaved)
+	stored, _ := posts.GetById(context.Background(), "1")
+	if stored.Content != "Oh, hello world!" || stored.Title != "Renamed" || stored.Version != 3 {
+		t.Errorf("expected both changes to be kept, got %q %q version %d", stored.Title, stored.Content, stored.Version)
+	}
+}
+
+func TestSession_PostDeleted(t *testing.T) {
+	hub, posts := newTestHub(t, time.Hour)
+	alice := join(t, hub, "Alice")
+
+	posts.Delete(context.Background(), "1")
+	receive(t, alice, MessageDeleted)
+	if _, ok := <-alice.Messages(); ok {
+		t.Error("expected Alice to be dropped")
+	}
+
+	if _, err := hub.Join(context.Background(), "1", "Bob"); err != services.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSession_DropsUnsyncedEditors(t *testing.T) {
+	hub, _ := newTestHub(t, time.Hour)
+	alice := join(t, hub, "Alice")
+
+	alice.Receive(Message{Type: MessageOp, Rev: 5, Op: operation(t, `[11, "!"]`)})
+	if m := receive(t, alice, MessageError); m.Message != "revision is unknown, reload the document" {
+		t.Errorf("unexpected error %q", m.Message)
+	}
+	if _, ok := <-alice.Messages(); ok {
+		t.Error("expected Alice to be dropped")
+	}
+
+	bob := join(t, hub, "Bob")
+	bob.Receive(Message{Type: MessageOp, Rev: 0, Op: operation(t, `[3, "!"]`)})
+	receive(t, bob, MessageError)
+}
+
+func TestSession_RejectsOverflowingOperations(t *testing.T) {
+	hub, posts := newTestHub(t, time.Hour)
+	alice := join(t, hub, "Alice")
+
+	// the lengths wrap around to the 11 characters of the document
+	op := Operation{{Retain: math.MaxInt}, {Insert: "x"}, {Retain: math.MaxInt}, {Insert: "x"}, {Retain: 13}}
+	alice.Receive(Message{Type: MessageOp, Rev: 0, Op: op})
+	receive(t, alice, MessageError)
+
+	bob := join(t, hub, "Bob")
+	bob.Receive(Message{Type: MessageOp, Rev: 0, Op: operation(t, `[11, "!"]`)})
+	receive(t, bob, MessageAck)
+	bob.Receive(Message{Type: MessageSave})
+	receive(t, bob, MessageSaved)
+	if stored, _ := posts.GetById(context.Background(), "1"); stored.Content != "hello world!" {
+		t.Errorf("expected the session to keep working, got %q", stored.Content)
+	}
+}
+
+func TestHub_CloseSavesDocuments(t *testing.T) {
+	hub, posts := newTestHub(t, time.Hour)
+	alice := join(t, hub, "Alice")
+	alice.Receive(Message{Type: MessageOp, Rev: 0, Op: operation(t, `[11, "!"]`)})
+	receive(t, alice, MessageAck)
+
+	if err := hub.Close(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	<-alice.Done()
+	if stored, _ := posts.GetById(context.Background(), "1"); stored.Content != "hello world!" {
+		t.Errorf("expected the document to be saved, got %q", stored.Content)
+	}
+	if _, err := hub.Join(context.Background(), "1", "Bob"); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
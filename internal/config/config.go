@@ -0,0 +1,336 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the runtime settings of the API process
+type Config struct {
+	Server ServerConfig
+	Site   SiteConfig
+	Feed   FeedConfig
+	// Webhook holds the settings of webhook deliveries
+	Webhook WebhookConfig
+	Outbox  OutboxConfig
+	Stream  StreamConfig
+	Collab  CollabConfig
+	GRPC    GRPCConfig
+	GraphQL GraphQLConfig
+	OpenAPI OpenAPIConfig
+	// Attachments holds the settings of the files uploaded to posts
+	Attachments AttachmentConfig
+	Images      ImageConfig
+	// Auth holds the settings of the user accounts
+	Auth AuthConfig
+	Mail MailConfig
+}
+
+// ServerConfig holds the HTTP server settings
+type ServerConfig struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownDelay is how long the server keeps accepting requests while
+	// reporting not-ready, so that load balancers can stop routing to it
+	ShutdownDelay time.Duration
+	// DrainTimeout bounds how long in-flight requests are given to finish
+	// once a shutdown signal has been received
+	DrainTimeout time.Duration
+	// HookTimeout bounds how long the shutdown hooks are given to run
+	HookTimeout time.Duration
+}
+
+// SiteConfig describes the public blog, used in feeds and links
+type SiteConfig struct {
+	// URL is the public base URL without a trailing slash
+	URL         string
+	Title       string
+	Description string
+	// ThemeDir holds theme files overriding the built-in theme of the
+	// HTML pages
+	ThemeDir string
+	// PostsPerPage is the number of posts of the index and tag pages
+	PostsPerPage int
+}
+
+// FeedConfig holds the syndication feed settings
+type FeedConfig struct {
+	// Items is the number of most recent posts in a feed
+	Items int
+	// FullContent puts the whole rendered post in feeds instead of an excerpt
+	FullContent bool
+	// ExcerptLength is the maximum length in characters of an excerpt
+	ExcerptLength int
+}
+
+// WebhookConfig holds the webhook delivery settings
+type WebhookConfig struct {
+	// Workers is the number of deliveries sent concurrently
+	Workers int
+	// Timeout bounds every delivery attempt
+	Timeout time.Duration
+	// MaxAttempts is the number of failed attempts after which a delivery
+	// is dead
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled on every retry
+	// up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// AllowPrivateNetworks lets deliveries reach loopback, link-local and
+	// private addresses
+	AllowPrivateNetworks bool
+}
+
+// OutboxConfig holds the settings of the relay publishing the events of
+// the outbox
+type OutboxConfig struct {
+	// File, when set, is an NDJSON file every event is appended to
+	File string
+	// PollInterval is how often the outbox is read besides the wake-ups
+	// following every change
+	PollInterval time.Duration
+	// BatchSize is the number of events read from the outbox at once
+	BatchSize int
+}
+
+// StreamConfig holds the settings of the server-sent event stream of post
+// changes
+type StreamConfig struct {
+	// LogSize is the number of recent events kept to resume streams
+	LogSize int
+	// Heartbeat is how often idle streams get a comment, keeping proxies
+	// from closing them
+	Heartbeat time.Duration
+	// WriteTimeout bounds every write to a stream, closing the streams of
+	// clients not reading them
+	WriteTimeout time.Duration
+}
+
+// CollabConfig holds the settings of collaborative editing
+type CollabConfig struct {
+	// SaveDelay is the time between the first unsaved change of a document
+	// and its save
+	SaveDelay time.Duration
+	// Origins are the host patterns of the other origins allowed to open an
+	// editing session, e.g. "admin.example.com" or "*.example.com"
+	Origins []string
+}
+
+// GRPCConfig holds the settings of the gRPC server, which watch streams
+// resume from the last StreamConfig.LogSize events
+type GRPCConfig struct {
+	// Addr is the address of the gRPC server, a port of its own
+	Addr string
+}
+
+// GraphQLConfig holds the limits of GraphQL queries
+type GraphQLConfig struct {
+	// MaxDepth is the maximum nesting of the fields of a query
+	MaxDepth int
+	// MaxComplexity is the maximum number of fields a query may resolve,
+	// counting the fields of lists once per item
+	MaxComplexity int
+}
+
+// OpenAPIConfig selects what is validated against the OpenAPI description
+// of the REST API
+type OpenAPIConfig struct {
+	// ValidateRequests rejects the requests not matching the description
+	ValidateRequests bool
+	// ValidateResponses logs the responses not matching the description
+	ValidateResponses bool
+}
+
+// AttachmentConfig holds the settings of post attachments
+type AttachmentConfig struct {
+	// Dir is the directory the files are stored in
+	Dir string
+	// MaxBytes is the maximum size of a file
+	MaxBytes int64
+	// Types are the accepted content types, e.g. image/png, the service
+	// defaults when empty
+	Types []string
+}
+
+// ImageConfig holds the settings of the processing of image attachments
+// into resized variants
+type ImageConfig struct {
+	// Workers is the number of images processed concurrently
+	Workers int
+	// MaxPixels bounds the size of the images processed
+	MaxPixels int
+}
+
+// AuthConfig holds the settings of user accounts, sessions and access
+// tokens
+type AuthConfig struct {
+	// JWTSecret signs the access tokens; a random one is generated when
+	// empty, so that the tokens are only valid until restart
+	JWTSecret string
+	// AccessTokenTTL is the lifetime of access tokens
+	AccessTokenTTL time.Duration
+	// SessionTTL is the lifetime of the sessions of the HTML pages
+	SessionTTL time.Duration
+	// VerificationTTL and ResetTTL are the lifetimes of the links sent by
+	// email to verify an address and to reset a password
+	VerificationTTL time.Duration
+	ResetTTL        time.Duration
+	// PasswordHash is the algorithm of new password hashes, argon2id or
+	// bcrypt
+	PasswordHash string
+}
+
+// MailConfig holds the settings of the emails sent to users
+type MailConfig struct {
+	// From is the sender of the emails
+	From string
+	// Dir, when set, is a directory every email is written to as a file,
+	// instead of the log
+	Dir string
+}
+
+// Load builds the config from environment variables, falling back to
+// defaults suitable for local development
+func Load() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:              getString("SERVER_ADDR", ":8080"),
+			ReadTimeout:       getDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+			ReadHeaderTimeout: getDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+			WriteTimeout:      getDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+			IdleTimeout:       getDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			ShutdownDelay:     getDuration("SERVER_SHUTDOWN_DELAY", 0),
+			DrainTimeout:      getDuration("SERVER_DRAIN_TIMEOUT", 20*time.Second),
+			HookTimeout:       getDuration("SERVER_HOOK_TIMEOUT", 10*time.Second),
+		},
+		Site: SiteConfig{
+			URL:          strings.TrimRight(getString("SITE_URL", "http://localhost:8080"), "/"),
+			Title:        getString("SITE_TITLE", "Blog Posts"),
+			Description:  getString("SITE_DESCRIPTION", "Latest blog posts"),
+			ThemeDir:     getString("SITE_THEME_DIR", ""),
+			PostsPerPage: getInt("SITE_POSTS_PER_PAGE", 10),
+		},
+		Feed: FeedConfig{
+			Items:         getInt("FEED_ITEMS", 20),
+			FullContent:   getBool("FEED_FULL_CONTENT", true),
+			ExcerptLength: getInt("FEED_EXCERPT_LENGTH", 280),
+		},
+		Webhook: WebhookConfig{
+			Workers:              getInt("WEBHOOK_WORKERS", 4),
+			Timeout:              getDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:          getInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			Backoff:              getDuration("WEBHOOK_BACKOFF", 10*time.Second),
+			MaxBackoff:           getDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+			AllowPrivateNetworks: getBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
+		Outbox: OutboxConfig{
+			File:         getString("OUTBOX_FILE", ""),
+			PollInterval: getDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:    getInt("OUTBOX_BATCH_SIZE", 100),
+		},
+		Stream: StreamConfig{
+			LogSize:      getInt("STREAM_LOG_SIZE", 1000),
+			Heartbeat:    getDuration("STREAM_HEARTBEAT", 15*time.Second),
+			WriteTimeout: getDuration("STREAM_WRITE_TIMEOUT", 10*time.Second),
+		},
+		Collab: CollabConfig{
+			SaveDelay: getDuration("COLLAB_SAVE_DELAY", 2*time.Second),
+			Origins:   getList("COLLAB_ORIGINS"),
+		},
+		GRPC: GRPCConfig{
+			Addr: getString("GRPC_ADDR", ":9090"),
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      getInt("GRAPHQL_MAX_DEPTH", 10),
+			MaxComplexity: getInt("GRAPHQL_MAX_COMPLEXITY", 2000),
+		},
+		OpenAPI: OpenAPIConfig{
+			ValidateRequests:  getBool("OPENAPI_VALIDATE_REQUESTS", false),
+			ValidateResponses: getBool("OPENAPI_VALIDATE_RESPONSES", false),
+		},
+		Attachments: AttachmentConfig{
+			Dir:      getString("ATTACHMENT_DIR", "data/attachments"),
+			MaxBytes: int64(getInt("ATTACHMENT_MAX_BYTES", 10<<20)),
+			Types:    getList("ATTACHMENT_TYPES"),
+		},
+		Images: ImageConfig{
+			Workers:   getInt("IMAGE_WORKERS", 2),
+			MaxPixels: getInt("IMAGE_MAX_PIXELS", 25_000_000),
+		},
+		Auth: AuthConfig{
+			JWTSecret:       getString("AUTH_JWT_SECRET", ""),
+			AccessTokenTTL:  getDuration("AUTH_ACCESS_TOKEN_TTL", time.Hour),
+			SessionTTL:      getDuration("AUTH_SESSION_TTL", 14*24*time.Hour),
+			VerificationTTL: getDuration("AUTH_VERIFICATION_TTL", 48*time.Hour),
+			ResetTTL:        getDuration("AUTH_RESET_TTL", time.Hour),
+			PasswordHash:    getString("AUTH_PASSWORD_HASH", "argon2id"),
+		},
+		Mail: MailConfig{
+			From: getString("MAIL_FROM", "Blog Posts <no-reply@localhost>"),
+			Dir:  getString("MAIL_DIR", ""),
+		},
+	}
+}
+
+func getString(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+// getList returns the comma-separated values of a variable
+func getList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("invalid duration %q for %s, using default %s", v, key, fallback)
+		return fallback
+	}
+	return d
+}
+
+func getInt(key string, fallback int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("invalid integer %q for %s, using default %d", v, key, fallback)
+		return fallback
+	}
+	return n
+}
+
+func getBool(key string, fallback bool) bool {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("invalid boolean %q for %s, using default %t", v, key, fallback)
+		return fallback
+	}
+	return b
+}
@@ -0,0 +1,94 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type atomDoc struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Gen      string      `xml:"generator"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Author     atomPerson     `xml:"author"`
+	Link       atomLink       `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+}
+
+// Atom serializes the feed as Atom 1.0 (RFC 4287)
+func (f *Feed) Atom() ([]byte, error) {
+	doc := atomDoc{
+		ID:       f.FeedURL,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  atomTime(f.Updated),
+		Links: []atomLink{
+			{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.SiteURL, Rel: "alternate", Type: "text/html"},
+		},
+		Gen: "blog-posts-api",
+	}
+
+	for _, it := range f.Items {
+		entry := atomEntry{
+			ID:        entryID(it.ID),
+			Title:     it.Title,
+			Updated:   atomTime(it.Updated),
+			Published: atomTime(it.Published),
+			Author:    atomPerson{Name: it.Author},
+			Link:      atomLink{Href: it.URL, Rel: "alternate", Type: "text/html"},
+		}
+		for _, tag := range it.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		if it.ContentHTML != "" {
+			entry.Content = &atomText{Type: "html", Value: it.ContentHTML}
+		} else {
+			entry.Summary = &atomText{Type: "text", Value: it.Summary}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshalXML(doc)
+}
+
+// atomTime formats an RFC 3339 date; updated is mandatory, so an unknown
+// time is written as the Unix epoch
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(0, 0)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
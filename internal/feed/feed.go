@@ -0,0 +1,47 @@
+// Package feed serializes a list of posts as RSS 2.0, Atom 1.0 and JSON
+// Feed 1.1 documents.
+package feed
+
+import (
+	"time"
+)
+
+// Feed is the format-independent description of a syndication feed
+type Feed struct {
+	Title       string
+	Description string
+	// SiteURL is the home page of the blog
+	SiteURL string
+	// FeedURL is the URL the feed is served at
+	FeedURL string
+	// Updated is the last time any item of the feed changed
+	Updated time.Time
+	Items   []Item
+}
+
+// Item is a single post of a feed
+type Item struct {
+	ID        string
+	Title     string
+	URL       string
+	Author    string
+	Tags      []string
+	Published time.Time
+	Updated   time.Time
+	// ContentHTML is the full rendered post, set when feeds carry full content
+	ContentHTML string
+	// Summary is a plain text excerpt, set when feeds carry excerpts
+	Summary string
+}
+
+// Content types of the serialized feeds
+const (
+	ContentTypeRSS  = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom = "application/atom+xml; charset=utf-8"
+	ContentTypeJSON = "application/feed+json; charset=utf-8"
+)
+
+// entryID returns a stable, globally unique id for an item
+func entryID(id string) string {
+	return "urn:uuid:" + id
+}
@@ -0,0 +1,322 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// The tests below validate the feeds against the schemas of the
+// specifications in testdata: the RELAX NG schema of RFC 4287 for Atom, one
+// of RSS 2.0 with xmllint, and a JSON Schema of JSON Feed 1.1. The rules a
+// schema can't express, such as the author of an entry, are checked by hand.
+
+// validateXML validates a feed against a RELAX NG schema of testdata with
+// xmllint, in a subtest skipped when it isn't installed
+func validateXML(t *testing.T, schema string, feed []byte) {
+	t.Helper()
+	t.Run(schema, func(t *testing.T) {
+		xmllint, err := exec.LookPath("xmllint")
+		if err != nil {
+			t.Skip("xmllint is not installed, the feed can't be validated")
+		}
+		cmd := exec.Command(xmllint, "--noout", "--relaxng", "testdata/"+schema, "-")
+		cmd.Stdin = bytes.NewReader(feed)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("feed doesn't match %s: %v\n%s\n%s", schema, err, out, feed)
+		}
+	})
+}
+
+// validateJSONFeed validates a feed against the JSON Feed schema of testdata
+func validateJSONFeed(t *testing.T, feed []byte) {
+	t.Helper()
+	c := jsonschema.NewCompiler()
+	c.AssertFormat()
+	schema, err := c.Compile("testdata/jsonfeed.schema.json")
+	if err != nil {
+		t.Fatalf("failed to compile the JSON Feed schema: %v", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(feed))
+	if err != nil {
+		t.Fatalf("feed is not valid JSON: %v", err)
+	}
+	if err := schema.Validate(doc); err != nil {
+		t.Errorf("feed doesn't match the JSON Feed schema: %v\n%s", err, feed)
+	}
+}
+
+func testFeed() *Feed {
+	published := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	return &Feed{
+		Title:       "Blog & <Posts>",
+		Description: "Latest blog posts",
+		SiteURL:     "https://blog.example.com/",
+		FeedURL:     "https://blog.example.com/feed.rss",
+		Updated:     published.Add(time.Hour),
+		Items: []Item{
+			{
+				ID:          "550e8400-e29b-41d4-a716-446655440000",
+				Title:       "Getting Started with Go",
+				URL:         "https://blog.example.com/posts/550e8400-e29b-41d4-a716-446655440000",
+				Author:      "John Doe",
+				Tags:        []string{"go", "tutorial"},
+				Published:   published,
+				Updated:     published.Add(time.Hour),
+				ContentHTML: `<p>Go &amp; <em>you</em></p>`,
+			},
+			{
+				ID:        "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+				Title:     "Excerpt only",
+				URL:       "https://blog.example.com/posts/6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+				Author:    "Jane Smith",
+				Published: published,
+				Updated:   published,
+				Summary:   "A short excerpt on <img src=x onerror=alert(1)> & co…",
+			},
+		},
+	}
+}
+
+func requireAbsoluteURL(t *testing.T, what, raw string) {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() {
+		t.Errorf("%s must be an absolute URL, got %q", what, raw)
+	}
+}
+
+func TestFeed_RSS(t *testing.T) {
+	out, err := testFeed().RSS()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	validateXML(t, "rss.rng", out)
+
+	var doc struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+			// both the RSS link and atom:link are named link
+			Links []struct {
+				XMLName xml.Name
+				Href    string `xml:"href,attr"`
+				Rel     string `xml:"rel,attr"`
+				Value   string `xml:",chardata"`
+			} `xml:"link"`
+			Description string `xml:"description"`
+			Items       []struct {
+				Title       string   `xml:"title"`
+				Link        string   `xml:"link"`
+				Description string   `xml:"description"`
+				GUID        string   `xml:"guid"`
+				PubDate     string   `xml:"pubDate"`
+				Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Categories  []string `xml:"category"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("feed is not well-formed XML: %v\n%s", err, out)
+	}
+
+	if doc.Version != "2.0" {
+		t.Errorf("expected version 2.0, got %q", doc.Version)
+	}
+	// title, link and description are required channel elements
+	if doc.Channel.Title != "Blog & <Posts>" || doc.Channel.Description == "" {
+		t.Errorf("missing required channel elements: %+v", doc.Channel)
+	}
+	var link, self string
+	for _, l := range doc.Channel.Links {
+		switch l.XMLName.Space {
+		case "":
+			link = l.Value
+		case "http://www.w3.org/2005/Atom":
+			if l.Rel == "self" {
+				self = l.Href
+			}
+		}
+	}
+	requireAbsoluteURL(t, "channel link", link)
+	if self != "https://blog.example.com/feed.rss" {
+		t.Errorf("expected atom:link rel=self to the feed URL, got %q", self)
+	}
+
+	if len(doc.Channel.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(doc.Channel.Items))
+	}
+	for _, it := range doc.Channel.Items {
+		// an item must have a title or a description
+		if it.Title == "" && it.Description == "" {
+			t.Error("item has neither title nor description")
+		}
+		requireAbsoluteURL(t, "item link", it.Link)
+		if _, err := time.Parse(time.RFC1123Z, it.PubDate); err != nil {
+			t.Errorf("pubDate must be an RFC 822 date, got %q", it.PubDate)
+		}
+		if it.GUID == "" {
+			t.Error("item has no guid")
+		}
+	}
+	first := doc.Channel.Items[0]
+	if first.Description != `<p>Go &amp; <em>you</em></p>` {
+		t.Errorf("expected escaped HTML description, got %q", first.Description)
+	}
+	if first.Creator != "John Doe" {
+		t.Errorf("expected dc:creator 'John Doe', got %q", first.Creator)
+	}
+	if strings.Join(first.Categories, ",") != "go,tutorial" {
+		t.Errorf("expected categories go,tutorial, got %v", first.Categories)
+	}
+	// the excerpt is text, escaped once more to be HTML
+	if second := doc.Channel.Items[1]; second.Description != "A short excerpt on &lt;img src=x onerror=alert(1)&gt; &amp; co…" {
+		t.Errorf("expected the excerpt as escaped HTML, got %q", second.Description)
+	}
+}
+
+func TestFeed_Atom(t *testing.T) {
+	out, err := testFeed().Atom()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	validateXML(t, "atom.rng", out)
+
+	type link struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Title   string   `xml:"title"`
+		Updated string   `xml:"updated"`
+		Links   []link   `xml:"link"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Title   string `xml:"title"`
+			Updated string `xml:"updated"`
+			Author  struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+			Link    link `xml:"link"`
+			Content *struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+			Summary *struct {
+				Type string `xml:"type,attr"`
+			} `xml:"summary"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("feed is not well-formed Atom: %v\n%s", err, out)
+	}
+
+	// atom:feed requires id, title and updated (RFC 4287 4.1.1)
+	if doc.ID == "" || doc.Title == "" {
+		t.Errorf("missing required feed elements: id=%q title=%q", doc.ID, doc.Title)
+	}
+	if _, err := time.Parse(time.RFC3339, doc.Updated); err != nil {
+		t.Errorf("updated must be an RFC 3339 date, got %q", doc.Updated)
+	}
+	hasSelf := false
+	for _, l := range doc.Links {
+		hasSelf = hasSelf || l.Rel == "self"
+	}
+	if !hasSelf {
+		t.Error("feed should have a self link")
+	}
+
+	for _, e := range doc.Entries {
+		// atom:entry requires id, title, updated and an author when the feed has none (4.1.2)
+		requireAbsoluteURL(t, "entry id", e.ID)
+		if e.Title == "" || e.Author.Name == "" {
+			t.Errorf("entry %s misses title or author", e.ID)
+		}
+		if _, err := time.Parse(time.RFC3339, e.Updated); err != nil {
+			t.Errorf("entry updated must be an RFC 3339 date, got %q", e.Updated)
+		}
+		// an entry without content must have an alternate link and a summary
+		if e.Content == nil && (e.Summary == nil || e.Link.Rel != "alternate") {
+			t.Errorf("entry %s has neither content nor summary with an alternate link", e.ID)
+		}
+	}
+	if doc.Entries[0].Content == nil || doc.Entries[0].Content.Type != "html" {
+		t.Error("expected html content on the first entry")
+	}
+}
+
+func TestFeed_JSON(t *testing.T) {
+	out, err := testFeed().JSON()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	validateJSONFeed(t, out)
+
+	var doc map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("feed is not valid JSON: %v", err)
+	}
+
+	// version, title and items are required top-level fields
+	if doc["version"] != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("unexpected version %v", doc["version"])
+	}
+	if doc["title"] != "Blog & <Posts>" {
+		t.Errorf("unexpected title %v", doc["title"])
+	}
+	items, ok := doc["items"].([]any)
+	if !ok || len(items) != 2 {
+		t.Fatalf("expected 2 items, got %v", doc["items"])
+	}
+	for _, raw := range items {
+		item := raw.(map[string]any)
+		// id is required, and content_html or content_text must be present
+		if id, _ := item["id"].(string); id == "" {
+			t.Error("item has no id")
+		}
+		if item["content_html"] == nil && item["content_text"] == nil {
+			t.Errorf("item %v has neither content_html nor content_text", item["id"])
+		}
+		if _, err := time.Parse(time.RFC3339, item["date_published"].(string)); err != nil {
+			t.Errorf("date_published must be an RFC 3339 date, got %v", item["date_published"])
+		}
+	}
+}
+
+func TestFeed_Empty(t *testing.T) {
+	f := &Feed{Title: "Empty", SiteURL: "https://blog.example.com/", FeedURL: "https://blog.example.com/feed.atom"}
+
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(string(atom), "<updated>1970-01-01T00:00:00Z</updated>") {
+		t.Errorf("expected epoch updated date for an empty feed, got %s", atom)
+	}
+
+	js, err := f.JSON()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(string(js), `"items": []`) {
+		t.Errorf("expected an empty items array, got %s", js)
+	}
+	validateJSONFeed(t, js)
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	validateXML(t, "rss.rng", rss)
+	validateXML(t, "atom.rng", atom)
+}
@@ -0,0 +1,50 @@
+package gql
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"errors"
+	"log"
+	"strings"
+)
+
+// resolveError exposes a domain error to clients along with what REST
+// would report, e.g. "extensions": {"code": "NOT_FOUND", "status": 404}.
+// Its cause is never exposed.
+type resolveError struct {
+	err *apperrors.Error
+}
+
+func (e resolveError) Error() string {
+	return e.err.Message
+}
+
+func (e resolveError) Extensions() map[string]any {
+	ext := map[string]any{
+		"code":   strings.ToUpper(strings.ReplaceAll(e.err.Kind.String(), " ", "_")),
+		"status": e.err.Kind.HTTPStatus(),
+	}
+	if len(e.err.Fields) > 0 {
+		params := make([]map[string]string, len(e.err.Fields))
+		for i, f := range e.err.Fields {
+			params[i] = map[string]string{"name": f.Field, "reason": f.Reason}
+		}
+		ext["invalidParams"] = params
+	}
+	return ext
+}
+
+// toResolveError wraps the error of a resolver, hiding the ones which are
+// not domain errors behind an internal error with the given message
+func toResolveError(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	var appErr *apperrors.Error
+	if !errors.As(apperrors.Wrap(err, message), &appErr) {
+		return err
+	}
+	if appErr.Kind == apperrors.KindInternal {
+		log.Printf("graphql: %v", err)
+	}
+	return resolveError{err: appErr}
+}
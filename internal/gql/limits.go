@@ -0,0 +1,134 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound the cost of the queries, rejected before running
+type Limits struct {
+	// MaxDepth is the maximum nesting of fields
+	MaxDepth int
+	// MaxComplexity is the maximum number of fields a query may resolve:
+	// every field counts for one, and the fields selected under a field
+	// with a first argument count once per item it may return
+	MaxComplexity int
+}
+
+// check returns an error if the operation exceeds the limits. Introspection
+// fields are left out so that tools keep working.
+func (l Limits) check(schema *graphql.Schema, doc *ast.Document, op *ast.OperationDefinition, variables map[string]any) error {
+	m := measurer{schema: schema, fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+	for _, def := range doc.Definitions {
+		if f, ok := def.(*ast.FragmentDefinition); ok {
+			m.fragments[f.Name.Value] = f
+		}
+	}
+	root := schema.QueryType()
+	if op.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	depth, complexity := m.selectionSet(op.SelectionSet, root)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("query has a depth of %d, at most %d is allowed", depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return fmt.Errorf("query has a complexity of %d, at most %d is allowed", complexity, l.MaxComplexity)
+	}
+	return nil
+}
+
+// measurer computes the depth and complexity of validated documents, which
+// only select existing fields and have no fragment cycles
+type measurer struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+func (m measurer) selectionSet(set *ast.SelectionSet, parent *graphql.Object) (depth, complexity int) {
+	if set == nil || parent == nil {
+		return 0, 0
+	}
+	for _, selection := range set.Selections {
+		var d, c int
+		switch s := selection.(type) {
+		case *ast.Field:
+			def := parent.Fields()[s.Name.Value]
+			if def == nil || strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			d, c = m.selectionSet(s.SelectionSet, objectOf(def.Type))
+			d, c = d+1, 1+m.items(s, def)*c
+		case *ast.InlineFragment:
+			d, c = m.selectionSet(s.SelectionSet, m.condition(s.TypeCondition, parent))
+		case *ast.FragmentSpread:
+			if f := m.fragments[s.Name.Value]; f != nil {
+				d, c = m.selectionSet(f.SelectionSet, m.condition(f.TypeCondition, parent))
+			}
+		}
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity
+}
+
+// items returns the number of items of a field with a first argument, or 1
+func (m measurer) items(field *ast.Field, def *graphql.FieldDefinition) int {
+	for _, arg := range def.Args {
+		if arg.Name() == "first" {
+			return pageSize(m.first(field))
+		}
+	}
+	return 1
+}
+
+// first returns the value of the first argument of a field, 0 for the
+// default
+func (m measurer) first(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			n, _ := strconv.Atoi(v.Value)
+			return n
+		case *ast.Variable:
+			switch value := m.variables[v.Name.Value].(type) {
+			case float64:
+				return int(value)
+			case int:
+				return value
+			}
+		}
+	}
+	return 0
+}
+
+func (m measurer) condition(name *ast.Named, parent *graphql.Object) *graphql.Object {
+	if name == nil {
+		return parent
+	}
+	return objectOf(m.schema.Type(name.Name.Value))
+}
+
+// objectOf returns the object type of the values of a type, nil for scalars
+func objectOf(t graphql.Type) *graphql.Object {
+	for {
+		switch typ := t.(type) {
+		case *graphql.NonNull:
+			t = typ.OfType
+		case *graphql.List:
+			t = typ.OfType
+		case *graphql.Object:
+			return typ
+		default:
+			return nil
+		}
+	}
+}
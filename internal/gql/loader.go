@@ -0,0 +1,73 @@
+package gql
+
+import (
+	"context"
+	"sync"
+)
+
+// Loader batches the loads of a request, DataLoader style: resolvers call
+// Load and return the thunk it returns, and the executor resolves the
+// thunks of a level of the query once every resolver of the level ran, so
+// that the first thunk run loads every key of the level in one call of the
+// batch function. Values are cached for the rest of the request.
+type Loader[K comparable, V any] struct {
+	batch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	results map[K]*loaded[V]
+}
+
+type loaded[V any] struct {
+	value V
+	err   error
+	done  bool
+}
+
+func NewLoader[K comparable, V any](batch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{batch: batch, results: map[K]*loaded[V]{}}
+}
+
+// Load queues a key and returns a thunk returning its value, the zero
+// value for keys the batch function left out
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok {
+		l.results[key] = &loaded[V]{}
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		r := l.results[key]
+		if !r.done {
+			l.dispatch(ctx)
+		}
+		return r.value, r.err
+	}
+}
+
+// Prime caches the value of a key, e.g. one loaded along with a list
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r, ok := l.results[key]; !ok || !r.done {
+		l.results[key] = &loaded[V]{value: value, done: true}
+	}
+}
+
+// dispatch loads the pending keys, l.mu being held
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	values, err := l.batch(ctx, keys)
+	for _, key := range keys {
+		r := l.results[key]
+		if r.done {
+			continue
+		}
+		r.value, r.err, r.done = values[key], err, true
+	}
+}
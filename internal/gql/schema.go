@@ -0,0 +1,495 @@
+// Package gql serves a GraphQL schema over the posts of the service, for
+// clients fetching posts along with their authors and tags in one round
+// trip. Authors and tags are derived from the posts.
+//
+// Resolvers of a level of the query batch their repository reads through
+// per-request loaders, and queries exceeding the depth and complexity
+// limits are rejected before running.
+package gql
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"context"
+	"errors"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request is a GraphQL request, as sent over HTTP
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Schema executes GraphQL requests
+type Schema struct {
+	schema graphql.Schema
+	posts  *services.BlogPostService
+	limits Limits
+}
+
+// connection is a page of posts
+type connection struct {
+	nodes []*models.BlogPost
+	// next is the token of the next page, empty on the last one
+	next string
+}
+
+// loaders batch the reads of a request
+type loaders struct {
+	// postsByAuthor and postsByTag return the posts of an author or with a
+	// tag, newest first
+	postsByAuthor *Loader[string, []*models.BlogPost]
+	postsByTag    *Loader[string, []*models.BlogPost]
+}
+
+type loadersKey struct{}
+
+func NewSchema(posts *services.BlogPostService, limits Limits) (*Schema, error) {
+	s := &Schema{posts: posts, limits: limits}
+	query, postType := s.queryType()
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: s.mutationType(postType)})
+	if err != nil {
+		return nil, err
+	}
+	s.schema = schema
+	return s, nil
+}
+
+// Execute runs a request. Mutations are rejected unless allowed, e.g. for
+// GET requests which must not change anything.
+func (s *Schema) Execute(ctx context.Context, req Request, allowMutations bool) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if result := graphql.ValidateDocument(&s.schema, doc, nil); !result.IsValid {
+		return &graphql.Result{Errors: result.Errors}
+	}
+
+	// an unknown operation is reported by graphql.Execute
+	if op := operation(doc, req.OperationName); op != nil {
+		if op.Operation == ast.OperationTypeMutation && !allowMutations {
+			return &graphql.Result{Errors: gqlerrors.FormatErrors(errors.New("mutations must be sent with POST"))}
+		}
+		if err := s.limits.check(&s.schema, doc, op, req.Variables); err != nil {
+			return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+		}
+	}
+
+	ctx = context.WithValue(ctx, loadersKey{}, s.newLoaders())
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+}
+
+// operation returns the operation of the document to run, nil if there is
+// no such operation
+func operation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" && found != nil {
+			// ambiguous without a name
+			return nil
+		}
+		if name == "" || (op.Name != nil && op.Name.Value == name) {
+			found = op
+		}
+	}
+	return found
+}
+
+func (s *Schema) newLoaders() *loaders {
+	return &loaders{
+		postsByAuthor: NewLoader(func(ctx context.Context, names []string) (map[string][]*models.BlogPost, error) {
+			return s.groupPosts(ctx, models.PostFilter{Authors: names}, names, func(p *models.BlogPost) []string { return []string{p.Author} })
+		}),
+		postsByTag: NewLoader(func(ctx context.Context, names []string) (map[string][]*models.BlogPost, error) {
+			return s.groupPosts(ctx, models.PostFilter{Tags: names}, names, func(p *models.BlogPost) []string { return p.Tags })
+		}),
+	}
+}
+
+// groupPosts returns the posts matching the filter, newest first, grouped
+// by the given keys. Only the groups named in wanted are returned, or every
+// group when wanted is nil.
+func (s *Schema) groupPosts(ctx context.Context, filter models.PostFilter, wanted []string, keys func(p *models.BlogPost) []string) (map[string][]*models.BlogPost, error) {
+	groups := map[string][]*models.BlogPost{}
+	if wanted != nil && len(wanted) == 0 {
+		return groups, nil
+	}
+	posts, err := s.posts.GetLatest(ctx, filter, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		for _, key := range keys(p) {
+			if wanted == nil || slices.Contains(wanted, key) {
+				groups[key] = append(groups[key], p)
+			}
+		}
+	}
+	return groups, nil
+}
+
+func loadersOf(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// pageSize returns the size of a page asked for with a first argument
+func pageSize(first int) int {
+	if first <= 0 {
+		return services.DefaultPageSize
+	}
+	return min(first, services.MaxPageSize)
+}
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"endCursor": &graphql.Field{
+			Type:        graphql.String,
+			Description: "Cursor to pass as after to get the next page, null on the last page",
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				if c := p.Source.(connection); c.next != "" {
+					return c.next, nil
+				}
+				return nil, nil
+			},
+		},
+		"hasNextPage": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(connection).next != "", nil
+			},
+		},
+	},
+})
+
+// pageArgs are the arguments of the fields returning a connection
+var pageArgs = graphql.FieldConfigArgument{
+	"first": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Number of posts, 50 by default and at most 100"},
+	"after": &graphql.ArgumentConfig{Type: graphql.String, Description: "endCursor of the previous page"},
+}
+
+// queryType returns the query type along with the post type, the types of
+// the query referencing each other
+func (s *Schema) queryType() (*graphql.Object, *graphql.Object) {
+	var postType, authorType, tagType, connectionType *graphql.Object
+
+	postField := func(typ graphql.Output, value func(p *models.BlogPost) any) *graphql.Field {
+		return &graphql.Field{Type: typ, Resolve: func(p graphql.ResolveParams) (any, error) {
+			return value(p.Source.(*models.BlogPost)), nil
+		}}
+	}
+	postType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":            postField(graphql.NewNonNull(graphql.ID), func(p *models.BlogPost) any { return p.ID }),
+				"title":         postField(graphql.NewNonNull(graphql.String), func(p *models.BlogPost) any { return p.Title }),
+				"slug":          postField(graphql.NewNonNull(graphql.String), func(p *models.BlogPost) any { return p.Slug }),
+				"content":       postField(graphql.NewNonNull(graphql.String), func(p *models.BlogPost) any { return p.Content }),
+				"contentFormat": postField(graphql.NewNonNull(graphql.String), func(p *models.BlogPost) any { return p.ContentFormat }),
+				"contentHtml": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.String),
+					Description: "Content rendered to sanitized HTML",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						html, err := s.posts.RenderContent(p.Source.(*models.BlogPost))
+						return html, toResolveError(err, "failed to render the post")
+					},
+				},
+				"author": postField(graphql.NewNonNull(authorType), func(p *models.BlogPost) any { return p.Author }),
+				"tags":   postField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))), func(p *models.BlogPost) any { return p.Tags }),
+				"status": postField(graphql.NewNonNull(graphql.String), func(p *models.BlogPost) any { return p.Status }),
+				"publishedAt": postField(graphql.DateTime, func(p *models.BlogPost) any {
+					if p.PublishedAt == nil {
+						return nil
+					}
+					return *p.PublishedAt
+				}),
+				"version":   postField(graphql.NewNonNull(graphql.Int), func(p *models.BlogPost) any { return p.Version }),
+				"createdAt": postField(graphql.NewNonNull(graphql.DateTime), func(p *models.BlogPost) any { return p.CreatedAt }),
+				"updatedAt": postField(graphql.NewNonNull(graphql.DateTime), func(p *models.BlogPost) any { return p.UpdatedAt }),
+			}
+		}),
+	})
+
+	connectionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "PostConnection",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"nodes": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postType))),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(connection).nodes, nil
+					},
+				},
+				"pageInfo": &graphql.Field{
+					Type: graphql.NewNonNull(pageInfoType),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source, nil
+					},
+				},
+			}
+		}),
+	})
+
+	authorType = s.groupType("Author", "Author of posts", connectionType, func(l *loaders) *Loader[string, []*models.BlogPost] { return l.postsByAuthor })
+	tagType = s.groupType("Tag", "Tag of posts", connectionType, func(l *loaders) *Loader[string, []*models.BlogPost] { return l.postsByTag })
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": &graphql.Field{
+				Type:        postType,
+				Description: "Post with the ID, null if there is none",
+				Args:        graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					post, err := s.posts.GetById(p.Context, p.Args["id"].(string))
+					if apperrors.Is(err, apperrors.KindNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, toResolveError(err, "failed to retrieve a blog post with a given id")
+					}
+					return post, nil
+				},
+			},
+			"posts": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "Posts matching the filters, newest first",
+				Args: graphql.FieldConfigArgument{
+					"first":  pageArgs["first"],
+					"after":  pageArgs["after"],
+					"author": &graphql.ArgumentConfig{Type: graphql.String},
+					"tag":    &graphql.ArgumentConfig{Type: graphql.String},
+					"status": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					filter := models.PostFilter{Author: stringArg(p, "author"), Tag: stringArg(p, "tag"), Status: stringArg(p, "status")}
+					posts, next, err := s.posts.GetPage(p.Context, filter, stringArg(p, "after"), pageSize(intArg(p, "first")))
+					if err != nil {
+						return nil, toResolveError(err, "failed to retrieve the posts")
+					}
+					return connection{nodes: posts, next: next}, nil
+				},
+			},
+			"author": s.groupField(authorType, func(l *loaders) *Loader[string, []*models.BlogPost] { return l.postsByAuthor }),
+			"authors": s.groupsField(authorType, func(l *loaders) *Loader[string, []*models.BlogPost] { return l.postsByAuthor },
+				func(p *models.BlogPost) []string { return []string{p.Author} }),
+			"tag": s.groupField(tagType, func(l *loaders) *Loader[string, []*models.BlogPost] { return l.postsByTag }),
+			"tags": s.groupsField(tagType, func(l *loaders) *Loader[string, []*models.BlogPost] { return l.postsByTag },
+				func(p *models.BlogPost) []string { return p.Tags }),
+		},
+	})
+	return query, postType
+}
+
+// groupType returns the type of a group of posts sharing an author or a
+// tag, whose values are the names of the groups
+func (s *Schema) groupType(name, description string, connectionType *graphql.Object, loader func(l *loaders) *Loader[string, []*models.BlogPost]) *graphql.Object {
+	load := func(p graphql.ResolveParams) func() ([]*models.BlogPost, error) {
+		return loader(loadersOf(p.Context)).Load(p.Context, p.Source.(string))
+	}
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        name,
+		Description: description,
+		Fields: graphql.Fields{
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source, nil
+				},
+			},
+			"postCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					thunk := load(p)
+					return func() (any, error) {
+						posts, err := thunk()
+						return len(posts), toResolveError(err, "failed to retrieve the posts")
+					}, nil
+				},
+			},
+			"posts": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "Posts newest first",
+				Args:        pageArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					thunk := load(p)
+					return func() (any, error) {
+						posts, err := thunk()
+						if err == nil {
+							var next string
+							posts, next, err = services.Paginate(posts, stringArg(p, "after"), pageSize(intArg(p, "first")))
+							if err == nil {
+								return connection{nodes: posts, next: next}, nil
+							}
+						}
+						return nil, toResolveError(err, "failed to retrieve the posts")
+					}, nil
+				},
+			},
+		},
+	})
+}
+
+// groupField returns a field looking up a group by name, null if it has no
+// posts
+func (s *Schema) groupField(typ *graphql.Object, loader func(l *loaders) *Loader[string, []*models.BlogPost]) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Args: graphql.FieldConfigArgument{"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			name := p.Args["name"].(string)
+			thunk := loader(loadersOf(p.Context)).Load(p.Context, name)
+			return func() (any, error) {
+				posts, err := thunk()
+				if err != nil || len(posts) == 0 {
+					return nil, toResolveError(err, "failed to retrieve the posts")
+				}
+				return name, nil
+			}, nil
+		},
+	}
+}
+
+// groupsField returns a field listing the groups by name, whose posts are
+// loaded along
+func (s *Schema) groupsField(typ *graphql.Object, loader func(l *loaders) *Loader[string, []*models.BlogPost], keys func(p *models.BlogPost) []string) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(typ))),
+		Description: "Sorted by name",
+		Args:        graphql.FieldConfigArgument{"first": pageArgs["first"]},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			groups, err := s.groupPosts(p.Context, models.PostFilter{}, nil, keys)
+			if err != nil {
+				return nil, toResolveError(err, "failed to retrieve the posts")
+			}
+			l := loader(loadersOf(p.Context))
+			names := make([]string, 0, len(groups))
+			for name, posts := range groups {
+				l.Prime(name, posts)
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return names[:min(len(names), pageSize(intArg(p, "first")))], nil
+		},
+	}
+}
+
+func (s *Schema) mutationType(postType *graphql.Object) *graphql.Object {
+	inputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "PostInput",
+		Description: "Editable fields of a post, with the defaults and validation rules of the REST API",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":         &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"slug":          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"content":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"contentFormat": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"author":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"tags":          &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"status":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(inputType)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					post := postInput(p.Args["input"])
+					post.ID = uuid.New().String()
+					created, err := s.posts.Create(p.Context, &post)
+					if err != nil {
+						return nil, toResolveError(err, "failed to create a new blog post")
+					}
+					return created, nil
+				},
+			},
+			"updatePost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(inputType)},
+					"version": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Fails with a CONFLICT error unless the post is still at this version"},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					post := postInput(p.Args["input"])
+					updated, err := s.posts.UpdateVersion(p.Context, p.Args["id"].(string), intArg(p, "version"), &post)
+					if err != nil {
+						return nil, toResolveError(err, "failed to update a blog post with a given id")
+					}
+					return updated, nil
+				},
+			},
+			"deletePost": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "Deletes a post and returns its ID",
+				Args:        graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id := p.Args["id"].(string)
+					if err := s.posts.Delete(p.Context, id); err != nil {
+						return nil, toResolveError(err, "failed to delete a blog post with a given id")
+					}
+					return id, nil
+				},
+			},
+		},
+	})
+}
+
+func postInput(arg any) models.BlogPost {
+	in, _ := arg.(map[string]any)
+	str := func(key string) string {
+		v, _ := in[key].(string)
+		return v
+	}
+	post := models.BlogPost{
+		Title:         str("title"),
+		Slug:          str("slug"),
+		Content:       str("content"),
+		ContentFormat: str("contentFormat"),
+		Author:        str("author"),
+		Status:        str("status"),
+	}
+	if tags, ok := in["tags"].([]any); ok {
+		for _, tag := range tags {
+			if t, ok := tag.(string); ok {
+				post.Tags = append(post.Tags, t)
+			}
+		}
+	}
+	return post
+}
+
+func stringArg(p graphql.ResolveParams, name string) string {
+	v, _ := p.Args[name].(string)
+	return v
+}
+
+func intArg(p graphql.ResolveParams, name string) int {
+	v, _ := p.Args[name].(int)
+	return v
+}
@@ -0,0 +1,218 @@
+package gql
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingRepo counts the reads of every post and records the filters of
+// the selective reads
+type countingRepo struct {
+	*services.InMemoryStoreBlogPostRepo
+	getAll atomic.Int32
+	mu     sync.Mutex
+	finds  []models.PostFilter
+}
+
+func (r *countingRepo) GetAll(ctx context.Context) ([]*models.BlogPost, error) {
+	r.getAll.Add(1)
+	return r.InMemoryStoreBlogPostRepo.GetAll(ctx)
+}
+
+func (r *countingRepo) Find(ctx context.Context, filter models.PostFilter) ([]*models.BlogPost, error) {
+	r.mu.Lock()
+	r.finds = append(r.finds, filter)
+	r.mu.Unlock()
+	return r.InMemoryStoreBlogPostRepo.Find(ctx, filter)
+}
+
+func newTestSchema(t *testing.T, limits Limits) (*Schema, *countingRepo) {
+	t.Helper()
+	repo := &countingRepo{InMemoryStoreBlogPostRepo: services.NewInMemoryStoreBlogPostRepo()}
+	service := services.NewBlogPostService(repo)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, p := range []struct{ id, author, tag string }{
+		{"1", "Alice", "go"}, {"2", "Bob", "go"}, {"3", "Alice", "rust"}, {"4", "Carol", "go"}, {"5", "Bob", "rust"},
+	} {
+		service.Create(context.Background(), &models.BlogPost{ID: p.id, Title: "Post " + p.id, Content: "Test content", Author: p.author, Tags: []string{p.tag}, CreatedAt: base.Add(time.Duration(i) * time.Hour)})
+	}
+
+	schema, err := NewSchema(service, limits)
+	if err != nil {
+		t.Fatalf("failed to build the schema: %v", err)
+	}
+	return schema, repo
+}
+
+// run executes a request and returns its data as JSON
+func run(t *testing.T, schema *Schema, query string, variables map[string]any) (string, []map[string]any) {
+	t.Helper()
+	result := schema.Execute(context.Background(), Request{Query: query, Variables: variables}, true)
+	data, _ := json.Marshal(result.Data)
+	var errs []map[string]any
+	encoded, _ := json.Marshal(result.Errors)
+	json.Unmarshal(encoded, &errs)
+	return string(data), errs
+}
+
+func TestSchema_BatchesNestedReads(t *testing.T) {
+	schema, repo := newTestSchema(t, Limits{})
+
+	data, errs := run(t, schema, `{
+		posts(first: 5) {
+			nodes { id author { name postCount posts(first: 1) { nodes { id } } } tags { name postCount } }
+			pageInfo { hasNextPage }
+		}
+	}`, nil)
+	if len(errs) > 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+	if !strings.Contains(data, `{"author":{"name":"Alice","postCount":2,"posts":{"nodes":[{"id":"3"}]}},"id":"1","tags":[{"name":"go","postCount":3}]}`) {
+		t.Errorf("expected post 1 with its author and tags, got %s", data)
+	}
+	// only the page reads every post, the authors and tags are loaded by name
+	if n := repo.getAll.Load(); n != 1 {
+		t.Errorf("expected 1 read of every post, got %d", n)
+	}
+	if len(repo.finds) != 2 {
+		t.Fatalf("expected 2 selective reads, got %v", repo.finds)
+	}
+	for _, filter := range repo.finds {
+		names := slices.Sorted(slices.Values(append(filter.Authors, filter.Tags...)))
+		if !slices.Equal(names, []string{"Alice", "Bob", "Carol"}) && !slices.Equal(names, []string{"go", "rust"}) {
+			t.Errorf("expected the authors or the tags of the page, got %+v", filter)
+		}
+	}
+}
+
+func TestSchema_LoadsOnlyRequestedGroups(t *testing.T) {
+	schema, _ := newTestSchema(t, Limits{})
+	loaders := schema.newLoaders()
+	ctx := context.Background()
+
+	groups, err := loaders.postsByTag.batch(ctx, []string{"rust"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(groups) != 1 || len(groups["rust"]) != 2 {
+		t.Errorf("expected only the 2 rust posts, got %v", groups)
+	}
+	groups, err = loaders.postsByAuthor.batch(ctx, []string{"Carol", "Dave"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(groups) != 1 || len(groups["Carol"]) != 1 || groups["Carol"][0].ID != "4" {
+		t.Errorf("expected only the post of Carol, got %v", groups)
+	}
+}
+
+func TestSchema_Pagination(t *testing.T) {
+	schema, _ := newTestSchema(t, Limits{})
+
+	data, _ := run(t, schema, `{ posts(first: 2, tag: "go") { nodes { id } pageInfo { endCursor hasNextPage } } }`, nil)
+	var page struct {
+		Posts struct {
+			Nodes    []struct{ ID string }
+			PageInfo struct {
+				EndCursor   string
+				HasNextPage bool
+			}
+		}
+	}
+	json.Unmarshal([]byte(data), &page)
+	if len(page.Posts.Nodes) != 2 || page.Posts.Nodes[0].ID != "4" || !page.Posts.PageInfo.HasNextPage {
+		t.Fatalf("expected posts 4 and 2 and a next page, got %s", data)
+	}
+
+	data, _ = run(t, schema, `query($after: String) { posts(first: 2, tag: "go", after: $after) { nodes { id } pageInfo { hasNextPage } } }`,
+		map[string]any{"after": page.Posts.PageInfo.EndCursor})
+	if data != `{"posts":{"nodes":[{"id":"1"}],"pageInfo":{"hasNextPage":false}}}` {
+		t.Errorf("expected post 1 on the last page, got %s", data)
+	}
+
+	data, _ = run(t, schema, `{ authors { name } tag(name: "rust") { postCount } missing: post(id: "missing") { id } }`, nil)
+	if data != `{"authors":[{"name":"Alice"},{"name":"Bob"},{"name":"Carol"}],"missing":null,"tag":{"postCount":2}}` {
+		t.Errorf("unexpected data %s", data)
+	}
+}
+
+func TestSchema_Mutations(t *testing.T) {
+	schema, _ := newTestSchema(t, Limits{})
+
+	data, errs := run(t, schema, `mutation { createPost(input: {title: " New ", content: "Test content", author: "Dave", tags: ["Go"]}) { id title slug version tags { name } } }`, nil)
+	var created struct {
+		CreatePost struct {
+			ID, Title, Slug string
+			Version         int
+		}
+	}
+	json.Unmarshal([]byte(data), &created)
+	if len(errs) > 0 || created.CreatePost.Title != "New" || created.CreatePost.Slug != "new" || !strings.Contains(data, `"tags":[{"name":"go"}]`) {
+		t.Fatalf("expected a sanitized post, got %s and %v", data, errs)
+	}
+
+	update := `mutation($id: ID!, $version: Int) { updatePost(id: $id, version: $version, input: {title: "Updated", content: "Test content", author: "Dave"}) { version } }`
+	if data, errs := run(t, schema, update, map[string]any{"id": created.CreatePost.ID, "version": 1}); len(errs) > 0 || data != `{"updatePost":{"version":2}}` {
+		t.Errorf("expected version 2, got %s and %v", data, errs)
+	}
+	_, errs = run(t, schema, update, map[string]any{"id": created.CreatePost.ID, "version": 1})
+	if len(errs) != 1 || errs[0]["extensions"].(map[string]any)["code"] != "CONFLICT" || errs[0]["extensions"].(map[string]any)["status"] != float64(409) {
+		t.Errorf("expected a conflict, got %v", errs)
+	}
+
+	_, errs = run(t, schema, `mutation { createPost(input: {title: "", content: "Test content", author: "Dave", status: "hidden"}) { id } }`, nil)
+	if len(errs) != 1 {
+		t.Fatalf("expected a validation error, got %v", errs)
+	}
+	ext := errs[0]["extensions"].(map[string]any)
+	if params, _ := ext["invalidParams"].([]any); ext["code"] != "VALIDATION" || len(params) != 2 {
+		t.Errorf("expected the invalid title and status, got %v", ext)
+	}
+
+	if data, errs := run(t, schema, `mutation { deletePost(id: "1") }`, nil); len(errs) > 0 || data != `{"deletePost":"1"}` {
+		t.Errorf("expected the post to be deleted, got %s and %v", data, errs)
+	}
+}
+
+func TestSchema_RejectsMutationsUnlessAllowed(t *testing.T) {
+	schema, _ := newTestSchema(t, Limits{})
+
+	result := schema.Execute(context.Background(), Request{Query: `mutation { deletePost(id: "1") }`}, false)
+	if len(result.Errors) != 1 || result.Errors[0].Message != "mutations must be sent with POST" {
+		t.Errorf("expected the mutation to be rejected, got %v", result.Errors)
+	}
+}
+
+func TestSchema_Limits(t *testing.T) {
+	schema, _ := newTestSchema(t, Limits{MaxDepth: 5, MaxComplexity: 300})
+
+	deep := `{ posts { nodes { author { posts { nodes { id } } } } } }`
+	if _, errs := run(t, schema, deep, nil); len(errs) != 1 || errs[0]["message"] != "query has a depth of 6, at most 5 is allowed" {
+		t.Errorf("expected the depth to be rejected, got %v", errs)
+	}
+
+	// 1 + 100 * (nodes + id + title) through a fragment
+	complex := `query($n: Int) { posts(first: $n) { nodes { ...fields } } } fragment fields on Post { id title }`
+	if _, errs := run(t, schema, complex, map[string]any{"n": 100}); len(errs) != 1 || errs[0]["message"] != "query has a complexity of 301, at most 300 is allowed" {
+		t.Errorf("expected the complexity to be rejected, got %v", errs)
+	}
+	if _, errs := run(t, schema, complex, map[string]any{"n": 10}); len(errs) > 0 {
+		t.Errorf("expected a smaller page to be accepted, got %v", errs)
+	}
+
+	// introspection queries are nested deeper than the limits
+	result := schema.Execute(context.Background(), Request{Query: testIntrospectionQuery}, false)
+	if len(result.Errors) > 0 {
+		t.Errorf("expected introspection to be allowed, got %v", result.Errors)
+	}
+}
+
+var testIntrospectionQuery = `{ __schema { types { name fields { name type { name ofType { name ofType { name ofType { name } } } } } } } }`
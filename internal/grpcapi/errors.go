@@ -0,0 +1,57 @@
+package grpcapi
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"context"
+	"errors"
+	"log"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// unaryErrors reports the errors of the calls as statuses, like the
+// Problems middleware does for REST handlers
+func unaryErrors(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	return resp, toStatus(info.FullMethod, err)
+}
+
+func streamErrors(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return toStatus(info.FullMethod, handler(srv, ss))
+}
+
+// toStatus maps a domain error to a gRPC status, listing the invalid fields
+// of validation errors in a google.rpc.BadRequest detail
+func toStatus(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		appErr = apperrors.Internal("internal server error", err)
+	}
+	if appErr.Kind == apperrors.KindInternal {
+		log.Printf("%s: %v", method, err)
+	}
+
+	st := status.New(appErr.Kind.GRPCCode(), appErr.Message)
+	if len(appErr.Fields) > 0 {
+		details := &errdetails.BadRequest{}
+		for _, f := range appErr.Fields {
+			details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Reason})
+		}
+		if withDetails, err := st.WithDetails(details); err == nil {
+			st = withDetails
+		}
+	}
+	return st.Err()
+}
@@ -0,0 +1,185 @@
+package grpcapi
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/stream"
+	pb "blog-posts-api/proto/blogposts/v1"
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var eventTypes = map[services.EventType]pb.PostEvent_Type{
+	services.EventPostCreated:   pb.PostEvent_TYPE_POST_CREATED,
+	services.EventPostUpdated:   pb.PostEvent_TYPE_POST_UPDATED,
+	services.EventPostDeleted:   pb.PostEvent_TYPE_POST_DELETED,
+	services.EventPostPublished: pb.PostEvent_TYPE_POST_PUBLISHED,
+}
+
+// postServer implements the blog post service on top of the one of the
+// REST API
+type postServer struct {
+	pb.UnimplementedBlogPostServiceServer
+	service *services.BlogPostService
+	log     *stream.Log
+}
+
+func newPostServer(s *services.BlogPostService, logSize int) *postServer {
+	p := &postServer{service: s, log: stream.NewLog(logSize)}
+	s.Subscribe(p.log.Append)
+	return p
+}
+
+func (p *postServer) GetPost(ctx context.Context, req *pb.GetPostRequest) (*pb.BlogPost, error) {
+	post, err := p.service.GetById(ctx, req.GetId())
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to retrieve a blog post with a given id")
+	}
+	return toProto(post), nil
+}
+
+func (p *postServer) ListPosts(ctx context.Context, req *pb.ListPostsRequest) (*pb.ListPostsResponse, error) {
+	filter := models.PostFilter{Author: req.GetAuthor(), Tag: req.GetTag(), Status: req.GetStatus()}
+	posts, next, err := p.service.GetPage(ctx, filter, req.GetPageToken(), int(req.GetPageSize()))
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to retrieve the posts")
+	}
+
+	resp := &pb.ListPostsResponse{Posts: make([]*pb.BlogPost, len(posts)), NextPageToken: next}
+	for i, post := range posts {
+		resp.Posts[i] = toProto(post)
+	}
+	return resp, nil
+}
+
+func (p *postServer) CreatePost(ctx context.Context, req *pb.CreatePostRequest) (*pb.BlogPost, error) {
+	post := fromInput(req.GetPost())
+	post.ID = uuid.New().String()
+	created, err := p.service.Create(ctx, &post)
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to create a new blog post")
+	}
+	return toProto(created), nil
+}
+
+func (p *postServer) UpdatePost(ctx context.Context, req *pb.UpdatePostRequest) (*pb.BlogPost, error) {
+	post := fromInput(req.GetPost())
+	updated, err := p.service.UpdateVersion(ctx, req.GetId(), int(req.GetVersion()), &post)
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to update a blog post with a given id")
+	}
+	return toProto(updated), nil
+}
+
+func (p *postServer) DeletePost(ctx context.Context, req *pb.DeletePostRequest) (*emptypb.Empty, error) {
+	if err := p.service.Delete(ctx, req.GetId()); err != nil {
+		return nil, apperrors.Wrap(err, "failed to delete a blog post with a given id")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// WatchPosts streams the events of the log like the server-sent event
+// stream of the REST API, ending with UNAVAILABLE when the server shuts
+// down so that clients resume on another instance
+func (p *postServer) WatchPosts(req *pb.WatchPostsRequest, ws grpc.ServerStreamingServer[pb.PostEvent]) error {
+	filter := models.PostFilter{Author: req.GetAuthor(), Tag: req.GetTag()}
+	cursor, resumed := p.log.Head(), true
+	if token := req.GetResumeToken(); token != "" {
+		if cursor, resumed = p.log.Resume(token); !resumed {
+			cursor = p.log.Head()
+		}
+	}
+
+	var err error
+	if resumed {
+		// tells the client the stream is open
+		err = ws.SendHeader(nil)
+	} else {
+		err = ws.Send(&pb.PostEvent{Type: pb.PostEvent_TYPE_RESET})
+	}
+
+	ctx := ws.Context()
+	for err == nil {
+		entries, next, changed, ok := p.log.Read(cursor)
+		if !ok {
+			// fell behind: the log does not wait for slow clients
+			entries, next = nil, p.log.Head()
+			err = ws.Send(&pb.PostEvent{Type: pb.PostEvent_TYPE_RESET})
+		}
+		for _, e := range entries {
+			if err != nil {
+				break
+			}
+			if e.Event.Post == nil || filter.Matches(e.Event.Post) {
+				err = ws.Send(toEvent(e))
+			}
+		}
+		cursor = next
+		if err != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+			if p.log.Closed() {
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+		}
+	}
+	return err
+}
+
+func toProto(post *models.BlogPost) *pb.BlogPost {
+	out := &pb.BlogPost{
+		Id:            post.ID,
+		Title:         post.Title,
+		Slug:          post.Slug,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		Author:        post.Author,
+		Tags:          post.Tags,
+		Status:        post.Status,
+		Version:       int64(post.Version),
+		CreatedAt:     timestamppb.New(post.CreatedAt),
+		UpdatedAt:     timestamppb.New(post.UpdatedAt),
+	}
+	if post.PublishedAt != nil {
+		out.PublishedAt = timestamppb.New(*post.PublishedAt)
+	}
+	return out
+}
+
+func fromInput(in *pb.PostInput) models.BlogPost {
+	return models.BlogPost{
+		Title:         in.GetTitle(),
+		Slug:          in.GetSlug(),
+		Content:       in.GetContent(),
+		ContentFormat: in.GetContentFormat(),
+		Author:        in.GetAuthor(),
+		Tags:          in.GetTags(),
+		Status:        in.GetStatus(),
+	}
+}
+
+func toEvent(e stream.Entry) *pb.PostEvent {
+	out := &pb.PostEvent{
+		Id:         e.ID,
+		Type:       eventTypes[e.Event.Type],
+		PostId:     e.Event.PostID,
+		OccurredAt: timestamppb.New(e.Event.OccurredAt),
+		EventId:    e.Event.ID,
+	}
+	if e.Event.Post != nil {
+		out.Post = toProto(e.Event.Post)
+	}
+	return out
+}
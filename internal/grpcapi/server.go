@@ -0,0 +1,73 @@
+// Package grpcapi serves the blog posts over gRPC, for internal services,
+// alongside the REST API. Both go through services.BlogPostService, so
+// validation, events and errors are the same; errors are mapped to status
+// codes with the table of the apperrors package.
+package grpcapi
+
+import (
+	"blog-posts-api/internal/api/services"
+	pb "blog-posts-api/proto/blogposts/v1"
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server is the gRPC server of the process, with the blog post service,
+// the standard health service and server reflection
+type Server struct {
+	grpc   *grpc.Server
+	health *health.Server
+	posts  *postServer
+}
+
+// New returns a server of the posts of the service, keeping the last
+// logSize changes to resume watch streams
+func New(posts *services.BlogPostService, logSize int) *Server {
+	s := &Server{
+		grpc: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(unaryErrors),
+			grpc.ChainStreamInterceptor(streamErrors),
+		),
+		health: health.NewServer(),
+		posts:  newPostServer(posts, logSize),
+	}
+	pb.RegisterBlogPostServiceServer(s.grpc, s.posts)
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	reflection.Register(s.grpc)
+	s.health.SetServingStatus(pb.BlogPostService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	return s
+}
+
+// Serve accepts connections on the listener until the server is shut down
+func (s *Server) Serve(ln net.Listener) error {
+	return s.grpc.Serve(ln)
+}
+
+// Drain reports the server as not serving and ends the watch streams, as
+// soon as shutdown starts, so that clients move to another instance
+func (s *Server) Drain() {
+	s.health.Shutdown()
+	s.posts.log.Close()
+}
+
+// Shutdown stops accepting calls and waits for the running ones, canceling
+// them once ctx expires
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,206 @@
+package grpcapi
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	pb "blog-posts-api/proto/blogposts/v1"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestServer(t *testing.T) (*Server, *grpc.ClientConn, *services.BlogPostService) {
+	t.Helper()
+	service := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	srv := New(service, 10)
+	ln := bufconn.Listen(1 << 20)
+	go srv.Serve(ln)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		srv.Shutdown(context.Background())
+	})
+	return srv, conn, service
+}
+
+func TestBlogPostService_CRUD(t *testing.T) {
+	_, conn, _ := newTestServer(t)
+	client := pb.NewBlogPostServiceClient(conn)
+	ctx := context.Background()
+
+	created, err := client.CreatePost(ctx, &pb.CreatePostRequest{Post: &pb.PostInput{Title: "  Test Post ", Content: "Test content", Author: "Test Author", Tags: []string{"Go"}}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// the rules of the REST API apply
+	if created.Title != "Test Post" || created.Slug != "test-post" || created.Status != models.StatusPublished || created.Tags[0] != "go" || created.PublishedAt == nil {
+		t.Errorf("expected a sanitized published post, got %v", created)
+	}
+
+	got, err := client.GetPost(ctx, &pb.GetPostRequest{Id: created.Id})
+	if err != nil || got.Title != "Test Post" || got.Version != 1 {
+		t.Errorf("expected the created post, got %v and %v", got, err)
+	}
+
+	updated, err := client.UpdatePost(ctx, &pb.UpdatePostRequest{Id: created.Id, Version: 1, Post: &pb.PostInput{Title: "Updated", Content: "Test content", Author: "Test Author"}})
+	if err != nil || updated.Title != "Updated" || updated.Version != 2 {
+		t.Errorf("expected version 2, got %v and %v", updated, err)
+	}
+	_, err = client.UpdatePost(ctx, &pb.UpdatePostRequest{Id: created.Id, Version: 1, Post: &pb.PostInput{Title: "Stale", Content: "Test content", Author: "Test Author"}})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("expected Aborted, got %v", err)
+	}
+
+	if _, err := client.DeletePost(ctx, &pb.DeletePostRequest{Id: created.Id}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if _, err := client.GetPost(ctx, &pb.GetPostRequest{Id: created.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
+
+func TestBlogPostService_InvalidFields(t *testing.T) {
+	_, conn, _ := newTestServer(t)
+	client := pb.NewBlogPostServiceClient(conn)
+
+	_, err := client.CreatePost(context.Background(), &pb.CreatePostRequest{Post: &pb.PostInput{Content: "Test content", Status: "hidden"}})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+
+	violations := map[string]bool{}
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				violations[v.Field] = true
+			}
+		}
+	}
+	for _, field := range []string{"title", "author", "status"} {
+		if !violations[field] {
+			t.Errorf("expected a violation of %s, got %v", field, violations)
+		}
+	}
+}
+
+func TestBlogPostService_ListPosts(t *testing.T) {
+	_, conn, service := newTestServer(t)
+	client := pb.NewBlogPostServiceClient(conn)
+	ctx := context.Background()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		author := "Alice"
+		if id == "c" {
+			author = "Bob"
+		}
+		service.Create(ctx, &models.BlogPost{ID: id, Title: "Post " + id, Content: "Test content", Author: author, CreatedAt: base.Add(time.Duration(i) * time.Hour)})
+	}
+
+	var ids []string
+	req := &pb.ListPostsRequest{PageSize: 2, Author: "Alice"}
+	for pages := 0; ; pages++ {
+		resp, err := client.ListPosts(ctx, req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		for _, p := range resp.Posts {
+			ids = append(ids, p.Id)
+		}
+		if resp.NextPageToken == "" {
+			if pages != 1 {
+				t.Errorf("expected 2 pages, got %d", pages+1)
+			}
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	if len(ids) != 4 || ids[0] != "e" || ids[1] != "d" || ids[2] != "b" || ids[3] != "a" {
+		t.Errorf("expected the posts of Alice newest first, got %v", ids)
+	}
+
+	if _, err := client.ListPosts(ctx, &pb.ListPostsRequest{PageToken: "invalid"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestBlogPostService_WatchPosts(t *testing.T) {
+	srv, conn, service := newTestServer(t)
+	client := pb.NewBlogPostServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watch, err := client.WatchPosts(ctx, &pb.WatchPostsRequest{Tag: "go"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// the stream is open once the headers are received
+	watch.Header()
+
+	service.Create(ctx, &models.BlogPost{ID: "1", Title: "Other", Content: "Test content", Author: "Test Author"})
+	service.Create(ctx, &models.BlogPost{ID: "2", Title: "Go", Content: "Test content", Author: "Test Author", Tags: []string{"go"}, Status: models.StatusDraft})
+	service.Delete(ctx, "1")
+
+	created, err := watch.Recv()
+	if err != nil || created.Type != pb.PostEvent_TYPE_POST_CREATED || created.PostId != "2" || created.Post.Title != "Go" {
+		t.Fatalf("expected the created event of post 2, got %v and %v", created, err)
+	}
+	deleted, err := watch.Recv()
+	if err != nil || deleted.Type != pb.PostEvent_TYPE_POST_DELETED || deleted.PostId != "1" || deleted.Post != nil {
+		t.Fatalf("expected the deleted event of post 1, got %v and %v", deleted, err)
+	}
+
+	// resumed after the created event
+	resumed, err := client.WatchPosts(ctx, &pb.WatchPostsRequest{ResumeToken: created.Id})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if e, err := resumed.Recv(); err != nil || e.Id != deleted.Id {
+		t.Errorf("expected the missed event, got %v and %v", e, err)
+	}
+
+	unknown, _ := client.WatchPosts(ctx, &pb.WatchPostsRequest{ResumeToken: "unknown-1"})
+	if e, err := unknown.Recv(); err != nil || e.Type != pb.PostEvent_TYPE_RESET {
+		t.Errorf("expected a reset, got %v and %v", e, err)
+	}
+
+	srv.Drain()
+	if _, err := watch.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable once draining, got %v", err)
+	}
+}
+
+func TestServer_Health(t *testing.T) {
+	srv, conn, _ := newTestServer(t)
+	client := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	for _, service := range []string{"", pb.BlogPostService_ServiceDesc.ServiceName} {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("expected %q to be serving, got %v and %v", service, resp, err)
+		}
+	}
+
+	srv.Drain()
+	if resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected not serving once draining, got %v and %v", resp, err)
+	}
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Pinger is implemented by dependencies that can verify their own
+// connectivity, such as repositories
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingCheck adapts a Pinger to a CheckFunc
+func PingCheck(p Pinger) CheckFunc {
+	return p.Ping
+}
+
+// DiskSpaceCheck fails when the filesystem holding path has less than
+// minFree bytes available
+func DiskSpaceCheck(path string, minFree uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeBytes(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if free < minFree {
+			return fmt.Errorf("only %d bytes free on %s, need at least %d", free, path, minFree)
+		}
+		return nil
+	}
+}
+
+// Heartbeat is beaten by a background worker on every iteration of its
+// loop; the check fails if the worker has not beaten within maxAge
+type Heartbeat struct {
+	last atomic.Int64
+}
+
+func NewHeartbeat() *Heartbeat {
+	h := &Heartbeat{}
+	h.Beat()
+	return h
+}
+
+// Beat records that the worker is alive
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Last returns the time of the last beat
+func (h *Heartbeat) Last() time.Time {
+	return time.Unix(0, h.last.Load())
+}
+
+// Check returns a CheckFunc failing when the last beat is older than maxAge
+func (h *Heartbeat) Check(maxAge time.Duration) CheckFunc {
+	return func(ctx context.Context) error {
+		if age := time.Since(h.Last()); age > maxAge {
+			return fmt.Errorf("no heartbeat for %s", age.Truncate(time.Millisecond))
+		}
+		return nil
+	}
+}
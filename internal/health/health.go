@@ -0,0 +1,204 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Probe selects which endpoint(s) a check contributes to
+type Probe int
+
+const (
+	// Liveness checks tell whether the process should be restarted
+	Liveness Probe = 1 << iota
+	// Readiness checks tell whether the process can serve traffic
+	Readiness
+)
+
+const (
+	StatusOK          = "ok"
+	StatusFail        = "fail"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+)
+
+const (
+	DefaultTimeout  = 2 * time.Second
+	DefaultCacheTTL = time.Second
+)
+
+// CheckFunc reports a problem with a dependency by returning an error
+type CheckFunc func(ctx context.Context) error
+
+// Check describes a single dependency check registered by a component
+type Check struct {
+	Name  string
+	Probe Probe
+	// Timeout bounds a single run of the check, DefaultTimeout if zero
+	Timeout time.Duration
+	// CacheTTL is how long a result is reused before the check runs
+	// again, DefaultCacheTTL if zero
+	CacheTTL time.Duration
+	Fn       CheckFunc
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Status     string    `json:"status" example:"ok"`
+	Error      string    `json:"error,omitempty" example:"context deadline exceeded"`
+	DurationMs int64     `json:"duration_ms" example:"3"`
+	CheckedAt  time.Time `json:"checked_at" example:"2025-01-01T00:00:00Z"`
+}
+
+// Report aggregates the results of all checks of a probe
+type Report struct {
+	Status string            `json:"status" example:"ok"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// OK tells whether the probe passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type registeredCheck struct {
+	Check
+
+	mu     sync.Mutex
+	last   Result
+	cached bool
+}
+
+// Registry holds the checks registered by the components of the process
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []*registeredCheck
+	draining atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check to the registry. Checks with an empty probe
+// are registered for readiness.
+func (r *Registry) Register(c Check) {
+	if c.Fn == nil {
+		panic(fmt.Sprintf("health: check %q has no function", c.Name))
+	}
+	if c.Probe == 0 {
+		c.Probe = Readiness
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = DefaultCacheTTL
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, &registeredCheck{Check: c})
+}
+
+// SetDraining makes the readiness probe fail so that load balancers stop
+// routing new traffic while in-flight requests are drained
+func (r *Registry) SetDraining() {
+	r.draining.Store(true)
+}
+
+// Draining tells whether SetDraining has been called
+func (r *Registry) Draining() bool {
+	return r.draining.Load()
+}
+
+// Liveness runs the liveness checks
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, Liveness)
+}
+
+// Readiness runs the readiness checks. The report fails while draining,
+// regardless of the checks' results.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	report := r.run(ctx, Readiness)
+	if r.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, probe Probe) Report {
+	r.mu.RLock()
+	checks := make([]*registeredCheck, 0, len(r.checks))
+	for _, c := range r.checks {
+		if c.Probe&probe != 0 {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for i, c := range checks {
+		go func() {
+			defer wg.Done()
+			results[i] = c.result(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.Name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+func (c *registeredCheck) result(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached && time.Since(c.last.CheckedAt) < c.CacheTTL {
+		return c.last
+	}
+
+	// the result is shared with the other probes until it expires, so it
+	// must not depend on the request that happened to run the check
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.Fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		// do not wait for checks that ignore their context
+		err = ctx.Err()
+	}
+
+	res := Result{
+		Status:     StatusOK,
+		DurationMs: time.Since(start).Milliseconds(),
+		CheckedAt:  start,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	c.last = res
+	c.cached = true
+	return res
+}
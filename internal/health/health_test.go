@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Readiness_AllPassing(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "repo", Fn: func(ctx context.Context) error { return nil }})
+
+	report := r.Readiness(context.Background())
+	if !report.OK() {
+		t.Fatalf("expected status %q, got %q", StatusOK, report.Status)
+	}
+	if report.Checks["repo"].Status != StatusOK {
+		t.Errorf("expected check status %q, got %q", StatusOK, report.Checks["repo"].Status)
+	}
+}
+
+func TestRegistry_Readiness_FailingCheck(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "repo", Fn: func(ctx context.Context) error { return nil }})
+	r.Register(Check{Name: "disk", Fn: func(ctx context.Context) error { return errors.New("disk full") }})
+
+	report := r.Readiness(context.Background())
+	if report.Status != StatusUnavailable {
+		t.Errorf("expected status %q, got %q", StatusUnavailable, report.Status)
+	}
+	if report.Checks["disk"].Error != "disk full" {
+		t.Errorf("expected error 'disk full', got '%s'", report.Checks["disk"].Error)
+	}
+	if report.Checks["repo"].Status != StatusOK {
+		t.Errorf("expected passing check to be reported as %q, got %q", StatusOK, report.Checks["repo"].Status)
+	}
+}
+
+func TestRegistry_ProbesAreSeparated(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "worker", Probe: Liveness, Fn: func(ctx context.Context) error { return nil }})
+	r.Register(Check{Name: "repo", Probe: Readiness, Fn: func(ctx context.Context) error { return nil }})
+	r.Register(Check{Name: "both", Probe: Liveness | Readiness, Fn: func(ctx context.Context) error { return nil }})
+
+	live := r.Liveness(context.Background())
+	if _, ok := live.Checks["repo"]; ok {
+		t.Error("expected readiness check not to be part of liveness")
+	}
+	if len(live.Checks) != 2 {
+		t.Errorf("expected 2 liveness checks, got %d", len(live.Checks))
+	}
+
+	ready := r.Readiness(context.Background())
+	if _, ok := ready.Checks["worker"]; ok {
+		t.Error("expected liveness check not to be part of readiness")
+	}
+	if len(ready.Checks) != 2 {
+		t.Errorf("expected 2 readiness checks, got %d", len(ready.Checks))
+	}
+}
+
+func TestRegistry_CheckTimeout(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Fn: func(ctx context.Context) error {
+			time.Sleep(time.Second) // ignores its context on purpose
+			return nil
+		},
+	})
+
+	start := time.Now()
+	report := r.Readiness(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected check to be abandoned after its timeout, took %s", elapsed)
+	}
+	if report.Checks["slow"].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected deadline exceeded, got '%s'", report.Checks["slow"].Error)
+	}
+}
+
+func TestRegistry_ResultsAreCached(t *testing.T) {
+	r := NewRegistry()
+	var calls atomic.Int32
+	r.Register(Check{
+		Name:     "repo",
+		CacheTTL: time.Hour,
+		Fn: func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		},
+	})
+
+	r.Readiness(context.Background())
+	r.Readiness(context.Background())
+
+	if calls.Load() != 1 {
+		t.Errorf("expected check to run once, ran %d times", calls.Load())
+	}
+}
+
+func TestRegistry_CanceledProbeIsNotCached(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "repo", CacheTTL: time.Hour, Fn: func(ctx context.Context) error { return ctx.Err() }})
+
+	// a probe client disconnecting must not fail the probes that follow
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Readiness(ctx)
+
+	if report := r.Readiness(context.Background()); !report.OK() {
+		t.Errorf("expected status %q, got %q: %+v", StatusOK, report.Status, report.Checks)
+	}
+}
+
+func TestRegistry_Draining(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "repo", Probe: Liveness | Readiness, Fn: func(ctx context.Context) error { return nil }})
+
+	r.SetDraining()
+
+	if report := r.Readiness(context.Background()); report.Status != StatusDraining {
+		t.Errorf("expected readiness status %q, got %q", StatusDraining, report.Status)
+	}
+	if report := r.Liveness(context.Background()); !report.OK() {
+		t.Errorf("expected liveness to be unaffected by draining, got %q", report.Status)
+	}
+}
+
+func TestHeartbeat_Check(t *testing.T) {
+	h := NewHeartbeat()
+	check := h.Check(20 * time.Millisecond)
+
+	if err := check(context.Background()); err != nil {
+		t.Errorf("expected fresh heartbeat to pass, got %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := check(context.Background()); err == nil {
+		t.Error("expected stale heartbeat to fail")
+	}
+
+	h.Beat()
+	if err := check(context.Background()); err != nil {
+		t.Errorf("expected heartbeat to pass after a beat, got %v", err)
+	}
+}
+
+func TestDiskSpaceCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := DiskSpaceCheck(dir, 1)(context.Background()); err != nil {
+		t.Errorf("expected check to pass, got %v", err)
+	}
+	if err := DiskSpaceCheck(dir, ^uint64(0))(context.Background()); err == nil {
+		t.Error("expected check to fail when requiring more space than available")
+	}
+}
@@ -0,0 +1,120 @@
+package imagemeta
+
+import (
+	"bufio"
+	"io"
+)
+
+// GIF blocks and extensions
+const (
+	gifImage       = 0x2c
+	gifExtension   = 0x21
+	gifTrailer     = 0x3b
+	gifComment     = 0xfe
+	gifApplication = 0xff
+)
+
+// gifApplications are the application extensions kept, both telling how
+// many times an animation loops
+var gifApplications = map[string]bool{"NETSCAPE2.0": true, "ANIMEXTS1.0": true}
+
+// stripGIF drops the comments and the application extensions other than
+// the looping of animations, e.g. XMP data
+func stripGIF(w *bufio.Writer, r *bufio.Reader) error {
+	// the header and the logical screen descriptor
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	if string(header[:6]) != "GIF87a" && string(header[:6]) != "GIF89a" {
+		return ErrMalformed
+	}
+	w.Write(header)
+	if err := copyN(w, r, colorTableSize(header[10])); err != nil {
+		return err
+	}
+
+	for {
+		block, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch block {
+		case gifTrailer:
+			w.WriteByte(block)
+			return nil
+		case gifImage:
+			// the image descriptor, then the minimum code size of the data
+			descriptor := make([]byte, 10)
+			if _, err := io.ReadFull(r, descriptor[1:]); err != nil {
+				return err
+			}
+			descriptor[0] = block
+			w.Write(descriptor)
+			if err := copyN(w, r, colorTableSize(descriptor[9])+1); err != nil {
+				return err
+			}
+			if err := subBlocks(w, r); err != nil {
+				return err
+			}
+		case gifExtension:
+			label, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			keep := label != gifComment
+			if label == gifApplication {
+				identifier, err := r.Peek(12)
+				if err != nil {
+					return err
+				}
+				keep = identifier[0] == 11 && gifApplications[string(identifier[1:])]
+			}
+			if !keep {
+				if err := subBlocks(nil, r); err != nil {
+					return err
+				}
+				continue
+			}
+			w.Write([]byte{block, label})
+			if err := subBlocks(w, r); err != nil {
+				return err
+			}
+		default:
+			return ErrMalformed
+		}
+	}
+}
+
+// colorTableSize returns the size of the color table flagged in the packed
+// field of a descriptor
+func colorTableSize(packed byte) int64 {
+	if packed&0x80 == 0 {
+		return 0
+	}
+	return 3 << (packed&0x07 + 1)
+}
+
+// subBlocks copies data sub-blocks up to the terminating empty one, or
+// skips them when w is nil
+func subBlocks(w *bufio.Writer, r *bufio.Reader) error {
+	for {
+		size, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if w == nil {
+			if err := discard(r, int(size)); err != nil {
+				return err
+			}
+		} else {
+			w.WriteByte(size)
+			if err := copyN(w, r, int64(size)); err != nil {
+				return err
+			}
+		}
+		if size == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package imagemeta removes the metadata of images, e.g. the EXIF data of
+// a photo with the position it was taken at, without decoding their pixels.
+//
+// Images are streamed segment by segment and only the parts needed to
+// display them are kept: the pixels, their color profile and the timing of
+// animations. Text, comments, EXIF, XMP and IPTC data are dropped, as is
+// anything following the end of the image. The orientation of a JPEG photo
+// is kept in a minimal EXIF segment of its own, since browsers rotate the
+// pixels by it.
+package imagemeta
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// ErrMalformed is returned for images whose structure cannot be walked,
+// e.g. truncated ones
+var ErrMalformed = errors.New("image is malformed")
+
+// strippers walk the images of each type, copying what they keep
+var strippers = map[string]func(w *bufio.Writer, r *bufio.Reader) error{
+	"image/jpeg": stripJPEG,
+	"image/png":  stripPNG,
+	"image/gif":  stripGIF,
+	"image/webp": stripWebP,
+}
+
+// Strips tells whether the metadata of images of a content type is removed
+// by Strip
+func Strips(contentType string) bool {
+	_, ok := strippers[contentType]
+	return ok
+}
+
+// Strip copies an image of the given content type from r to w without its
+// metadata. Content of other types is copied as is.
+func Strip(w io.Writer, r io.Reader, contentType string) error {
+	strip, ok := strippers[contentType]
+	if !ok {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	if err := strip(bw, bufio.NewReader(r)); err != nil {
+		return malformed(err)
+	}
+	return bw.Flush()
+}
+
+// malformed reports an image ending prematurely as ErrMalformed, leaving
+// the errors of the reader as they are
+func malformed(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrMalformed
+	}
+	return err
+}
+
+// copyN copies n bytes, failing if r ends before
+func copyN(w io.Writer, r io.Reader, n int64) error {
+	_, err := io.CopyN(w, r, n)
+	return err
+}
+
+// discard skips n bytes, failing if r ends before
+func discard(r *bufio.Reader, n int) error {
+	_, err := r.Discard(n)
+	return err
+}
@@ -0,0 +1,238 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand/v2"
+	"testing"
+)
+
+// secret marks the metadata that must not be kept
+const secret = "GPS 48.8584 N 2.2945 E"
+
+// noise returns an image of random pixels, whose JPEG encoding has stuffed
+// bytes
+func noise(w, h int) *image.RGBA {
+	rng := rand.New(rand.NewPCG(1, 2))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = byte(rng.Uint32())
+	}
+	return img
+}
+
+func strip(t *testing.T, data []byte, contentType string) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Strip(&out, bytes.NewReader(data), contentType); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if bytes.Contains(out.Bytes(), []byte(secret)) {
+		t.Errorf("expected the metadata to be stripped, got %q", out.Bytes())
+	}
+	return out.Bytes()
+}
+
+// jpegSegment returns a JPEG segment with a payload
+func jpegSegment(marker byte, payload string) []byte {
+	segment := []byte{0xff, marker}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func TestStrip_JPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, noise(64, 48), nil); err != nil {
+		t.Fatalf("failed to encode the image: %v", err)
+	}
+	encoded := buf.Bytes()
+
+	// EXIF data with an orientation of 6 and a GPS position
+	tiff := []byte("II\x2a\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, OrientationTag)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 6)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, secret...)
+	icc := "ICC_PROFILE\x00\x01\x01profile"
+
+	data := []byte{0xff, 0xd8}
+	data = append(data, jpegSegment(0xe0, "JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")...)
+	data = append(data, jpegSegment(0xe1, "Exif\x00\x00"+string(tiff))...)
+	data = append(data, jpegSegment(0xe1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>"+secret)...)
+	data = append(data, jpegSegment(0xe2, icc)...)
+	data = append(data, jpegSegment(0xe2, "MPF\x00"+secret)...)
+	data = append(data, jpegSegment(0xed, "Photoshop 3.0\x00"+secret)...)
+	data = append(data, jpegSegment(0xfe, secret)...)
+	data = append(data, encoded[2:]...)
+	// a trailing image of a Multi-Picture Format file
+	data = append(data, 0xff, 0xd8)
+	data = append(data, jpegSegment(0xe1, "Exif\x00\x00"+secret)...)
+
+	stripped := strip(t, data, "image/jpeg")
+	if !bytes.Contains(stripped, []byte(icc)) || !bytes.Contains(stripped, []byte("JFIF")) {
+		t.Errorf("expected the JFIF segment and the color profile to be kept")
+	}
+	if !bytes.Contains(stripped, orientationSegment(6)) {
+		t.Errorf("expected the orientation to be kept")
+	}
+
+	want, _ := jpeg.Decode(bytes.NewReader(encoded))
+	got, err := jpeg.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		t.Fatalf("expected the stripped image to decode, got %v", err)
+	}
+	if w, g := want.(*image.YCbCr), got.(*image.YCbCr); !bytes.Equal(w.Y, g.Y) || !bytes.Equal(w.Cb, g.Cb) || !bytes.Equal(w.Cr, g.Cr) {
+		t.Errorf("expected the pixels to be left alone")
+	}
+
+	// without an orientation, no EXIF data is left at all
+	if stripped := strip(t, append([]byte{0xff, 0xd8}, encoded[2:]...), "image/jpeg"); bytes.Contains(stripped, exifPrefix) {
+		t.Errorf("expected no EXIF segment")
+	}
+}
+
+// pngChunk returns a PNG chunk with its checksum
+func pngChunk(typ, data string) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestStrip_PNG(t *testing.T) {
+	img := noise(16, 16)
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	encoded := buf.Bytes()
+	// the signature and the IHDR chunk
+	ihdr := len(pngSignature) + 25
+	gama := pngChunk("gAMA", "\x00\x00\xb1\x8f")
+
+	var data []byte
+	data = append(data, encoded[:ihdr]...)
+	data = append(data, gama...)
+	data = append(data, pngChunk("tEXt", "Comment\x00"+secret)...)
+	data = append(data, pngChunk("iTXt", "XML:com.adobe.xmp\x00\x00\x00\x00\x00"+secret)...)
+	data = append(data, pngChunk("eXIf", "MM\x00\x2a"+secret)...)
+	data = append(data, pngChunk("prVt", secret)...)
+	data = append(data, encoded[ihdr:]...)
+	data = append(data, secret...)
+
+	stripped := strip(t, data, "image/png")
+	if want := append(append(encoded[:ihdr:ihdr], gama...), encoded[ihdr:]...); !bytes.Equal(stripped, want) {
+		t.Errorf("expected only the known chunks to be kept, got %q", stripped)
+	}
+	if _, err := png.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("expected the stripped image to decode, got %v", err)
+	}
+}
+
+func TestStrip_GIF(t *testing.T) {
+	frame := func(c uint8) *image.Paletted {
+		img := image.NewPaletted(image.Rect(0, 0, 8, 8), palette.Plan9)
+		for i := range img.Pix {
+			img.Pix[i] = c + uint8(i)
+		}
+		return img
+	}
+	var buf bytes.Buffer
+	gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{frame(0), frame(64)}, Delay: []int{10, 20}, LoopCount: 3})
+	encoded := buf.Bytes()
+
+	var data []byte
+	data = append(data, encoded[:len(encoded)-1]...)
+	data = append(data, 0x21, 0xfe, byte(len(secret)))
+	data = append(data, secret...)
+	data = append(data, 0, 0x21, 0xff, 11)
+	data = append(data, "XMP DataXMP"...)
+	data = append(data, byte(len(secret)))
+	data = append(data, secret...)
+	data = append(data, 0, 0x3b)
+	data = append(data, secret...)
+
+	stripped := strip(t, data, "image/gif")
+	if !bytes.Equal(stripped, encoded) {
+		t.Errorf("expected the image as encoded, got %q", stripped)
+	}
+	decoded, err := gif.DecodeAll(bytes.NewReader(stripped))
+	if err != nil || len(decoded.Image) != 2 || decoded.LoopCount != 3 {
+		t.Errorf("expected the looping animation to be kept, got %+v and %v", decoded, err)
+	}
+}
+
+// webpChunk returns a WebP chunk, padded to an even size
+func webpChunk(fourCC, data string) []byte {
+	chunk := binary.LittleEndian.AppendUint32([]byte(fourCC), uint32(len(data)))
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func riff(chunks ...[]byte) []byte {
+	body := bytes.Join(chunks, nil)
+	data := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(4+len(body)))
+	data = append(data, "WEBP"...)
+	return append(data, body...)
+}
+
+func TestStrip_WebP(t *testing.T) {
+	// the ICC, EXIF and XMP flags, and the size of the canvas
+	vp8x := func(flags byte) []byte { return webpChunk("VP8X", string([]byte{flags, 0, 0, 0, 0, 0, 0, 0, 0, 0})) }
+	iccp := webpChunk("ICCP", "profile")
+	vp8l := webpChunk("VP8L", "\x2f\x00\x00\x00\x00")
+
+	data := riff(vp8x(0x20|0x08|0x04), iccp, vp8l, webpChunk("EXIF", "MM\x00\x2a"+secret), webpChunk("XMP ", secret))
+	data = append(data, secret...)
+
+	stripped := strip(t, data, "image/webp")
+	if want := riff(vp8x(0x20), iccp, vp8l); !bytes.Equal(stripped, want) {
+		t.Errorf("expected %q, got %q", want, stripped)
+	}
+}
+
+func TestStrip_Malformed(t *testing.T) {
+	var jpg, pngData, gifData bytes.Buffer
+	jpeg.Encode(&jpg, noise(8, 8), nil)
+	png.Encode(&pngData, noise(8, 8))
+	gif.Encode(&gifData, noise(8, 8), nil)
+	webp := riff(webpChunk("VP8L", "\x2f\x00\x00\x00\x00"))
+
+	for name, tc := range map[string]struct {
+		data        []byte
+		contentType string
+	}{
+		"truncated JPEG": {jpg.Bytes()[:jpg.Len()-10], "image/jpeg"},
+		"truncated PNG":  {pngData.Bytes()[:pngData.Len()-20], "image/png"},
+		"truncated GIF":  {gifData.Bytes()[:gifData.Len()-5], "image/gif"},
+		"truncated WebP": {webp[:len(webp)-4], "image/webp"},
+		"not a JPEG":     {pngData.Bytes(), "image/jpeg"},
+		"not a PNG":      {jpg.Bytes(), "image/png"},
+	} {
+		if err := Strip(&bytes.Buffer{}, bytes.NewReader(tc.data), tc.contentType); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: expected ErrMalformed, got %v", name, err)
+		}
+	}
+}
+
+func TestStrip_OtherTypes(t *testing.T) {
+	data := []byte("%PDF-1.7\n" + secret)
+	var out bytes.Buffer
+	if err := Strip(&out, bytes.NewReader(data), "application/pdf"); err != nil || !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("expected the content as is, got %q and %v", out.Bytes(), err)
+	}
+	if Strips("application/pdf") || !Strips("image/jpeg") {
+		t.Errorf("expected only images to be stripped")
+	}
+}
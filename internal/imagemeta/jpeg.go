@@ -0,0 +1,216 @@
+package imagemeta
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// OrientationTag is the EXIF tag of the orientation of the pixels
+const OrientationTag = 0x0112
+
+// JPEG markers
+const (
+	markerSOI  = 0xd8
+	markerEOI  = 0xd9
+	markerSOS  = 0xda
+	markerAPP0 = 0xe0
+	markerAPP1 = 0xe1
+	markerAPP2 = 0xe2
+	markerAPPE = 0xee
+	markerAPPF = 0xef
+	markerCOM  = 0xfe
+)
+
+var (
+	exifPrefix = []byte("Exif\x00\x00")
+	iccPrefix  = []byte("ICC_PROFILE\x00")
+)
+
+// stripJPEG keeps the segments needed to decode the image: JFIF, the ICC
+// profile and the Adobe color transform among the application segments,
+// and a minimal EXIF segment holding the orientation unless it is 1
+func stripJPEG(w *bufio.Writer, r *bufio.Reader) error {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil {
+		return err
+	}
+	if soi[0] != 0xff || soi[1] != markerSOI {
+		return ErrMalformed
+	}
+	w.Write(soi[:])
+
+	marker, err := readMarker(r)
+	for err == nil {
+		switch {
+		case marker == markerEOI:
+			// whatever follows, e.g. the other images of a Multi-Picture
+			// Format file with EXIF data of their own, is dropped
+			w.Write([]byte{0xff, markerEOI})
+			return nil
+		case marker == 0x01 || marker >= 0xd0 && marker <= 0xd7:
+			// TEM and RST markers have no length
+			w.Write([]byte{0xff, marker})
+			marker, err = readMarker(r)
+			continue
+		}
+
+		var size [2]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return err
+		}
+		length := int(binary.BigEndian.Uint16(size[:])) - 2
+		if length < 0 {
+			return ErrMalformed
+		}
+		switch {
+		case marker == markerAPP1:
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return err
+			}
+			if bytes.HasPrefix(payload, exifPrefix) {
+				if o := ExifOrientation(payload[len(exifPrefix):]); o != 1 {
+					w.Write(orientationSegment(o))
+				}
+			}
+		case marker == markerCOM || marker >= markerAPP0 && marker <= markerAPPF && !keepSegment(marker, r, length):
+			if err := discard(r, length); err != nil {
+				return err
+			}
+		default:
+			w.Write([]byte{0xff, marker, size[0], size[1]})
+			if err := copyN(w, r, int64(length)); err != nil {
+				return err
+			}
+		}
+
+		if marker == markerSOS {
+			marker, err = copyScan(w, r)
+		} else {
+			marker, err = readMarker(r)
+		}
+	}
+	return err
+}
+
+// keepSegment tells whether an application segment is needed to display
+// the image
+func keepSegment(marker byte, r *bufio.Reader, length int) bool {
+	switch marker {
+	case markerAPP0, markerAPPE:
+		// JFIF and Adobe, telling how to convert the colors
+		return true
+	case markerAPP2:
+		prefix, _ := r.Peek(min(length, len(iccPrefix)))
+		return bytes.Equal(prefix, iccPrefix)
+	}
+	return false
+}
+
+// readMarker reads the next marker, skipping fill bytes
+func readMarker(r *bufio.Reader) (byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xff {
+		return 0, ErrMalformed
+	}
+	for b == 0xff {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	if b == 0 {
+		return 0, ErrMalformed
+	}
+	return b, nil
+}
+
+// copyScan copies the entropy-coded data following a start of scan, and
+// returns the marker ending it. Stuffed bytes and restart markers are part
+// of the data.
+func copyScan(w *bufio.Writer, r *bufio.Reader) (byte, error) {
+	for {
+		data, err := r.ReadSlice(0xff)
+		if err == bufio.ErrBufferFull {
+			w.Write(data)
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		w.Write(data[:len(data)-1])
+
+		b, err := r.ReadByte()
+		for err == nil && b == 0xff {
+			b, err = r.ReadByte()
+		}
+		if err != nil {
+			return 0, err
+		}
+		if b != 0 && (b < 0xd0 || b > 0xd7) {
+			return b, nil
+		}
+		w.Write([]byte{0xff, b})
+	}
+}
+
+// orientationSegment returns an APP1 segment whose EXIF data only holds an
+// orientation
+func orientationSegment(orientation int) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, OrientationTag)
+	// a single SHORT, stored in the first bytes of the value field
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0)
+	// no next directory
+	tiff = binary.BigEndian.AppendUint32(tiff, 0)
+
+	segment := []byte{0xff, markerAPP1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(2+len(exifPrefix)+len(tiff)))
+	segment = append(segment, exifPrefix...)
+	return append(segment, tiff...)
+}
+
+// ExifOrientation reads the orientation from the first directory of the
+// TIFF structure of EXIF data, 1 when it has none
+func ExifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[offset:]))
+	for n := 0; n < entries; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != OrientationTag {
+			continue
+		}
+		// a SHORT value, stored in the first bytes of the value field
+		if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+			return v
+		}
+		return 1
+	}
+	return 1
+}
@@ -0,0 +1,57 @@
+package imagemeta
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngChunks are the ancillary chunks kept: transparency, color, physical
+// size and animation. Critical chunks are always kept.
+var pngChunks = map[string]bool{
+	"tRNS": true, "gAMA": true, "cHRM": true, "sRGB": true, "iCCP": true, "cICP": true,
+	"mDCV": true, "cLLI": true, "sBIT": true, "bKGD": true, "hIST": true, "sPLT": true,
+	"pHYs": true, "acTL": true, "fcTL": true, "fdAT": true,
+}
+
+// stripPNG drops the text, EXIF and time chunks, and any chunk it does not
+// know. Chunks are dropped whole, so the checksums of the others still hold.
+func stripPNG(w *bufio.Writer, r *bufio.Reader) error {
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, signature); err != nil {
+		return err
+	}
+	if !bytes.Equal(signature, pngSignature) {
+		return ErrMalformed
+	}
+	w.Write(signature)
+
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		if length > 1<<31-1 {
+			return ErrMalformed
+		}
+		typ := string(header[4:])
+		// the data and its checksum
+		size := int64(length) + 4
+		// critical chunks have an uppercase first letter
+		if typ[0]&0x20 == 0 || pngChunks[typ] {
+			w.Write(header[:])
+			if err := copyN(w, r, size); err != nil {
+				return err
+			}
+		} else if err := discard(r, int(size)); err != nil {
+			return err
+		}
+		if typ == "IEND" {
+			return nil
+		}
+	}
+}
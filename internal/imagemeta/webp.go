@@ -0,0 +1,72 @@
+package imagemeta
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// webpChunks are the chunks kept: the image data, its alpha channel, its
+// animation and its color profile
+var webpChunks = map[string]bool{"VP8 ": true, "VP8L": true, "VP8X": true, "ALPH": true, "ANIM": true, "ANMF": true, "ICCP": true}
+
+// VP8X flags of the dropped chunks
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// stripWebP drops the EXIF and XMP chunks and any chunk it does not know.
+// The file is read whole first, since the RIFF header holds its size.
+func stripWebP(w *bufio.Writer, r *bufio.Reader) error {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	if string(header[:4]) != "RIFF" || string(header[8:]) != "WEBP" {
+		return ErrMalformed
+	}
+	size := int64(binary.LittleEndian.Uint32(header[4:])) - 4
+	if size < 0 {
+		return ErrMalformed
+	}
+	data, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return ErrMalformed
+	}
+
+	var kept []byte
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return ErrMalformed
+		}
+		length := int64(binary.LittleEndian.Uint32(data[4:8]))
+		// chunks are padded to an even size
+		end := 8 + length + length&1
+		if end > int64(len(data)) {
+			if end-1 != int64(len(data)) {
+				return ErrMalformed
+			}
+			// a last chunk missing its padding
+			end--
+		}
+		chunk := data[:end]
+		data = data[end:]
+		if !webpChunks[string(chunk[:4])] {
+			continue
+		}
+		if string(chunk[:4]) == "VP8X" && length > 0 {
+			chunk[8] &^= webpFlagEXIF | webpFlagXMP
+		}
+		kept = append(kept, chunk...)
+	}
+
+	w.WriteString("RIFF")
+	binary.Write(w, binary.LittleEndian, uint32(4+len(kept)))
+	w.WriteString("WEBP")
+	w.Write(kept)
+	return nil
+}
@@ -0,0 +1,36 @@
+package imaging
+
+import (
+	"blog-posts-api/internal/imagemeta"
+	"bytes"
+	"encoding/binary"
+)
+
+// orientation returns the EXIF orientation of a JPEG image, 1 when it has
+// none or is not a JPEG image
+func orientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	// walk the segments preceding the image data
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xda || marker == 0xd9 {
+			// start of scan or end of image
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return imagemeta.ExifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
@@ -0,0 +1,154 @@
+// Package imaging resizes the images attached to posts into variants fit
+// for web pages, e.g. thumbnails, in a bounded pool of workers.
+//
+// Variants are decoded and encoded again, so they carry none of the
+// metadata of the original, EXIF data included; its orientation is applied
+// to the pixels first. Opaque images are encoded as JPEG and the others as
+// PNG, keeping their transparency.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+
+	// decoders of the other processed types
+	_ "golang.org/x/image/webp"
+	_ "image/gif"
+)
+
+// Types are the content types of the images that can be processed
+var Types = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// DefaultMaxPixels bounds the size of the images decoded, which take 4
+// bytes per pixel in memory
+const DefaultMaxPixels = 25_000_000
+
+// jpegQuality is the quality of the JPEG variants
+const jpegQuality = 85
+
+// ErrTooLarge is returned for images of more than the maximum number of
+// pixels, which are not decoded
+var ErrTooLarge = errors.New("image is too large to process")
+
+// ErrInvalidImage is returned for images that do not decode
+var ErrInvalidImage = errors.New("image could not be decoded")
+
+// Variant describes a resized copy of an image
+type Variant struct {
+	Name string
+	// Width and Height bound the size of the variant. Images are scaled
+	// down to fit in the box, never up.
+	Width, Height int
+	// Crop fills the whole box, cutting the edges of the image that do not
+	// fit, e.g. for square thumbnails
+	Crop bool
+}
+
+// DefaultVariants are the variants of every image unless configured
+var DefaultVariants = []Variant{
+	{Name: "thumbnail", Width: 200, Height: 200, Crop: true},
+	{Name: "medium", Width: 800, Height: 800},
+	{Name: "large", Width: 1600, Height: 1600},
+}
+
+// Decode decodes an image of at most maxPixels pixels and applies its EXIF
+// orientation
+func Decode(data []byte, maxPixels int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	return orient(img, orientation(data)), nil
+}
+
+// Resize returns the variant of an image
+func Resize(img image.Image, v Variant) *image.RGBA {
+	src := img.Bounds()
+	w, h := src.Dx(), src.Dy()
+
+	var scale float64
+	if v.Crop {
+		scale = max(float64(v.Width)/float64(w), float64(v.Height)/float64(h))
+	} else {
+		scale = min(float64(v.Width)/float64(w), float64(v.Height)/float64(h))
+	}
+	scale = min(scale, 1)
+
+	dw, dh := max(1, int(float64(w)*scale+0.5)), max(1, int(float64(h)*scale+0.5))
+	if v.Crop {
+		// cut the edges of the source that would fall out of the box, so
+		// that the middle of the image is kept
+		cw, ch := min(dw, v.Width), min(dh, v.Height)
+		sw, sh := int(float64(cw)/scale+0.5), int(float64(ch)/scale+0.5)
+		x0, y0 := src.Min.X+(w-sw)/2, src.Min.Y+(h-sh)/2
+		src = image.Rect(x0, y0, x0+sw, y0+sh)
+		dw, dh = cw, ch
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
+
+// Encode writes an image as JPEG, or as PNG when it has transparent
+// pixels, and returns the content type written
+func Encode(w io.Writer, img *image.RGBA) (string, error) {
+	if img.Opaque() {
+		return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	return "image/png", enc.Encode(w, img)
+}
+
+// orient applies an EXIF orientation, 1 to 8, to an image
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		// rotated by a quarter turn
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // rotated by a half turn
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated counterclockwise
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,172 @@
+package imaging
+
+import (
+	"blog-posts-api/internal/imagemeta"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func opaqueImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
+
+// withOrientation inserts an EXIF segment with an orientation after the
+// start of a JPEG image
+func withOrientation(t *testing.T, img image.Image, o uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("failed to encode the image: %v", err)
+	}
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, imagemeta.OrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, o)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	data := []byte{0xff, 0xd8, 0xff, 0xe1}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(segment)+2))
+	data = append(data, segment...)
+	return append(data, buf.Bytes()[2:]...)
+}
+
+func TestResize(t *testing.T) {
+	cases := []struct {
+		name    string
+		w, h    int
+		variant Variant
+		want    image.Point
+	}{
+		{name: "fits in the box", w: 1000, h: 500, variant: Variant{Width: 800, Height: 800}, want: image.Pt(800, 400)},
+		{name: "portrait", w: 500, h: 1000, variant: Variant{Width: 800, Height: 800}, want: image.Pt(400, 800)},
+		{name: "never upscales", w: 300, h: 100, variant: Variant{Width: 800, Height: 800}, want: image.Pt(300, 100)},
+		{name: "crops to the box", w: 1000, h: 500, variant: Variant{Width: 200, Height: 200, Crop: true}, want: image.Pt(200, 200)},
+		{name: "crops small images", w: 300, h: 100, variant: Variant{Width: 200, Height: 200, Crop: true}, want: image.Pt(200, 100)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Resize(opaqueImage(tc.w, tc.h), tc.variant).Bounds().Size()
+			if got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestResize_KeepsTheMiddle(t *testing.T) {
+	// a red square between two blue bands
+	img := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.RGBA{B: 0xff, A: 0xff}
+			if x >= 100 && x < 200 {
+				c = color.RGBA{R: 0xff, A: 0xff}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	thumbnail := Resize(img, Variant{Width: 50, Height: 50, Crop: true})
+	for _, p := range []image.Point{{2, 2}, {47, 47}} {
+		if r, _, b, _ := thumbnail.At(p.X, p.Y).RGBA(); r < 0xf000 || b > 0x1000 {
+			t.Errorf("expected the middle of the image at %v, got %v", p, thumbnail.At(p.X, p.Y))
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+	contentType, err := Encode(&buf, opaqueImage(10, 10))
+	if err != nil || contentType != "image/jpeg" {
+		t.Fatalf("expected an opaque image to be encoded as JPEG, got %q: %v", contentType, err)
+	}
+	if _, err := jpeg.Decode(&buf); err != nil {
+		t.Errorf("expected a JPEG image, got %v", err)
+	}
+
+	buf.Reset()
+	contentType, err = Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	if err != nil || contentType != "image/png" {
+		t.Fatalf("expected a transparent image to be encoded as PNG, got %q: %v", contentType, err)
+	}
+	if _, err := png.Decode(&buf); err != nil {
+		t.Errorf("expected a PNG image, got %v", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, opaqueImage(100, 50))
+
+	if _, err := Decode(buf.Bytes(), 100*50); err != nil {
+		t.Errorf("expected the image to decode, got %v", err)
+	}
+	if _, err := Decode(buf.Bytes(), 100*50-1); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	if _, err := Decode(buf.Bytes()[:40], DefaultMaxPixels); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("expected ErrInvalidImage for a truncated image, got %v", err)
+	}
+	if _, err := Decode([]byte("not an image"), DefaultMaxPixels); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("expected ErrInvalidImage, got %v", err)
+	}
+}
+
+func TestDecode_AppliesTheOrientation(t *testing.T) {
+	// the left half is black, the right half white
+	img := opaqueImage(64, 32)
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			img.Set(x, y, color.Black)
+		}
+	}
+
+	data := withOrientation(t, img, 6)
+	if got := orientation(data); got != 6 {
+		t.Fatalf("expected orientation 6, got %d", got)
+	}
+	decoded, err := Decode(data, DefaultMaxPixels)
+	if err != nil {
+		t.Fatalf("failed to decode the image: %v", err)
+	}
+	if size := decoded.Bounds().Size(); size != image.Pt(32, 64) {
+		t.Fatalf("expected the image to be rotated, got %v", size)
+	}
+	// rotated clockwise, the left half is at the top
+	if r, _, _, _ := decoded.At(16, 8).RGBA(); r > 0x2000 {
+		t.Errorf("expected the top to be black, got %v", decoded.At(16, 8))
+	}
+	if r, _, _, _ := decoded.At(16, 56).RGBA(); r < 0xe000 {
+		t.Errorf("expected the bottom to be white, got %v", decoded.At(16, 56))
+	}
+}
+
+func TestOrientation_Malformed(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, opaqueImage(4, 4), nil)
+	valid := withOrientation(t, opaqueImage(4, 4), 3)
+
+	cases := map[string][]byte{
+		"no EXIF data":      buf.Bytes(),
+		"not a JPEG image":  []byte("GIF89a"),
+		"truncated segment": valid[:20],
+		"out of range":      withOrientation(t, opaqueImage(4, 4), 9),
+	}
+	for name, data := range cases {
+		if got := orientation(data); got != 1 {
+			t.Errorf("%s: expected orientation 1, got %d", name, got)
+		}
+	}
+}
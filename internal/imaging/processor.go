@@ -0,0 +1,170 @@
+package imaging
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/health"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// pollInterval is how often the loop beats while no image is queued
+const pollInterval = time.Second
+
+// Options tune a Processor
+type Options struct {
+	// Workers is the number of images processed concurrently
+	Workers int
+	// Variants are the variants of every image, DefaultVariants when empty
+	Variants []Variant
+	// MaxPixels bounds the size of the images processed, larger images
+	// fail. DefaultMaxPixels when zero.
+	MaxPixels int
+	// Heartbeat, when set, is beaten on every iteration of the loop
+	Heartbeat *health.Heartbeat
+}
+
+// Processor resizes the images queued by an AttachmentService into their
+// variants, in the background
+type Processor struct {
+	service *services.AttachmentService
+	opts    Options
+
+	mu       sync.Mutex
+	inflight int
+	// done wakes the loop when a worker is free again
+	done chan struct{}
+}
+
+func NewProcessor(service *services.AttachmentService, opts Options) *Processor {
+	if opts.Workers <= 0 {
+		opts.Workers = 2
+	}
+	if len(opts.Variants) == 0 {
+		opts.Variants = DefaultVariants
+	}
+	if opts.MaxPixels <= 0 {
+		opts.MaxPixels = DefaultMaxPixels
+	}
+	return &Processor{service: service, opts: opts, done: make(chan struct{}, 1)}
+}
+
+// Run processes the queued images until ctx is canceled, then waits for
+// the images in flight. The images left pending by a previous run are
+// queued again first.
+func (p *Processor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	if err := p.service.RequeuePending(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("imaging: failed to queue the pending images: %v", err)
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if p.opts.Heartbeat != nil {
+			p.opts.Heartbeat.Beat()
+		}
+		p.dispatch(ctx, &wg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.service.QueuedImages():
+		case <-p.done:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch starts a worker for every queued image, up to the number of
+// free workers
+func (p *Processor) dispatch(ctx context.Context, wg *sync.WaitGroup) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, job := range p.service.NextImages(p.opts.Workers - p.inflight) {
+		p.inflight++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// images in flight are finished on shutdown, rather than left
+			// pending until the next run
+			p.Process(context.WithoutCancel(ctx), job)
+			p.mu.Lock()
+			p.inflight--
+			p.mu.Unlock()
+			select {
+			case p.done <- struct{}{}:
+			default:
+			}
+		}()
+	}
+}
+
+// errProcessing is recorded on the attachments of the images that failed
+// for other reasons than their content, which are logged
+var errProcessing = errors.New("image could not be processed")
+
+// Process creates the variants of an image and records them, or why they
+// could not be created, on its attachment
+func (p *Processor) Process(ctx context.Context, job services.ImageJob) {
+	variants, procErr := p.variants(ctx, job)
+	if apperrors.Is(procErr, apperrors.KindNotFound) {
+		// deleted meanwhile
+		return
+	}
+	if procErr != nil {
+		log.Printf("imaging: failed to process attachment %s of post %s: %v", job.AttachmentID, job.PostID, procErr)
+		if !errors.Is(procErr, ErrTooLarge) && !errors.Is(procErr, ErrInvalidImage) {
+			procErr = errProcessing
+		}
+	}
+	err := p.service.FinishProcessing(ctx, job, variants, procErr)
+	if err != nil && !apperrors.Is(err, apperrors.KindNotFound) {
+		log.Printf("imaging: failed to record the variants of attachment %s of post %s: %v", job.AttachmentID, job.PostID, err)
+	}
+}
+
+// variants creates the variants of an image, returning those stored so far
+// on failure
+func (p *Processor) variants(ctx context.Context, job services.ImageJob) ([]models.AttachmentVariant, error) {
+	_, file, err := p.service.Open(ctx, job.PostID, job.AttachmentID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the image: %w", err)
+	}
+
+	img, err := Decode(data, p.opts.MaxPixels)
+	if err != nil {
+		return nil, err
+	}
+	variants := make([]models.AttachmentVariant, 0, len(p.opts.Variants))
+	var buf bytes.Buffer
+	for _, v := range p.opts.Variants {
+		resized := Resize(img, v)
+		buf.Reset()
+		contentType, err := Encode(&buf, resized)
+		if err != nil {
+			return variants, fmt.Errorf("failed to encode the %s variant: %w", v.Name, err)
+		}
+		size := resized.Bounds().Size()
+		variant, err := p.service.StoreVariant(ctx, job, v.Name, contentType, size.X, size.Y, &buf)
+		if err != nil {
+			return variants, err
+		}
+		variants = append(variants, *variant)
+	}
+	return variants, nil
+}
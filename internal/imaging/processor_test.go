@@ -0,0 +1,179 @@
+package imaging
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/blob"
+	"bytes"
+	"context"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestService(t *testing.T) (*services.BlogPostService, *services.AttachmentService, string) {
+	t.Helper()
+	posts := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	dir := t.TempDir()
+	store, err := blob.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create the store: %v", err)
+	}
+	attachments := services.NewAttachmentService(posts, store, services.AttachmentOptions{
+		MaxBytes:   1 << 20,
+		URLPrefix:  "/api/v1/posts",
+		ImageTypes: Types,
+	})
+	if _, err := posts.Create(context.Background(), &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"}); err != nil {
+		t.Fatalf("failed to create the post: %v", err)
+	}
+	return posts, attachments, dir
+}
+
+func pngContent(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, opaqueImage(w, h)); err != nil {
+		t.Fatalf("failed to encode the image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// countFiles returns the number of files in the store
+func countFiles(t *testing.T, dir string) int {
+	t.Helper()
+	n := 0
+	filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+func TestProcessor_Run(t *testing.T) {
+	_, attachments, _ := newTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		NewProcessor(attachments, Options{Workers: 1}).Run(ctx)
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	attachment, err := attachments.Upload(ctx, "1", "photo.png", bytes.NewReader(pngContent(t, 1000, 500)))
+	if err != nil {
+		t.Fatalf("failed to upload the image: %v", err)
+	}
+	if attachment.Processing != models.ProcessingPending {
+		t.Fatalf("expected the image to be pending, got %q", attachment.Processing)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for attachment.Processing == models.ProcessingPending && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		attachment, _ = attachments.Get(ctx, "1", attachment.ID)
+	}
+	if attachment.Processing != models.ProcessingSucceeded {
+		t.Fatalf("expected the image to be processed, got %+v", attachment)
+	}
+	var got []string
+	for _, v := range attachment.Variants {
+		got = append(got, v.Name)
+		if v.ContentType != "image/jpeg" || v.Width > 1000 || !strings.HasSuffix(v.URL, "/variants/"+v.Name) {
+			t.Errorf("unexpected variant %+v", v)
+		}
+	}
+	if strings.Join(got, ",") != "thumbnail,medium,large" {
+		t.Errorf("expected the default variants, got %v", got)
+	}
+}
+
+func TestProcessor_Failures(t *testing.T) {
+	_, attachments, dir := newTestService(t)
+	ctx := context.Background()
+	processor := NewProcessor(attachments, Options{MaxPixels: 100 * 100})
+
+	large, _ := attachments.Upload(ctx, "1", "large.png", bytes.NewReader(pngContent(t, 200, 200)))
+	corrupted := pngContent(t, 50, 50)
+	// flip a byte of the pixel data, whose checksum no longer holds
+	corrupted[bytes.Index(corrupted, []byte("IDAT"))+8] ^= 0xff
+	invalid, _ := attachments.Upload(ctx, "1", "corrupted.png", bytes.NewReader(corrupted))
+	for _, job := range attachments.NextImages(10) {
+		processor.Process(ctx, job)
+	}
+
+	for _, tc := range []struct {
+		attachment *models.Attachment
+		want       error
+	}{
+		{large, ErrTooLarge},
+		{invalid, ErrInvalidImage},
+	} {
+		got, _ := attachments.Get(ctx, "1", tc.attachment.ID)
+		if got.Processing != models.ProcessingFailed || !strings.HasPrefix(got.ProcessingError, tc.want.Error()) || len(got.Variants) != 0 {
+			t.Errorf("expected %s to fail with %q, got %+v", got.Filename, tc.want, got)
+		}
+	}
+	if n := countFiles(t, dir); n != 2 {
+		t.Errorf("expected only the originals to be stored, got %d files", n)
+	}
+}
+
+func TestProcessor_DeletedAttachment(t *testing.T) {
+	_, attachments, dir := newTestService(t)
+	ctx := context.Background()
+	attachment, _ := attachments.Upload(ctx, "1", "photo.png", bytes.NewReader(pngContent(t, 300, 300)))
+	jobs := attachments.NextImages(10)
+	if len(jobs) != 1 {
+		t.Fatalf("expected the image to be queued, got %v", jobs)
+	}
+
+	// deleted while its variants were created
+	variant, err := attachments.StoreVariant(ctx, jobs[0], "thumbnail", "image/jpeg", 1, 1, strings.NewReader("jpeg"))
+	if err != nil {
+		t.Fatalf("failed to store the variant: %v", err)
+	}
+	if err := attachments.Delete(ctx, "1", attachment.ID); err != nil {
+		t.Fatalf("failed to delete the attachment: %v", err)
+	}
+	err = attachments.FinishProcessing(ctx, jobs[0], []models.AttachmentVariant{*variant}, nil)
+	if !apperrors.Is(err, apperrors.KindNotFound) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if n := countFiles(t, dir); n != 0 {
+		t.Errorf("expected the variants to be removed, got %d files", n)
+	}
+
+	// and before it was processed
+	NewProcessor(attachments, Options{}).Process(ctx, jobs[0])
+	if n := countFiles(t, dir); n != 0 {
+		t.Errorf("expected no variant to be stored, got %d files", n)
+	}
+}
+
+func TestProcessor_RequeuesPendingImages(t *testing.T) {
+	_, attachments, _ := newTestService(t)
+	ctx := context.Background()
+	attachment, _ := attachments.Upload(ctx, "1", "photo.png", bytes.NewReader(pngContent(t, 10, 10)))
+	// the queue of a previous run is lost
+	attachments.NextImages(10)
+
+	if err := attachments.RequeuePending(ctx); err != nil {
+		t.Fatalf("failed to queue the pending images: %v", err)
+	}
+	attachments.RequeuePending(ctx)
+	jobs := attachments.NextImages(10)
+	want := services.ImageJob{PostID: "1", AttachmentID: attachment.ID}
+	if len(jobs) != 1 || jobs[0] != want {
+		t.Errorf("expected %v to be queued once, got %v", want, jobs)
+	}
+}
@@ -0,0 +1,59 @@
+// Package importer holds what the content importers share: loading a
+// converted post through the blog post service and reporting the outcome.
+// The importers themselves live in sub-packages, one per source format.
+package importer
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"context"
+)
+
+// Options tune an import
+type Options struct {
+	services.ImportOptions
+	// Author is used for the posts without an author
+	Author string
+}
+
+// Result reports what happened to an imported item, Outcome is one of the
+// models.Import* values
+type Result struct {
+	// Source locates the item in the imported content, e.g. a file path
+	Source  string
+	ID      string
+	Slug    string
+	Outcome string
+	Err     error
+	// Note explains a skipped item or content left out of the post
+	Note string
+}
+
+// Load imports a converted post through the service, with the same
+// validation as the API
+func Load(ctx context.Context, service *services.BlogPostService, source string, post *models.BlogPost, opts Options) Result {
+	result := Result{Source: source, ID: post.ID}
+	if post.Author == "" {
+		post.Author = opts.Author
+	}
+
+	outcome, err := service.Import(ctx, post, opts.ImportOptions)
+	result.ID, result.Slug = post.ID, post.Slug
+	if err != nil {
+		return Failed(result, err)
+	}
+	result.Outcome = outcome
+	return result
+}
+
+// Failed marks the result as invalid for content errors and as failed for
+// the others
+func Failed(result Result, err error) Result {
+	result.Err = err
+	result.Outcome = models.ImportFailed
+	if apperrors.Is(err, apperrors.KindValidation) || apperrors.Is(err, apperrors.KindBadRequest) {
+		result.Outcome = models.ImportInvalid
+	}
+	return result
+}
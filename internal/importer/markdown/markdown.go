@@ -0,0 +1,225 @@
+// Package markdown converts Markdown files with front matter, as written
+// for Hugo and Jekyll, into blog posts.
+//
+// YAML front matter is delimited by "---" lines and TOML front matter by
+// "+++" lines. The recognized keys are title, author (or the first of
+// authors), date, tags (merged with categories), draft (or published: false)
+// and slug. Jekyll file names such as 2020-01-02-my-post.md provide the
+// date and slug when the front matter does not, as do the directories of
+// Hugo page bundles such as my-post/index.md.
+package markdown
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// namespace of the IDs derived from file paths, so that re-running an
+// import over the same directory targets the same posts
+var namespace = uuid.MustParse("6f1f4d2e-54a4-4c41-9bb3-7a0f6c1b8e0a")
+
+var jekyllName = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+
+// maxTags mirrors the limit of the API
+const maxTags = 10
+
+// dateLayouts are tried in order for dates given as strings
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Parse converts a Markdown file into a blog post. path is the path of the
+// file relative to the imported directory; it names the post ID, so that
+// the same file always maps to the same post. Fields missing from the front
+// matter are left empty for validation to report. notes list the content
+// left out of the post.
+func Parse(path string, data []byte) (post *models.BlogPost, notes []string, err error) {
+	meta, body, err := splitFrontMatter(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	post = &models.BlogPost{
+		ID:            uuid.NewSHA1(namespace, []byte(filepath.ToSlash(path))).String(),
+		Content:       strings.TrimSpace(string(body)),
+		ContentFormat: models.ContentFormatMarkdown,
+		Status:        models.StatusPublished,
+	}
+
+	post.Title = stringValue(meta["title"])
+	post.Author = stringValue(meta["author"])
+	if post.Author == "" {
+		if authors := stringList(meta["authors"]); len(authors) > 0 {
+			post.Author = authors[0]
+		}
+	}
+	// categories become tags too, the ones beyond the limit of the API
+	// are left out rather than failing the whole post
+	var dropped []string
+	for _, tag := range append(stringList(meta["tags"]), stringList(meta["categories"])...) {
+		tag = strings.TrimSpace(tag)
+		switch {
+		case tag == "" || containsFold(post.Tags, tag):
+		case len([]rune(tag)) > 50 || len(post.Tags) == maxTags:
+			dropped = append(dropped, tag)
+		default:
+			post.Tags = append(post.Tags, tag)
+		}
+	}
+	if len(dropped) > 0 {
+		notes = append(notes, "tags not imported: "+strings.Join(dropped, ", "))
+	}
+	// e.g. my_post in Jekyll, which the slug rule rejects
+	post.Slug = services.Slugify(stringValue(meta["slug"]))
+
+	if draft, ok := meta["draft"].(bool); ok && draft {
+		post.Status = models.StatusDraft
+	}
+	if published, ok := meta["published"].(bool); ok && !published {
+		post.Status = models.StatusDraft
+	}
+
+	if raw, ok := meta["date"]; ok {
+		date, err := timeValue(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid date: %w", err)
+		}
+		post.CreatedAt = date
+	}
+
+	// Jekyll posts carry their date and slug in the file name
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if m := jekyllName.FindStringSubmatch(name); m != nil {
+		if post.CreatedAt.IsZero() {
+			post.CreatedAt, _ = time.Parse("2006-01-02", m[1])
+		}
+		name = m[2]
+	}
+	// Hugo page bundles are named after their directory, e.g. my-post/index.md
+	if name == "index" {
+		name = filepath.Base(filepath.Dir(path))
+	}
+	if post.Slug == "" && name != "." {
+		post.Slug = services.Slugify(name)
+	}
+
+	return post, notes, nil
+}
+
+// splitFrontMatter returns the decoded front matter and the rest of the file
+func splitFrontMatter(data []byte) (map[string]any, []byte, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	var delim string
+	switch {
+	case bytes.HasPrefix(data, []byte("---\n")):
+		delim = "---"
+	case bytes.HasPrefix(data, []byte("+++\n")):
+		delim = "+++"
+	default:
+		return nil, nil, errors.New("missing front matter")
+	}
+
+	rest := data[len(delim)+1:]
+	var raw, body []byte
+	if bytes.HasPrefix(rest, []byte(delim+"\n")) {
+		// empty front matter
+		body = rest[len(delim)+1:]
+	} else {
+		end := bytes.Index(rest, []byte("\n"+delim+"\n"))
+		if end < 0 {
+			if !bytes.HasSuffix(rest, []byte("\n"+delim)) {
+				return nil, nil, errors.New("unterminated front matter")
+			}
+			end = len(rest) - len(delim) - 1
+		}
+		raw = rest[:end]
+		body = rest[min(end+len(delim)+2, len(rest)):]
+	}
+
+	meta := map[string]any{}
+	var err error
+	if delim == "---" {
+		err = yaml.Unmarshal(raw, &meta)
+	} else {
+		err = toml.Unmarshal(raw, &meta)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid front matter: %w", err)
+	}
+	return meta, body, nil
+}
+
+func stringValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// stringList accepts both lists and a single comma or space separated
+// string, as Jekyll does for tags
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			out = append(out, stringValue(item))
+		}
+		return out
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	default:
+		return nil
+	}
+}
+
+func containsFold(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func timeValue(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC(), nil
+	case toml.LocalDate:
+		return v.AsTime(time.UTC), nil
+	case toml.LocalDateTime:
+		return v.AsTime(time.UTC), nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unsupported date format %q", v)
+	default:
+		return time.Time{}, fmt.Errorf("unsupported date value %v", v)
+	}
+}
@@ -0,0 +1,182 @@
+package markdown
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/importer"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_YAML(t *testing.T) {
+	data := "---\ntitle: Hello, World\nauthors: [John Doe, Jane Smith]\ndate: 2020-01-02 10:30:00 +0200\ntags: go intro\ncategories: [News]\n---\n# Hi\r\n\r\nFirst post.\n"
+
+	post, _, err := Parse("posts/hello.md", []byte(data))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if post.Title != "Hello, World" || post.Author != "John Doe" {
+		t.Errorf("unexpected title or author %q %q", post.Title, post.Author)
+	}
+	if strings.Join(post.Tags, ",") != "go,intro,News" {
+		t.Errorf("expected tags and categories, got %v", post.Tags)
+	}
+	if !post.CreatedAt.Equal(time.Date(2020, 1, 2, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %s", post.CreatedAt)
+	}
+	if post.Content != "# Hi\n\nFirst post." || post.ContentFormat != models.ContentFormatMarkdown {
+		t.Errorf("unexpected content %q", post.Content)
+	}
+	if post.Slug != "hello" || post.Status != models.StatusPublished {
+		t.Errorf("unexpected slug or status %q %q", post.Slug, post.Status)
+	}
+}
+
+func TestParse_TagLimit(t *testing.T) {
+	data := "---\ntitle: Tags\nauthor: Jane Smith\ntags: [a, b, c, d, e, f, g, h]\ncategories: [A, i, j, k, l]\n---\nHi"
+
+	post, notes, err := Parse("tags.md", []byte(data))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if strings.Join(post.Tags, ",") != "a,b,c,d,e,f,g,h,i,j" {
+		t.Errorf("expected the first 10 distinct tags, got %v", post.Tags)
+	}
+	if len(notes) != 1 || notes[0] != "tags not imported: k, l" {
+		t.Errorf("expected a note about the dropped tags, got %v", notes)
+	}
+	if err := services.ValidateCreate(post); err != nil {
+		t.Errorf("expected a valid post, got %v", err)
+	}
+}
+
+func TestParse_TOML(t *testing.T) {
+	data := "+++\ntitle = \"TOML post\"\nauthor = \"Jane Smith\"\ndate = 2021-03-04\ndraft = true\nslug = \"custom\"\n+++\nBody"
+
+	post, _, err := Parse("toml.md", []byte(data))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if post.Status != models.StatusDraft || post.Slug != "custom" {
+		t.Errorf("unexpected status or slug %q %q", post.Status, post.Slug)
+	}
+	if !post.CreatedAt.Equal(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %s", post.CreatedAt)
+	}
+}
+
+func TestParse_JekyllFileName(t *testing.T) {
+	post, _, err := Parse("_posts/2019-05-06-My Post.md", []byte("---\ntitle: Old\npublished: false\n---\n"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if post.Slug != "my-post" || post.Status != models.StatusDraft {
+		t.Errorf("unexpected slug or status %q %q", post.Slug, post.Status)
+	}
+	if !post.CreatedAt.Equal(time.Date(2019, 5, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the date of the file name, got %s", post.CreatedAt)
+	}
+}
+
+func TestParse_Slugs(t *testing.T) {
+	for path, want := range map[string]string{
+		"my-bundle/index.md":        "my-bundle",
+		"posts/My Bundle/index.md":  "my-bundle",
+		"index.md":                  "",
+		"_posts/2019-05-06-post.md": "my-post",
+	} {
+		data := "---\ntitle: Test\n---\n"
+		if strings.HasPrefix(path, "_posts") {
+			// front matter slugs are made valid too
+			data = "---\ntitle: Test\nslug: My_Post\n---\n"
+		}
+		post, _, err := Parse(path, []byte(data))
+		if err != nil || post.Slug != want {
+			t.Errorf("%s: expected slug %q, got %q and %v", path, want, post.Slug, err)
+		}
+	}
+}
+
+func TestParse_StableIDs(t *testing.T) {
+	data := []byte("---\ntitle: Test\n---\n")
+	a, _, _ := Parse("a.md", data)
+	again, _, _ := Parse("a.md", data)
+	b, _, _ := Parse("b.md", data)
+
+	if a.ID != again.ID || a.ID == b.ID {
+		t.Errorf("expected IDs derived from the path, got %s %s %s", a.ID, again.ID, b.ID)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for name, data := range map[string]string{
+		"missing":      "# no front matter",
+		"unterminated": "---\ntitle: Test\n",
+		"invalid":      "---\ntitle: [broken\n---\n",
+		"bad date":     "---\ndate: yesterday\n---\n",
+	} {
+		if _, _, err := Parse("post.md", []byte(data)); err == nil {
+			t.Errorf("expected an error for %s front matter", name)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"2020-01-02-hello.md": "---\ntitle: Hello\n---\nHi",
+		"nested/other.md":     "---\ntitle: Other\nauthor: Jane Smith\n---\nHi",
+		"invalid.md":          "---\nauthor: Jane Smith\n---\nNo title",
+		"_index.md":           "---\ntitle: Section\n---\n",
+		".drafts/hidden.md":   "---\ntitle: Hidden\n---\n",
+		"notes.txt":           "not markdown",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	opts := importer.Options{ImportOptions: services.ImportOptions{OnConflict: services.ConflictSkip}, Author: "John Doe"}
+	run := func() map[string]string {
+		outcomes := map[string]string{}
+		err := Import(context.Background(), service, root, opts, func(r importer.Result) {
+			outcomes[filepath.ToSlash(r.Source)] = r.Outcome
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return outcomes
+	}
+
+	outcomes := run()
+	expected := map[string]string{
+		"2020-01-02-hello.md": models.ImportCreated,
+		"nested/other.md":     models.ImportCreated,
+		"invalid.md":          models.ImportInvalid,
+	}
+	if len(outcomes) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, outcomes)
+	}
+	for path, outcome := range expected {
+		if outcomes[path] != outcome {
+			t.Errorf("expected %s to be %s, got %s", path, outcome, outcomes[path])
+		}
+	}
+
+	// a second run maps the files to the same posts
+	if outcomes := run(); outcomes["2020-01-02-hello.md"] != models.ImportSkipped {
+		t.Errorf("expected a re-run to skip existing posts, got %v", outcomes)
+	}
+	if posts, _ := service.GetAll(context.Background()); len(posts) != 2 {
+		t.Errorf("expected 2 posts, got %d", len(posts))
+	}
+}
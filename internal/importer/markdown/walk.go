@@ -0,0 +1,65 @@
+package markdown
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/importer"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Import loads every Markdown file under root through the service and
+// calls report with the result of each file. Hidden entries and files
+// starting with an underscore (e.g. Hugo _index.md section pages) are
+// skipped. Only a failure to walk root is returned, every file error is
+// reported as a result.
+func Import(ctx context.Context, service *services.BlogPostService, root string, opts importer.Options, report func(importer.Result)) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !isMarkdown(name) {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		report(importFile(ctx, service, path, rel, opts))
+		return nil
+	})
+}
+
+func importFile(ctx context.Context, service *services.BlogPostService, path, rel string, opts importer.Options) importer.Result {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return importer.Result{Source: rel, Outcome: models.ImportFailed, Err: err}
+	}
+	post, notes, err := Parse(rel, data)
+	if err != nil {
+		return importer.Result{Source: rel, Outcome: models.ImportInvalid, Err: err}
+	}
+	result := importer.Load(ctx, service, rel, post, opts)
+	result.Note = strings.Join(notes, "; ")
+	return result
+}
+
+func isMarkdown(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".mdown", ".mkd":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package wxr
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/importer"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// IDMap maps WordPress post IDs to the IDs of the imported posts. Loaded
+// from a previous run, it keeps the posts of a re-run import in place even
+// when the site URL of the export changed.
+type IDMap map[string]string
+
+// Import streams the posts of the export read from r through the service
+// and calls report with the result of each item. ids, when not nil, provides
+// the IDs of the posts imported before and receives the new ones. Only an
+// unreadable export is returned, every item error is reported as a result.
+func Import(ctx context.Context, service *services.BlogPostService, r io.Reader, opts importer.Options, ids IDMap, report func(importer.Result)) error {
+	reader := NewReader(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		report(importItem(ctx, service, reader, item, opts, ids))
+	}
+}
+
+func importItem(ctx context.Context, service *services.BlogPostService, reader *Reader, item *Item, opts importer.Options, ids IDMap) importer.Result {
+	source := item.Link
+	if source == "" {
+		source = "post " + item.PostID
+	}
+
+	post, notes, err := reader.Post(item)
+	if err != nil {
+		return importer.Result{Source: source, Outcome: models.ImportInvalid, Err: err}
+	}
+	if post == nil {
+		return importer.Result{Source: source, Outcome: models.ImportSkipped, Note: strings.Join(notes, "; ")}
+	}
+
+	if id, ok := ids[item.PostID]; ok {
+		post.ID = id
+	}
+	result := importer.Load(ctx, service, source, post, opts)
+	result.Note = strings.Join(notes, "; ")
+	if ids != nil && result.Err == nil {
+		ids[item.PostID] = result.ID
+	}
+	return result
+}
+
+// ReadIDMap reads a map written by WriteIDMap
+func ReadIDMap(r io.Reader) (IDMap, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	ids := IDMap{}
+	for i, record := range records {
+		if i == 0 && record[0] == "wordpress_id" {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected wordpress_id,post_id", i+1)
+		}
+		ids[record[0]] = record[1]
+	}
+	return ids, nil
+}
+
+// WriteIDMap writes the map as CSV, with a wordpress_id,post_id header
+func WriteIDMap(w io.Writer, ids IDMap) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"wordpress_id", "post_id"}); err != nil {
+		return err
+	}
+	for _, wpID := range slices.Sorted(maps.Keys(ids)) {
+		if err := out.Write([]string{wpID, ids[wpID]}); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
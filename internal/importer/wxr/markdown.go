@@ -0,0 +1,270 @@
+package wxr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// shortcodes wrapping content that is kept, e.g. [caption]<img>text[/caption]
+	wrapperShortcodes = regexp.MustCompile(`\[/?(?:caption|wp_caption)\b[^\]]*\]`)
+	// shortcodes standing for media that cannot be converted
+	mediaShortcodes = regexp.MustCompile(`\[(?:gallery|audio|video|playlist)\b[^\]]*\](?:[^\[]*\[/(?:audio|video)\])?`)
+	embedShortcode  = regexp.MustCompile(`\[embed\b[^\]]*\]([^\[]*)\[/embed\]`)
+
+	markdownSpecial = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`)
+	blankLines      = regexp.MustCompile(`\n{3,}`)
+)
+
+// ToMarkdown converts WordPress post content to Markdown. WordPress keeps
+// paragraphs as blank lines rather than <p> elements, which Markdown reads
+// the same way. Elements without a Markdown equivalent keep their text,
+// while scripts, styles, embeds and block editor comments are dropped.
+func ToMarkdown(content string) (string, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = wrapperShortcodes.ReplaceAllString(content, "")
+	content = mediaShortcodes.ReplaceAllString(content, "")
+	content = embedShortcode.ReplaceAllString(content, "\n\n$1\n\n")
+
+	nodes, err := html.ParseFragment(strings.NewReader(content), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return "", fmt.Errorf("failed to parse content: %w", err)
+	}
+
+	var c converter
+	for _, n := range nodes {
+		c.node(n)
+	}
+	out := blankLines.ReplaceAllString(c.b.String(), "\n\n")
+	return strings.TrimSpace(out), nil
+}
+
+type converter struct {
+	b strings.Builder
+}
+
+func (c *converter) block(s string) {
+	c.b.WriteString("\n\n")
+	c.b.WriteString(s)
+	c.b.WriteString("\n\n")
+}
+
+// inline converts the children of n into a separate buffer
+func inline(n *html.Node) string {
+	var c converter
+	c.children(n)
+	return c.b.String()
+}
+
+func (c *converter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child)
+	}
+}
+
+func (c *converter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.b.WriteString(markdownSpecial.Replace(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		// comments, e.g. <!-- wp:paragraph --> block delimiters
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Iframe, atom.Object, atom.Embed, atom.Noscript:
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Figure:
+		c.block(strings.TrimSpace(inline(n)))
+	case atom.Figcaption:
+		if text := strings.TrimSpace(inline(n)); text != "" {
+			c.block("*" + text + "*")
+		}
+	case atom.Br:
+		c.b.WriteString("  \n")
+	case atom.Hr:
+		c.block("---")
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		c.block(strings.Repeat("#", level) + " " + singleLine(inline(n)))
+	case atom.Strong, atom.B:
+		c.wrap(n, "**")
+	case atom.Em, atom.I:
+		c.wrap(n, "*")
+	case atom.Del, atom.S, atom.Strike:
+		c.wrap(n, "~~")
+	case atom.Code:
+		c.b.WriteString("`" + textContent(n) + "`")
+	case atom.A:
+		text := inline(n)
+		href := attr(n, "href")
+		if href == "" {
+			c.b.WriteString(text)
+			return
+		}
+		c.b.WriteString("[" + text + "](" + escapeURL(href) + ")")
+	case atom.Img:
+		if src := attr(n, "src"); src != "" {
+			c.b.WriteString("![" + markdownSpecial.Replace(attr(n, "alt")) + "](" + escapeURL(src) + ")")
+		}
+	case atom.Pre:
+		c.block(codeBlock(n))
+	case atom.Blockquote:
+		text := blankLines.ReplaceAllString(strings.TrimSpace(inline(n)), "\n\n")
+		c.block(prefixLines(text, "> ", "> "))
+	case atom.Ul, atom.Ol:
+		c.block(list(n))
+	case atom.Table:
+		c.block(table(n))
+	default:
+		c.children(n)
+	}
+}
+
+func (c *converter) wrap(n *html.Node, marker string) {
+	text := inline(n)
+	if strings.TrimSpace(text) == "" {
+		c.b.WriteString(text)
+		return
+	}
+	c.b.WriteString(marker + text + marker)
+}
+
+func list(n *html.Node) string {
+	var items []string
+	i := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", i)
+		}
+		i++
+		text := blankLines.ReplaceAllString(strings.TrimSpace(inline(li)), "\n\n")
+		// nested content is indented under the marker
+		items = append(items, prefixLines(text, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+func table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Tr {
+			var cells []string
+			for cell := n.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					cells = append(cells, strings.ReplaceAll(singleLine(inline(cell)), "|", `\|`))
+				}
+			}
+			rows = append(rows, cells)
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			// GFM tables need a header row, the first row serves as one
+			b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// codeBlock fences the text of a <pre> element, with the language of
+// SyntaxHighlighter ("brush: go") or Prism ("language-go") classes
+func codeBlock(n *html.Node) string {
+	lang := ""
+	classes := attr(n, "class")
+	if code := n.FirstChild; code != nil && code.Type == html.ElementNode && code.DataAtom == atom.Code {
+		classes += " " + attr(code, "class")
+	}
+	for _, class := range strings.Fields(strings.ReplaceAll(classes, ";", " ")) {
+		if after, ok := strings.CutPrefix(class, "language-"); ok {
+			lang = after
+		}
+	}
+	if lang == "" {
+		if _, after, ok := strings.Cut(classes, "brush:"); ok {
+			lang = strings.Fields(strings.ReplaceAll(after, ";", " "))[0]
+		}
+	}
+
+	text := strings.Trim(textContent(n), "\n")
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + text + "\n" + fence
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Br {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func escapeURL(u string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(strings.TrimSpace(u))
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "  \n", " ")), " ")
+}
+
+func prefixLines(s, first, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line == "":
+			lines[i] = strings.TrimSpace(rest)
+		default:
+			lines[i] = rest + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,56 @@
+package wxr
+
+import "testing"
+
+func TestToMarkdown(t *testing.T) {
+	for name, tc := range map[string]struct{ html, expected string }{
+		"paragraphs": {
+			"First line\n\n<p>Second <strong>bold</strong> and <em>em</em></p>",
+			"First line\n\nSecond **bold** and *em*",
+		},
+		"block comments": {
+			"<!-- wp:paragraph -->\n<p>Hi</p>\n<!-- /wp:paragraph -->",
+			"Hi",
+		},
+		"headings and links": {
+			`<h2>Title <a href="https://example.com/a b">link</a></h2>`,
+			"## Title [link](https://example.com/a%20b)",
+		},
+		"escaping": {
+			"2 * 3 = [x] <b>_y_</b>",
+			`2 \* 3 = \[x\] **\_y\_**`,
+		},
+		"lists": {
+			"<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>",
+			"- one\n- two\n\n  1. nested",
+		},
+		"code": {
+			"<pre class=\"brush: go; gutter: false\">fmt.Println(&quot;*&quot;)\n</pre><p>Use <code>a*b</code></p>",
+			"```go\nfmt.Println(\"*\")\n```\n\nUse `a*b`",
+		},
+		"blockquote": {
+			"<blockquote><p>One</p><p>Two</p></blockquote>",
+			"> One\n>\n> Two",
+		},
+		"shortcodes": {
+			`[caption id="1"]<img src="/a.png" alt="A" /> Caption[/caption][gallery ids="1,2"][embed]https://youtu.be/x[/embed]`,
+			"![A](/a.png) Caption\n\nhttps://youtu.be/x",
+		},
+		"dropped": {
+			`<script>alert(1)</script><iframe src="x"></iframe>Text`,
+			"Text",
+		},
+		"table": {
+			"<table><tr><th>A</th><th>B</th></tr><tr><td>1|2</td></tr></table>",
+			"| A | B |\n| --- | --- |\n| 1\\|2 |  |",
+		},
+	} {
+		got, err := ToMarkdown(tc.html)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", name, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Package wxr imports WordPress eXtended RSS (WXR) exports, as written by
+// Tools > Export in the WordPress admin, into blog posts.
+//
+// The export is streamed: items are decoded and imported one at a time, so
+// the size of the file does not matter. Posts keep their author display
+// name, categories and tags (as tags), slug, dates and status, and their
+// HTML content is converted to Markdown. Pages, attachments and the other
+// item types are skipped, and comments are counted but not imported.
+package wxr
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// namespace of the IDs derived from the site URL and WordPress post ID, so
+// that re-running an import of the same site targets the same posts
+var namespace = uuid.MustParse("0c3c2f55-8a57-4d5e-9d0b-2b7e4f0d6a31")
+
+// maxTags mirrors the limit of the API
+const maxTags = 10
+
+// wordpressDate is the layout of the wp:post_date fields
+const wordpressDate = "2006-01-02 15:04:05"
+
+// Item is an <item> of the export, with the fields the import maps
+type Item struct {
+	Title      string     `xml:"title"`
+	Link       string     `xml:"link"`
+	Creator    string     `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Content    string     `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostID     string     `xml:"post_id"`
+	PostDate   string     `xml:"post_date"`
+	PostGMT    string     `xml:"post_date_gmt"`
+	PostName   string     `xml:"post_name"`
+	Status     string     `xml:"status"`
+	PostType   string     `xml:"post_type"`
+	Categories []Category `xml:"category"`
+	Comments   []Comment  `xml:"comment"`
+}
+
+// Category is a category or tag of an item
+type Category struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+// Comment is a comment of an item, only counted
+type Comment struct {
+	Approved string `xml:"comment_approved"`
+}
+
+type author struct {
+	Login       string `xml:"author_login"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+// Reader streams the items of an export. The channel elements preceding the
+// items, the site URL and the authors, are collected along the way.
+type Reader struct {
+	dec *xml.Decoder
+	// SiteURL is the base_site_url of the export
+	SiteURL string
+	authors map[string]string
+}
+
+// NewReader returns a reader of the export read from r
+func NewReader(r io.Reader) *Reader {
+	dec := xml.NewDecoder(r)
+	// exports declare UTF-8, other charsets are read as is
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	// WordPress leaves HTML entities such as &nbsp; in titles
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	return &Reader{dec: dec, authors: map[string]string{}}
+}
+
+// Next returns the next item, io.EOF once the export has no more items
+func (r *Reader) Next() (*Item, error) {
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("invalid export: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "item":
+			var item Item
+			if err := r.dec.DecodeElement(&item, &start); err != nil {
+				return nil, fmt.Errorf("invalid item: %w", err)
+			}
+			return &item, nil
+		case "author":
+			var a author
+			if err := r.dec.DecodeElement(&a, &start); err != nil {
+				return nil, fmt.Errorf("invalid author: %w", err)
+			}
+			if a.DisplayName != "" {
+				r.authors[a.Login] = a.DisplayName
+			}
+		case "base_site_url":
+			var url string
+			if err := r.dec.DecodeElement(&url, &start); err != nil {
+				return nil, fmt.Errorf("invalid site URL: %w", err)
+			}
+			r.SiteURL = strings.TrimSpace(url)
+		}
+	}
+}
+
+// Post converts an item into a blog post. It returns a nil post, with the
+// reason, for the items that are not imported: other types than posts and
+// trashed or auto-saved posts. notes list the content left out of the post.
+func (r *Reader) Post(item *Item) (post *models.BlogPost, notes []string, err error) {
+	if item.PostType != "post" {
+		return nil, []string{item.PostType + " not imported"}, nil
+	}
+	status, ok := statuses[item.Status]
+	if !ok {
+		return nil, []string{item.Status + " post not imported"}, nil
+	}
+
+	content, err := ToMarkdown(item.Content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	post = &models.BlogPost{
+		ID:            uuid.NewSHA1(namespace, []byte(r.SiteURL+"#"+item.PostID)).String(),
+		Title:         strings.TrimSpace(item.Title),
+		Content:       content,
+		ContentFormat: models.ContentFormatMarkdown,
+		Author:        item.Creator,
+		Status:        status,
+	}
+	if name, ok := r.authors[item.Creator]; ok {
+		post.Author = name
+	}
+
+	// post_name is empty for drafts and percent-encoded for non-ASCII slugs
+	post.Slug = item.PostName
+	if !validSlug(post.Slug) {
+		post.Slug = services.Slugify(post.Title)
+	}
+
+	if date, ok := parseDate(item.PostGMT); ok {
+		post.CreatedAt = date
+	} else if date, ok := parseDate(item.PostDate); ok {
+		// drafts have no GMT date, their local date is the best there is
+		post.CreatedAt = date
+	}
+	if status == models.StatusPublished && !post.CreatedAt.IsZero() {
+		publishedAt := post.CreatedAt
+		post.PublishedAt = &publishedAt
+	}
+
+	var dropped []string
+	for _, c := range item.Categories {
+		if c.Domain != "category" && c.Domain != "post_tag" {
+			continue
+		}
+		name := strings.TrimSpace(c.Name)
+		switch {
+		case name == "" || c.Nicename == "uncategorized":
+		case len([]rune(name)) > 50 || len(post.Tags) == maxTags:
+			dropped = append(dropped, name)
+		default:
+			post.Tags = appendUnique(post.Tags, name)
+		}
+	}
+	if len(dropped) > 0 {
+		notes = append(notes, "tags not imported: "+strings.Join(dropped, ", "))
+	}
+
+	if n := approved(item.Comments); n > 0 {
+		notes = append(notes, fmt.Sprintf("%d comments not imported", n))
+	}
+	return post, notes, nil
+}
+
+// statuses maps the WordPress statuses of imported posts. Scheduled,
+// pending and private posts are not public yet, so they become drafts.
+var statuses = map[string]string{
+	"publish": models.StatusPublished,
+	"future":  models.StatusDraft,
+	"draft":   models.StatusDraft,
+	"pending": models.StatusDraft,
+	"private": models.StatusDraft,
+}
+
+// parseDate reads a wp:post_date field, which is all zeros when unset
+func parseDate(s string) (time.Time, bool) {
+	t, err := time.Parse(wordpressDate, strings.TrimSpace(s))
+	if err != nil || t.Year() < 1 {
+		return time.Time{}, false
+	}
+	return t.UTC(), true
+}
+
+func validSlug(s string) bool {
+	return s != "" && services.Slugify(s) == s
+}
+
+func appendUnique(tags []string, tag string) []string {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+func approved(comments []Comment) int {
+	n := 0
+	for _, c := range comments {
+		if c.Approved == "1" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,174 @@
+package wxr
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/importer"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const export = `<?xml version="1.0" encoding="UTF-8" ?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>My Blog</title>
+	<wp:base_site_url>https://blog.example.com</wp:base_site_url>
+	<wp:author><wp:author_login><![CDATA[jdoe]]></wp:author_login><wp:author_display_name><![CDATA[John Doe]]></wp:author_display_name></wp:author>
+	<item>
+		<title>Hello&nbsp;World</title>
+		<link>https://blog.example.com/2020/01/hello-world/</link>
+		<dc:creator><![CDATA[jdoe]]></dc:creator>
+		<content:encoded><![CDATA[<!-- wp:paragraph --><p>Hello <strong>there</strong></p><!-- /wp:paragraph -->]]></content:encoded>
+		<excerpt:encoded xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"><![CDATA[Excerpt]]></excerpt:encoded>
+		<wp:post_id>12</wp:post_id>
+		<wp:post_date><![CDATA[2020-01-02 12:00:00]]></wp:post_date>
+		<wp:post_date_gmt><![CDATA[2020-01-02 10:00:00]]></wp:post_date_gmt>
+		<wp:post_name><![CDATA[hello-world]]></wp:post_name>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<category domain="category" nicename="uncategorized"><![CDATA[Uncategorized]]></category>
+		<category domain="category" nicename="news"><![CDATA[News]]></category>
+		<category domain="post_tag" nicename="go"><![CDATA[Go]]></category>
+		<category domain="post_format" nicename="post-format-aside"><![CDATA[Aside]]></category>
+		<wp:comment><wp:comment_id>1</wp:comment_id><wp:comment_approved><![CDATA[1]]></wp:comment_approved></wp:comment>
+		<wp:comment><wp:comment_id>2</wp:comment_id><wp:comment_approved><![CDATA[spam]]></wp:comment_approved></wp:comment>
+	</item>
+	<item>
+		<title>Work in progress</title>
+		<dc:creator><![CDATA[someone]]></dc:creator>
+		<content:encoded><![CDATA[Draft text]]></content:encoded>
+		<wp:post_id>13</wp:post_id>
+		<wp:post_date><![CDATA[2021-05-06 08:00:00]]></wp:post_date>
+		<wp:post_date_gmt><![CDATA[0000-00-00 00:00:00]]></wp:post_date_gmt>
+		<wp:post_name><![CDATA[]]></wp:post_name>
+		<wp:status><![CDATA[draft]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+	</item>
+	<item>
+		<title>About</title>
+		<link>https://blog.example.com/about/</link>
+		<wp:post_id>14</wp:post_id>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[page]]></wp:post_type>
+	</item>
+	<item>
+		<title>Empty</title>
+		<link>https://blog.example.com/empty/</link>
+		<dc:creator><![CDATA[jdoe]]></dc:creator>
+		<content:encoded><![CDATA[]]></content:encoded>
+		<wp:post_id>15</wp:post_id>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+	</item>
+</channel>
+</rss>`
+
+func TestReader(t *testing.T) {
+	r := NewReader(strings.NewReader(export))
+	item, err := r.Next()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if r.SiteURL != "https://blog.example.com" {
+		t.Errorf("unexpected site URL %q", r.SiteURL)
+	}
+
+	post, notes, err := r.Post(item)
+	if err != nil || post == nil {
+		t.Fatalf("expected a post, got %v %v", post, err)
+	}
+	if post.Title != "Hello World" || post.Author != "John Doe" || post.Slug != "hello-world" {
+		t.Errorf("unexpected title, author or slug %q %q %q", post.Title, post.Author, post.Slug)
+	}
+	if post.Content != "Hello **there**" || post.ContentFormat != models.ContentFormatMarkdown {
+		t.Errorf("unexpected content %q", post.Content)
+	}
+	if strings.Join(post.Tags, ",") != "News,Go" {
+		t.Errorf("expected the categories and tags, got %v", post.Tags)
+	}
+	created := time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)
+	if !post.CreatedAt.Equal(created) || post.PublishedAt == nil || !post.PublishedAt.Equal(created) {
+		t.Errorf("expected the GMT date, got %s %v", post.CreatedAt, post.PublishedAt)
+	}
+	if len(notes) != 1 || notes[0] != "1 comments not imported" {
+		t.Errorf("expected a note about the approved comment, got %v", notes)
+	}
+
+	item, _ = r.Next()
+	post, _, _ = r.Post(item)
+	if post.Status != models.StatusDraft || post.Slug != "work-in-progress" || post.Author != "someone" {
+		t.Errorf("unexpected draft %q %q %q", post.Status, post.Slug, post.Author)
+	}
+	if !post.CreatedAt.Equal(time.Date(2021, 5, 6, 8, 0, 0, 0, time.UTC)) || post.PublishedAt != nil {
+		t.Errorf("expected the local date of the draft, got %s %v", post.CreatedAt, post.PublishedAt)
+	}
+
+	item, _ = r.Next()
+	if post, notes, _ := r.Post(item); post != nil || notes[0] != "page not imported" {
+		t.Errorf("expected pages to be skipped, got %v %v", post, notes)
+	}
+}
+
+func TestReader_InvalidExport(t *testing.T) {
+	r := NewReader(strings.NewReader("<rss><channel><item><title>Truncated"))
+	if _, err := r.Next(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestImport(t *testing.T) {
+	service := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	opts := importer.Options{ImportOptions: services.ImportOptions{OnConflict: services.ConflictSkip}}
+	run := func(ids IDMap) map[string]importer.Result {
+		results := map[string]importer.Result{}
+		err := Import(context.Background(), service, strings.NewReader(export), opts, ids, func(r importer.Result) {
+			results[r.Source] = r
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return results
+	}
+
+	ids := IDMap{}
+	results := run(ids)
+	expected := map[string]string{
+		"https://blog.example.com/2020/01/hello-world/": models.ImportCreated,
+		"post 13":                         models.ImportCreated,
+		"https://blog.example.com/about/": models.ImportSkipped,
+		"https://blog.example.com/empty/": models.ImportInvalid,
+	}
+	for source, outcome := range expected {
+		if results[source].Outcome != outcome {
+			t.Errorf("expected %s to be %s, got %+v", source, outcome, results[source])
+		}
+	}
+	if len(ids) != 2 || ids["12"] != results["https://blog.example.com/2020/01/hello-world/"].ID {
+		t.Errorf("expected the IDs of the imported posts, got %v", ids)
+	}
+
+	// the map survives a round trip and keeps re-runs on the same posts
+	var buf bytes.Buffer
+	if err := WriteIDMap(&buf, ids); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "wordpress_id,post_id\n12,"+ids["12"]+"\n13,"+ids["13"]+"\n" {
+		t.Errorf("unexpected map %q", buf.String())
+	}
+	read, err := ReadIDMap(&buf)
+	if err != nil || len(read) != 2 || read["13"] != ids["13"] {
+		t.Fatalf("expected the written map, got %v %v", read, err)
+	}
+	if results := run(read); results["post 13"].Outcome != models.ImportSkipped {
+		t.Errorf("expected a re-run to skip existing posts, got %+v", results["post 13"])
+	}
+	if posts, _ := service.GetAll(context.Background()); len(posts) != 2 {
+		t.Errorf("expected 2 posts, got %d", len(posts))
+	}
+}
@@ -0,0 +1,77 @@
+// Package mail sends the emails of the application, such as the links
+// verifying email addresses. Mailer is the extension point: LogMailer and
+// FileMailer are meant for local use, where no mail server is at hand.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer writes the emails to the log instead of sending them
+type LogMailer struct {
+	// Logger is the standard logger when nil
+	Logger *log.Logger
+}
+
+func (m LogMailer) Send(_ context.Context, msg Message) error {
+	logf := log.Printf
+	if m.Logger != nil {
+		logf = m.Logger.Printf
+	}
+	logf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
+
+// FileMailer writes every email to a file of its own in Dir, in the
+// Internet Message Format, so that mail clients can open them
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	name := filepath.Join(m.Dir, now.Format("20060102T150405.000000000Z")+"-"+uuid.NewString()[:8]+".eml")
+	return os.WriteFile(name, m.format(msg, now), 0o600)
+}
+
+// format returns the message with its headers and CRLF line endings
+func (m FileMailer) format(msg Message, date time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Text, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
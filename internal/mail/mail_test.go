@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := FileMailer{Dir: dir, From: "blog@example.com"}
+
+	msg := Message{To: "ann@example.com", Subject: "Vérifiez", Text: "Hello,\nopen the link.\n"}
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	m.Send(context.Background(), msg)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 2 {
+		t.Fatalf("expected a file per email, got %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a valid message, got %v", err)
+	}
+	if to := parsed.Header.Get("To"); to != "ann@example.com" {
+		t.Errorf("expected the recipient, got %q", to)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); subject != "Vérifiez" {
+		t.Errorf("expected the encoded subject, got %q", parsed.Header.Get("Subject"))
+	}
+	var buf bytes.Buffer
+	buf.ReadFrom(parsed.Body)
+	if buf.String() != "Hello,\r\nopen the link.\r\n" {
+		t.Errorf("expected the text with CRLF line endings, got %q", buf.String())
+	}
+}
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	m := LogMailer{Logger: log.New(&buf, "", 0)}
+	m.Send(context.Background(), Message{To: "ann@example.com", Subject: "Hello", Text: "https://blog.example.com/verify?token=abc"})
+	if !strings.Contains(buf.String(), "ann@example.com") || !strings.Contains(buf.String(), "token=abc") {
+		t.Errorf("expected the email to be logged, got %q", buf.String())
+	}
+}
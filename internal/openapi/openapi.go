@@ -0,0 +1,396 @@
+// Package openapi loads the OpenAPI 3.1 description of the REST API and
+// validates requests and responses against it.
+//
+// The description in openapi.yaml is the contract of the API: the request
+// and response bodies are checked against its JSON schemas, and a handler
+// test fails when a handler drifts from it.
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var document []byte
+
+// documentURL identifies the document while compiling its schemas
+const documentURL = "openapi.json"
+
+// Spec is a loaded OpenAPI description
+type Spec struct {
+	json []byte
+	// basePath is the path of the first server, e.g. /api/v1
+	basePath   string
+	operations map[string]*Operation
+}
+
+// Operation is an operation of the spec, a method on a path
+type Operation struct {
+	ID     string
+	Method string
+	// Path is the path template relative to the server, e.g. /posts/{id}
+	Path string
+
+	params    []*parameter
+	body      *requestBody
+	responses map[string]*response
+}
+
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	// typ is the JSON type the raw value is converted to before validation
+	typ    string
+	schema *jsonschema.Schema
+}
+
+type requestBody struct {
+	required bool
+	// content maps the media types to their schemas, nil for media types
+	// that are not JSON
+	content map[string]*jsonschema.Schema
+}
+
+type response struct {
+	headers map[string]*header
+	content map[string]*jsonschema.Schema
+}
+
+type header struct {
+	required bool
+	schema   *jsonschema.Schema
+}
+
+// Load parses and compiles the embedded description
+func Load() (*Spec, error) {
+	return Parse(document)
+}
+
+// Parse parses and compiles an OpenAPI 3.1 description in YAML or JSON
+func Parse(data []byte) (*Spec, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse the OpenAPI description: %w", err)
+	}
+	// the schemas are compiled from the JSON document, which also decodes
+	// numbers the way the validator expects
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the OpenAPI description to JSON: %w", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{doc: doc, compiler: jsonschema.NewCompiler()}
+	p.compiler.DefaultDraft(jsonschema.Draft2020)
+	if err := p.compiler.AddResource(documentURL, doc); err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{json: encoded, operations: map[string]*Operation{}}
+	root, _ := doc.(map[string]any)
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.1.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, want 3.1", version)
+	}
+	if servers, _ := root["servers"].([]any); len(servers) > 0 {
+		server, _ := servers[0].(map[string]any)
+		url, _ := server["url"].(string)
+		spec.basePath = strings.TrimSuffix(url, "/")
+	}
+
+	paths, _ := root["paths"].(map[string]any)
+	for path, item := range paths {
+		itemPtr := "/paths/" + escapePointer(path)
+		item, _ := item.(map[string]any)
+		shared, err := p.parameters(item["parameters"], itemPtr+"/parameters")
+		if err != nil {
+			return nil, err
+		}
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodPatch, http.MethodHead, http.MethodOptions} {
+			raw, ok := item[strings.ToLower(method)].(map[string]any)
+			if !ok {
+				continue
+			}
+			op, err := p.operation(raw, itemPtr+"/"+strings.ToLower(method), shared)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			op.Method, op.Path = method, path
+			spec.operations[method+" "+path] = op
+		}
+	}
+	return spec, nil
+}
+
+// JSON returns the description as a JSON document
+func (s *Spec) JSON() []byte {
+	return s.json
+}
+
+// Operation returns the operation of a method on a gin route, e.g.
+// /api/v1/posts/:id for /posts/{id}, or nil when the spec does not describe
+// it
+func (s *Spec) Operation(method, route string) *Operation {
+	path, ok := strings.CutPrefix(route, s.basePath)
+	if !ok || (path != "" && path[0] != '/') {
+		return nil
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return s.operations[method+" "+strings.Join(segments, "/")]
+}
+
+// Operations returns every operation of the spec, sorted by path and
+// method
+func (s *Spec) Operations() []*Operation {
+	ops := make([]*Operation, 0, len(s.operations))
+	for _, op := range s.operations {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	return ops
+}
+
+// String returns the method and path of the operation
+func (op *Operation) String() string {
+	return op.Method + " " + op.Path
+}
+
+// HasJSONBody tells whether the operation accepts a JSON request body,
+// the body the middleware reads to validate it
+func (op *Operation) HasJSONBody() bool {
+	if op.body == nil {
+		return false
+	}
+	for mediaType := range op.body.content {
+		if isJSON(mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// parser compiles the parts of the document into operations
+type parser struct {
+	doc      any
+	compiler *jsonschema.Compiler
+}
+
+func (p *parser) operation(raw map[string]any, ptr string, shared []*parameter) (*Operation, error) {
+	op := &Operation{responses: map[string]*response{}}
+	op.ID, _ = raw["operationId"].(string)
+
+	params, err := p.parameters(raw["parameters"], ptr+"/parameters")
+	if err != nil {
+		return nil, err
+	}
+	// operation parameters override the path item ones
+	for _, param := range shared {
+		overridden := false
+		for _, own := range params {
+			overridden = overridden || (own.name == param.name && own.in == param.in)
+		}
+		if !overridden {
+			params = append(params, param)
+		}
+	}
+	op.params = params
+
+	if raw["requestBody"] != nil {
+		body, bodyPtr, err := p.resolve(raw["requestBody"], ptr+"/requestBody")
+		if err != nil {
+			return nil, err
+		}
+		op.body = &requestBody{}
+		op.body.required, _ = body["required"].(bool)
+		if op.body.content, err = p.content(body["content"], bodyPtr+"/content"); err != nil {
+			return nil, err
+		}
+	}
+
+	responses, _ := raw["responses"].(map[string]any)
+	for status, rawResp := range responses {
+		resp, respPtr, err := p.resolve(rawResp, ptr+"/responses/"+escapePointer(status))
+		if err != nil {
+			return nil, err
+		}
+		r := &response{headers: map[string]*header{}}
+		if r.content, err = p.content(resp["content"], respPtr+"/content"); err != nil {
+			return nil, err
+		}
+		headers, _ := resp["headers"].(map[string]any)
+		for name, rawHeader := range headers {
+			h, headerPtr, err := p.resolve(rawHeader, respPtr+"/headers/"+escapePointer(name))
+			if err != nil {
+				return nil, err
+			}
+			compiled := &header{}
+			compiled.required, _ = h["required"].(bool)
+			if h["schema"] != nil {
+				if compiled.schema, err = p.schema(headerPtr + "/schema"); err != nil {
+					return nil, err
+				}
+			}
+			r.headers[http.CanonicalHeaderKey(name)] = compiled
+		}
+		op.responses[strings.ToUpper(status)] = r
+	}
+	return op, nil
+}
+
+func (p *parser) parameters(raw any, ptr string) ([]*parameter, error) {
+	list, _ := raw.([]any)
+	params := make([]*parameter, 0, len(list))
+	for i, rawParam := range list {
+		param, paramPtr, err := p.resolve(rawParam, ptr+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		compiled := &parameter{typ: "string"}
+		compiled.name, _ = param["name"].(string)
+		compiled.in, _ = param["in"].(string)
+		compiled.required, _ = param["required"].(bool)
+		if compiled.in == "header" {
+			compiled.name = http.CanonicalHeaderKey(compiled.name)
+		}
+		if param["schema"] != nil {
+			if compiled.schema, err = p.schema(paramPtr + "/schema"); err != nil {
+				return nil, err
+			}
+			compiled.typ = p.schemaType(param["schema"])
+		}
+		params = append(params, compiled)
+	}
+	return params, nil
+}
+
+// content compiles the schemas of the JSON media types of a content map
+func (p *parser) content(raw any, ptr string) (map[string]*jsonschema.Schema, error) {
+	media, _ := raw.(map[string]any)
+	if len(media) == 0 {
+		return nil, nil
+	}
+	content := make(map[string]*jsonschema.Schema, len(media))
+	for mediaType, rawMedia := range media {
+		content[mediaType] = nil
+		m, _ := rawMedia.(map[string]any)
+		if !isJSON(mediaType) || m["schema"] == nil {
+			continue
+		}
+		schema, err := p.schema(ptr + "/" + escapePointer(mediaType) + "/schema")
+		if err != nil {
+			return nil, err
+		}
+		content[mediaType] = schema
+	}
+	return content, nil
+}
+
+func (p *parser) schema(ptr string) (*jsonschema.Schema, error) {
+	return p.compiler.Compile(documentURL + "#" + ptr)
+}
+
+// schemaType returns the type of a parameter schema, following its
+// reference
+func (p *parser) schemaType(raw any) string {
+	schema, _, err := p.resolve(raw, "")
+	if err != nil {
+		return "string"
+	}
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if s, _ := v.(string); s != "null" {
+				return s
+			}
+		}
+	}
+	return "string"
+}
+
+// resolve follows the $ref of an object of the document, returning the
+// object along with its JSON pointer
+func (p *parser) resolve(raw any, ptr string) (map[string]any, string, error) {
+	for range 10 {
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return nil, "", fmt.Errorf("%s is not an object", ptr)
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj, ptr, nil
+		}
+		target, ok := strings.CutPrefix(ref, "#")
+		if !ok {
+			return nil, "", fmt.Errorf("%s: only local references are supported, got %q", ptr, ref)
+		}
+		if raw, ok = lookup(p.doc, target); !ok {
+			return nil, "", fmt.Errorf("%s: unresolved reference %q", ptr, ref)
+		}
+		ptr = target
+	}
+	return nil, "", fmt.Errorf("%s: too many nested references", ptr)
+}
+
+// lookup returns the value at a JSON pointer
+func lookup(doc any, ptr string) (any, bool) {
+	if ptr == "" {
+		return doc, true
+	}
+	v := doc
+	for _, token := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[token]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// isJSON tells whether a media type is JSON, e.g. application/json or
+// application/problem+json
+func isJSON(mediaType string) bool {
+	mediaType, _, _ = mime.ParseMediaType(mediaType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,198 @@
+package openapi
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func loadSpec(t *testing.T) *Spec {
+	t.Helper()
+	spec, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load the spec: %v", err)
+	}
+	return spec
+}
+
+func TestSpec_Operation(t *testing.T) {
+	spec := loadSpec(t)
+
+	cases := []struct {
+		method, route string
+		want          string
+	}{
+		{http.MethodGet, "/api/v1/posts/:id", "getPost"},
+		{http.MethodGet, "/posts/:id", ""},
+		{http.MethodPost, "/api/v1/webhooks/deliveries/:id/redeliver", "redeliver"},
+		{http.MethodPatch, "/api/v1/posts/:id", ""},
+		{http.MethodGet, "/api/v1/missing", ""},
+		{http.MethodGet, "/api/v10/posts", ""},
+	}
+	for _, tc := range cases {
+		op := spec.Operation(tc.method, tc.route)
+		got := ""
+		if op != nil {
+			got = op.ID
+		}
+		if got != tc.want {
+			t.Errorf("%s %s: expected operation %q, got %q", tc.method, tc.route, tc.want, got)
+		}
+	}
+}
+
+func TestOperation_ValidateRequest(t *testing.T) {
+	spec := loadSpec(t)
+
+	cases := []struct {
+		name        string
+		method      string
+		route       string
+		target      string
+		contentType string
+		body        string
+		valid       bool
+		want        map[string]string
+		wantKind    apperrors.Kind
+	}{
+		{
+			name:        "valid body",
+			method:      http.MethodPost,
+			route:       "/api/v1/posts",
+			target:      "/api/v1/posts",
+			contentType: "application/json",
+			body:        `{"title":"Go","content":"hello","author":"Ann","tags":null,"status":""}`,
+			valid:       true,
+		},
+		{
+			name:        "invalid body",
+			method:      http.MethodPost,
+			route:       "/api/v1/posts",
+			target:      "/api/v1/posts",
+			contentType: "application/json",
+			body:        `{"title":1,"author":"Ann","status":"hidden","tags":["go",2],"extra":true}`,
+			want: map[string]string{
+				"content": "is required",
+				"extra":   "is not allowed",
+				"title":   "has an invalid type",
+				"status":  "must be one of: draft, published",
+				"tags[1]": "has an invalid type",
+			},
+			wantKind: apperrors.KindValidation,
+		},
+		{
+			name:     "missing body",
+			method:   http.MethodPost,
+			route:    "/api/v1/posts",
+			target:   "/api/v1/posts",
+			want:     map[string]string{"body": "is required"},
+			wantKind: apperrors.KindValidation,
+		},
+		{
+			name:        "malformed body",
+			method:      http.MethodPost,
+			route:       "/api/v1/posts",
+			target:      "/api/v1/posts",
+			contentType: "application/json",
+			body:        `{"title":`,
+			wantKind:    apperrors.KindBadRequest,
+		},
+		{
+			name:     "invalid query",
+			method:   http.MethodGet,
+			route:    "/api/v1/posts",
+			target:   "/api/v1/posts?page_size=abc&status=hidden",
+			want:     map[string]string{"page_size": "has an invalid type", "status": "must be one of: draft, published"},
+			wantKind: apperrors.KindValidation,
+		},
+		{
+			name:   "valid query",
+			method: http.MethodPost,
+			route:  "/api/v1/posts/import",
+			target: "/api/v1/posts/import?dry_run=true&on_conflict=upsert",
+			// NDJSON bodies are left to the handler
+			contentType: "application/x-ndjson",
+			body:        "not json",
+			valid:       true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			err := spec.Operation(tc.method, tc.route).ValidateRequest(req, nil, []byte(tc.body))
+			if tc.valid {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			var appErr *apperrors.Error
+			if !errors.As(err, &appErr) || appErr.Kind != tc.wantKind {
+				t.Fatalf("expected a %s error, got %v", tc.wantKind, err)
+			}
+			if tc.want == nil {
+				return
+			}
+			got := map[string]string{}
+			for _, f := range appErr.Fields {
+				got[f.Field] = f.Reason
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected invalid fields %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestOperation_ValidateResponse(t *testing.T) {
+	spec := loadSpec(t)
+	op := spec.Operation(http.MethodGet, "/api/v1/posts/:id")
+	jsonHeader := http.Header{"Content-Type": {"application/json; charset=utf-8"}, "Etag": {`"2"`}}
+	const post = `{"id":"1","title":"Go","slug":"go","content":"hello","content_format":"plain","author":"Ann","tags":[],"status":"published","version":2,"created_at":"2025-01-01T10:00:00Z","updated_at":"2025-01-01T10:00:00Z"}`
+
+	cases := []struct {
+		name   string
+		status int
+		header http.Header
+		body   string
+		want   string
+	}{
+		{name: "valid", status: http.StatusOK, header: jsonHeader, body: post},
+		{name: "html", status: http.StatusOK, header: http.Header{"Content-Type": {"text/html; charset=utf-8"}}, body: "<p>hello</p>"},
+		{name: "not modified", status: http.StatusNotModified, header: http.Header{}},
+		{name: "problem", status: http.StatusNotFound, header: http.Header{"Content-Type": {"application/problem+json"}}, body: `{"type":"/problems/not-found","title":"Resource not found","status":404}`},
+		{name: "undocumented status", status: http.StatusTeapot, header: jsonHeader, body: post, want: "the status is not documented"},
+		{name: "undocumented content type", status: http.StatusOK, header: http.Header{"Content-Type": {"text/plain"}}, body: "hello", want: `content type "text/plain" is not documented`},
+		{name: "invalid header", status: http.StatusOK, header: http.Header{"Content-Type": {"application/json"}, "Etag": {"2"}}, body: post, want: "header Etag"},
+		{name: "invalid body", status: http.StatusOK, header: jsonHeader, body: `{"id":"1","tags":null}`, want: "title is required"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body []byte
+			if tc.body != "" {
+				body = []byte(tc.body)
+			}
+			err := op.ValidateResponse(tc.status, tc.header, body)
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			var respErr *ResponseError
+			if !errors.As(err, &respErr) {
+				t.Fatalf("expected a response error, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected the error to contain %q, got %q", tc.want, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package openapi
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var printer = message.NewPrinter(language.English)
+
+// ValidateRequest checks the parameters and the JSON body of a request,
+// given its path parameters and body. Only JSON bodies are read and
+// checked, the media type of other bodies must be documented. Every
+// invalid field is reported at once in a validation error; a body that is
+// not JSON is reported as a bad request.
+func (op *Operation) ValidateRequest(r *http.Request, pathParams map[string]string, body []byte) error {
+	var fields []apperrors.FieldError
+	query := r.URL.Query()
+	for _, param := range op.params {
+		var values []string
+		switch param.in {
+		case "path":
+			if v, ok := pathParams[param.name]; ok {
+				values = []string{v}
+			}
+		case "query":
+			values = query[param.name]
+		case "header":
+			values = r.Header.Values(param.name)
+		case "cookie":
+			if cookie, err := r.Cookie(param.name); err == nil {
+				values = []string{cookie.Value}
+			}
+		}
+		if len(values) == 0 {
+			if param.required {
+				fields = append(fields, apperrors.FieldError{Field: param.name, Reason: "is required"})
+			}
+			continue
+		}
+		if param.schema == nil {
+			continue
+		}
+		if err := param.schema.Validate(param.value(values)); err != nil {
+			fields = append(fields, fieldErrors(param.name, err)...)
+		}
+	}
+
+	if op.body != nil {
+		bodyFields, err := op.validateBody(r, body)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, bodyFields...)
+	}
+
+	if len(fields) > 0 {
+		return apperrors.Validation("request does not match the API description", fields...)
+	}
+	return nil
+}
+
+func (op *Operation) validateBody(r *http.Request, body []byte) ([]apperrors.FieldError, error) {
+	contentType := r.Header.Get("Content-Type")
+	if len(body) == 0 && contentType == "" && r.ContentLength <= 0 {
+		if op.body.required {
+			return []apperrors.FieldError{{Field: "body", Reason: "is required"}}, nil
+		}
+		return nil, nil
+	}
+	mediaType, schema, ok := match(op.body.content, contentType)
+	if !ok {
+		// clients commonly leave out the content type of JSON bodies
+		if contentType != "" || !op.HasJSONBody() {
+			return nil, apperrors.BadRequest(fmt.Sprintf("content type %q is not supported", contentType), nil)
+		}
+		mediaType, schema, _ = match(op.body.content, "application/json")
+	}
+	if !isJSON(mediaType) || schema == nil {
+		return nil, nil
+	}
+
+	v, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid body provided", err)
+	}
+	if err := schema.Validate(v); err != nil {
+		return fieldErrors("", err), nil
+	}
+	return nil, nil
+}
+
+// value converts the raw values of a parameter to the JSON value its
+// schema expects. Values that do not convert are left as strings for the
+// schema to reject them.
+func (param *parameter) value(values []string) any {
+	if param.typ == "array" {
+		items := make([]any, len(values))
+		for i, v := range values {
+			items[i] = v
+		}
+		return items
+	}
+	v := values[0]
+	switch param.typ {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return json.Number(v)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// ResponseError describes how a response differs from the API description
+type ResponseError struct {
+	Operation string
+	Status    int
+	Reasons   []string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s: %d response does not match the API description: %s", e.Operation, e.Status, strings.Join(e.Reasons, "; "))
+}
+
+// ValidateResponse checks the status, headers and JSON body of a
+// response. A nil body skips the body check, e.g. for streams.
+func (op *Operation) ValidateResponse(status int, header http.Header, body []byte) error {
+	resp := op.response(status)
+	if resp == nil {
+		return &ResponseError{Operation: op.String(), Status: status, Reasons: []string{"the status is not documented"}}
+	}
+
+	var reasons []string
+	for name, h := range resp.headers {
+		value := header.Get(name)
+		if value == "" {
+			if h.required {
+				reasons = append(reasons, fmt.Sprintf("header %s is missing", name))
+			}
+			continue
+		}
+		if h.schema != nil {
+			if err := h.schema.Validate(value); err != nil {
+				reasons = append(reasons, fmt.Sprintf("header %s %s", name, describe(err)))
+			}
+		}
+	}
+
+	contentType := header.Get("Content-Type")
+	switch {
+	case len(resp.content) == 0:
+		if len(body) > 0 {
+			reasons = append(reasons, "the response has a body, none is documented")
+		}
+	case contentType == "":
+		if status != http.StatusNotModified {
+			reasons = append(reasons, "the content type is missing")
+		}
+	default:
+		mediaType, schema, ok := match(resp.content, contentType)
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("content type %q is not documented", contentType))
+			break
+		}
+		if body == nil || !isJSON(mediaType) || schema == nil {
+			break
+		}
+		v, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+		if err != nil {
+			reasons = append(reasons, "the body is not valid JSON")
+			break
+		}
+		if err := schema.Validate(v); err != nil {
+			for _, f := range fieldErrors("", err) {
+				name := f.Field
+				if name == "" {
+					name = "body"
+				}
+				reasons = append(reasons, name+" "+f.Reason)
+			}
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &ResponseError{Operation: op.String(), Status: status, Reasons: reasons}
+	}
+	return nil
+}
+
+// response returns the response documented for a status, falling back to
+// its range (2XX) and then to the default response
+func (op *Operation) response(status int) *response {
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", "DEFAULT"} {
+		if resp, ok := op.responses[key]; ok {
+			return resp
+		}
+	}
+	return nil
+}
+
+// match returns the media type of a content map matching a content type
+func match(content map[string]*jsonschema.Schema, contentType string) (string, *jsonschema.Schema, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", nil, false
+	}
+	if schema, ok := content[mediaType]; ok {
+		return mediaType, schema, true
+	}
+	// ranges such as text/* and */*
+	for candidate, schema := range content {
+		prefix, ok := strings.CutSuffix(candidate, "*")
+		if ok && (candidate == "*/*" || strings.HasPrefix(mediaType, prefix)) {
+			return mediaType, schema, true
+		}
+	}
+	return "", nil, false
+}
+
+// fieldErrors flattens a schema validation error into the errors of the
+// invalid fields, named after their location in the value, e.g.
+// operations[2].op
+func fieldErrors(parent string, err error) []apperrors.FieldError {
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return []apperrors.FieldError{{Field: parent, Reason: err.Error()}}
+	}
+	var fields []apperrors.FieldError
+	collect(verr, parent, &fields)
+	slices.SortStableFunc(fields, func(a, b apperrors.FieldError) int { return strings.Compare(a.Field, b.Field) })
+	return slices.Compact(fields)
+}
+
+func collect(verr *jsonschema.ValidationError, parent string, fields *[]apperrors.FieldError) {
+	if len(verr.Causes) > 0 {
+		for _, cause := range verr.Causes {
+			collect(cause, parent, fields)
+		}
+		return
+	}
+
+	name := fieldName(parent, verr.InstanceLocation)
+	switch k := verr.ErrorKind.(type) {
+	case *kind.Required:
+		for _, missing := range k.Missing {
+			*fields = append(*fields, apperrors.FieldError{Field: fieldName(name, []string{missing}), Reason: "is required"})
+		}
+	case *kind.AdditionalProperties:
+		for _, property := range k.Properties {
+			*fields = append(*fields, apperrors.FieldError{Field: fieldName(name, []string{property}), Reason: "is not allowed"})
+		}
+	default:
+		*fields = append(*fields, apperrors.FieldError{Field: name, Reason: reason(verr.ErrorKind)})
+	}
+}
+
+// reason phrases the error of a field like the validation package does
+func reason(k jsonschema.ErrorKind) string {
+	switch k := k.(type) {
+	case *kind.Type:
+		return "has an invalid type"
+	case *kind.Enum:
+		allowed := make([]string, 0, len(k.Want))
+		for _, v := range k.Want {
+			if s, ok := v.(string); !ok || s != "" {
+				allowed = append(allowed, fmt.Sprint(v))
+			}
+		}
+		return "must be one of: " + strings.Join(allowed, ", ")
+	case *kind.MaxLength:
+		return fmt.Sprintf("must be at most %d characters long", k.Want)
+	case *kind.MinLength:
+		return fmt.Sprintf("must be at least %d characters long", k.Want)
+	case *kind.MaxItems:
+		return fmt.Sprintf("must have at most %d items", k.Want)
+	case *kind.Minimum:
+		return "must be at least " + k.Want.RatString()
+	}
+	return k.LocalizedString(printer)
+}
+
+// describe returns the reasons of a schema validation error of a
+// single value
+func describe(err error) string {
+	var reasons []string
+	for _, f := range fieldErrors("", err) {
+		reasons = append(reasons, f.Reason)
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// fieldName appends the tokens of a JSON pointer to a field name, array
+// indexes as [i]
+func fieldName(parent string, tokens []string) string {
+	name := parent
+	for _, token := range tokens {
+		if _, err := strconv.Atoi(token); err == nil {
+			name += "[" + token + "]"
+			continue
+		}
+		if name != "" {
+			name += "."
+		}
+		name += token
+	}
+	return name
+}
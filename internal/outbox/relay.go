@@ -0,0 +1,157 @@
+// Package outbox publishes the events recorded in the outbox of a
+// repositories.OutboxRepo to sinks: webhooks, a message broker, a file.
+// BlogPostService records the events of a change in the transaction of the
+// change, so a relay publishes every committed change, even one made right
+// before the process stopped.
+//
+// Delivery is at least once: an event is removed from the outbox only once
+// every sink accepted it, and a failing sink makes the relay publish the
+// event again to every sink. Sinks tell duplicates apart with the event ID.
+// Events are published in the order they were recorded, a failing event
+// holding back the ones after it.
+package outbox
+
+import (
+	"blog-posts-api/internal/api/repositories"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/health"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Sink receives the events of the outbox
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+	Publish(ctx context.Context, e services.Event) error
+}
+
+// NewSink returns a Sink publishing with fn
+func NewSink(name string, fn func(ctx context.Context, e services.Event) error) Sink {
+	return funcSink{name: name, fn: fn}
+}
+
+type funcSink struct {
+	name string
+	fn   func(ctx context.Context, e services.Event) error
+}
+
+func (s funcSink) Name() string { return s.name }
+
+func (s funcSink) Publish(ctx context.Context, e services.Event) error { return s.fn(ctx, e) }
+
+// Options tune a Relay
+type Options struct {
+	// BatchSize is the number of events read from the outbox at once
+	BatchSize int
+	// PollInterval is how often the outbox is read when the relay is not
+	// woken up by Notify
+	PollInterval time.Duration
+	// Backoff is the delay before publishing again after a failure,
+	// doubled on every consecutive failure up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Heartbeat, when set, is beaten on every iteration of the loop
+	Heartbeat *health.Heartbeat
+}
+
+// Relay publishes the events of an outbox to sinks
+type Relay struct {
+	repo  repositories.OutboxRepo
+	sinks []Sink
+	opts  Options
+	wake  chan struct{}
+}
+
+func NewRelay(repo repositories.OutboxRepo, sinks []Sink, opts Options) *Relay {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Second
+	}
+	if opts.MaxBackoff < opts.Backoff {
+		opts.MaxBackoff = max(opts.Backoff, time.Minute)
+	}
+	return &Relay{repo: repo, sinks: sinks, opts: opts, wake: make(chan struct{}, 1)}
+}
+
+// Notify wakes the relay up, it is a services.Listener so that committed
+// events are published right away rather than on the next poll
+func (r *Relay) Notify(services.Event) {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes the events of the outbox until ctx is canceled
+func (r *Relay) Run(ctx context.Context) {
+	backoff := time.Duration(0)
+	for {
+		if r.opts.Heartbeat != nil {
+			r.opts.Heartbeat.Beat()
+		}
+
+		wait := r.opts.PollInterval
+		if _, err := r.Flush(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			backoff = min(max(backoff*2, r.opts.Backoff), r.opts.MaxBackoff)
+			wait = backoff
+			log.Printf("outbox: %v, retrying in %s", err, wait)
+		} else {
+			backoff = 0
+		}
+
+		wake := r.wake
+		if backoff > 0 {
+			// a failing sink is not retried before its backoff
+			wake = nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Flush publishes the pending events until the outbox is empty and returns
+// how many were published. It stops at the first event a sink failed to
+// publish.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		events, err := r.repo.PendingEvents(ctx, r.opts.BatchSize)
+		if err != nil {
+			return published, fmt.Errorf("failed to read the outbox: %w", err)
+		}
+		if len(events) == 0 {
+			return published, nil
+		}
+
+		for _, record := range events {
+			e := services.EventFromOutbox(record)
+			for _, sink := range r.sinks {
+				if err := sink.Publish(ctx, e); err != nil {
+					return published, fmt.Errorf("sink %s failed to publish event %s: %w", sink.Name(), e.ID, err)
+				}
+			}
+			if err := r.repo.MarkPublished(ctx, e.ID); err != nil {
+				return published, fmt.Errorf("failed to mark event %s as published: %w", e.ID, err)
+			}
+			published++
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package outbox
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a sink recording the IDs of the events it published
+type recorder struct {
+	mu   sync.Mutex
+	ids  []string
+	fail error
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Publish(ctx context.Context, e services.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail != nil {
+		return r.fail
+	}
+	r.ids = append(r.ids, e.ID)
+	return nil
+}
+
+func (r *recorder) published() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.ids)
+}
+
+func newPost(id string) *models.BlogPost {
+	return &models.BlogPost{ID: id, Title: "Test Post", Content: "Test content", Author: "Test Author", Status: models.StatusDraft}
+}
+
+func TestRelay_FlushPublishesInOrder(t *testing.T) {
+	repo := services.NewInMemoryStoreBlogPostRepo()
+	posts := services.NewBlogPostService(repo)
+	var expected []string
+	posts.Subscribe(func(e services.Event) { expected = append(expected, e.ID) })
+	ctx := context.Background()
+	posts.Create(ctx, newPost("1"))
+	posts.Create(ctx, newPost("2"))
+	posts.Delete(ctx, "1")
+
+	first, second := &recorder{}, &recorder{}
+	relay := NewRelay(repo, []Sink{first, second}, Options{BatchSize: 2})
+	n, err := relay.Flush(ctx)
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 events to be published, got %d and %v", n, err)
+	}
+	if !slices.Equal(first.published(), expected) || !slices.Equal(second.published(), expected) {
+		t.Errorf("expected every sink to get %v, got %v and %v", expected, first.published(), second.published())
+	}
+	if pending, _ := repo.PendingEvents(ctx, 0); len(pending) != 0 {
+		t.Errorf("expected an empty outbox, got %d events", len(pending))
+	}
+}
+
+func TestRelay_FailingSinkKeepsEvents(t *testing.T) {
+	repo := services.NewInMemoryStoreBlogPostRepo()
+	posts := services.NewBlogPostService(repo)
+	ctx := context.Background()
+	posts.Create(ctx, newPost("1"))
+	posts.Create(ctx, newPost("2"))
+
+	healthy, failing := &recorder{}, &recorder{fail: errors.New("broker unavailable")}
+	relay := NewRelay(repo, []Sink{healthy, failing}, Options{})
+	if n, err := relay.Flush(ctx); err == nil || n != 0 {
+		t.Fatalf("expected the flush to fail before publishing, got %d and %v", n, err)
+	}
+	if pending, _ := repo.PendingEvents(ctx, 0); len(pending) != 2 {
+		t.Fatalf("expected the events to stay in the outbox, got %d", len(pending))
+	}
+
+	failing.fail = nil
+	if n, err := relay.Flush(ctx); err != nil || n != 2 {
+		t.Fatalf("expected 2 events to be published, got %d and %v", n, err)
+	}
+	// at least once: the healthy sink gets the first event again
+	if ids := healthy.published(); len(ids) != 3 || ids[0] != ids[1] {
+		t.Errorf("expected the first event to be published twice, got %v", ids)
+	}
+	if ids := failing.published(); len(ids) != 2 {
+		t.Errorf("expected 2 events once the sink recovered, got %v", ids)
+	}
+}
+
+func TestRelay_RunPublishesOnNotify(t *testing.T) {
+	repo := services.NewInMemoryStoreBlogPostRepo()
+	posts := services.NewBlogPostService(repo)
+	sink := &recorder{}
+	relay := NewRelay(repo, []Sink{sink}, Options{PollInterval: time.Hour})
+	posts.Subscribe(relay.Notify)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	posts.Create(context.Background(), newPost("1"))
+	deadline := time.Now().Add(5 * time.Second)
+	for len(sink.published()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the event to be published")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	sink, err := OpenFileSink(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	ctx := context.Background()
+	sink.Publish(ctx, services.Event{ID: "e1", Type: services.EventPostCreated, PostID: "1", Post: newPost("1")})
+	sink.Publish(ctx, services.Event{ID: "e2", Type: services.EventPostDeleted, PostID: "1"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer f.Close()
+	var events []models.WebhookEvent
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		var e models.WebhookEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("expected a JSON line, got %q", scanner.Text())
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 || events[0].ID != "e1" || events[0].Data.Post == nil || events[1].Type != "post.deleted" {
+		t.Errorf("expected both events, got %+v", events)
+	}
+}
+
+type publisherFunc func(ctx context.Context, topic, key string, data []byte) error
+
+func (f publisherFunc) Publish(ctx context.Context, topic, key string, data []byte) error {
+	return f(ctx, topic, key, data)
+}
+
+func TestBrokerSink(t *testing.T) {
+	var topic, key string
+	sink := NewBrokerSink("nats", publisherFunc(func(ctx context.Context, t, k string, data []byte) error {
+		topic, key = t, k
+		return nil
+	}), "blog.")
+	if err := sink.Publish(context.Background(), services.Event{ID: "e1", Type: services.EventPostPublished, PostID: "1"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if topic != "blog.post.published" || key != "1" {
+		t.Errorf("expected topic blog.post.published keyed by 1, got %s and %s", topic, key)
+	}
+
+	failing := NewBrokerSink("nats", publisherFunc(func(context.Context, string, string, []byte) error {
+		return errors.New("no servers available")
+	}), "blog.")
+	if err := failing.Publish(context.Background(), services.Event{Type: services.EventPostCreated}); err == nil || err.Error() != "failed to publish to blog.post.created: no servers available" {
+		t.Errorf("expected a wrapped error, got %v", err)
+	}
+}
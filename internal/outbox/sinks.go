@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"blog-posts-api/internal/api/services"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink appends the events to a file as NDJSON, one services.MarshalEvent
+// document per line, e.g. for an audit trail or a log shipper
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// OpenFileSink opens path for appending, creating it if needed
+func OpenFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{path: path, f: f}, nil
+}
+
+func (s *FileSink) Name() string {
+	return "file " + s.path
+}
+
+// Publish appends the event and syncs the file, so that an event removed
+// from the outbox is on disk
+func (s *FileSink) Publish(ctx context.Context, e services.Event) error {
+	data, err := services.MarshalEvent(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// Publisher is implemented by message broker clients, e.g. a thin adapter
+// of a NATS connection or of a Kafka producer
+type Publisher interface {
+	// Publish sends a message to a topic (a NATS subject, a Kafka topic).
+	// Messages with the same key must keep their order, e.g. by using it as
+	// the Kafka partition key.
+	Publish(ctx context.Context, topic, key string, data []byte) error
+}
+
+// NewBrokerSink returns a Sink publishing the services.MarshalEvent form of
+// the events to the topic prefix followed by the event type, e.g.
+// blog.post.created for the prefix "blog.". Messages are keyed by post ID,
+// so that the events of a post are consumed in order.
+func NewBrokerSink(name string, p Publisher, prefix string) Sink {
+	return NewSink(name, func(ctx context.Context, e services.Event) error {
+		data, err := services.MarshalEvent(e)
+		if err != nil {
+			return err
+		}
+		if err := p.Publish(ctx, prefix+string(e.Type), e.PostID, data); err != nil {
+			return fmt.Errorf("failed to publish to %s%s: %w", prefix, e.Type, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,92 @@
+// Package render turns blog post content into sanitized HTML.
+package render
+
+import (
+	"blog-posts-api/internal/api/models"
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+)
+
+// Renderer converts content to HTML. Its output is always sanitized, so it
+// is safe to embed in a page even if the content comes from untrusted
+// authors.
+type Renderer struct {
+	markdown goldmark.Markdown
+	policy   *bluemonday.Policy
+}
+
+func NewRenderer() *Renderer {
+	md := goldmark.New(
+		// CommonMark plus GFM tables, strikethrough, autolinks and task lists
+		goldmark.WithExtensions(
+			extension.GFM,
+			highlighting.NewHighlighting(
+				highlighting.WithFormatOptions(
+					// classes instead of inline styles, themes ship a chroma stylesheet
+					chromahtml.WithClasses(true),
+				),
+			),
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+
+	return &Renderer{markdown: md, policy: newPolicy()}
+}
+
+// Render returns the sanitized HTML for content written in the given format
+func (r *Renderer) Render(format, content string) (string, error) {
+	switch format {
+	case models.ContentFormatMarkdown:
+		var buf bytes.Buffer
+		if err := r.markdown.Convert([]byte(content), &buf); err != nil {
+			return "", fmt.Errorf("failed to render markdown: %w", err)
+		}
+		return r.policy.Sanitize(buf.String()), nil
+	case models.ContentFormatPlain, "":
+		return renderPlain(content), nil
+	default:
+		return "", fmt.Errorf("unsupported content format %q", format)
+	}
+}
+
+// renderPlain escapes the text and keeps its paragraphs and line breaks
+func renderPlain(content string) string {
+	var b strings.Builder
+	for _, para := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		lines := strings.Split(para, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.Join(lines, "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+var chromaClass = regexp.MustCompile(`^[a-zA-Z0-9 _-]+$`)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// heading anchors
+	p.AllowAttrs("id").Matching(bluemonday.SpaceSeparatedTokens).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	// syntax highlighting classes and GFM task list checkboxes
+	p.AllowAttrs("class").Matching(chromaClass).OnElements("pre", "code", "span")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
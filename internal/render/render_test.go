@@ -0,0 +1,107 @@
+package render
+
+import (
+	"blog-posts-api/internal/api/models"
+	"strings"
+	"testing"
+)
+
+func mustRender(t *testing.T, format, content string) string {
+	t.Helper()
+	html, err := NewRenderer().Render(format, content)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return html
+}
+
+func TestRender_MarkdownHeadingAnchors(t *testing.T) {
+	html := mustRender(t, models.ContentFormatMarkdown, "# Getting Started\n\nSome text")
+
+	if !strings.Contains(html, `<h1 id="getting-started">Getting Started</h1>`) {
+		t.Errorf("expected heading with anchor, got %s", html)
+	}
+}
+
+func TestRender_MarkdownTables(t *testing.T) {
+	html := mustRender(t, models.ContentFormatMarkdown, "| a | b |\n|---|---|\n| 1 | 2 |\n")
+
+	if !strings.Contains(html, "<table>") || !strings.Contains(html, "<td>1</td>") {
+		t.Errorf("expected GFM table, got %s", html)
+	}
+}
+
+func TestRender_MarkdownCodeHighlighting(t *testing.T) {
+	html := mustRender(t, models.ContentFormatMarkdown, "```go\nfunc main() {}\n```\n")
+
+	if !strings.Contains(html, `<pre class="chroma">`) {
+		t.Errorf("expected highlighted code block, got %s", html)
+	}
+	if !strings.Contains(html, `<span class="kd">func</span>`) {
+		t.Errorf("expected keyword token class, got %s", html)
+	}
+}
+
+func TestRender_MarkdownStripsXSS(t *testing.T) {
+	content := strings.Join([]string{
+		`<script>alert(1)</script>`,
+		`[click](javascript:alert(1))`,
+		`<img src="x" onerror="alert(1)">`,
+		`<a href="https://example.com" onclick="alert(1)">ok</a>`,
+	}, "\n\n")
+
+	html := mustRender(t, models.ContentFormatMarkdown, content)
+
+	for _, bad := range []string{"<script", "javascript:", "onerror", "onclick"} {
+		if strings.Contains(html, bad) {
+			t.Errorf("expected %q to be sanitized, got %s", bad, html)
+		}
+	}
+}
+
+func TestRender_PlainIsEscaped(t *testing.T) {
+	html := mustRender(t, models.ContentFormatPlain, "Hello <b>world</b>\nsecond line\n\nnext paragraph")
+
+	expected := "<p>Hello &lt;b&gt;world&lt;/b&gt;<br>\nsecond line</p>\n<p>next paragraph</p>\n"
+	if html != expected {
+		t.Errorf("expected %q, got %q", expected, html)
+	}
+}
+
+func TestRender_UnsupportedFormat(t *testing.T) {
+	if _, err := NewRenderer().Render("asciidoc", "= Title"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewCache(2)
+	cache.Put("a", "A")
+	cache.Put("b", "B")
+	cache.Get("a") // "b" is now the least recently used
+	cache.Put("c", "C")
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected 'b' to be evicted")
+	}
+	if html, ok := cache.Get("a"); !ok || html != "A" {
+		t.Errorf("expected 'a' to be cached, got %q, %t", html, ok)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.Len())
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	fragment := `<h1 id="intro">Intro</h1>
+<p>Go is <em>simple</em> &amp; fast.</p>
+<pre class="chroma"><code>func main() {}</code></pre>
+<p>More text here.</p>`
+
+	if got := Excerpt(fragment, 100); got != "Intro Go is simple & fast. More text here." {
+		t.Errorf("unexpected excerpt %q", got)
+	}
+	if got := Excerpt(fragment, 20); got != "Intro Go is simple…" {
+		t.Errorf("unexpected truncated excerpt %q", got)
+	}
+}
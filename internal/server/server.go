@@ -0,0 +1,138 @@
+package server
+
+import (
+	"blog-posts-api/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ShutdownFunc releases a resource owned by the process, e.g. stops a
+// background worker or closes a DB handle
+type ShutdownFunc func(ctx context.Context) error
+
+type shutdownHook struct {
+	name string
+	fn   ShutdownFunc
+}
+
+// Server wraps http.Server with signal-driven graceful shutdown and
+// ordered release of the resources registered with OnShutdown
+type Server struct {
+	cfg  config.ServerConfig
+	http *http.Server
+
+	mu      sync.Mutex
+	hooks   []shutdownHook
+	onDrain []func()
+}
+
+func New(cfg config.ServerConfig, handler http.Handler) *Server {
+	return &Server{
+		cfg: cfg,
+		http: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+	}
+}
+
+// OnShutdown registers a hook to run after the HTTP server has drained.
+// Hooks run in reverse registration order (like defer), so resources
+// registered first - e.g. repositories - are released after the workers
+// that depend on them.
+func (s *Server) OnShutdown(name string, fn ShutdownFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// OnDrain registers a callback invoked as soon as shutdown starts, before
+// in-flight requests are drained, e.g. to start failing readiness probes
+func (s *Server) OnDrain(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onDrain = append(s.onDrain, fn)
+}
+
+// Run starts listening on the configured address and blocks until ctx is
+// canceled or the server fails, then shuts everything down
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.cfg.Addr, err)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve is like Run but accepts connections on the given listener
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.http.Serve(ln)
+	}()
+
+	var runErr error
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			runErr = fmt.Errorf("server failed: %w", err)
+		}
+	case <-ctx.Done():
+		log.Printf("shutdown requested, draining in-flight requests (up to %s)", s.cfg.DrainTimeout)
+	}
+
+	return errors.Join(runErr, s.shutdown())
+}
+
+func (s *Server) shutdown() error {
+	var errs []error
+
+	s.mu.Lock()
+	onDrain := make([]func(), len(s.onDrain))
+	copy(onDrain, s.onDrain)
+	s.mu.Unlock()
+	for _, fn := range onDrain {
+		fn()
+	}
+	if s.cfg.ShutdownDelay > 0 {
+		log.Printf("reporting not-ready for %s before draining", s.cfg.ShutdownDelay)
+		time.Sleep(s.cfg.ShutdownDelay)
+	}
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), s.cfg.DrainTimeout)
+	defer cancel()
+	if err := s.http.Shutdown(drainCtx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain http server: %w", err))
+		// drop whatever is still open so that the hooks below do not race
+		// against handlers that are still running
+		s.http.Close()
+	}
+
+	s.mu.Lock()
+	hooks := make([]shutdownHook, len(s.hooks))
+	copy(hooks, s.hooks)
+	s.mu.Unlock()
+
+	hookCtx, cancelHooks := context.WithTimeout(context.Background(), s.cfg.HookTimeout)
+	defer cancelHooks()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if err := hook.fn(hookCtx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook %q failed: %w", hook.name, err))
+			continue
+		}
+		log.Printf("shutdown hook %q completed", hook.name)
+	}
+
+	return errors.Join(errs...)
+}
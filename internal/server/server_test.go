@@ -0,0 +1,164 @@
+package server
+
+import (
+	"blog-posts-api/internal/config"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testConfig() config.ServerConfig {
+	return config.ServerConfig{
+		Addr:         "127.0.0.1:0",
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+		IdleTimeout:  time.Second,
+		DrainTimeout: time.Second,
+		HookTimeout:  time.Second,
+	}
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	return ln
+}
+
+func TestServer_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	srv := New(testConfig(), handler)
+	ln := listen(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Serve(ctx, ln) }()
+
+	respErr := make(chan error, 1)
+	var body []byte
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			respErr <- err
+			return
+		}
+		defer resp.Body.Close()
+		body, err = io.ReadAll(resp.Body)
+		respErr <- err
+	}()
+
+	<-started
+	cancel()
+
+	if err := <-respErr; err != nil {
+		t.Fatalf("expected in-flight request to complete, got %v", err)
+	}
+	if string(body) != "done" {
+		t.Errorf("expected body 'done', got '%s'", body)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+}
+
+func TestServer_ShutdownHooksRunInReverseOrder(t *testing.T) {
+	srv := New(testConfig(), http.NotFoundHandler())
+
+	var order []string
+	srv.OnShutdown("repository", func(ctx context.Context) error {
+		order = append(order, "repository")
+		return nil
+	})
+	srv.OnShutdown("worker", func(ctx context.Context) error {
+		order = append(order, "worker")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := srv.Serve(ctx, listen(t)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []string{"worker", "repository"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected hook order %v, got %v", expected, order)
+	}
+}
+
+func TestServer_ShutdownHookErrorDoesNotStopOthers(t *testing.T) {
+	srv := New(testConfig(), http.NotFoundHandler())
+
+	hookErr := errors.New("close failed")
+	ran := false
+	srv.OnShutdown("repository", func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	srv.OnShutdown("worker", func(ctx context.Context) error {
+		return hookErr
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := srv.Serve(ctx, listen(t))
+	if !errors.Is(err, hookErr) {
+		t.Errorf("expected hook error to be reported, got %v", err)
+	}
+	if !ran {
+		t.Error("expected remaining hooks to run after a failure")
+	}
+}
+
+func TestServer_Run_ListenError(t *testing.T) {
+	ln := listen(t)
+	defer ln.Close()
+
+	cfg := testConfig()
+	cfg.Addr = ln.Addr().String() // already taken
+	srv := New(cfg, http.NotFoundHandler())
+
+	if err := srv.Run(context.Background()); err == nil {
+		t.Error("expected error when the address is already in use")
+	}
+}
+
+func TestServer_OnDrainRunsBeforeHooks(t *testing.T) {
+	srv := New(testConfig(), http.NotFoundHandler())
+
+	var order []string
+	srv.OnShutdown("repository", func(ctx context.Context) error {
+		order = append(order, "repository")
+		return nil
+	})
+	srv.OnDrain(func() {
+		order = append(order, "drain")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := srv.Serve(ctx, listen(t)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []string{"drain", "repository"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected order %v, got %v", expected, order)
+	}
+}
@@ -0,0 +1,224 @@
+// Package sitemap keeps an in-memory sitemap (https://www.sitemaps.org)
+// that is updated one URL at a time as posts change, so that serving it
+// never needs to walk the whole repository.
+//
+// URLs are split into files of at most MaxURLs entries. A single file is
+// served as the sitemap itself; beyond that a sitemap index lists the files.
+// Encoded files are cached and only the files affected by a change are
+// encoded again.
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MaxURLs is the protocol limit of URLs per sitemap file
+const MaxURLs = 50000
+
+// ContentType of sitemaps and sitemap indexes
+const ContentType = "application/xml; charset=utf-8"
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Entry is a URL of the sitemap
+type Entry struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type file struct {
+	body    []byte
+	lastMod time.Time
+}
+
+// Index holds the sitemap entries keyed by an application ID, e.g. a post ID
+type Index struct {
+	mu      sync.Mutex
+	perFile int
+	keys    []string // sorted, so that a key keeps its file until keys before it change
+	entries map[string]Entry
+	files   []*file // nil when the file must be encoded again
+}
+
+// NewIndex returns an empty index splitting URLs into files of perFile
+// entries, or MaxURLs when perFile is out of range
+func NewIndex(perFile int) *Index {
+	if perFile <= 0 || perFile > MaxURLs {
+		perFile = MaxURLs
+	}
+	return &Index{perFile: perFile, entries: map[string]Entry{}, files: make([]*file, 1)}
+}
+
+// Set adds or replaces the entry of a key
+func (x *Index) Set(key string, e Entry) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	pos, found := slices.BinarySearch(x.keys, key)
+	x.entries[key] = e
+	if found {
+		x.invalidate(pos, pos)
+		return
+	}
+	x.keys = slices.Insert(x.keys, pos, key)
+	// every following key moves one slot
+	x.invalidate(pos, len(x.keys)-1)
+}
+
+// Remove drops the entry of a key, if any
+func (x *Index) Remove(key string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	pos, found := slices.BinarySearch(x.keys, key)
+	if !found {
+		return
+	}
+	delete(x.entries, key)
+	x.keys = slices.Delete(x.keys, pos, pos+1)
+	x.invalidate(pos, len(x.keys))
+}
+
+// invalidate forgets the encoded files holding the keys from..to, and the
+// files past the last key
+func (x *Index) invalidate(from, to int) {
+	count := x.fileCount()
+	if len(x.files) > count {
+		x.files = x.files[:count]
+	}
+	for len(x.files) < count {
+		x.files = append(x.files, nil)
+	}
+	for n := from / x.perFile; n <= to/x.perFile && n < count; n++ {
+		x.files[n] = nil
+	}
+}
+
+func (x *Index) fileCount() int {
+	return max(1, (len(x.keys)+x.perFile-1)/x.perFile)
+}
+
+// Len returns the number of URLs
+func (x *Index) Len() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return len(x.keys)
+}
+
+// Files returns the number of sitemap files, at least one even when empty
+func (x *Index) Files() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.fileCount()
+}
+
+// File returns the encoded sitemap file n, counted from 0, and the most
+// recent lastmod of its entries. ok is false when n is out of range.
+func (x *Index) File(n int) (body []byte, lastMod time.Time, ok bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	f, ok := x.file(n)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	return f.body, f.lastMod, true
+}
+
+func (x *Index) file(n int) (*file, bool) {
+	if n < 0 || n >= x.fileCount() {
+		return nil, false
+	}
+	if x.files[n] != nil {
+		return x.files[n], true
+	}
+
+	start := n * x.perFile
+	end := min(start+x.perFile, len(x.keys))
+	set := urlSet{XMLNS: namespace, URLs: make([]url, 0, end-start)}
+	f := &file{}
+	for _, key := range x.keys[start:end] {
+		e := x.entries[key]
+		set.URLs = append(set.URLs, url{Loc: e.Loc, LastMod: w3cTime(e.LastMod)})
+		if e.LastMod.After(f.lastMod) {
+			f.lastMod = e.LastMod
+		}
+	}
+	f.body = encode(set)
+	x.files[n] = f
+	return f, true
+}
+
+// Root returns the document served at the sitemap location: the only file
+// while every URL fits in one, the sitemap index otherwise
+func (x *Index) Root(loc func(n int) string) (body []byte, lastMod time.Time) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.fileCount() == 1 {
+		f, _ := x.file(0)
+		return f.body, f.lastMod
+	}
+	return x.index(loc)
+}
+
+// IndexFile returns a sitemap index listing every file, located with the
+// given function, and the most recent lastmod of all entries
+func (x *Index) IndexFile(loc func(n int) string) (body []byte, lastMod time.Time) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.index(loc)
+}
+
+func (x *Index) index(loc func(n int) string) (body []byte, lastMod time.Time) {
+	idx := sitemapIndex{XMLNS: namespace}
+	for n := 0; n < x.fileCount(); n++ {
+		f, _ := x.file(n)
+		idx.Sitemaps = append(idx.Sitemaps, url{Loc: loc(n), LastMod: w3cTime(f.lastMod)})
+		if f.lastMod.After(lastMod) {
+			lastMod = f.lastMod
+		}
+	}
+	return encode(idx), lastMod
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	URLs    []url    `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Sitemaps []url    `xml:"sitemap"`
+}
+
+type url struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// w3cTime formats lastmod values in the W3C Datetime format required by the protocol
+func w3cTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func encode(v any) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	if err := enc.Encode(v); err != nil {
+		// only strings and well-formed structs are encoded
+		panic(fmt.Sprintf("sitemap: failed to encode: %v", err))
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,129 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeURLSet(t *testing.T, body []byte) urlSet {
+	t.Helper()
+	var set urlSet
+	if err := xml.Unmarshal(body, &set); err != nil {
+		t.Fatalf("failed to decode urlset: %v", err)
+	}
+	return set
+}
+
+func fileLoc(n int) string {
+	return fmt.Sprintf("https://blog.example.com/sitemaps/%d.xml", n+1)
+}
+
+func TestIndex_SingleFile(t *testing.T) {
+	x := NewIndex(0)
+	lastMod := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	x.Set("b", Entry{Loc: "https://blog.example.com/p/b", LastMod: lastMod})
+	x.Set("a", Entry{Loc: "https://blog.example.com/p/a?x=1&y=2", LastMod: lastMod.Add(-time.Hour)})
+
+	body, gotLastMod := x.Root(fileLoc)
+
+	if !strings.Contains(string(body), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`) {
+		t.Errorf("expected a urlset in the sitemaps namespace, got %s", body)
+	}
+	set := decodeURLSet(t, body)
+	if len(set.URLs) != 2 {
+		t.Fatalf("expected 2 urls, got %d", len(set.URLs))
+	}
+	if set.URLs[0].Loc != "https://blog.example.com/p/a?x=1&y=2" {
+		t.Errorf("expected the escaped loc to round trip, got %s", set.URLs[0].Loc)
+	}
+	if set.URLs[1].LastMod != "2025-01-02T10:00:00Z" {
+		t.Errorf("expected W3C datetime lastmod, got %s", set.URLs[1].LastMod)
+	}
+	if !gotLastMod.Equal(lastMod) {
+		t.Errorf("expected lastmod %v, got %v", lastMod, gotLastMod)
+	}
+}
+
+func TestIndex_Empty(t *testing.T) {
+	x := NewIndex(0)
+
+	set := decodeURLSet(t, mustRoot(x))
+	if len(set.URLs) != 0 {
+		t.Errorf("expected no urls, got %d", len(set.URLs))
+	}
+}
+
+func mustRoot(x *Index) []byte {
+	body, _ := x.Root(fileLoc)
+	return body
+}
+
+func TestIndex_SplitsIntoFiles(t *testing.T) {
+	x := NewIndex(2)
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		x.Set(key, Entry{Loc: "https://blog.example.com/p/" + key})
+	}
+
+	if x.Files() != 3 {
+		t.Fatalf("expected 3 files, got %d", x.Files())
+	}
+
+	var idx sitemapIndex
+	if err := xml.Unmarshal(mustRoot(x), &idx); err != nil {
+		t.Fatalf("expected a sitemap index: %v", err)
+	}
+	if len(idx.Sitemaps) != 3 || idx.Sitemaps[2].Loc != fileLoc(2) {
+		t.Errorf("expected 3 listed files, got %+v", idx.Sitemaps)
+	}
+
+	body, _, ok := x.File(2)
+	if !ok {
+		t.Fatal("expected the last file to exist")
+	}
+	if set := decodeURLSet(t, body); len(set.URLs) != 1 || set.URLs[0].Loc != "https://blog.example.com/p/e" {
+		t.Errorf("unexpected last file %+v", set.URLs)
+	}
+	if _, _, ok := x.File(3); ok {
+		t.Error("expected no file past the last one")
+	}
+}
+
+func TestIndex_ReencodesOnlyAffectedFiles(t *testing.T) {
+	x := NewIndex(2)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		x.Set(key, Entry{Loc: "https://blog.example.com/p/" + key})
+	}
+	first, _, _ := x.File(0)
+	second, _, _ := x.File(1)
+
+	x.Set("d", Entry{Loc: "https://blog.example.com/p/d", LastMod: time.Now()})
+
+	if again, _, _ := x.File(0); &again[0] != &first[0] {
+		t.Error("expected the untouched file to be served from cache")
+	}
+	if again, _, _ := x.File(1); &again[0] == &second[0] {
+		t.Error("expected the changed file to be encoded again")
+	}
+}
+
+func TestIndex_Remove(t *testing.T) {
+	x := NewIndex(2)
+	for _, key := range []string{"a", "b", "c"} {
+		x.Set(key, Entry{Loc: "https://blog.example.com/p/" + key})
+	}
+	x.File(1)
+
+	x.Remove("a")
+	x.Remove("missing")
+
+	if x.Len() != 2 || x.Files() != 1 {
+		t.Fatalf("expected 2 urls in 1 file, got %d in %d", x.Len(), x.Files())
+	}
+	set := decodeURLSet(t, mustRoot(x))
+	if len(set.URLs) != 2 || set.URLs[0].Loc != "https://blog.example.com/p/b" {
+		t.Errorf("unexpected urls after removal %+v", set.URLs)
+	}
+}
@@ -0,0 +1,204 @@
+// Package static renders the published posts as a static website: an
+// index with pagination, a page per post, a page per tag and the feeds,
+// written to a directory that can be served by any web server or CDN.
+//
+// The layout of the site matches the HTML frontend of the API:
+//
+//	/index.html, /page/2/index.html, ...
+//	/p/<slug>/index.html
+//	/tags/<tag>/index.html, /tags/<tag>/page/2/index.html, ...
+//	/feed.rss, /feed.atom, /feed.json and the same per tag
+//	/assets/ for the theme assets
+//
+// Links between pages are root-relative, so the site works on any host,
+// while feeds and canonical links use the configured site URL.
+package static
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/feed"
+	"blog-posts-api/internal/theme"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultPerPage is the number of posts of an index page
+const DefaultPerPage = 10
+
+// Options tune a build
+type Options struct {
+	Site config.SiteConfig
+	Feed config.FeedConfig
+	// PerPage is the number of posts of index and tag pages
+	PerPage int
+}
+
+// Generator builds the static site from the posts of the service
+type Generator struct {
+	service *services.BlogPostService
+	theme   *theme.Theme
+	opts    Options
+}
+
+func NewGenerator(service *services.BlogPostService, t *theme.Theme, opts Options) *Generator {
+	if opts.PerPage <= 0 {
+		opts.PerPage = DefaultPerPage
+	}
+	return &Generator{service: service, theme: t, opts: opts}
+}
+
+// Build renders the site into dir. Files whose content did not change are
+// left untouched and the files of a previous build that are no longer part
+// of the site are removed.
+func (g *Generator) Build(ctx context.Context, dir string) (Stats, error) {
+	published, err := g.service.GetLatest(ctx, models.PostFilter{Status: models.StatusPublished}, 0)
+	if err != nil {
+		return Stats{}, err
+	}
+	posts, tags := theme.Collect(published)
+	if err := theme.Render(g.service, posts, g.opts.Feed.ExcerptLength); err != nil {
+		return Stats{}, err
+	}
+
+	w := newWriter(dir)
+	for _, p := range posts {
+		if err := ctx.Err(); err != nil {
+			return w.stats, err
+		}
+		page := theme.NewPage(g.opts.Site, p.Title+" - "+g.opts.Site.Title, p.URL)
+		page.Post = p
+		page.Description = p.Excerpt
+		page.Type = "article"
+		if err := g.render(w, "post", page); err != nil {
+			return w.stats, err
+		}
+	}
+
+	if err := g.index(w, "index", "/", g.opts.Site.Title, nil, posts, tags); err != nil {
+		return w.stats, err
+	}
+	if err := g.feeds(w, "/", g.opts.Site.Title, posts); err != nil {
+		return w.stats, err
+	}
+	for _, tag := range tags {
+		title := "#" + tag.Name + " - " + g.opts.Site.Title
+		if err := g.index(w, "tag", tag.URL, title, tag, tag.Posts, tags); err != nil {
+			return w.stats, err
+		}
+		if err := g.feeds(w, tag.URL+"/", g.opts.Site.Title+" - #"+tag.Name, tag.Posts); err != nil {
+			return w.stats, err
+		}
+	}
+
+	if err := g.assets(w); err != nil {
+		return w.stats, err
+	}
+	if err := w.finish(); err != nil {
+		return w.stats, err
+	}
+	return w.stats, nil
+}
+
+// index renders the pages of a list of posts at base
+func (g *Generator) index(w *writer, name, base, title string, tag *theme.Tag, posts []*theme.Post, tags []*theme.Tag) error {
+	feeds := strings.TrimSuffix(base, "/") + "/"
+	for n := 1; ; n++ {
+		list, pagination, ok := theme.Paginate(base, posts, g.opts.PerPage, n)
+		if !ok {
+			return nil
+		}
+		page := theme.NewPage(g.opts.Site, title, theme.PagePath(base, n))
+		page.Feeds = theme.Feeds{RSS: feeds + "feed.rss", Atom: feeds + "feed.atom", JSON: feeds + "feed.json"}
+		page.Tag = tag
+		page.Tags = tags
+		page.Posts = list
+		page.Pagination = pagination
+		if n > 1 {
+			page.Title += " - page " + strconv.Itoa(n)
+		}
+		if err := g.render(w, name, page); err != nil {
+			return err
+		}
+	}
+}
+
+func (g *Generator) render(w *writer, name string, page *theme.Page) error {
+	var buf bytes.Buffer
+	if err := g.theme.Render(&buf, name, page); err != nil {
+		return err
+	}
+	return w.write(strings.TrimPrefix(strings.TrimSuffix(page.Path, "/")+"/index.html", "/"), buf.Bytes())
+}
+
+// feeds writes the RSS, Atom and JSON feeds of the latest posts in the
+// directory dir, a root-relative path ending with a slash
+func (g *Generator) feeds(w *writer, dir, title string, posts []*theme.Post) error {
+	if g.opts.Feed.Items > 0 && len(posts) > g.opts.Feed.Items {
+		posts = posts[:g.opts.Feed.Items]
+	}
+
+	f := &feed.Feed{
+		Title:       title,
+		Description: g.opts.Site.Description,
+		SiteURL:     g.opts.Site.URL + strings.TrimSuffix(dir, "/"),
+		Items:       make([]feed.Item, 0, len(posts)),
+	}
+	if dir == "/" {
+		f.SiteURL += "/"
+	}
+	for _, p := range posts {
+		item := feed.Item{
+			ID:        p.ID,
+			Title:     p.Title,
+			URL:       g.opts.Site.URL + p.URL,
+			Author:    p.Author,
+			Tags:      p.Tags,
+			Published: p.Date,
+			Updated:   p.UpdatedAt,
+		}
+		if g.opts.Feed.FullContent {
+			item.ContentHTML = string(p.HTML)
+		} else {
+			item.Summary = p.Excerpt
+		}
+		if p.UpdatedAt.After(f.Updated) {
+			f.Updated = p.UpdatedAt
+		}
+		f.Items = append(f.Items, item)
+	}
+
+	for name, encode := range map[string]func(*feed.Feed) ([]byte, error){
+		"feed.rss":  (*feed.Feed).RSS,
+		"feed.atom": (*feed.Feed).Atom,
+		"feed.json": (*feed.Feed).JSON,
+	} {
+		f.FeedURL = g.opts.Site.URL + dir + name
+		data, err := encode(f)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s%s: %w", dir, name, err)
+		}
+		if err := w.write(dir[1:]+name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assets copies the theme assets
+func (g *Generator) assets(w *writer) error {
+	for _, name := range g.theme.Assets() {
+		data, err := g.theme.Asset(name)
+		if err != nil {
+			return err
+		}
+		if err := w.write("assets/"+name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package static
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/theme"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newTestGenerator(t *testing.T) (*Generator, *services.InMemoryStoreBlogPostRepo) {
+	t.Helper()
+	defaultTheme, err := theme.Default()
+	if err != nil {
+		t.Fatalf("expected the default theme to load, got %v", err)
+	}
+
+	repo := services.NewInMemoryStoreBlogPostRepo()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		status := models.StatusPublished
+		if i == 3 {
+			status = models.StatusDraft
+		}
+		repo.Create(context.Background(), &models.BlogPost{
+			ID:            fmt.Sprintf("id-%d", i),
+			Title:         fmt.Sprintf("Post <%d>", i),
+			Slug:          fmt.Sprintf("post-%d", i),
+			Content:       "Some **markdown**",
+			ContentFormat: models.ContentFormatMarkdown,
+			Author:        "John Doe",
+			Tags:          []string{"go", "intro"},
+			Status:        status,
+			CreatedAt:     base.AddDate(0, 0, i),
+			UpdatedAt:     base.AddDate(0, 0, i),
+		})
+	}
+
+	opts := Options{
+		Site:    config.SiteConfig{URL: "https://blog.example.com", Title: "Blog"},
+		Feed:    config.FeedConfig{Items: 20, FullContent: true, ExcerptLength: 100},
+		PerPage: 1,
+	}
+	return NewGenerator(services.NewBlogPostService(repo), defaultTheme, opts), repo
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("expected %s to exist, got %v", name, err)
+	}
+	return string(data)
+}
+
+func TestBuild(t *testing.T) {
+	g, _ := newTestGenerator(t)
+	dir := t.TempDir()
+
+	if _, err := g.Build(context.Background(), dir); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	post := readFile(t, dir, "p/post-1/index.html")
+	if !strings.Contains(post, "<h1>Post &lt;1&gt;</h1>") || !strings.Contains(post, "<strong>markdown</strong>") {
+		t.Errorf("expected the escaped title and rendered content, got %s", post)
+	}
+	if !strings.Contains(post, `href="https://blog.example.com/p/post-1"`) || !strings.Contains(post, `href="/tags/go"`) {
+		t.Errorf("expected canonical and tag links, got %s", post)
+	}
+
+	// the latest post comes first, one per page
+	if index := readFile(t, dir, "index.html"); !strings.Contains(index, "/p/post-2") || !strings.Contains(index, `rel="next" href="/page/2"`) {
+		t.Errorf("unexpected first page %s", index)
+	}
+	if page := readFile(t, dir, "page/2/index.html"); !strings.Contains(page, "/p/post-1") || !strings.Contains(page, `rel="prev" href="/"`) {
+		t.Errorf("unexpected second page %s", page)
+	}
+	readFile(t, dir, "tags/intro/page/2/index.html")
+
+	if atom := readFile(t, dir, "tags/go/feed.atom"); !strings.Contains(atom, `href="https://blog.example.com/p/post-2"`) {
+		t.Errorf("expected the tag feed to link the posts, got %s", atom)
+	}
+	readFile(t, dir, "feed.rss")
+	readFile(t, dir, "feed.json")
+	readFile(t, dir, "assets/style.css")
+	readFile(t, dir, "assets/chroma.css")
+
+	if _, err := os.Stat(filepath.Join(dir, "p/post-3")); !os.IsNotExist(err) {
+		t.Errorf("expected drafts to be left out, got %v", err)
+	}
+}
+
+func TestBuild_DotTags(t *testing.T) {
+	g, repo := newTestGenerator(t)
+	dir := t.TempDir()
+	// stored before tags were validated, the pages of . and .. must not
+	// land on the home page or outside the directory
+	repo.Create(context.Background(), &models.BlogPost{
+		ID: "id-dots", Title: "Dots", Slug: "dots", Content: "Dots", Author: "John Doe",
+		Tags: []string{".", ".."}, Status: models.StatusPublished, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	if _, err := g.Build(context.Background(), dir); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if index := readFile(t, dir, "index.html"); !strings.Contains(index, "/p/post-2") {
+		t.Errorf("expected the home page to list the latest post, got %s", index)
+	}
+	if tag := readFile(t, dir, "tags/%2E%2E/index.html"); !strings.Contains(tag, "/p/dots") {
+		t.Errorf("expected the page of the .. tag, got %s", tag)
+	}
+	readFile(t, dir, "tags/%2E/index.html")
+}
+
+func TestBuild_OnlyRewritesChangedFiles(t *testing.T) {
+	g, repo := newTestGenerator(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	first, err := g.Build(ctx, dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if first.Written == 0 || first.Unchanged != 0 {
+		t.Fatalf("expected every file to be written, got %+v", first)
+	}
+
+	again, _ := g.Build(ctx, dir)
+	if again.Written != 0 || again.Unchanged != first.Written {
+		t.Errorf("expected nothing to be rewritten, got %+v", again)
+	}
+
+	// deleting a post removes its page, the now unneeded second pages, and
+	// rewrites the lists and feeds
+	repo.Delete(ctx, "id-1")
+	stats, _ := g.Build(ctx, dir)
+	if stats.Removed != 4 || stats.Written == 0 {
+		t.Errorf("expected the stale files to be removed, got %+v", stats)
+	}
+	for _, name := range []string{"p/post-1", "page", "tags/go/page"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", name, err)
+		}
+	}
+}
+
+func TestBuild_KeepsUnknownFiles(t *testing.T) {
+	g, _ := newTestGenerator(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "CNAME"), []byte("blog.example.com"), 0o644)
+	os.WriteFile(filepath.Join(dir, manifestName), []byte("../outside\nCNAME-not-there\n"), 0o644)
+
+	if _, err := g.Build(context.Background(), dir); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if readFile(t, dir, "CNAME") != "blog.example.com" {
+		t.Error("expected files unknown to the manifest to be kept")
+	}
+}
+
+func TestBuild_Theme(t *testing.T) {
+	files := fstest.MapFS{
+		"layout.html": {Data: []byte(`{{define "layout"}}[{{template "content" .}}]{{end}}`)},
+		"index.html":  {Data: []byte(`{{define "content"}}{{len .Posts}} posts{{end}}`)},
+		"post.html":   {Data: []byte(`{{define "content"}}{{.Post.Title}}{{end}}`)},
+		"tag.html":    {Data: []byte(`{{define "content"}}{{.Tag.Name}}{{end}}`)},
+		"chroma.css":  {Data: []byte(`/* own */`)},
+	}
+	loaded, err := theme.Load(files)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	g, _ := newTestGenerator(t)
+	g.theme = loaded
+	dir := t.TempDir()
+	if _, err := g.Build(context.Background(), dir); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if index := readFile(t, dir, "index.html"); index != "[1 posts]" {
+		t.Errorf("expected the theme layout, got %q", index)
+	}
+	if css := readFile(t, dir, "assets/chroma.css"); css != "/* own */" {
+		t.Errorf("expected the theme stylesheet, got %q", css)
+	}
+
+	delete(files, "tag.html")
+	if _, err := theme.Load(files); err == nil {
+		t.Error("expected an error for a theme without a tag page")
+	}
+}
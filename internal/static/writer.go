@@ -0,0 +1,145 @@
+package static
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// manifestName lists the files written by the previous build, so that the
+// files of deleted posts and pages can be removed without touching anything
+// else in the directory
+const manifestName = ".static-manifest"
+
+// Stats counts the files of a build
+type Stats struct {
+	Written   int
+	Unchanged int
+	Removed   int
+}
+
+// writer only rewrites the files whose content changed, so that repeated
+// builds keep modification times and only upload what changed to a CDN
+type writer struct {
+	dir   string
+	files map[string]bool
+	stats Stats
+}
+
+func newWriter(dir string) *writer {
+	return &writer{dir: dir, files: map[string]bool{}}
+}
+
+// write stores data at the slash-separated path rel, relative to the
+// directory
+func (w *writer) write(rel string, data []byte) error {
+	// a page path resolving elsewhere, e.g. tags/../index.html, would
+	// overwrite another page or leave the directory
+	if !filepath.IsLocal(filepath.FromSlash(rel)) || path.Clean(rel) != rel {
+		return fmt.Errorf("invalid file path %q", rel)
+	}
+	w.files[rel] = true
+	path := filepath.Join(w.dir, filepath.FromSlash(rel))
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		w.stats.Unchanged++
+		return nil
+	}
+
+	if err := writeAtomic(path, data); err != nil {
+		return err
+	}
+	w.stats.Written++
+	return nil
+}
+
+// finish removes the files of the previous build that were not written by
+// this one and records the new manifest
+func (w *writer) finish() error {
+	previous, err := w.readManifest()
+	if err != nil {
+		return err
+	}
+	for _, rel := range previous {
+		if w.files[rel] {
+			continue
+		}
+		path := filepath.Join(w.dir, filepath.FromSlash(rel))
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		w.stats.Removed++
+		w.removeEmptyParents(path)
+	}
+
+	files := make([]string, 0, len(w.files))
+	for rel := range w.files {
+		files = append(files, rel)
+	}
+	slices.Sort(files)
+	return writeAtomic(filepath.Join(w.dir, manifestName), []byte(strings.Join(files, "\n")+"\n"))
+}
+
+func (w *writer) readManifest() ([]string, error) {
+	f, err := os.Open(filepath.Join(w.dir, manifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var files []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		rel := scanner.Text()
+		// never follow a tampered manifest out of the directory
+		if rel == "" || !filepath.IsLocal(filepath.FromSlash(rel)) {
+			continue
+		}
+		files = append(files, rel)
+	}
+	return files, scanner.Err()
+}
+
+func (w *writer) removeEmptyParents(path string) {
+	root := filepath.Clean(w.dir)
+	for dir := filepath.Dir(path); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		// fails, and stops, on the first directory that is not empty
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// writeAtomic replaces the file with a rename, so that a sync running next
+// to the build never reads a partial file
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,157 @@
+// Package stream keeps a bounded in-memory log of the recent post events,
+// which server-sent event streams read at their own pace: appending never
+// waits for a stream, and a stream falling behind the log is told to reload
+// instead of holding events in memory.
+//
+// Every event gets an ID made of the epoch of the log, unique to the
+// process, and of a sequence number, so that a stream resumed from the ID
+// of its last event gets the events after it, and one resumed from an ID
+// the log no longer holds, or from another process, is told to reload.
+package stream
+
+import (
+	"blog-posts-api/internal/api/services"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Entry is an event of the log
+type Entry struct {
+	ID    string
+	Event services.Event
+	// Data is the services.MarshalEvent form of the event
+	Data []byte
+}
+
+// Log is a bounded in-memory log of events
+type Log struct {
+	epoch string
+
+	mu      sync.Mutex
+	entries []Entry // ring buffer of the last len(entries) events
+	next    uint64  // sequence number of the next event, starting at 1
+	changed chan struct{}
+	closed  bool
+}
+
+// NewLog returns a log keeping the last size events
+func NewLog(size int) *Log {
+	return &Log{
+		epoch:   newEpoch(),
+		entries: make([]Entry, max(size, 1)),
+		next:    1,
+		changed: make(chan struct{}),
+	}
+}
+
+// Append adds an event to the log and wakes up the streams waiting for it.
+// It is a services.Listener.
+func (l *Log) Append(e services.Event) {
+	data, err := services.MarshalEvent(e)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	seq := l.next
+	l.next++
+	l.entries[seq%uint64(len(l.entries))] = Entry{ID: l.id(seq), Event: e, Data: data}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Cursor is the position of a stream in the log, the sequence number of
+// the last event it got
+type Cursor uint64
+
+// Head returns the cursor of a stream starting after the last event
+func (l *Log) Head() Cursor {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Cursor(l.next - 1)
+}
+
+// Resume returns the cursor of a stream whose last event had the given ID.
+// It returns false if the log no longer holds the events after it.
+func (l *Log) Resume(id string) (Cursor, bool) {
+	epoch, seqText, ok := strings.Cut(id, "-")
+	if !ok || epoch != l.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if seq >= l.next || seq+1 < l.first() {
+		return 0, false
+	}
+	return Cursor(seq), true
+}
+
+// Read returns the events after the cursor and the cursor after them, along
+// with a channel closed once there are more events. It returns false if the
+// stream fell behind: the log no longer holds the events after the cursor.
+func (l *Log) Read(after Cursor) ([]Entry, Cursor, <-chan struct{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	seq := uint64(after)
+	if seq+1 < l.first() {
+		return nil, after, l.changed, false
+	}
+
+	entries := make([]Entry, 0, l.next-seq-1)
+	for s := seq + 1; s < l.next; s++ {
+		entries = append(entries, l.entries[s%uint64(len(l.entries))])
+	}
+	return entries, Cursor(l.next - 1), l.changed, true
+}
+
+// Close wakes up the waiting streams for the last time, e.g. when the
+// server shuts down, and drops the events appended afterwards
+func (l *Log) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		close(l.changed)
+	}
+}
+
+// Closed tells whether the log was closed
+func (l *Log) Closed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+// first returns the sequence number of the oldest event held
+func (l *Log) first() uint64 {
+	size := uint64(len(l.entries))
+	if l.next <= size {
+		return 1
+	}
+	return l.next - size
+}
+
+func (l *Log) id(seq uint64) string {
+	return fmt.Sprintf("%s-%d", l.epoch, seq)
+}
+
+// newEpoch returns a random epoch, telling apart the IDs of different
+// processes
+func newEpoch() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"blog-posts-api/internal/api/services"
+	"testing"
+)
+
+func appendEvents(l *Log, ids ...string) {
+	for _, id := range ids {
+		l.Append(services.Event{ID: id, Type: services.EventPostCreated, PostID: id})
+	}
+}
+
+func eventIDs(entries []Entry) []string {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.Event.ID
+	}
+	return ids
+}
+
+func TestLog_Read(t *testing.T) {
+	l := NewLog(3)
+	cursor := l.Head()
+	_, _, changed, _ := l.Read(cursor)
+
+	appendEvents(l, "a", "b")
+	select {
+	case <-changed:
+	default:
+		t.Fatal("expected the append to wake up the readers")
+	}
+
+	entries, cursor, _, ok := l.Read(cursor)
+	if !ok || len(entries) != 2 || entries[0].Event.ID != "a" || entries[1].Event.ID != "b" {
+		t.Fatalf("expected a and b, got %v", eventIDs(entries))
+	}
+	if entries, _, _, _ = l.Read(cursor); len(entries) != 0 {
+		t.Errorf("expected no event after the cursor, got %v", eventIDs(entries))
+	}
+
+	appendEvents(l, "c", "d", "e", "f")
+	if _, _, _, ok := l.Read(cursor); ok {
+		t.Error("expected a reader more than 3 events behind to fall behind")
+	}
+}
+
+func TestLog_Resume(t *testing.T) {
+	l := NewLog(3)
+	appendEvents(l, "a", "b", "c")
+	entries, _, _, _ := l.Read(0)
+
+	cursor, ok := l.Resume(entries[0].ID)
+	if !ok {
+		t.Fatalf("expected to resume after %s", entries[0].ID)
+	}
+	if entries, _, _, _ := l.Read(cursor); len(entries) != 2 || entries[0].Event.ID != "b" {
+		t.Errorf("expected b and c, got %v", eventIDs(entries))
+	}
+
+	appendEvents(l, "d", "e")
+	for _, id := range []string{entries[0].ID, "other-1", "garbage", NewLog(3).id(1)} {
+		if _, ok := l.Resume(id); ok {
+			t.Errorf("expected %q not to be resumable", id)
+		}
+	}
+}
+
+func TestLog_Close(t *testing.T) {
+	l := NewLog(3)
+	_, _, changed, _ := l.Read(l.Head())
+	l.Close()
+	l.Close()
+
+	<-changed
+	if !l.Closed() {
+		t.Error("expected the log to be closed")
+	}
+	appendEvents(l, "a")
+	if entries, _, _, _ := l.Read(0); len(entries) != 0 {
+		t.Errorf("expected no event after closing, got %v", eventIDs(entries))
+	}
+}
@@ -0,0 +1,224 @@
+package theme
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/render"
+	"html/template"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page is the data the theme templates are executed with
+type Page struct {
+	Site  config.SiteConfig
+	Title string
+	// Description is the summary of the page for search engines and
+	// link previews
+	Description string
+	// Type is the Open Graph type of the page, website or article
+	Type string
+	// Path is the root-relative URL of the page
+	Path string
+	// Canonical is the absolute URL of the page
+	Canonical string
+	Feeds     Feeds
+	// Post is set on post pages
+	Post *Post
+	// Posts are the posts of index and tag pages
+	Posts []*Post
+	// Tag is set on tag pages
+	Tag        *Tag
+	Tags       []*Tag
+	Pagination Pagination
+	// Account is set on account pages
+	Account *Account
+}
+
+// Account is the state of an account page, a form or the account of the
+// session
+type Account struct {
+	// View is the form or message shown: login, register, verify, forgot,
+	// reset or account
+	View string
+	// CSRF is the token the forms post back in the csrf_token field
+	CSRF string
+	// Error is the reason the form was rejected, Invalid its invalid fields
+	Error   string
+	Invalid []apperrors.FieldError
+	// Notice is a message shown above the form, e.g. after registering
+	Notice string
+	// Email, Name and Token fill the form back in
+	Email string
+	Name  string
+	Token string
+	// User is the account of the session
+	User *models.User
+}
+
+// Feeds are the URLs of the feeds matching a page
+type Feeds struct {
+	RSS  string
+	Atom string
+	JSON string
+}
+
+// Post is a published post with its rendered content
+type Post struct {
+	*models.BlogPost
+	URL  string
+	HTML template.HTML
+	// Date is the publication date
+	Date    time.Time
+	Excerpt string
+	TagList []*Tag
+}
+
+// Tag lists the posts of a tag
+type Tag struct {
+	Name  string
+	URL   string
+	Posts []*Post
+}
+
+// Pagination links the pages of an index, Prev and Next are empty on the
+// first and last pages
+type Pagination struct {
+	Page  int
+	Pages int
+	Prev  string
+	Next  string
+}
+
+// NewPage returns a page of the site with the site feeds, callers fill in
+// the rest
+func NewPage(site config.SiteConfig, title, path string) *Page {
+	return &Page{
+		Site:        site,
+		Title:       title,
+		Description: site.Description,
+		Type:        "website",
+		Path:        path,
+		Canonical:   site.URL + path,
+		Feeds:       Feeds{RSS: "/feed.rss", Atom: "/feed.atom", JSON: "/feed.json"},
+	}
+}
+
+// Collect returns the published posts, latest first as returned by
+// BlogPostService.GetLatest, with their URLs, and groups them by tag. Tags
+// are sorted by name. The content of the posts is rendered by Render, only
+// for the posts shown.
+func Collect(published []*models.BlogPost) ([]*Post, []*Tag) {
+	posts := make([]*Post, 0, len(published))
+	taken := map[string]bool{}
+	byURL := map[string]*Tag{}
+	for _, p := range published {
+		post := &Post{
+			BlogPost: p,
+			URL:      postPath(p, taken),
+			Date:     p.CreatedAt,
+		}
+		if p.PublishedAt != nil {
+			post.Date = *p.PublishedAt
+		}
+		for _, name := range p.Tags {
+			u := TagPath(name)
+			tag, ok := byURL[u]
+			if !ok {
+				tag = &Tag{Name: name, URL: u}
+				byURL[u] = tag
+			}
+			// a post listing the same tag twice appears once
+			if !slices.Contains(tag.Posts, post) {
+				tag.Posts = append(tag.Posts, post)
+				post.TagList = append(post.TagList, tag)
+			}
+		}
+		posts = append(posts, post)
+	}
+
+	tags := make([]*Tag, 0, len(byURL))
+	for _, tag := range byURL {
+		tags = append(tags, tag)
+	}
+	slices.SortFunc(tags, func(a, b *Tag) int { return strings.Compare(a.Name, b.Name) })
+	return posts, tags
+}
+
+// Render sets the HTML content and excerpt of the posts
+func Render(service *services.BlogPostService, posts []*Post, excerptLength int) error {
+	for _, p := range posts {
+		if p.HTML != "" {
+			continue
+		}
+		html, err := service.RenderContent(p.BlogPost)
+		if err != nil {
+			return err
+		}
+		p.HTML = template.HTML(html)
+		p.Excerpt = render.Excerpt(html, excerptLength)
+	}
+	return nil
+}
+
+// Paginate returns the posts of page n of the list at base, false when
+// there is no such page. A list without posts has a single empty page.
+func Paginate(base string, posts []*Post, perPage, n int) ([]*Post, Pagination, bool) {
+	pages := max(1, (len(posts)+perPage-1)/perPage)
+	if n < 1 || n > pages {
+		return nil, Pagination{}, false
+	}
+
+	p := Pagination{Page: n, Pages: pages}
+	if n > 1 {
+		p.Prev = PagePath(base, n-1)
+	}
+	if n < pages {
+		p.Next = PagePath(base, n+1)
+	}
+	return posts[(n-1)*perPage : min(n*perPage, len(posts))], p, true
+}
+
+// postPath returns the URL of a post page, falling back to the post ID for
+// posts without a slug or sharing the slug of a more recent post
+func postPath(p *models.BlogPost, taken map[string]bool) string {
+	u := "/p/" + p.Slug
+	if p.Slug == "" || taken[u] {
+		u = "/p/" + p.ID
+	}
+	taken[u] = true
+	return u
+}
+
+// TagPath returns the URL of a tag page, tags without ASCII letters or
+// digits are escaped rather than slugified
+func TagPath(name string) string {
+	slug := services.Slugify(name)
+	if slug == "" {
+		slug = EscapeSegment(name)
+	}
+	return "/tags/" + slug
+}
+
+// EscapeSegment escapes a path segment. Unlike url.PathEscape, it also
+// escapes the dot segments . and .., which would name the parent directory
+// of the page, e.g. /tags/.. the home page.
+func EscapeSegment(s string) string {
+	if s == "." || s == ".." {
+		return strings.Repeat("%2E", len(s))
+	}
+	return url.PathEscape(s)
+}
+
+// PagePath returns the URL of page n of the list at base
+func PagePath(base string, n int) string {
+	if n == 1 {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/page/" + strconv.Itoa(n)
+}
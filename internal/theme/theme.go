@@ -0,0 +1,213 @@
+// Package theme renders the public pages of the blog, the index, post and
+// tag pages and the account pages, with html/template themes. The same themes and page data are
+// used by the HTML frontend of the API and by the static site export.
+package theme
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+//go:embed themes/default
+var themes embed.FS
+
+const (
+	highlightAsset = "chroma.css"
+	highlightStyle = "github"
+)
+
+// pages are the templates every theme provides, next to layout.html
+var pages = []string{"index", "post", "tag"}
+
+// optionalPages are the templates of the pages served by the API only,
+// parsed when the theme provides them
+var optionalPages = []string{"account"}
+
+// Theme is a set of html/template pages and static assets. A theme is a
+// directory holding:
+//
+//   - layout.html, which defines a "layout" template wrapping the "content"
+//     template of every page, and any partial shared by the pages
+//   - index.html, post.html and tag.html, which define "content"
+//   - optionally account.html, which defines the "content" of the login,
+//     registration and password pages served by the API
+//   - any other file, served or copied as is under /assets/ (e.g. style.css)
+//
+// Rendered code blocks carry chroma CSS classes: themes without their own
+// chroma.css asset get one generated with the github style.
+//
+// Pages are executed with a Page.
+type Theme struct {
+	pages  map[string]*template.Template
+	fsys   fs.FS
+	assets []string
+	// highlight is the code highlighting stylesheet of themes without
+	// their own chroma.css
+	highlight []byte
+}
+
+// Default returns the theme shipped with the binary
+func Default() (*Theme, error) {
+	return Load(defaultFS())
+}
+
+// LoadDir returns the default theme with the files of dir taking
+// precedence, so that a theme can override a single template or asset.
+// An empty dir is the default theme.
+func LoadDir(dir string) (*Theme, error) {
+	if dir == "" {
+		return Default()
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	return Load(Overlay(os.DirFS(dir), defaultFS()))
+}
+
+// Load parses the templates of the theme rooted at fsys
+func Load(fsys fs.FS) (*Theme, error) {
+	t := &Theme{pages: map[string]*template.Template{}, fsys: fsys}
+	names := slices.Clone(pages)
+	for _, name := range optionalPages {
+		if _, err := fs.Stat(fsys, name+".html"); err == nil {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		tmpl, err := template.New(name).Funcs(funcs).ParseFS(fsys, "layout.html", name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the %s page: %w", name, err)
+		}
+		if tmpl.Lookup("layout") == nil || tmpl.Lookup("content") == nil {
+			return nil, fmt.Errorf("the %s page must define the layout and content templates", name)
+		}
+		t.pages[name] = tmpl
+	}
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") || path.Ext(p) == ".html" {
+			return nil
+		}
+		t.assets = append(t.assets, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the theme assets: %w", err)
+	}
+
+	if !slices.Contains(t.assets, highlightAsset) {
+		var buf bytes.Buffer
+		if err := chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(&buf, styles.Get(highlightStyle)); err != nil {
+			return nil, fmt.Errorf("failed to write the highlighting stylesheet: %w", err)
+		}
+		t.highlight = buf.Bytes()
+		t.assets = append(t.assets, highlightAsset)
+	}
+	return t, nil
+}
+
+// Has reports whether the theme provides the page template name
+func (t *Theme) Has(name string) bool {
+	_, ok := t.pages[name]
+	return ok
+}
+
+// Render executes the page template name, one of index, post and tag, or
+// account when the theme has it
+func (t *Theme) Render(w io.Writer, name string, page *Page) error {
+	tmpl, ok := t.pages[name]
+	if !ok {
+		return fmt.Errorf("unknown page %q", name)
+	}
+	// rendered to a buffer so that a failing template writes nothing
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", page); err != nil {
+		return fmt.Errorf("failed to render %s: %w", page.Path, err)
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// Assets returns the slash-separated names of the theme assets
+func (t *Theme) Assets() []string {
+	return slices.Clone(t.assets)
+}
+
+// Asset returns the content of an asset, fs.ErrNotExist for templates and
+// unknown names
+func (t *Theme) Asset(name string) ([]byte, error) {
+	if !slices.Contains(t.assets, name) {
+		return nil, fs.ErrNotExist
+	}
+	if name == highlightAsset && t.highlight != nil {
+		return t.highlight, nil
+	}
+	return fs.ReadFile(t.fsys, name)
+}
+
+func defaultFS() fs.FS {
+	fsys, err := fs.Sub(themes, "themes/default")
+	if err != nil {
+		panic(err)
+	}
+	return fsys
+}
+
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("January 2, 2006") },
+	"iso":  func(t time.Time) string { return t.Format(time.RFC3339) },
+}
+
+// Overlay returns a file system reading from top, and from base for the
+// files top does not have. Directories list the entries of both.
+func Overlay(top, base fs.FS) fs.FS {
+	return overlay{top: top, base: base}
+}
+
+type overlay struct {
+	top, base fs.FS
+}
+
+func (o overlay) Open(name string) (fs.File, error) {
+	f, err := o.top.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return o.base.Open(name)
+}
+
+func (o overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	top, topErr := fs.ReadDir(o.top, name)
+	base, baseErr := fs.ReadDir(o.base, name)
+	if topErr != nil && baseErr != nil {
+		return nil, topErr
+	}
+
+	entries := slices.Clone(top)
+	for _, e := range base {
+		if !slices.ContainsFunc(top, func(t fs.DirEntry) bool { return t.Name() == e.Name() }) {
+			entries = append(entries, e)
+		}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
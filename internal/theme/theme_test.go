@@ -0,0 +1,136 @@
+package theme
+
+import (
+	"blog-posts-api/internal/api/models"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOverlay(t *testing.T) {
+	top := fstest.MapFS{"post.html": {Data: []byte("top")}, "extra.css": {Data: []byte("extra")}}
+	base := fstest.MapFS{"post.html": {Data: []byte("base")}, "layout.html": {Data: []byte("layout")}}
+	fsys := Overlay(top, base)
+
+	if data, _ := fs.ReadFile(fsys, "post.html"); string(data) != "top" {
+		t.Errorf("expected the top file, got %q", data)
+	}
+	if data, _ := fs.ReadFile(fsys, "layout.html"); string(data) != "layout" {
+		t.Errorf("expected the base file, got %q", data)
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "extra.css,layout.html,post.html" {
+		t.Errorf("expected the entries of both, got %v", names)
+	}
+}
+
+func TestLoad_MissingPage(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layout.html": {Data: []byte(`{{define "layout"}}{{template "content" .}}{{end}}`)},
+		"index.html":  {Data: []byte(`{{define "content"}}{{end}}`)},
+		"post.html":   {Data: []byte(`{{define "content"}}{{end}}`)},
+	}
+	if _, err := Load(fsys); err == nil {
+		t.Error("expected an error for a theme without a tag page")
+	}
+}
+
+func TestLoad_OptionalPage(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layout.html": {Data: []byte(`{{define "layout"}}{{template "content" .}}{{end}}`)},
+		"index.html":  {Data: []byte(`{{define "content"}}{{end}}`)},
+		"post.html":   {Data: []byte(`{{define "content"}}{{end}}`)},
+		"tag.html":    {Data: []byte(`{{define "content"}}{{end}}`)},
+	}
+	th, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("expected a theme without account page to load, got %v", err)
+	}
+	if th.Has("account") || th.Render(io.Discard, "account", &Page{}) == nil {
+		t.Error("expected no account page")
+	}
+
+	th, err = Default()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var buf strings.Builder
+	page := &Page{Title: "Log in", Account: &Account{View: "login", CSRF: "tok<en", Email: "ann@example.com"}}
+	if err := th.Render(&buf, "account", page); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), `name="csrf_token" value="tok&lt;en"`) || !strings.Contains(buf.String(), `value="ann@example.com"`) {
+		t.Errorf("expected the login form, got %s", buf.String())
+	}
+}
+
+func TestCollect(t *testing.T) {
+	posts, tags := Collect([]*models.BlogPost{
+		{ID: "3", Slug: "same", Tags: []string{"Go", "go"}},
+		{ID: "2", Slug: "same", Tags: []string{"C++"}},
+		{ID: "1", Tags: []string{"日本"}},
+	})
+
+	var urls []string
+	for _, p := range posts {
+		urls = append(urls, p.URL)
+	}
+	if strings.Join(urls, ",") != "/p/same,/p/2,/p/1" {
+		t.Errorf("expected the latest post to keep a shared slug, got %v", urls)
+	}
+
+	var tagURLs []string
+	for _, tag := range tags {
+		tagURLs = append(tagURLs, tag.URL)
+	}
+	if strings.Join(tagURLs, ",") != "/tags/c,/tags/go,/tags/%E6%97%A5%E6%9C%AC" {
+		t.Errorf("unexpected tags %v", tagURLs)
+	}
+	if len(posts[0].TagList) != 1 || len(tags[1].Posts) != 1 {
+		t.Errorf("expected tags differing by case to be merged, got %v", posts[0].TagList)
+	}
+}
+
+func TestTagPath(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Go":   "/tags/go",
+		"c#":   "/tags/c",
+		"?":    "/tags/%3F",
+		".":    "/tags/%2E",
+		"..":   "/tags/%2E%2E",
+		"...":  "/tags/...",
+		"a/..": "/tags/a",
+	} {
+		if u := TagPath(name); u != expected {
+			t.Errorf("expected %s for %q, got %s", expected, name, u)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	posts := make([]*Post, 5)
+
+	list, p, ok := Paginate("/tags/go", posts, 2, 3)
+	if !ok || len(list) != 1 || p.Prev != "/tags/go/page/2" || p.Next != "" || p.Pages != 3 {
+		t.Errorf("unexpected last page %d %+v", len(list), p)
+	}
+	if _, p, ok := Paginate("/", posts, 2, 1); !ok || p.Next != "/page/2" || p.Prev != "" {
+		t.Errorf("unexpected first page %+v", p)
+	}
+	if _, _, ok := Paginate("/", posts, 2, 4); ok {
+		t.Error("expected no fourth page")
+	}
+	if list, _, ok := Paginate("/", nil, 2, 1); !ok || len(list) != 0 {
+		t.Error("expected a single empty page without posts")
+	}
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateDestination is returned when a delivery would connect to a
+// loopback, link-local or private address
+var ErrPrivateDestination = errors.New("webhook: private destination address")
+
+// newTransport returns the transport of the default client. Unless
+// allowPrivate is set, a webhook cannot reach the network of the server:
+// every connection is checked with the address it is made to, once the
+// host is resolved, so a host resolving to a public address at first and
+// to a private one later on gets nowhere either. The environment proxy is
+// not used, it would be the only destination checked.
+func newTransport(allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = checkDestination
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// checkDestination is a net.Dialer Control function refusing the
+// addresses a webhook must not reach
+func checkDestination(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("unexpected %s address %q: %w", network, address, err)
+	}
+	addr := addrPort.Addr().Unmap()
+	if addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() {
+		return fmt.Errorf("%w %s", ErrPrivateDestination, addr)
+	}
+	return nil
+}
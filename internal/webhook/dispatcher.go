@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"blog-posts-api/internal/api/apperrors"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/health"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxResponseBytes bounds the part of a response body read before the
+// connection is reused
+const maxResponseBytes = 64 << 10
+
+// Options tune a Dispatcher
+type Options struct {
+	// Workers is the number of deliveries sent concurrently
+	Workers int
+	// Timeout bounds every attempt
+	Timeout time.Duration
+	// PollInterval is how often due retries are looked for, new deliveries
+	// are sent right away
+	PollInterval time.Duration
+	// Heartbeat, when set, is beaten on every iteration of the loop
+	Heartbeat *health.Heartbeat
+	// Client sends the requests, by default a client with Timeout that does
+	// not follow redirects nor connect to private addresses
+	Client *http.Client
+	// AllowPrivateNetworks lets the default client connect to loopback,
+	// link-local and private addresses, e.g. to receivers on the same host
+	AllowPrivateNetworks bool
+}
+
+// Dispatcher sends the due deliveries of a WebhookService and records the
+// outcome of every attempt
+type Dispatcher struct {
+	service *services.WebhookService
+	opts    Options
+
+	mu       sync.Mutex
+	inflight map[string]bool
+	// done wakes the loop when a worker is free again
+	done chan struct{}
+}
+
+func NewDispatcher(service *services.WebhookService, opts Options) *Dispatcher {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{
+			Transport: newTransport(opts.AllowPrivateNetworks),
+			Timeout:   opts.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return &Dispatcher{
+		service:  service,
+		opts:     opts,
+		inflight: map[string]bool{},
+		done:     make(chan struct{}, 1),
+	}
+}
+
+// Run sends deliveries until ctx is canceled, then waits for the attempts
+// in flight to finish
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		if d.opts.Heartbeat != nil {
+			d.opts.Heartbeat.Beat()
+		}
+		d.dispatch(ctx, &wg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.service.Queued():
+		case <-d.done:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch starts an attempt for every due delivery a worker is free for
+func (d *Dispatcher) dispatch(ctx context.Context, wg *sync.WaitGroup) {
+	d.mu.Lock()
+	free := d.opts.Workers - len(d.inflight)
+	d.mu.Unlock()
+	if free <= 0 {
+		return
+	}
+
+	// deliveries in flight are still due, ask for enough to skip them
+	due, err := d.service.Due(ctx, free+d.opts.Workers)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("webhooks: failed to list the due deliveries: %v", err)
+		}
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, delivery := range due {
+		if len(d.inflight) >= d.opts.Workers {
+			return
+		}
+		if d.inflight[delivery.ID] {
+			continue
+		}
+		d.inflight[delivery.ID] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// attempts in flight run to completion on shutdown
+			d.attempt(context.WithoutCancel(ctx), delivery)
+			d.mu.Lock()
+			delete(d.inflight, delivery.ID)
+			d.mu.Unlock()
+			select {
+			case d.done <- struct{}{}:
+			default:
+			}
+		}()
+	}
+}
+
+// attempt sends a delivery once and records the outcome
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	webhook, err := d.service.GetById(ctx, delivery.WebhookID)
+	if apperrors.Is(err, apperrors.KindNotFound) {
+		// deleted along with its deliveries
+		return
+	}
+	if err != nil {
+		log.Printf("webhooks: failed to load webhook %s: %v", delivery.WebhookID, err)
+		return
+	}
+
+	start := time.Now()
+	status, err := d.send(ctx, webhook, delivery, start)
+	result := models.WebhookAttempt{At: start.UTC(), StatusCode: status, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if _, err := d.service.RecordAttempt(ctx, delivery.ID, result); err != nil && !apperrors.Is(err, apperrors.KindNotFound) {
+		log.Printf("webhooks: failed to record an attempt of delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// send POSTs the payload of a delivery to the webhook and returns the
+// response status, with an error unless it is 2xx
+func (d *Dispatcher) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "blog-posts-api-webhooks/1.0")
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, now, delivery.Payload))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,59 @@
+// Package webhook sends the deliveries queued by services.WebhookService to
+// the subscribed endpoints. Every delivery is a JSON models.WebhookEvent
+// POSTed with these headers:
+//
+//	X-Webhook-Id         the delivery ID, the same across retries
+//	X-Webhook-Event      the event type, e.g. post.published
+//	X-Webhook-Timestamp  the Unix time of the attempt
+//	X-Webhook-Signature  sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// Receivers recompute the signature with the webhook secret, see Verify,
+// and reject stale timestamps to prevent replays. Any 2xx response is a
+// success; other responses, redirects included, are retried.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of the delivery requests
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const signaturePrefix = "sha256="
+
+// Sign returns the X-Webhook-Signature of a body sent at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify tells whether signature and timestamp, the X-Webhook-Signature and
+// X-Webhook-Timestamp headers of a delivery, match the body and the
+// timestamp is within tolerance of now
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration, now time.Time) bool {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	sent := time.Unix(unix, 0)
+	if now.Sub(sent).Abs() > tolerance {
+		return false
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, sent, body)))
+}
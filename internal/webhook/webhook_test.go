@@ -0,0 +1,186 @@
+package webhook
+
+import (
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1735725600, 0)
+	body := []byte(`{"type":"post.created"}`)
+	signature := Sign("secret", now, body)
+	timestamp := "1735725600"
+
+	if !Verify("secret", signature, timestamp, body, time.Minute, now.Add(30*time.Second)) {
+		t.Error("expected a valid signature")
+	}
+	for name, ok := range map[string]bool{
+		"wrong secret": Verify("other", signature, timestamp, body, time.Minute, now),
+		"wrong body":   Verify("secret", signature, timestamp, []byte(`{}`), time.Minute, now),
+		"stale":        Verify("secret", signature, timestamp, body, time.Minute, now.Add(2*time.Minute)),
+		"bad time":     Verify("secret", signature, "soon", body, time.Minute, now),
+		"no prefix":    Verify("secret", signature[len("sha256="):], timestamp, body, time.Minute, now),
+	} {
+		if ok {
+			t.Errorf("expected the %s case to be rejected", name)
+		}
+	}
+}
+
+// waitFor polls the deliveries until they all have the status
+func waitFor(t *testing.T, service *services.WebhookService, status string) []*models.WebhookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries, _ := service.Deliveries(context.Background(), models.DeliveryFilter{})
+		done := len(deliveries) > 0
+		for _, d := range deliveries {
+			done = done && d.Status == status
+		}
+		if done {
+			return deliveries
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s deliveries", status)
+	return nil
+}
+
+func startDispatcher(t *testing.T, service *services.WebhookService) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// the test servers listen on the loopback interface
+		NewDispatcher(service, Options{Workers: 2, Timeout: time.Second, PollInterval: 10 * time.Millisecond, AllowPrivateNetworks: true}).Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestDispatcher_DeliversSignedEvents(t *testing.T) {
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify("a-secret-of-16-bytes", r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body, time.Minute, time.Now()) {
+			t.Errorf("expected a valid signature for %s", body)
+		}
+		if r.Header.Get(HeaderEvent) != "post.published" || r.Header.Get(HeaderID) == "" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		received.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	service := services.NewWebhookService(services.NewInMemoryWebhookRepo(), services.DefaultRetryPolicy)
+	posts := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	posts.Subscribe(func(e services.Event) { service.Enqueue(context.Background(), e) })
+	service.Create(context.Background(), &models.Webhook{URL: srv.URL, Events: []string{"post.published"}, Secret: "a-secret-of-16-bytes", Active: true})
+	startDispatcher(t, service)
+
+	posts.Create(context.Background(), &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+
+	deliveries := waitFor(t, service, models.DeliverySucceeded)
+	if received.Load() != 1 || len(deliveries[0].Attempts) != 1 || deliveries[0].Attempts[0].StatusCode != http.StatusNoContent {
+		t.Errorf("expected a single successful attempt, got %d requests and %+v", received.Load(), deliveries[0].Attempts)
+	}
+}
+
+func TestDispatcher_RetriesThenDeadLetters(t *testing.T) {
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		// redirects are failures too
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	policy := services.RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+	service := services.NewWebhookService(services.NewInMemoryWebhookRepo(), policy)
+	posts := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	posts.Subscribe(func(e services.Event) { service.Enqueue(context.Background(), e) })
+	service.Create(context.Background(), &models.Webhook{URL: srv.URL, Events: []string{"post.created"}, Active: true})
+	startDispatcher(t, service)
+
+	posts.Create(context.Background(), &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+
+	deliveries := waitFor(t, service, models.DeliveryDead)
+	if received.Load() != 3 || len(deliveries[0].Attempts) != 3 {
+		t.Errorf("expected 3 attempts, got %d requests and %+v", received.Load(), deliveries[0].Attempts)
+	}
+	if attempt := deliveries[0].Attempts[0]; attempt.StatusCode != http.StatusFound || attempt.Error != "unexpected status 302" {
+		t.Errorf("unexpected attempt %+v", attempt)
+	}
+}
+
+func TestDispatcher_RefusesPrivateNetworks(t *testing.T) {
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+	}))
+	defer srv.Close()
+
+	policy := services.RetryPolicy{MaxAttempts: 1}
+	service := services.NewWebhookService(services.NewInMemoryWebhookRepo(), policy)
+	posts := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	posts.Subscribe(func(e services.Event) { service.Enqueue(context.Background(), e) })
+	// localhost resolves to the loopback address the server listens on
+	url := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+	service.Create(context.Background(), &models.Webhook{URL: url, Events: []string{"post.created"}, Active: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewDispatcher(service, Options{Timeout: time.Second, PollInterval: 10 * time.Millisecond}).Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	posts.Create(context.Background(), &models.BlogPost{ID: "1", Title: "Test Post", Content: "Test content", Author: "Test Author"})
+
+	deliveries := waitFor(t, service, models.DeliveryDead)
+	if received.Load() != 0 {
+		t.Errorf("expected no request to reach the server, got %d", received.Load())
+	}
+	if attempt := deliveries[0].Attempts[0]; !strings.Contains(attempt.Error, ErrPrivateDestination.Error()) {
+		t.Errorf("expected the destination to be refused, got %+v", attempt)
+	}
+}
+
+func TestCheckDestination(t *testing.T) {
+	for address, refused := range map[string]bool{
+		"93.184.215.14:443":          false,
+		"[2606:2800:21f:cb07::1]:80": false,
+		"127.0.0.1:8080":             true,
+		"[::1]:80":                   true,
+		"0.0.0.0:80":                 true,
+		"10.0.0.1:80":                true,
+		"172.16.5.4:80":              true,
+		"192.168.1.1:80":             true,
+		"169.254.169.254:80":         true,
+		"[fe80::1]:80":               true,
+		"[fd00::1]:80":               true,
+		"[::ffff:127.0.0.1]:80":      true,
+	} {
+		err := checkDestination("tcp", address, nil)
+		if refused != errors.Is(err, ErrPrivateDestination) {
+			t.Errorf("expected %s to be refused: %t, got %v", address, refused, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// File is the content of a downloaded attachment or variant, to be closed
+// once read
+type File struct {
+	io.ReadCloser
+	ContentType string
+	// Filename is the name the server suggests saving the file under
+	Filename string
+	// SHA256 is the hex encoded checksum of the content
+	SHA256 string
+	// Size is the length of the content, -1 when unknown
+	Size int64
+}
+
+// UploadAttachment attaches a file to a post, as a new version of the
+// post. The content is streamed once and never retried; the server sniffs
+// its type rather than trusting the filename.
+func (c *Client) UploadAttachment(ctx context.Context, postID, filename string, content io.Reader) (*Attachment, error) {
+	body, w := io.Pipe()
+	form := multipart.NewWriter(w)
+	go func() {
+		part, err := form.CreateFormFile("file", filename)
+		if err == nil {
+			_, err = io.Copy(part, content)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		w.CloseWithError(err)
+	}()
+	// the server may answer before reading the whole body
+	defer body.Close()
+
+	var attachment Attachment
+	r := request{
+		method: http.MethodPost,
+		path:   attachmentsPath(postID),
+		header: http.Header{"Content-Type": {form.FormDataContentType()}},
+		body:   body,
+	}
+	if _, err := c.doJSON(ctx, r, &attachment); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// ListAttachments returns the metadata of the files attached to a post,
+// oldest first
+func (c *Client) ListAttachments(ctx context.Context, postID string) ([]*Attachment, error) {
+	var attachments []*Attachment
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: attachmentsPath(postID)}, &attachments)
+	return attachments, err
+}
+
+// GetAttachment returns the metadata of an attachment, e.g. to poll the
+// processing of an image until its variants are listed
+func (c *Client) GetAttachment(ctx context.Context, postID, id string) (*Attachment, error) {
+	var attachment Attachment
+	if _, err := c.doJSON(ctx, request{method: http.MethodGet, path: attachmentPath(postID, id) + "/metadata"}, &attachment); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// DownloadAttachment returns the content of an attachment, which the
+// caller must close
+func (c *Client) DownloadAttachment(ctx context.Context, postID, id string) (*File, error) {
+	return c.download(ctx, attachmentPath(postID, id))
+}
+
+// DownloadVariant returns the content of a resized variant of an image
+// attachment, e.g. thumbnail, which the caller must close. It is not found
+// until the image is processed.
+func (c *Client) DownloadVariant(ctx context.Context, postID, id, variant string) (*File, error) {
+	return c.download(ctx, attachmentPath(postID, id)+"/variants/"+url.PathEscape(variant))
+}
+
+func (c *Client) download(ctx context.Context, path string) (*File, error) {
+	resp, err := c.do(ctx, request{method: http.MethodGet, path: path, header: http.Header{"Accept": {"*/*"}}})
+	if err != nil {
+		return nil, err
+	}
+	file := &File{
+		ReadCloser:  resp.Body,
+		ContentType: resp.Header.Get("Content-Type"),
+		SHA256:      strings.Trim(resp.Header.Get("ETag"), `"`),
+		Size:        resp.ContentLength,
+	}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		file.Filename = params["filename"]
+	}
+	return file, nil
+}
+
+// DeleteAttachment detaches a file from a post, as a new version of the
+// post, and deletes its content
+func (c *Client) DeleteAttachment(ctx context.Context, postID, id string) error {
+	_, err := c.doJSON(ctx, request{method: http.MethodDelete, path: attachmentPath(postID, id)}, nil)
+	return err
+}
+
+func attachmentsPath(postID string) string {
+	return "/posts/" + url.PathEscape(postID) + "/attachments"
+}
+
+func attachmentPath(postID, id string) string {
+	return attachmentsPath(postID) + "/" + url.PathEscape(id)
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+// ListAuthors returns every author, sorted by name
+func (c *Client) ListAuthors(ctx context.Context) ([]*Author, error) {
+	var authors []*Author
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/authors"}, &authors)
+	return authors, err
+}
+
+// CreateAuthor creates an author, whose name must not be taken
+func (c *Client) CreateAuthor(ctx context.Context, author AuthorCreate) (*Author, error) {
+	var created Author
+	if _, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/authors", body: author}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// BackfillAuthors links the posts stored without author ID to the author
+// named after them, creating the authors missing, and returns the number
+// of posts linked
+func (c *Client) BackfillAuthors(ctx context.Context) (int, error) {
+	var backfill AuthorBackfill
+	if _, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/authors/backfill"}, &backfill); err != nil {
+		return 0, err
+	}
+	return backfill.Linked, nil
+}
+
+// GetAuthor returns an author by ID
+func (c *Client) GetAuthor(ctx context.Context, id string) (*Author, error) {
+	var author Author
+	if _, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/authors/" + url.PathEscape(id)}, &author); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// UpdateAuthor replaces the profile of an author. A new name is set on the
+// posts of the author.
+func (c *Client) UpdateAuthor(ctx context.Context, id string, author AuthorUpdate) (*Author, error) {
+	var updated Author
+	if _, err := c.doJSON(ctx, request{method: http.MethodPut, path: "/authors/" + url.PathEscape(id), body: author}, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteAuthor deletes an author, which must not have posts anymore
+func (c *Client) DeleteAuthor(ctx context.Context, id string) error {
+	_, err := c.doJSON(ctx, request{method: http.MethodDelete, path: "/authors/" + url.PathEscape(id)}, nil)
+	return err
+}
+
+// ListAuthorPostsPage returns a page of the posts of an author, newest
+// first, the first one for an empty token
+func (c *Client) ListAuthorPostsPage(ctx context.Context, authorID string, pageSize int, pageToken string) (*Page, error) {
+	return c.listPage(ctx, "/authors/"+url.PathEscape(authorID)+"/posts", url.Values{}, pageSize, pageToken)
+}
+
+// AuthorPosts iterates over the posts of an author, newest first, fetching
+// them a page at a time. Iteration stops after the first error.
+func (c *Client) AuthorPosts(ctx context.Context, authorID string) iter.Seq2[*BlogPost, error] {
+	return c.pages(func(token string) (*Page, error) {
+		return c.ListAuthorPostsPage(ctx, authorID, 0, token)
+	})
+}
@@ -0,0 +1,294 @@
+// Package client is a Go client of the blog posts REST API (/api/v1).
+//
+// Every endpoint has a typed method taking a context. Requests failing with
+// 429 Too Many Requests are retried with exponential backoff, as are the
+// idempotent ones (GET, PUT, DELETE) failing with a 5xx status or a network
+// error. Errors returned by the API are *Error values carrying the problem
+// details of the response.
+//
+//	c, err := client.New("http://localhost:8080", client.Options{})
+//	for post, err := range c.Posts(ctx, client.PostQuery{Tag: "go"}) {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of the Options
+const (
+	DefaultMaxRetries = 3
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+)
+
+// userAgent identifies the client in the server logs
+const userAgent = "blog-posts-api-go-client"
+
+// Options configures a Client, zero values select the defaults
+type Options struct {
+	// HTTPClient sends the requests, http.DefaultClient unless set
+	HTTPClient *http.Client
+	// MaxRetries is the number of retries of a failed request, a negative
+	// value disables them
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubled on every
+	// retry up to MaxBackoff. A Retry-After header takes precedence.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// AccessToken is sent as a bearer token with every request, see
+	// Client.Login
+	AccessToken string
+}
+
+// Client calls the blog posts API, it is safe for concurrent use
+type Client struct {
+	base *url.URL
+	http *http.Client
+	opts Options
+}
+
+// New returns a client of the API served at baseURL, e.g.
+// "https://blog.example.com"
+func New(baseURL string, opts Options) (*Client, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: an absolute http or https URL is required", baseURL)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/") + "/api/v1"
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = DefaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	return &Client{base: base, http: opts.HTTPClient, opts: opts}, nil
+}
+
+// WithAccessToken returns a copy of the client sending the access token
+// with its requests, e.g. the one returned by Login
+func (c *Client) WithAccessToken(token string) *Client {
+	clone := *c
+	clone.opts.AccessToken = token
+	return &clone
+}
+
+// request describes a call of the API
+type request struct {
+	method string
+	// path is relative to /api/v1, with its segments escaped
+	path   string
+	query  url.Values
+	header http.Header
+	// body is sent as is, or encoded as JSON unless nil. A stream is sent
+	// as newline delimited JSON unless header sets its Content-Type.
+	body any
+}
+
+// do sends a request, retrying it when allowed, and returns the response
+// of a 2xx or 304 status. Other statuses are returned as an *Error.
+func (c *Client) do(ctx context.Context, r request) (*http.Response, error) {
+	var payload []byte
+	var stream io.Reader
+	contentType := "application/json"
+	switch body := r.body.(type) {
+	case nil:
+	case io.Reader:
+		// a stream can only be sent once
+		stream, contentType = body, "application/x-ndjson"
+	default:
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to encode the request body: %w", err)
+		}
+	}
+
+	target := *c.base
+	path, err := url.PathUnescape(r.path)
+	if err != nil {
+		return nil, err
+	}
+	target.Path += path
+	target.RawPath = c.base.EscapedPath() + r.path
+	target.RawQuery = r.query.Encode()
+	idempotent := r.method == http.MethodGet || r.method == http.MethodPut || r.method == http.MethodDelete
+
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		switch {
+		case stream != nil:
+			body = stream
+		case payload != nil:
+			body = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequestWithContext(ctx, r.method, target.String(), body)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range r.header {
+			req.Header[name] = values
+		}
+		req.Header.Set("User-Agent", userAgent)
+		if c.opts.AccessToken != "" && req.Header.Get("Authorization") == "" {
+			req.Header.Set("Authorization", "Bearer "+c.opts.AccessToken)
+		}
+		if req.Header.Get("Accept") == "" {
+			req.Header.Set("Accept", "application/json")
+		}
+		if body != nil && req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp, err := c.http.Do(req)
+		retry := stream == nil && attempt < c.opts.MaxRetries
+		if err != nil {
+			if !retry || !idempotent || ctx.Err() != nil {
+				return nil, err
+			}
+			if err := c.wait(ctx, attempt, ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode < 400 {
+			return resp, nil
+		}
+
+		apiErr := readError(resp)
+		if !retry || !(resp.StatusCode == http.StatusTooManyRequests || idempotent && resp.StatusCode >= 500) {
+			return nil, apiErr
+		}
+		if err := c.wait(ctx, attempt, resp.Header.Get("Retry-After")); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait sleeps before a retry, as long as the Retry-After header of the
+// response asks, otherwise with an exponential backoff and jitter
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter string) error {
+	delay := retryAfterDelay(retryAfter)
+	if delay <= 0 {
+		backoff := min(c.opts.MinBackoff<<attempt, c.opts.MaxBackoff)
+		delay = backoff/2 + rand.N(backoff/2+1)
+	}
+	delay = min(delay, c.opts.MaxBackoff)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryAfterDelay parses a Retry-After header, in seconds or as a date
+func retryAfterDelay(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// doJSON sends a request and decodes the JSON body of the response into
+// out, unless nil
+func (c *Client) doJSON(ctx context.Context, r request, out any) (*http.Response, error) {
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, resp.Body)
+		return resp, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to decode the response: %w", err)
+	}
+	return resp, nil
+}
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	// Problem holds the problem details of the response, when it has some
+	Problem Problem
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("blog posts API: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Problem.Detail != "" {
+		msg += ": " + e.Problem.Detail
+	}
+	for i, p := range e.Problem.InvalidParams {
+		if i == 0 {
+			msg += ": "
+		} else {
+			msg += "; "
+		}
+		msg += p.Name + " " + p.Reason
+	}
+	return msg
+}
+
+// readError returns the error of a response, closing its body
+func readError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	e := &Error{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &e.Problem) != nil || e.Problem.Status == 0 {
+		e.Problem = Problem{Status: resp.StatusCode, Title: http.StatusText(resp.StatusCode), Detail: strings.TrimSpace(string(data))}
+	}
+	return e
+}
+
+// StatusCode returns the status of the API error carried by err, 0 if
+// there is none
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is a 404 response of the API
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsPreconditionFailed reports whether err is a 412 response of the API,
+// returned by updates based on a version that is no longer current
+func IsPreconditionFailed(err error) bool {
+	return StatusCode(err) == http.StatusPreconditionFailed
+}
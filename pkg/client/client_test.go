@@ -0,0 +1,604 @@
+package client
+
+import (
+	"blog-posts-api/internal/api/handlers"
+	"blog-posts-api/internal/api/middleware"
+	"blog-posts-api/internal/api/models"
+	"blog-posts-api/internal/api/services"
+	"blog-posts-api/internal/blob"
+	"blog-posts-api/internal/config"
+	"blog-posts-api/internal/imaging"
+	"blog-posts-api/internal/mail"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestAPI serves the real handlers of the API, the way main does, to a
+// client signed in as a user
+func newTestAPI(t *testing.T) (*Client, *services.BlogPostService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	posts := services.NewBlogPostService(services.NewInMemoryStoreBlogPostRepo())
+	webhooks := services.NewWebhookService(services.NewInMemoryWebhookRepo(), services.RetryPolicy{})
+	posts.Subscribe(func(e services.Event) { webhooks.Enqueue(context.Background(), e) })
+	authors := services.NewAuthorService(services.NewInMemoryAuthorRepo(), posts)
+	streams := handlers.NewEventStreamHandler(posts, config.StreamConfig{LogSize: 10})
+	files, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open the attachment store: %v", err)
+	}
+	attachments := services.NewAttachmentService(posts, files, services.AttachmentOptions{
+		MaxBytes:   1 << 20,
+		URLPrefix:  "/api/v1/posts",
+		ImageTypes: imaging.Types,
+	})
+	posts.Subscribe(attachments.Notify)
+	mailer := &linkMailer{}
+	users := services.NewUserService(services.NewInMemoryUserRepo(), services.NewInMemoryTokenRepo(), mailer, services.UserOptions{})
+	token := newTestAccessToken(t, users, mailer)
+	ctx, cancel := context.WithCancel(context.Background())
+	processed := make(chan struct{})
+	go func() {
+		imaging.NewProcessor(attachments, imaging.Options{}).Run(ctx)
+		close(processed)
+	}()
+
+	router := gin.New()
+	router.Use(middleware.Problems())
+	v1 := router.Group("/api/v1")
+	handlers.NewBlogPostHandler(posts).RegisterRoutes(v1)
+	streams.RegisterRoutes(v1)
+	handlers.NewWebhookHandler(webhooks, users).RegisterRoutes(v1)
+	handlers.NewAuthorHandler(authors).RegisterRoutes(v1)
+	handlers.NewAttachmentHandler(attachments).RegisterRoutes(v1)
+	handlers.NewAuthHandler(users).RegisterRoutes(v1)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(func() {
+		streams.Close()
+		srv.Close()
+		cancel()
+		<-processed
+	})
+
+	c, err := New(srv.URL, Options{MinBackoff: time.Millisecond, AccessToken: token})
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+	return c, posts
+}
+
+// newTestAccessToken registers a verified account and returns an access
+// token of it
+func newTestAccessToken(t *testing.T, users *services.UserService, mailer *linkMailer) string {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := users.Register(ctx, &models.UserRegister{Email: "ops@example.com", Password: "correct horse", Name: "Ops"}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	user, err := users.VerifyEmail(ctx, mailer.last())
+	if err != nil {
+		t.Fatalf("failed to verify the email: %v", err)
+	}
+	token, err := users.IssueAccessToken(user)
+	if err != nil {
+		t.Fatalf("failed to issue an access token: %v", err)
+	}
+	return token.AccessToken
+}
+
+func TestClient_Posts(t *testing.T) {
+	c, _ := newTestAPI(t)
+	ctx := context.Background()
+
+	created, err := c.CreatePost(ctx, BlogPostCreate{Title: " Test Post ", Content: "# Hello", ContentFormat: "markdown", Author: "Test Author", Tags: []string{"Go"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if created.Title != "Test Post" || created.Tags[0] != "go" || created.Version != 1 {
+		t.Errorf("expected a sanitized post at version 1, got %+v", created)
+	}
+
+	if got, err := c.GetPost(ctx, created.ID); err != nil || got.Title != "Test Post" {
+		t.Errorf("expected the created post, got %+v and %v", got, err)
+	}
+	if rendered, err := c.GetRenderedPost(ctx, created.ID); err != nil || rendered.ContentHTML == "" {
+		t.Errorf("expected the rendered content, got %+v and %v", rendered, err)
+	}
+
+	body := created.UpdateBody()
+	body.Title = "Updated"
+	updated, err := c.UpdatePostVersion(ctx, created.ID, created.Version, body)
+	if err != nil || updated.Version != 2 {
+		t.Fatalf("expected version 2, got %+v and %v", updated, err)
+	}
+	// based on a version which is no longer current
+	if _, err := c.UpdatePostVersion(ctx, created.ID, created.Version, body); !IsPreconditionFailed(err) {
+		t.Errorf("expected a precondition failure, got %v", err)
+	}
+
+	edited, err := c.EditPost(ctx, created.ID, func(p *BlogPostUpdate) error {
+		p.Tags = append(p.Tags, "tutorial")
+		return nil
+	})
+	if err != nil || edited.Version != 3 || len(edited.Tags) != 2 {
+		t.Errorf("expected the tag added in version 3, got %+v and %v", edited, err)
+	}
+
+	_, err = c.CreatePost(ctx, BlogPostCreate{Content: "Test content", Author: "Test Author"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Problem.InvalidParams) == 0 {
+		t.Errorf("expected a validation problem, got %v", err)
+	}
+
+	if err := c.DeletePost(ctx, created.ID); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if _, err := c.GetPost(ctx, created.ID); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestClient_PostsIterator(t *testing.T) {
+	c, _ := newTestAPI(t)
+	ctx := context.Background()
+
+	for i := range 7 {
+		author := "Alice"
+		if i%3 == 2 {
+			author = "Bob"
+		}
+		if _, err := c.CreatePost(ctx, BlogPostCreate{Title: "Post", Content: "Test content", Author: author}); err != nil {
+			t.Fatalf("failed to create a post: %v", err)
+		}
+		// posts created within the same clock tick are ordered by ID
+		time.Sleep(time.Millisecond)
+	}
+
+	all, err := c.ListPosts(ctx, PostQuery{Author: "Alice"})
+	if err != nil || len(all) != 5 {
+		t.Fatalf("expected the 5 posts of Alice, got %d and %v", len(all), err)
+	}
+
+	var ids []string
+	for post, err := range c.Posts(ctx, PostQuery{Author: "Alice", PageSize: 2}) {
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		ids = append(ids, post.ID)
+	}
+	if len(ids) != 5 {
+		t.Fatalf("expected 5 posts over 3 pages, got %v", ids)
+	}
+	for i := range ids {
+		for j := range i {
+			if ids[i] == ids[j] {
+				t.Errorf("expected every post once, got %v", ids)
+			}
+		}
+	}
+
+	seen := 0
+	for range c.Posts(ctx, PostQuery{PageSize: 2}) {
+		if seen++; seen == 3 {
+			break
+		}
+	}
+	if seen != 3 {
+		t.Errorf("expected the iteration to stop, got %d posts", seen)
+	}
+
+	if _, err := c.ListPostsPage(ctx, PostQuery{}, "invalid"); StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("expected a bad request, got %v", err)
+	}
+}
+
+func TestClient_ExportImportBatch(t *testing.T) {
+	c, _ := newTestAPI(t)
+	ctx := context.Background()
+
+	batch, err := c.BatchPosts(ctx, BatchRequest{Atomic: true, Operations: []BatchOperation{
+		CreateOperation("550e8400-e29b-41d4-a716-446655440000", BlogPostCreate{Title: "First", Content: "Test content", Author: "Test Author"}),
+		CreateOperation("", BlogPostCreate{Title: "Second", Content: "Test content", Author: "Test Author"}),
+		UpdateOperation("550e8400-e29b-41d4-a716-446655440000", BlogPostUpdate{Title: "First updated", Content: "Test content", Author: "Test Author"}),
+	}})
+	if err != nil || len(batch.Results) != 3 || batch.Results[2].Data.Title != "First updated" {
+		t.Fatalf("expected the batch to be applied, got %+v and %v", batch, err)
+	}
+	secondID := batch.Results[1].Data.ID
+	if batch, err = c.BatchPosts(ctx, BatchRequest{Operations: []BatchOperation{DeleteOperation(secondID)}}); err != nil || batch.Results[0].Status != http.StatusNoContent {
+		t.Fatalf("expected the post to be deleted, got %+v and %v", batch, err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePosts(&buf, c.ExportPosts(ctx)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	report, err := c.ImportPosts(ctx, &buf, ImportOptions{OnConflict: OnConflictUpsert})
+	if err != nil || report.Summary.Lines != 1 || report.Summary.Updated != 1 {
+		t.Errorf("expected the exported post to be updated, got %+v and %v", report, err)
+	}
+
+	if _, err := c.ImportPosts(ctx, &bytes.Buffer{}, ImportOptions{OnConflict: "replace"}); StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("expected a bad request, got %v", err)
+	}
+}
+
+func TestClient_StreamEvents(t *testing.T) {
+	c, posts := newTestAPI(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan Event)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.StreamEvents(ctx, EventQuery{Tag: "go"}, func(e Event) error {
+			events <- e
+			return nil
+		})
+	}()
+
+	// creates posts until the stream, connected meanwhile, sends one
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	var first Event
+	for first.ID == "" {
+		select {
+		case <-ticker.C:
+			c.CreatePost(ctx, BlogPostCreate{Title: "Go", Content: "Test content", Author: "Test Author", Tags: []string{"go"}})
+		case first = <-events:
+		case err := <-done:
+			t.Fatalf("expected the stream to stay open, got %v", err)
+		}
+	}
+	ticker.Stop()
+	if first.Type != "post.created" || first.Data == nil || first.Data.Data.Post.Title != "Go" {
+		t.Errorf("expected a created event, got %+v", first)
+	}
+
+	// resumed after the first event, it gets the ones sent since
+	resumed := make(chan Event, 1)
+	go c.StreamEvents(ctx, EventQuery{Tag: "go", LastEventID: first.ID}, func(e Event) error {
+		resumed <- e
+		return errors.New("stop")
+	})
+	select {
+	case e := <-resumed:
+		if e.ID == first.ID || e.Data == nil {
+			t.Errorf("expected an event after the first one, got %+v", e)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected the resumed stream to send an event")
+	}
+
+	posts.Create(ctx, &BlogPost{ID: "other", Title: "Rust", Content: "Test content", Author: "Test Author", Tags: []string{"rust"}})
+	cancel()
+	for {
+		select {
+		case e := <-events:
+			if e.Data != nil && e.Data.Data.PostID == "other" {
+				t.Errorf("expected the events to be filtered, got %+v", e)
+			}
+			continue
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected the stream to end with the context, got %v", err)
+			}
+		}
+		break
+	}
+}
+
+func TestClient_Webhooks(t *testing.T) {
+	c, _ := newTestAPI(t)
+	ctx := context.Background()
+
+	if _, err := c.WithAccessToken("").ListWebhooks(ctx); StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("expected an unauthorized error without token, got %v", err)
+	}
+	created, err := c.CreateWebhook(ctx, WebhookCreate{URL: "https://hooks.example.com/blog", Events: []string{"post.created"}})
+	if err != nil || created.Secret == "" {
+		t.Fatalf("expected a webhook with its secret, got %+v and %v", created, err)
+	}
+	if webhooks, err := c.ListWebhooks(ctx); err != nil || len(webhooks) != 1 {
+		t.Errorf("expected 1 webhook, got %v and %v", webhooks, err)
+	}
+	active := false
+	if updated, err := c.UpdateWebhook(ctx, created.ID, WebhookUpdate{URL: created.URL, Events: []string{"post.deleted"}, Active: &active}); err != nil || updated.Active {
+		t.Errorf("expected an inactive webhook, got %+v and %v", updated, err)
+	}
+	if got, err := c.GetWebhook(ctx, created.ID); err != nil || got.Events[0] != "post.deleted" {
+		t.Errorf("expected the updated webhook, got %+v and %v", got, err)
+	}
+
+	active = true
+	c.UpdateWebhook(ctx, created.ID, WebhookUpdate{URL: created.URL, Events: []string{"post.created"}, Active: &active})
+	c.CreatePost(ctx, BlogPostCreate{Title: "Test Post", Content: "Test content", Author: "Test Author"})
+	deliveries, err := c.ListWebhookDeliveries(ctx, created.ID, DeliveryPending)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("expected a pending delivery, got %v and %v", deliveries, err)
+	}
+	if all, err := c.ListDeliveries(ctx, DeliveryDead); err != nil || len(all) != 0 {
+		t.Errorf("expected no dead deliveries, got %v and %v", all, err)
+	}
+	if got, err := c.GetDelivery(ctx, deliveries[0].ID); err != nil || got.Event != "post.created" {
+		t.Errorf("expected the delivery, got %+v and %v", got, err)
+	}
+	if _, err := c.Redeliver(ctx, deliveries[0].ID); StatusCode(err) != http.StatusConflict {
+		t.Errorf("expected a conflict for a pending delivery, got %v", err)
+	}
+
+	if err := c.DeleteWebhook(ctx, created.ID); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if _, err := c.GetWebhook(ctx, created.ID); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestClient_Authors(t *testing.T) {
+	c, _ := newTestAPI(t)
+	ctx := context.Background()
+
+	ann, err := c.CreateAuthor(ctx, AuthorCreate{Name: "Ann", Links: []string{"https://github.com/ann"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := c.CreateAuthor(ctx, AuthorCreate{Name: "Ann"}); StatusCode(err) != http.StatusConflict {
+		t.Errorf("expected a conflict for a taken name, got %v", err)
+	}
+	for range 3 {
+		if _, err := c.CreatePost(ctx, BlogPostCreate{Title: "Test Post", Content: "Test content", AuthorID: ann.ID}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	c.CreatePost(ctx, BlogPostCreate{Title: "Test Post", Content: "Test content", Author: "Bob"})
+	if authors, err := c.ListAuthors(ctx); err != nil || len(authors) != 2 {
+		t.Errorf("expected Ann and Bob, got %v and %v", authors, err)
+	}
+
+	if _, err := c.UpdateAuthor(ctx, ann.ID, AuthorUpdate{Name: "Ann Smith"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	page, err := c.ListAuthorPostsPage(ctx, ann.ID, 2, "")
+	if err != nil || len(page.Posts) != 2 || page.NextPageToken == "" {
+		t.Fatalf("expected a first page of 2 posts, got %+v and %v", page, err)
+	}
+	var names []string
+	for post, err := range c.AuthorPosts(ctx, ann.ID) {
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		names = append(names, post.Author)
+	}
+	if len(names) != 3 || names[0] != "Ann Smith" {
+		t.Errorf("expected the 3 posts of the renamed author, got %v", names)
+	}
+
+	if err := c.DeleteAuthor(ctx, ann.ID); StatusCode(err) != http.StatusConflict {
+		t.Errorf("expected a conflict while the author has posts, got %v", err)
+	}
+	if _, err := c.GetAuthor(ctx, "missing"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if linked, err := c.BackfillAuthors(ctx); err != nil || linked != 0 {
+		t.Errorf("expected every post to be linked already, got %d and %v", linked, err)
+	}
+}
+
+func TestClient_Attachments(t *testing.T) {
+	c, _ := newTestAPI(t)
+	ctx := context.Background()
+
+	post, err := c.CreatePost(ctx, BlogPostCreate{Title: "Test Post", Content: "Test content", Author: "Test Author"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var img bytes.Buffer
+	png.Encode(&img, image.NewGray(image.Rect(0, 0, 4, 4)))
+	uploaded, err := c.UploadAttachment(ctx, post.ID, "photo.png", bytes.NewReader(img.Bytes()))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if uploaded.ContentType != "image/png" || uploaded.Size != int64(img.Len()) {
+		t.Errorf("expected a PNG image of %d bytes, got %+v", img.Len(), uploaded)
+	}
+	if _, err := c.UploadAttachment(ctx, post.ID, "notes.txt", strings.NewReader("plain text")); StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("expected a bad request for an unsupported type, got %v", err)
+	}
+	if attachments, err := c.ListAttachments(ctx, post.ID); err != nil || len(attachments) != 1 || attachments[0].ID != uploaded.ID {
+		t.Errorf("expected the uploaded attachment, got %v and %v", attachments, err)
+	}
+
+	file, err := c.DownloadAttachment(ctx, post.ID, uploaded.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if !bytes.Equal(content, img.Bytes()) || file.ContentType != "image/png" || file.Filename != "photo.png" || file.SHA256 != uploaded.SHA256 {
+		t.Errorf("expected the uploaded image, got %d bytes and %+v", len(content), file)
+	}
+
+	// the image is resized in the background
+	attachment := uploaded
+	for deadline := time.Now().Add(5 * time.Second); attachment.Processing == ProcessingPending && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		if attachment, err = c.GetAttachment(ctx, post.ID, uploaded.ID); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if attachment.Processing != ProcessingSucceeded || len(attachment.Variants) == 0 {
+		t.Fatalf("expected the variants of the image, got %+v", attachment)
+	}
+	variant, err := c.DownloadVariant(ctx, post.ID, uploaded.ID, attachment.Variants[0].Name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	content, _ = io.ReadAll(variant)
+	variant.Close()
+	if int64(len(content)) != attachment.Variants[0].Size || variant.SHA256 != attachment.Variants[0].SHA256 {
+		t.Errorf("expected the %s variant, got %d bytes and %+v", attachment.Variants[0].Name, len(content), variant)
+	}
+	if _, err := c.DownloadVariant(ctx, post.ID, uploaded.ID, "missing"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	if err := c.DeleteAttachment(ctx, post.ID, uploaded.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := c.GetAttachment(ctx, post.ID, uploaded.ID); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if _, err := c.DownloadAttachment(ctx, post.ID, uploaded.ID); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+// linkMailer keeps the token of the link of the last email
+type linkMailer struct {
+	mu    sync.Mutex
+	token string
+}
+
+var linkToken = regexp.MustCompile(`\?token=([\w-]+)`)
+
+func (m *linkMailer) Send(_ context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if match := linkToken.FindStringSubmatch(msg.Text); match != nil {
+		m.token = match[1]
+	}
+	return nil
+}
+
+func (m *linkMailer) last() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.token
+}
+
+func TestClient_Users(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mailer := &linkMailer{}
+	users := services.NewUserService(services.NewInMemoryUserRepo(), services.NewInMemoryTokenRepo(), mailer, services.UserOptions{})
+	router := gin.New()
+	router.Use(middleware.Problems())
+	handlers.NewAuthHandler(users).RegisterRoutes(router.Group("/api/v1"))
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL, Options{MinBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := c.Register(ctx, UserRegister{Email: "ann@example.com", Password: "correct horse", Name: "Ann"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	credentials := Credentials{Email: "ann@example.com", Password: "correct horse"}
+	if _, err := c.Login(ctx, credentials); StatusCode(err) != http.StatusForbidden {
+		t.Errorf("expected a forbidden error before verifying, got %v", err)
+	}
+	if user, err := c.VerifyEmail(ctx, mailer.last()); err != nil || !user.EmailVerified {
+		t.Fatalf("expected a verified user, got %+v and %v", user, err)
+	}
+
+	if _, err := c.Me(ctx); StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("expected an unauthorized error without token, got %v", err)
+	}
+	token, err := c.Login(ctx, credentials)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if me, err := c.WithAccessToken(token.AccessToken).Me(ctx); err != nil || me.Email != "ann@example.com" {
+		t.Errorf("expected the user of the token, got %+v and %v", me, err)
+	}
+
+	if err := c.ForgotPassword(ctx, "ann@example.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := c.ResetPassword(ctx, PasswordReset{Token: mailer.last(), Password: "battery staple"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := c.Login(ctx, credentials); StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("expected the old password to be rejected, got %v", err)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	var failures int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			if r.Method == http.MethodPost {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","version":1}`))
+	}))
+	defer srv.Close()
+	c, _ := New(srv.URL, Options{MaxRetries: 2, MinBackoff: time.Millisecond})
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		failures int32
+		call     func() error
+		calls    int32
+		status   int
+	}{
+		{"get retried", 2, func() error { _, err := c.GetPost(ctx, "1"); return err }, 3, 0},
+		{"get exhausted", 3, func() error { _, err := c.GetPost(ctx, "1"); return err }, 3, http.StatusServiceUnavailable},
+		{"post rate limited", 1, func() error { _, err := c.CreatePost(ctx, BlogPostCreate{}); return err }, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			failures = tt.failures
+			err := tt.call()
+			if StatusCode(err) != tt.status || (tt.status == 0 && err != nil) {
+				t.Errorf("expected status %d, got %v", tt.status, err)
+			}
+			if calls.Load() != tt.calls {
+				t.Errorf("expected %d calls, got %d", tt.calls, calls.Load())
+			}
+		})
+	}
+
+	// a create failing with a 5xx status may have been applied
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	calls.Store(0)
+	c, _ = New(failing.URL, Options{MinBackoff: time.Millisecond})
+	if _, err := c.CreatePost(ctx, BlogPostCreate{}); StatusCode(err) != http.StatusInternalServerError || calls.Load() != 1 {
+		t.Errorf("expected a single call failing with status 500, got %v after %d calls", err, calls.Load())
+	}
+
+	if _, err := New("localhost:8080", Options{}); err == nil {
+		t.Error("expected a relative base URL to be rejected")
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// EventReset is the type of the events telling that events were missed,
+// after which the posts should be reloaded
+const EventReset = "reset"
+
+// maxEventSize bounds a line of the event stream, which carries a post
+const maxEventSize = 4 << 20
+
+// Event is a change of a blog post streamed by StreamEvents
+type Event struct {
+	// ID resumes the stream after this event, as EventQuery.LastEventID
+	ID string
+	// Type is post.created, post.updated, post.deleted, post.published or
+	// EventReset
+	Type string
+	// Data is nil for reset events
+	Data *WebhookEvent
+}
+
+// EventQuery selects the streamed events
+type EventQuery struct {
+	// Author and Tag filter the events by the post after the change,
+	// deletions are always sent
+	Author string
+	Tag    string
+	// LastEventID resumes the stream after this event
+	LastEventID string
+}
+
+// StreamEvents calls fn with the post events until the context is done,
+// fn fails or the server ends the stream, e.g. when shutting down, which
+// returns nil. Calling it again with the ID of the last event as
+// LastEventID resumes the stream. The HTTP client must not have a
+// timeout shorter than the stream.
+func (c *Client) StreamEvents(ctx context.Context, q EventQuery, fn func(Event) error) error {
+	r := request{method: http.MethodGet, path: "/posts/events", query: url.Values{}, header: http.Header{"Accept": {"text/event-stream"}}}
+	if q.Author != "" {
+		r.query.Set("author", q.Author)
+	}
+	if q.Tag != "" {
+		r.query.Set("tag", q.Tag)
+	}
+	if q.LastEventID != "" {
+		r.header.Set("Last-Event-ID", q.LastEventID)
+	}
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxEventSize)
+	var e Event
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "id":
+				e.ID = value
+			case "event":
+				e.Type = value
+			case "data":
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+			}
+			continue
+		}
+
+		// a blank line ends an event, comments have no type
+		if e.Type != "" {
+			if e.Type != EventReset {
+				e.Data = &WebhookEvent{}
+				if err := json.Unmarshal([]byte(data.String()), e.Data); err != nil {
+					return fmt.Errorf("failed to decode the %s event %s: %w", e.Type, e.ID, err)
+				}
+			}
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		e = Event{}
+		data.Reset()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return scanner.Err()
+}
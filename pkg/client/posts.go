@@ -0,0 +1,310 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultPageSize is the page size of ListPostsPage unless set
+const defaultPageSize = 50
+
+// PostQuery selects blog posts by author, tag and status, empty fields
+// match any post
+type PostQuery struct {
+	Author string
+	Tag    string
+	Status string
+	// PageSize is the number of posts fetched per request by ListPostsPage
+	// and Posts, at most 100
+	PageSize int
+}
+
+func (q PostQuery) values() url.Values {
+	v := url.Values{}
+	for name, value := range map[string]string{"author": q.Author, "tag": q.Tag, "status": q.Status} {
+		if value != "" {
+			v.Set(name, value)
+		}
+	}
+	return v
+}
+
+// Page is a page of blog posts
+type Page struct {
+	Posts []*BlogPost
+	// NextPageToken fetches the next page, empty on the last one
+	NextPageToken string
+}
+
+// ListPosts returns every post matching the query at once, see Posts to
+// fetch them a page at a time
+func (c *Client) ListPosts(ctx context.Context, q PostQuery) ([]*BlogPost, error) {
+	var posts []*BlogPost
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/posts", query: q.values()}, &posts)
+	return posts, err
+}
+
+// ListPostsPage returns a page of the posts matching the query, newest
+// first, the first one for an empty token
+func (c *Client) ListPostsPage(ctx context.Context, q PostQuery, pageToken string) (*Page, error) {
+	return c.listPage(ctx, "/posts", q.values(), q.PageSize, pageToken)
+}
+
+func (c *Client) listPage(ctx context.Context, path string, query url.Values, pageSize int, pageToken string) (*Page, error) {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	query.Set("page_size", strconv.Itoa(pageSize))
+	if pageToken != "" {
+		query.Set("page_token", pageToken)
+	}
+
+	page := &Page{}
+	resp, err := c.doJSON(ctx, request{method: http.MethodGet, path: path, query: query}, &page.Posts)
+	if err != nil {
+		return nil, err
+	}
+	page.NextPageToken = nextPageToken(resp.Header.Get("Link"))
+	return page, nil
+}
+
+// nextPageToken returns the page token of the rel="next" link of a Link
+// header
+func nextPageToken(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err == nil {
+			return u.Query().Get("page_token")
+		}
+	}
+	return ""
+}
+
+// Posts iterates over the posts matching the query, newest first, fetching
+// them a page at a time. Iteration stops after the first error.
+func (c *Client) Posts(ctx context.Context, q PostQuery) iter.Seq2[*BlogPost, error] {
+	return c.pages(func(token string) (*Page, error) {
+		return c.ListPostsPage(ctx, q, token)
+	})
+}
+
+// pages iterates over the posts of the pages returned by list, starting
+// with the first one
+func (c *Client) pages(list func(pageToken string) (*Page, error)) iter.Seq2[*BlogPost, error] {
+	return func(yield func(*BlogPost, error) bool) {
+		token := ""
+		for {
+			page, err := list(token)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, post := range page.Posts {
+				if !yield(post, nil) {
+					return
+				}
+			}
+			if page.NextPageToken == "" {
+				return
+			}
+			token = page.NextPageToken
+		}
+	}
+}
+
+func (c *Client) GetPost(ctx context.Context, id string) (*BlogPost, error) {
+	var post BlogPost
+	if _, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/posts/" + url.PathEscape(id)}, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// GetRenderedPost returns a blog post along with its content rendered to
+// sanitized HTML
+func (c *Client) GetRenderedPost(ctx context.Context, id string) (*RenderedBlogPost, error) {
+	var post RenderedBlogPost
+	r := request{method: http.MethodGet, path: "/posts/" + url.PathEscape(id), query: url.Values{"render": {"html"}}}
+	if _, err := c.doJSON(ctx, r, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// CreatePost creates a blog post, which is not retried on 5xx errors
+// since it may have been created
+func (c *Client) CreatePost(ctx context.Context, post BlogPostCreate) (*BlogPost, error) {
+	var created BlogPost
+	if _, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/posts", body: post}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdatePost replaces a blog post whatever its current version, see
+// UpdatePostVersion to avoid overwriting concurrent updates
+func (c *Client) UpdatePost(ctx context.Context, id string, post BlogPostUpdate) (*BlogPost, error) {
+	return c.UpdatePostVersion(ctx, id, 0, post)
+}
+
+// UpdatePostVersion replaces a blog post unless it changed since the given
+// version, failing with a 412 error then (see IsPreconditionFailed). The
+// ETag of a post is its version, which is sent as If-Match. A zero version
+// updates any version.
+func (c *Client) UpdatePostVersion(ctx context.Context, id string, version int, post BlogPostUpdate) (*BlogPost, error) {
+	r := request{method: http.MethodPut, path: "/posts/" + url.PathEscape(id), body: post}
+	if version != 0 {
+		r.header = http.Header{"If-Match": {`"` + strconv.Itoa(version) + `"`}}
+	}
+	var updated BlogPost
+	if _, err := c.doJSON(ctx, r, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// EditPost applies edit to the current version of a blog post and saves
+// it with UpdatePostVersion. When the post changes meanwhile, edit is
+// applied again to the new version, up to MaxRetries times.
+func (c *Client) EditPost(ctx context.Context, id string, edit func(post *BlogPostUpdate) error) (*BlogPost, error) {
+	for attempt := 0; ; attempt++ {
+		current, err := c.GetPost(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		body := current.UpdateBody()
+		if err := edit(&body); err != nil {
+			return nil, err
+		}
+		updated, err := c.UpdatePostVersion(ctx, id, current.Version, body)
+		if !IsPreconditionFailed(err) || attempt >= c.opts.MaxRetries {
+			return updated, err
+		}
+	}
+}
+
+// DeletePost deletes a blog post
+func (c *Client) DeletePost(ctx context.Context, id string) error {
+	_, err := c.doJSON(ctx, request{method: http.MethodDelete, path: "/posts/" + url.PathEscape(id)}, nil)
+	return err
+}
+
+// ExportPosts iterates over every blog post, oldest first, as they are
+// streamed by the server. Iteration stops after the first error.
+func (c *Client) ExportPosts(ctx context.Context) iter.Seq2[*BlogPost, error] {
+	return func(yield func(*BlogPost, error) bool) {
+		resp, err := c.do(ctx, request{method: http.MethodGet, path: "/posts/export", header: http.Header{"Accept": {"application/x-ndjson"}}})
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var post BlogPost
+			err := dec.Decode(&post)
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to decode the export: %w", err))
+				return
+			}
+			if !yield(&post, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Policies of ImportOptions.OnConflict
+const (
+	OnConflictSkip   = "skip"
+	OnConflictUpsert = "upsert"
+)
+
+// ImportOptions configures ImportPosts
+type ImportOptions struct {
+	// DryRun validates and reports the lines without writing anything
+	DryRun bool
+	// OnConflict tells what to do with posts whose ID already exists,
+	// OnConflictSkip unless set
+	OnConflict string
+}
+
+// ImportPosts imports newline delimited JSON, one BlogPostImport per line,
+// e.g. written by WritePosts from ExportPosts. The stream is sent once and
+// never retried.
+func (c *Client) ImportPosts(ctx context.Context, ndjson io.Reader, opts ImportOptions) (*ImportReport, error) {
+	query := url.Values{}
+	if opts.DryRun {
+		query.Set("dry_run", "true")
+	}
+	if opts.OnConflict != "" {
+		query.Set("on_conflict", opts.OnConflict)
+	}
+	var report ImportReport
+	// an io.Reader body is streamed as is
+	if _, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/posts/import", query: query, body: ndjson}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// WritePosts writes posts as newline delimited JSON, the format of
+// ImportPosts
+func WritePosts(w io.Writer, posts iter.Seq2[*BlogPost, error]) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for post, err := range posts {
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(post); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// BatchPosts applies a batch of operations, built with CreateOperation,
+// UpdateOperation and DeleteOperation
+func (c *Client) BatchPosts(ctx context.Context, batch BatchRequest) (*BatchResponse, error) {
+	var resp BatchResponse
+	if _, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/posts/batch", body: batch}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateOperation returns the operation of a batch creating a post, with
+// the given ID unless empty
+func CreateOperation(id string, post BlogPostCreate) BatchOperation {
+	data, _ := json.Marshal(post)
+	return BatchOperation{Op: "create", ID: id, Post: data}
+}
+
+// UpdateOperation returns the operation of a batch updating a post
+func UpdateOperation(id string, post BlogPostUpdate) BatchOperation {
+	data, _ := json.Marshal(post)
+	return BatchOperation{Op: "update", ID: id, Post: data}
+}
+
+// DeleteOperation returns the operation of a batch deleting a post
+func DeleteOperation(id string) BatchOperation {
+	return BatchOperation{Op: "delete", ID: id}
+}
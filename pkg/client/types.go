@@ -0,0 +1,70 @@
+package client
+
+import "blog-posts-api/internal/api/models"
+
+// The types of the API are those of the server, so that the client cannot
+// drift from it
+type (
+	BlogPost         = models.BlogPost
+	RenderedBlogPost = models.RenderedBlogPost
+	BlogPostCreate   = models.BlogPostCreate
+	BlogPostUpdate   = models.BlogPostUpdate
+	BlogPostImport   = models.BlogPostImport
+
+	BatchRequest   = models.BatchRequest
+	BatchOperation = models.BatchOperation
+	BatchResult    = models.BatchResult
+	BatchResponse  = models.BatchResponse
+
+	ImportResult  = models.ImportResult
+	ImportSummary = models.ImportSummary
+	ImportReport  = models.ImportReport
+
+	Webhook          = models.Webhook
+	WebhookCreate    = models.WebhookCreate
+	WebhookCreated   = models.WebhookCreated
+	WebhookUpdate    = models.WebhookUpdate
+	WebhookEvent     = models.WebhookEvent
+	WebhookEventData = models.WebhookEventData
+	WebhookDelivery  = models.WebhookDelivery
+	WebhookAttempt   = models.WebhookAttempt
+
+	Attachment        = models.Attachment
+	AttachmentVariant = models.AttachmentVariant
+
+	Author         = models.Author
+	AuthorCreate   = models.AuthorCreate
+	AuthorUpdate   = models.AuthorUpdate
+	AuthorBackfill = models.AuthorBackfill
+
+	User              = models.User
+	UserRegister      = models.UserRegister
+	Credentials       = models.Credentials
+	AccessToken       = models.AccessToken
+	EmailRequest      = models.EmailRequest
+	EmailVerification = models.EmailVerification
+	PasswordReset     = models.PasswordReset
+
+	Problem      = models.Problem
+	InvalidParam = models.InvalidParam
+)
+
+// Statuses of a blog post
+const (
+	StatusDraft     = models.StatusDraft
+	StatusPublished = models.StatusPublished
+)
+
+// Processing states of the variants of an image attachment
+const (
+	ProcessingPending   = models.ProcessingPending
+	ProcessingSucceeded = models.ProcessingSucceeded
+	ProcessingFailed    = models.ProcessingFailed
+)
+
+// Statuses of a webhook delivery
+const (
+	DeliveryPending   = models.DeliveryPending
+	DeliverySucceeded = models.DeliverySucceeded
+	DeliveryDead      = models.DeliveryDead
+)
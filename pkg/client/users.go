@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Register creates an account and has the API send the link verifying its
+// email address, logging in is possible once it is opened
+func (c *Client) Register(ctx context.Context, user UserRegister) (*User, error) {
+	var created User
+	if _, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/auth/register", body: user}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// VerifyEmail verifies the email address of an account with the token of
+// the link sent to it
+func (c *Client) VerifyEmail(ctx context.Context, token string) (*User, error) {
+	var user User
+	body := EmailVerification{Token: token}
+	if _, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/auth/verify", body: body}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResendVerification has the API send a new verification link to an
+// unverified account. Unknown addresses are not reported.
+func (c *Client) ResendVerification(ctx context.Context, email string) error {
+	body := EmailRequest{Email: email}
+	_, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/auth/verify/resend", body: body}, nil)
+	return err
+}
+
+// Login returns an access token for the credentials of a verified account,
+// to be sent by a client from WithAccessToken
+func (c *Client) Login(ctx context.Context, credentials Credentials) (*AccessToken, error) {
+	var token AccessToken
+	if _, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/auth/token", body: credentials}, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ForgotPassword has the API send a password reset link. Unknown addresses
+// are not reported.
+func (c *Client) ForgotPassword(ctx context.Context, email string) error {
+	body := EmailRequest{Email: email}
+	_, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/auth/password/forgot", body: body}, nil)
+	return err
+}
+
+// ResetPassword sets a new password with the token of the reset link. The
+// access tokens of the account are revoked.
+func (c *Client) ResetPassword(ctx context.Context, reset PasswordReset) error {
+	_, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/auth/password/reset", body: reset}, nil)
+	return err
+}
+
+// Me returns the account of the access token of the client
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var user User
+	if _, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/auth/me"}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListWebhooks returns every webhook, oldest first, without their secrets.
+// Like every webhook method, it needs an access token, see WithAccessToken.
+func (c *Client) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+	var webhooks []*Webhook
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/webhooks"}, &webhooks)
+	return webhooks, err
+}
+
+// CreateWebhook subscribes an endpoint to post events. The secret signing
+// the deliveries is only returned here.
+func (c *Client) CreateWebhook(ctx context.Context, webhook WebhookCreate) (*WebhookCreated, error) {
+	var created WebhookCreated
+	if _, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/webhooks", body: webhook}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetWebhook returns a webhook by ID
+func (c *Client) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+	var webhook Webhook
+	if _, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/webhooks/" + url.PathEscape(id)}, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// UpdateWebhook replaces the settings of a webhook, rotating its secret
+// when set
+func (c *Client) UpdateWebhook(ctx context.Context, id string, webhook WebhookUpdate) (*Webhook, error) {
+	var updated Webhook
+	if _, err := c.doJSON(ctx, request{method: http.MethodPut, path: "/webhooks/" + url.PathEscape(id), body: webhook}, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteWebhook deletes a webhook along with its deliveries
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := c.doJSON(ctx, request{method: http.MethodDelete, path: "/webhooks/" + url.PathEscape(id)}, nil)
+	return err
+}
+
+// ListDeliveries returns the deliveries of every webhook, latest first,
+// in the given status unless empty: DeliveryDead lists the dead letters
+func (c *Client) ListDeliveries(ctx context.Context, status string) ([]*WebhookDelivery, error) {
+	return c.listDeliveries(ctx, "/webhooks/deliveries", status)
+}
+
+// ListWebhookDeliveries returns the deliveries of a webhook, latest first,
+// in the given status unless empty
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookID, status string) ([]*WebhookDelivery, error) {
+	return c.listDeliveries(ctx, "/webhooks/"+url.PathEscape(webhookID)+"/deliveries", status)
+}
+
+func (c *Client) listDeliveries(ctx context.Context, path, status string) ([]*WebhookDelivery, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	var deliveries []*WebhookDelivery
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: path, query: query}, &deliveries)
+	return deliveries, err
+}
+
+// GetDelivery returns a webhook delivery by ID, with its attempts
+func (c *Client) GetDelivery(ctx context.Context, id string) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	if _, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/webhooks/deliveries/" + url.PathEscape(id)}, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Redeliver queues a succeeded or dead delivery again
+func (c *Client) Redeliver(ctx context.Context, id string) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	if _, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/webhooks/deliveries/" + url.PathEscape(id) + "/redeliver"}, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
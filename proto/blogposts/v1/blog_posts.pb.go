@@ -0,0 +1,960 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: blogposts/v1/blog_posts.proto
+
+// Blog posts served over gRPC alongside the REST API, backed by the same
+// service: validation rules, events and errors are the REST ones.
+
+package blogpostsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PostEvent_Type int32
+
+const (
+	PostEvent_TYPE_UNSPECIFIED    PostEvent_Type = 0
+	PostEvent_TYPE_POST_CREATED   PostEvent_Type = 1
+	PostEvent_TYPE_POST_UPDATED   PostEvent_Type = 2
+	PostEvent_TYPE_POST_DELETED   PostEvent_Type = 3
+	PostEvent_TYPE_POST_PUBLISHED PostEvent_Type = 4
+	// TYPE_RESET tells that events were missed, because the stream fell
+	// behind or was resumed from an event no longer kept: the posts should
+	// be reloaded. It has no id.
+	PostEvent_TYPE_RESET PostEvent_Type = 5
+)
+
+// Enum value maps for PostEvent_Type.
+var (
+	PostEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_POST_CREATED",
+		2: "TYPE_POST_UPDATED",
+		3: "TYPE_POST_DELETED",
+		4: "TYPE_POST_PUBLISHED",
+		5: "TYPE_RESET",
+	}
+	PostEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED":    0,
+		"TYPE_POST_CREATED":   1,
+		"TYPE_POST_UPDATED":   2,
+		"TYPE_POST_DELETED":   3,
+		"TYPE_POST_PUBLISHED": 4,
+		"TYPE_RESET":          5,
+	}
+)
+
+func (x PostEvent_Type) Enum() *PostEvent_Type {
+	p := new(PostEvent_Type)
+	*p = x
+	return p
+}
+
+func (x PostEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PostEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_blogposts_v1_blog_posts_proto_enumTypes[0].Descriptor()
+}
+
+func (PostEvent_Type) Type() protoreflect.EnumType {
+	return &file_blogposts_v1_blog_posts_proto_enumTypes[0]
+}
+
+func (x PostEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PostEvent_Type.Descriptor instead.
+func (PostEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{9, 0}
+}
+
+type BlogPost struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	// slug identifies the post in public URLs
+	Slug    string `protobuf:"bytes,3,opt,name=slug,proto3" json:"slug,omitempty"`
+	Content string `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	// content_format is "plain" or "markdown"
+	ContentFormat string   `protobuf:"bytes,5,opt,name=content_format,json=contentFormat,proto3" json:"content_format,omitempty"`
+	Author        string   `protobuf:"bytes,6,opt,name=author,proto3" json:"author,omitempty"`
+	Tags          []string `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	// status is "draft" or "published"
+	Status string `protobuf:"bytes,8,opt,name=status,proto3" json:"status,omitempty"`
+	// published_at is unset for drafts
+	PublishedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=published_at,json=publishedAt,proto3" json:"published_at,omitempty"`
+	// version is incremented on every update
+	Version       int64                  `protobuf:"varint,10,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlogPost) Reset() {
+	*x = BlogPost{}
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlogPost) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlogPost) ProtoMessage() {}
+
+func (x *BlogPost) ProtoReflect() protoreflect.Message {
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlogPost.ProtoReflect.Descriptor instead.
+func (*BlogPost) Descriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BlogPost) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BlogPost) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *BlogPost) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *BlogPost) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *BlogPost) GetContentFormat() string {
+	if x != nil {
+		return x.ContentFormat
+	}
+	return ""
+}
+
+func (x *BlogPost) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *BlogPost) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *BlogPost) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *BlogPost) GetPublishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishedAt
+	}
+	return nil
+}
+
+func (x *BlogPost) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *BlogPost) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *BlogPost) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// PostInput holds the editable fields of a post. Empty fields get the
+// defaults of the REST API: plain content, published, a slug derived from
+// the title.
+type PostInput struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Slug          string                 `protobuf:"bytes,2,opt,name=slug,proto3" json:"slug,omitempty"`
+	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	ContentFormat string                 `protobuf:"bytes,4,opt,name=content_format,json=contentFormat,proto3" json:"content_format,omitempty"`
+	Author        string                 `protobuf:"bytes,5,opt,name=author,proto3" json:"author,omitempty"`
+	Tags          []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	Status        string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PostInput) Reset() {
+	*x = PostInput{}
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostInput) ProtoMessage() {}
+
+func (x *PostInput) ProtoReflect() protoreflect.Message {
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostInput.ProtoReflect.Descriptor instead.
+func (*PostInput) Descriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PostInput) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *PostInput) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *PostInput) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *PostInput) GetContentFormat() string {
+	if x != nil {
+		return x.ContentFormat
+	}
+	return ""
+}
+
+func (x *PostInput) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *PostInput) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *PostInput) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type GetPostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPostRequest) Reset() {
+	*x = GetPostRequest{}
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPostRequest) ProtoMessage() {}
+
+func (x *GetPostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPostRequest.ProtoReflect.Descriptor instead.
+func (*GetPostRequest) Descriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetPostRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListPostsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// page_size defaults to 50 and is at most 100
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token is the next_page_token of the previous page
+	PageToken     string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	Author        string `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	Tag           string `protobuf:"bytes,4,opt,name=tag,proto3" json:"tag,omitempty"`
+	Status        string `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPostsRequest) Reset() {
+	*x = ListPostsRequest{}
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPostsRequest) ProtoMessage() {}
+
+func (x *ListPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPostsRequest.ProtoReflect.Descriptor instead.
+func (*ListPostsRequest) Descriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListPostsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPostsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListPostsRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *ListPostsRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *ListPostsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type ListPostsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Posts []*BlogPost            `protobuf:"bytes,1,rep,name=posts,proto3" json:"posts,omitempty"`
+	// next_page_token is empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPostsResponse) Reset() {
+	*x = ListPostsResponse{}
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPostsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPostsResponse) ProtoMessage() {}
+
+func (x *ListPostsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPostsResponse.ProtoReflect.Descriptor instead.
+func (*ListPostsResponse) Descriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListPostsResponse) GetPosts() []*BlogPost {
+	if x != nil {
+		return x.Posts
+	}
+	return nil
+}
+
+func (x *ListPostsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type CreatePostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Post          *PostInput             `protobuf:"bytes,1,opt,name=post,proto3" json:"post,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreatePostRequest) Reset() {
+	*x = CreatePostRequest{}
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePostRequest) ProtoMessage() {}
+
+func (x *CreatePostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePostRequest.ProtoReflect.Descriptor instead.
+func (*CreatePostRequest) Descriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreatePostRequest) GetPost() *PostInput {
+	if x != nil {
+		return x.Post
+	}
+	return nil
+}
+
+type UpdatePostRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Post  *PostInput             `protobuf:"bytes,2,opt,name=post,proto3" json:"post,omitempty"`
+	// version, when set, makes the update fail with ABORTED unless the post
+	// is still at this version
+	Version       int64 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdatePostRequest) Reset() {
+	*x = UpdatePostRequest{}
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdatePostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdatePostRequest) ProtoMessage() {}
+
+func (x *UpdatePostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdatePostRequest.ProtoReflect.Descriptor instead.
+func (*UpdatePostRequest) Descriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdatePostRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdatePostRequest) GetPost() *PostInput {
+	if x != nil {
+		return x.Post
+	}
+	return nil
+}
+
+func (x *UpdatePostRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeletePostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePostRequest) Reset() {
+	*x = DeletePostRequest{}
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePostRequest) ProtoMessage() {}
+
+func (x *DeletePostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePostRequest.ProtoReflect.Descriptor instead.
+func (*DeletePostRequest) Descriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeletePostRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type WatchPostsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// author and tag filter the changes by the post after the change,
+	// deletions are always sent
+	Author string `protobuf:"bytes,1,opt,name=author,proto3" json:"author,omitempty"`
+	Tag    string `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`
+	// resume_token is the id of the last event received, to get the events
+	// missed while reconnecting
+	ResumeToken   string `protobuf:"bytes,3,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchPostsRequest) Reset() {
+	*x = WatchPostsRequest{}
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPostsRequest) ProtoMessage() {}
+
+func (x *WatchPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPostsRequest.ProtoReflect.Descriptor instead.
+func (*WatchPostsRequest) Descriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchPostsRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *WatchPostsRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *WatchPostsRequest) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type PostEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is the resume token of the stream after this event
+	Id     string         `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type   PostEvent_Type `protobuf:"varint,2,opt,name=type,proto3,enum=blogposts.v1.PostEvent_Type" json:"type,omitempty"`
+	PostId string         `protobuf:"bytes,3,opt,name=post_id,json=postId,proto3" json:"post_id,omitempty"`
+	// post is the post after the change, unset for deletions
+	Post       *BlogPost              `protobuf:"bytes,4,opt,name=post,proto3" json:"post,omitempty"`
+	OccurredAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	// event_id is the ID of the event in webhook deliveries and the outbox
+	EventId       string `protobuf:"bytes,6,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PostEvent) Reset() {
+	*x = PostEvent{}
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostEvent) ProtoMessage() {}
+
+func (x *PostEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_blogposts_v1_blog_posts_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostEvent.ProtoReflect.Descriptor instead.
+func (*PostEvent) Descriptor() ([]byte, []int) {
+	return file_blogposts_v1_blog_posts_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PostEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PostEvent) GetType() PostEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return PostEvent_TYPE_UNSPECIFIED
+}
+
+func (x *PostEvent) GetPostId() string {
+	if x != nil {
+		return x.PostId
+	}
+	return ""
+}
+
+func (x *PostEvent) GetPost() *BlogPost {
+	if x != nil {
+		return x.Post
+	}
+	return nil
+}
+
+func (x *PostEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *PostEvent) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+var File_blogposts_v1_blog_posts_proto protoreflect.FileDescriptor
+
+const file_blogposts_v1_blog_posts_proto_rawDesc = "" +
+	"\n" +
+	"\x1dblogposts/v1/blog_posts.proto\x12\fblogposts.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x98\x03\n" +
+	"\bBlogPost\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04slug\x18\x03 \x01(\tR\x04slug\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\tR\acontent\x12%\n" +
+	"\x0econtent_format\x18\x05 \x01(\tR\rcontentFormat\x12\x16\n" +
+	"\x06author\x18\x06 \x01(\tR\x06author\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\x12\x16\n" +
+	"\x06status\x18\b \x01(\tR\x06status\x12=\n" +
+	"\fpublished_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\vpublishedAt\x12\x18\n" +
+	"\aversion\x18\n" +
+	" \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xba\x01\n" +
+	"\tPostInput\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x12\n" +
+	"\x04slug\x18\x02 \x01(\tR\x04slug\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12%\n" +
+	"\x0econtent_format\x18\x04 \x01(\tR\rcontentFormat\x12\x16\n" +
+	"\x06author\x18\x05 \x01(\tR\x06author\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\" \n" +
+	"\x0eGetPostRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x90\x01\n" +
+	"\x10ListPostsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x16\n" +
+	"\x06author\x18\x03 \x01(\tR\x06author\x12\x10\n" +
+	"\x03tag\x18\x04 \x01(\tR\x03tag\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\"i\n" +
+	"\x11ListPostsResponse\x12,\n" +
+	"\x05posts\x18\x01 \x03(\v2\x16.blogposts.v1.BlogPostR\x05posts\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"@\n" +
+	"\x11CreatePostRequest\x12+\n" +
+	"\x04post\x18\x01 \x01(\v2\x17.blogposts.v1.PostInputR\x04post\"j\n" +
+	"\x11UpdatePostRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12+\n" +
+	"\x04post\x18\x02 \x01(\v2\x17.blogposts.v1.PostInputR\x04post\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\"#\n" +
+	"\x11DeletePostRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"`\n" +
+	"\x11WatchPostsRequest\x12\x16\n" +
+	"\x06author\x18\x01 \x01(\tR\x06author\x12\x10\n" +
+	"\x03tag\x18\x02 \x01(\tR\x03tag\x12!\n" +
+	"\fresume_token\x18\x03 \x01(\tR\vresumeToken\"\xf7\x02\n" +
+	"\tPostEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x120\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x1c.blogposts.v1.PostEvent.TypeR\x04type\x12\x17\n" +
+	"\apost_id\x18\x03 \x01(\tR\x06postId\x12*\n" +
+	"\x04post\x18\x04 \x01(\v2\x16.blogposts.v1.BlogPostR\x04post\x12;\n" +
+	"\voccurred_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x12\x19\n" +
+	"\bevent_id\x18\x06 \x01(\tR\aeventId\"\x8a\x01\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11TYPE_POST_CREATED\x10\x01\x12\x15\n" +
+	"\x11TYPE_POST_UPDATED\x10\x02\x12\x15\n" +
+	"\x11TYPE_POST_DELETED\x10\x03\x12\x17\n" +
+	"\x13TYPE_POST_PUBLISHED\x10\x04\x12\x0e\n" +
+	"\n" +
+	"TYPE_RESET\x10\x052\xbf\x03\n" +
+	"\x0fBlogPostService\x12?\n" +
+	"\aGetPost\x12\x1c.blogposts.v1.GetPostRequest\x1a\x16.blogposts.v1.BlogPost\x12L\n" +
+	"\tListPosts\x12\x1e.blogposts.v1.ListPostsRequest\x1a\x1f.blogposts.v1.ListPostsResponse\x12E\n" +
+	"\n" +
+	"CreatePost\x12\x1f.blogposts.v1.CreatePostRequest\x1a\x16.blogposts.v1.BlogPost\x12E\n" +
+	"\n" +
+	"UpdatePost\x12\x1f.blogposts.v1.UpdatePostRequest\x1a\x16.blogposts.v1.BlogPost\x12E\n" +
+	"\n" +
+	"DeletePost\x12\x1f.blogposts.v1.DeletePostRequest\x1a\x16.google.protobuf.Empty\x12H\n" +
+	"\n" +
+	"WatchPosts\x12\x1f.blogposts.v1.WatchPostsRequest\x1a\x17.blogposts.v1.PostEvent0\x01B/Z-blog-posts-api/proto/blogposts/v1;blogpostsv1b\x06proto3"
+
+var (
+	file_blogposts_v1_blog_posts_proto_rawDescOnce sync.Once
+	file_blogposts_v1_blog_posts_proto_rawDescData []byte
+)
+
+func file_blogposts_v1_blog_posts_proto_rawDescGZIP() []byte {
+	file_blogposts_v1_blog_posts_proto_rawDescOnce.Do(func() {
+		file_blogposts_v1_blog_posts_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_blogposts_v1_blog_posts_proto_rawDesc), len(file_blogposts_v1_blog_posts_proto_rawDesc)))
+	})
+	return file_blogposts_v1_blog_posts_proto_rawDescData
+}
+
+var file_blogposts_v1_blog_posts_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_blogposts_v1_blog_posts_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_blogposts_v1_blog_posts_proto_goTypes = []any{
+	(PostEvent_Type)(0),           // 0: blogposts.v1.PostEvent.Type
+	(*BlogPost)(nil),              // 1: blogposts.v1.BlogPost
+	(*PostInput)(nil),             // 2: blogposts.v1.PostInput
+	(*GetPostRequest)(nil),        // 3: blogposts.v1.GetPostRequest
+	(*ListPostsRequest)(nil),      // 4: blogposts.v1.ListPostsRequest
+	(*ListPostsResponse)(nil),     // 5: blogposts.v1.ListPostsResponse
+	(*CreatePostRequest)(nil),     // 6: blogposts.v1.CreatePostRequest
+	(*UpdatePostRequest)(nil),     // 7: blogposts.v1.UpdatePostRequest
+	(*DeletePostRequest)(nil),     // 8: blogposts.v1.DeletePostRequest
+	(*WatchPostsRequest)(nil),     // 9: blogposts.v1.WatchPostsRequest
+	(*PostEvent)(nil),             // 10: blogposts.v1.PostEvent
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 12: google.protobuf.Empty
+}
+var file_blogposts_v1_blog_posts_proto_depIdxs = []int32{
+	11, // 0: blogposts.v1.BlogPost.published_at:type_name -> google.protobuf.Timestamp
+	11, // 1: blogposts.v1.BlogPost.created_at:type_name -> google.protobuf.Timestamp
+	11, // 2: blogposts.v1.BlogPost.updated_at:type_name -> google.protobuf.Timestamp
+	1,  // 3: blogposts.v1.ListPostsResponse.posts:type_name -> blogposts.v1.BlogPost
+	2,  // 4: blogposts.v1.CreatePostRequest.post:type_name -> blogposts.v1.PostInput
+	2,  // 5: blogposts.v1.UpdatePostRequest.post:type_name -> blogposts.v1.PostInput
+	0,  // 6: blogposts.v1.PostEvent.type:type_name -> blogposts.v1.PostEvent.Type
+	1,  // 7: blogposts.v1.PostEvent.post:type_name -> blogposts.v1.BlogPost
+	11, // 8: blogposts.v1.PostEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	3,  // 9: blogposts.v1.BlogPostService.GetPost:input_type -> blogposts.v1.GetPostRequest
+	4,  // 10: blogposts.v1.BlogPostService.ListPosts:input_type -> blogposts.v1.ListPostsRequest
+	6,  // 11: blogposts.v1.BlogPostService.CreatePost:input_type -> blogposts.v1.CreatePostRequest
+	7,  // 12: blogposts.v1.BlogPostService.UpdatePost:input_type -> blogposts.v1.UpdatePostRequest
+	8,  // 13: blogposts.v1.BlogPostService.DeletePost:input_type -> blogposts.v1.DeletePostRequest
+	9,  // 14: blogposts.v1.BlogPostService.WatchPosts:input_type -> blogposts.v1.WatchPostsRequest
+	1,  // 15: blogposts.v1.BlogPostService.GetPost:output_type -> blogposts.v1.BlogPost
+	5,  // 16: blogposts.v1.BlogPostService.ListPosts:output_type -> blogposts.v1.ListPostsResponse
+	1,  // 17: blogposts.v1.BlogPostService.CreatePost:output_type -> blogposts.v1.BlogPost
+	1,  // 18: blogposts.v1.BlogPostService.UpdatePost:output_type -> blogposts.v1.BlogPost
+	12, // 19: blogposts.v1.BlogPostService.DeletePost:output_type -> google.protobuf.Empty
+	10, // 20: blogposts.v1.BlogPostService.WatchPosts:output_type -> blogposts.v1.PostEvent
+	15, // [15:21] is the sub-list for method output_type
+	9,  // [9:15] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_blogposts_v1_blog_posts_proto_init() }
+func file_blogposts_v1_blog_posts_proto_init() {
+	if File_blogposts_v1_blog_posts_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_blogposts_v1_blog_posts_proto_rawDesc), len(file_blogposts_v1_blog_posts_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_blogposts_v1_blog_posts_proto_goTypes,
+		DependencyIndexes: file_blogposts_v1_blog_posts_proto_depIdxs,
+		EnumInfos:         file_blogposts_v1_blog_posts_proto_enumTypes,
+		MessageInfos:      file_blogposts_v1_blog_posts_proto_msgTypes,
+	}.Build()
+	File_blogposts_v1_blog_posts_proto = out.File
+	file_blogposts_v1_blog_posts_proto_goTypes = nil
+	file_blogposts_v1_blog_posts_proto_depIdxs = nil
+}
@@ -0,0 +1,5 @@
+package blogpostsv1
+
+// Regenerate the code after changing blog_posts.proto, with protoc,
+// protoc-gen-go and protoc-gen-go-grpc on the PATH
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative blogposts/v1/blog_posts.proto